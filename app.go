@@ -2,11 +2,13 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"runtime"
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -32,13 +34,29 @@ import (
 // 全域切換鎖（與自動切換共用）
 var globalSwitchMu sync.Mutex
 
+// 切換帳號時使用的操作，可於測試時替換
+var (
+	refreshBackupFunc  = backup.RefreshAndWriteBackup
+	restoreBackupFunc  = backup.RestoreBackup
+	patchExtensionFunc = softreset.PatchExtensionJS
+)
+
+// 一鍵新機使用的操作，可於測試時替換
+var softResetFunc = softreset.SoftResetEnvironmentWithProgress
+
 // 自動切換監控器
 var autoSwitchMonitor *autoswitch.Monitor
 var autoSwitchMonitorMu sync.RWMutex
 
+// 進行中 OAuth 登入的取消函數（oauthLoginSeq 用於辨識是哪一次登入）
+var oauthLoginCancel context.CancelFunc
+var oauthLoginSeq uint64
+var oauthLoginMu sync.Mutex
+
 // App struct
 type App struct {
-	ctx context.Context
+	ctx     context.Context
+	emitter Emitter // 發送進度事件（startup 時預設為 Wails runtime）
 }
 
 // NewApp creates a new App application struct
@@ -49,6 +67,9 @@ func NewApp() *App {
 // startup is called when the app starts
 func (a *App) startup(ctx context.Context) {
 	a.ctx = ctx
+	if a.emitter == nil {
+		a.emitter = wailsEmitter{ctx: ctx}
+	}
 	// 不再於啟動時自動備份，避免觸發防毒軟體誤報
 	// 改為在用戶首次執行需要備份的操作時才觸發
 
@@ -58,29 +79,32 @@ func (a *App) startup(ctx context.Context) {
 		println("Warning: Failed to register URL scheme:", err.Error())
 	}
 
-	// 檢查啟動時的命令行參數是否包含 deep link URL
-	for _, arg := range os.Args[1:] {
-		if strings.HasPrefix(arg, "kiro://") {
-			result, err := deeplink.HandleDeepLinkCallback(arg)
-			if err == nil {
-				deeplink.SendCallback(result)
-			}
-			break
+	// 自動清理超出保留數量的舊備份
+	if settings.IsAutoPruneEnabled() {
+		if _, err := backup.PruneBackups(backup.PrunePolicy{MaxCount: settings.GetAutoPruneKeepCount()}); err != nil {
+			println("Warning: Failed to prune backups:", err.Error())
 		}
 	}
+
+	// 檢查啟動時的命令行參數是否包含 deep link URL
+	if rawURL, ok := deeplink.FindDeepLinkArg(os.Args[1:]); ok {
+		deeplink.ForwardDeepLink(rawURL)
+	}
 }
 
 // BackupItem 備份項目（前端用）
 type BackupItem struct {
-	Name              string  `json:"name"`
-	BackupTime        string  `json:"backupTime"`
-	HasToken          bool    `json:"hasToken"`
-	HasMachineID      bool    `json:"hasMachineId"`
-	MachineID         string  `json:"machineId"`
-	Provider          string  `json:"provider"`
-	IsCurrent         bool    `json:"isCurrent"`
-	IsOriginalMachine bool    `json:"isOriginalMachine"` // Machine ID 與原始機器相同
-	IsTokenExpired    bool    `json:"isTokenExpired"`    // Token 是否已過期
+	Name                 string `json:"name"`
+	BackupTime           string `json:"backupTime"`
+	HasToken             bool   `json:"hasToken"`
+	HasMachineID         bool   `json:"hasMachineId"`
+	MachineID            string `json:"machineId"`
+	Provider             string `json:"provider"`
+	IsCurrent            bool   `json:"isCurrent"`
+	IsOriginalMachine    bool   `json:"isOriginalMachine"`    // Machine ID 與原始機器相同
+	IsTokenExpired       bool   `json:"isTokenExpired"`       // Token 是否已過期（無法解析過期時間時亦為 true）
+	ExpiryKnownAndPassed bool   `json:"expiryKnownAndPassed"` // 過期時間已知且已過（過期時間未知時為 false）
+	ExpiresAt            string `json:"expiresAt"`            // Token 過期時間（RFC3339），空字串表示未知
 	// Usage 相關欄位 (Requirements: 1.1, 1.2)
 	SubscriptionTitle string  `json:"subscriptionTitle"` // 訂閱類型名稱
 	UsageLimit        float64 `json:"usageLimit"`        // 總額度
@@ -88,14 +112,57 @@ type BackupItem struct {
 	Balance           float64 `json:"balance"`           // 餘額
 	IsLowBalance      bool    `json:"isLowBalance"`      // 餘額低於 20%
 	CachedAt          string  `json:"cachedAt"`          // 緩存時間（用於前端判斷冷卻期）
+	HasUsage          bool    `json:"hasUsage"`          // 是否有餘額緩存（false 時用量欄位皆為零值）
 	// 文件夾相關欄位
 	FolderId          string  `json:"folderId"`          // 所屬文件夾 ID，空字串表示未分類
 }
 
 // Result 通用回傳結果
 type Result struct {
-	Success bool   `json:"success"`
-	Message string `json:"message"`
+	Success   bool   `json:"success"`
+	Message   string `json:"message"`
+	ErrorCode string `json:"errorCode,omitempty"` // 失敗原因代碼（供前端判斷，Message 僅用於顯示）
+}
+
+// Result.ErrorCode 可能的值
+const (
+	ErrorCodeKiroRunning       = "KIRO_RUNNING"         // Kiro 執行中或無法關閉
+	ErrorCodeNeedsAdmin        = "NEEDS_ADMIN"          // 檔案權限不足，需要管理員權限
+	ErrorCodeBackupNotFound    = "BACKUP_NOT_FOUND"     // 備份不存在
+	ErrorCodeBusy              = "BUSY"                 // 其他切換或重置操作進行中
+	ErrorCodeInvalidInput      = "INVALID_INPUT"        // 參數為空或格式無效
+	ErrorCodeInvalidBackup     = "INVALID_BACKUP"       // 備份內容不完整
+	ErrorCodeTokenRefresh      = "TOKEN_REFRESH_FAILED" // Token 刷新失敗
+	ErrorCodeTokenExpired      = "TOKEN_EXPIRED"        // refresh token 已失效，需要重新登入
+	ErrorCodeExtensionNotFound = "EXTENSION_NOT_FOUND"  // 找不到 extension.js
+	ErrorCodeUnknown           = "UNKNOWN"              // 其他錯誤
+)
+
+// errorCodeFor 依錯誤類型判斷 Result.ErrorCode
+func errorCodeFor(err error) string {
+	switch {
+	case errors.Is(err, softreset.ErrKiroRunning):
+		return ErrorCodeKiroRunning
+	case errors.Is(err, os.ErrPermission):
+		return ErrorCodeNeedsAdmin
+	case errors.Is(err, backup.ErrBackupNotFound):
+		return ErrorCodeBackupNotFound
+	case errors.Is(err, backup.ErrEmptyTokenFile):
+		return ErrorCodeInvalidBackup
+	case errors.Is(err, softreset.ErrInvalidMachineID), errors.Is(err, backup.ErrInvalidBackupName):
+		return ErrorCodeInvalidInput
+	case errors.Is(err, softreset.ErrExtensionNotFound):
+		return ErrorCodeExtensionNotFound
+	case errors.Is(err, tokenrefresh.ErrTokenExpired):
+		return ErrorCodeTokenExpired
+	default:
+		return ErrorCodeUnknown
+	}
+}
+
+// errorResult 以錯誤建立失敗結果，ErrorCode 依錯誤類型判斷
+func errorResult(message string, err error) Result {
+	return Result{Success: false, Message: message, ErrorCode: errorCodeFor(err)}
 }
 
 // PathDetectionResult 路徑偵測結果（前端用）
@@ -158,11 +225,17 @@ func (a *App) GetBackupList() ([]BackupItem, error) {
 				}
 				// 檢查 token 是否已過期
 				item.IsTokenExpired = awssso.IsTokenExpired(token)
+				// 過期時間無法解析時視為未知，不標記為已過期
+				if expiresAt, err := backup.ParseTokenExpiry(token); err == nil && !expiresAt.IsZero() {
+					item.ExpiresAt = expiresAt.Format(time.RFC3339)
+					item.ExpiryKnownAndPassed = time.Now().After(expiresAt)
+				}
 			}
 		}
 
 		// 從緩存讀取用量資訊（不再自動呼叫 API）
 		if usageCache, err := backup.ReadUsageCache(b.Name); err == nil && usageCache != nil {
+			item.HasUsage = true
 			item.SubscriptionTitle = usageCache.SubscriptionTitle
 			item.UsageLimit = usageCache.UsageLimit
 			item.CurrentUsage = usageCache.CurrentUsage
@@ -205,6 +278,12 @@ type UsageCacheResult struct {
 // RefreshBackupUsage 刷新指定備份的餘額資訊
 // 需求: 1.1, 1.2, 1.3, 1.4, 1.5
 func (a *App) RefreshBackupUsage(name string) UsageCacheResult {
+	return a.refreshBackupUsage(context.Background(), name)
+}
+
+// refreshBackupUsage 刷新指定備份的餘額資訊（內部實作）
+// ctx 取消時會中止進行中的 Token 刷新（供自動切換監控器使用）
+func (a *App) refreshBackupUsage(ctx context.Context, name string) UsageCacheResult {
 	if name == "" {
 		return UsageCacheResult{Success: false, Message: "備份名稱不能為空"}
 	}
@@ -228,38 +307,16 @@ func (a *App) RefreshBackupUsage(name string) UsageCacheResult {
 
 	// 檢查 token 是否已過期（需求 1.1）
 	if awssso.IsTokenExpired(token) {
-		// 嘗試刷新 Token（需求 1.1, 1.2, 1.3）
-		// 使用對應環境快照的 Machine ID 的 SHA256 雜湊值
-		var newTokenInfo *tokenrefresh.TokenInfo
-		var err error
-
-		// 檢查是否為 IdC 認證，如果是則從備份目錄讀取 clientId/clientSecret
-		authType := tokenrefresh.DetectAuthType(token)
-		if authType == "idc" && token.ClientIdHash != "" {
-			// 從備份目錄讀取 IdC credentials
-			clientID, clientSecret, credErr := backup.ReadBackupIdCCredentials(name, token.ClientIdHash)
-			if credErr != nil {
-				return UsageCacheResult{Success: false, Message: "無法讀取 IdC 認證資訊: " + credErr.Error()}
-			}
-			newTokenInfo, err = tokenrefresh.RefreshAccessTokenFromBackup(token, hashedMachineID, clientID, clientSecret)
-		} else {
-			// Social 認證或其他情況，使用原有邏輯
-			newTokenInfo, err = tokenrefresh.RefreshAccessToken(token, hashedMachineID)
-		}
-
+		// 刷新 Token 並持久化至備份（需求 1.1, 1.2, 1.3, 3.1, 3.2）
+		newTokenInfo, err := backup.RefreshAndWriteBackupContext(ctx, name)
 		if err != nil {
 			// 刷新失敗，返回錯誤（需求 1.5）
 			return UsageCacheResult{Success: false, Message: err.Error()}
 		}
 
-		// 更新 token 結構的新值（需求 1.2, 1.3）
+		// 更新 token 結構的新值，供後續 API 呼叫使用
 		token.AccessToken = newTokenInfo.AccessToken
 		token.ExpiresAt = newTokenInfo.ExpiresAt.UTC().Format("2006-01-02T15:04:05.000Z")
-
-		// 呼叫 WriteBackupToken() 持久化刷新後的 token（需求 3.1, 3.2）
-		if err := backup.WriteBackupToken(name, token.AccessToken, token.ExpiresAt); err != nil {
-			return UsageCacheResult{Success: false, Message: "Token 刷新成功但寫入失敗: " + err.Error()}
-		}
 	}
 
 	// 呼叫 API 取得用量資訊（需求 1.4）
@@ -308,6 +365,73 @@ func (a *App) RefreshBackupUsage(name string) UsageCacheResult {
 	}
 }
 
+// RefreshAllBackupUsage 刷新所有備份的餘額緩存
+// 個別備份失敗不影響其他備份，失敗的備份會列在訊息中
+func (a *App) RefreshAllBackupUsage() Result {
+	backups, err := backup.ListBackups()
+	if err != nil {
+		return Result{Success: false, Message: fmt.Sprintf("讀取備份列表失敗: %v", err)}
+	}
+
+	var names []string
+	for _, b := range backups {
+		if b.Name == backup.OriginalBackupName || !b.HasToken {
+			continue
+		}
+		names = append(names, b.Name)
+	}
+
+	errs := usage.RefreshAllUsageCaches(names, fetchBackupUsage)
+	if len(errs) == 0 {
+		return Result{Success: true, Message: fmt.Sprintf("已刷新 %d 個備份的餘額", len(names))}
+	}
+
+	failures := make([]string, len(errs))
+	for i, err := range errs {
+		failures[i] = err.Error()
+	}
+	return Result{
+		Success: len(errs) < len(names),
+		Message: fmt.Sprintf("已刷新 %d/%d 個備份的餘額，失敗: %s", len(names)-len(errs), len(names), strings.Join(failures, "; ")),
+	}
+}
+
+// fetchBackupUsage 以備份的 token 與 Machine ID 查詢餘額（token 刷新由呼叫端處理）
+func fetchBackupUsage(name string) (*backup.UsageCache, error) {
+	mid, err := backup.ReadBackupMachineID(name)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := backup.ReadBackupToken(name)
+	if err != nil {
+		return nil, err
+	}
+
+	usageInfo, err := usage.GetUsageLimitsWithMachineID(token, machineid.HashMachineID(mid.MachineID))
+	if err != nil {
+		return nil, err
+	}
+	if usageInfo == nil || usageInfo.SubscriptionTitle == "" {
+		return nil, errors.New("usage info unavailable")
+	}
+
+	// 使用設定的閾值重新計算 IsLowBalance
+	threshold := settings.GetLowBalanceThreshold()
+	isLowBalance := false
+	if usageInfo.UsageLimit > 0 {
+		isLowBalance = (usageInfo.Balance / usageInfo.UsageLimit) < threshold
+	}
+
+	return &backup.UsageCache{
+		SubscriptionTitle: usageInfo.SubscriptionTitle,
+		UsageLimit:        usageInfo.UsageLimit,
+		CurrentUsage:      usageInfo.CurrentUsage,
+		Balance:           usageInfo.Balance,
+		IsLowBalance:      isLowBalance,
+	}, nil
+}
+
 // CreateBackup 建立新備份
 func (a *App) CreateBackup(name string) Result {
 	if name == "" {
@@ -322,75 +446,121 @@ func (a *App) CreateBackup(name string) Result {
 }
 
 // SwitchToBackup 切換至指定備份帳號（恢復 token）
-// V4 Patch 支援動態讀取 Machine ID，無需重啟 Kiro IDE
+// Patch 支援動態讀取 Machine ID，無需重啟 Kiro IDE
 // 切換前會先刷新 Token，確保載入至 SSO 文件夾的 Token 是有效的
 func (a *App) SwitchToBackup(name string) Result {
 	// 嘗試取得全域切換鎖，避免與自動切換衝突
 	if !globalSwitchMu.TryLock() {
-		return Result{Success: false, Message: "正在切換中，請稍後再試"}
+		return a.emitDone(OperationSwitch, switchBusyResult())
 	}
 	defer globalSwitchMu.Unlock()
 
+	return a.switchToBackupLocked(name)
+}
+
+// switchBusyResult 全域切換鎖已被其他切換或重置操作佔用時的結果
+func switchBusyResult() Result {
+	return Result{Success: false, Message: "正在切換中，請稍後再試", ErrorCode: ErrorCodeBusy}
+}
+
+// switchToBackupLocked 執行切換流程並發送 operation:done，呼叫者必須已持有 globalSwitchMu
+func (a *App) switchToBackupLocked(name string) Result {
+	return a.emitDone(OperationSwitch, a.runSwitch(name))
+}
+
+// runSwitch 依序刷新（Token 過期時）、恢復備份並確保 extension.js 已 patch，各階段發送 operation:progress
+func (a *App) runSwitch(name string) Result {
 	if name == "" {
-		return Result{Success: false, Message: "請選擇備份"}
+		return Result{Success: false, Message: "請選擇備份", ErrorCode: ErrorCodeInvalidInput}
 	}
 
 	if !backup.BackupExists(name) {
-		return Result{Success: false, Message: "備份不存在"}
+		return Result{Success: false, Message: "備份不存在", ErrorCode: ErrorCodeBackupNotFound}
 	}
 
-	// 讀取備份的 Machine ID（用於 Token 刷新）
-	mid, err := backup.ReadBackupMachineID(name)
-	if err != nil {
-		return Result{Success: false, Message: "無法讀取備份的 Machine ID"}
+	// 讀取備份的 Machine ID（確認備份完整）
+	if _, err := backup.ReadBackupMachineID(name); err != nil {
+		return Result{Success: false, Message: "無法讀取備份的 Machine ID", ErrorCode: ErrorCodeInvalidBackup}
 	}
-	hashedMachineID := machineid.HashMachineID(mid.MachineID)
 
 	// 讀取備份的 token
 	token, err := backup.ReadBackupToken(name)
 	if err != nil {
-		return Result{Success: false, Message: "無法讀取備份的 token"}
+		return Result{Success: false, Message: "無法讀取備份的 token", ErrorCode: ErrorCodeInvalidBackup}
 	}
 
-	// 檢查 token 是否已過期，若過期則先刷新
+	// 檢查 token 是否已過期，若過期則以備份的 Machine ID 雜湊刷新並寫入備份目錄
+	// 在恢復前刷新，刷新失敗時不會覆蓋目前登入的帳號，避免 Kiro 載入過期 Token 後立即登出
 	if awssso.IsTokenExpired(token) {
-		var newTokenInfo *tokenrefresh.TokenInfo
-		var refreshErr error
-
-		// 檢查是否為 IdC 認證，如果是則從備份目錄讀取 clientId/clientSecret
-		authType := tokenrefresh.DetectAuthType(token)
-		if authType == "idc" && token.ClientIdHash != "" {
-			// 從備份目錄讀取 IdC credentials
-			clientID, clientSecret, credErr := backup.ReadBackupIdCCredentials(name, token.ClientIdHash)
-			if credErr != nil {
-				return Result{Success: false, Message: "無法讀取 IdC 認證資訊: " + credErr.Error()}
+		a.emitProgress(OperationSwitch, StageRefreshingToken)
+		if _, err := refreshBackupFunc(name); err != nil {
+			if errors.Is(err, tokenrefresh.ErrTokenExpired) {
+				return errorResult("此帳號的 Token 已失效，請重新登入後再切換", err)
 			}
-			newTokenInfo, refreshErr = tokenrefresh.RefreshAccessTokenFromBackup(token, hashedMachineID, clientID, clientSecret)
-		} else {
-			// Social 認證或其他情況
-			newTokenInfo, refreshErr = tokenrefresh.RefreshAccessToken(token, hashedMachineID)
-		}
-
-		if refreshErr != nil {
 			// Token 刷新失敗，返回錯誤提示用戶
-			return Result{Success: false, Message: fmt.Sprintf("Token 刷新失敗，無法切換: %v", refreshErr)}
-		}
-
-		// 將刷新後的 Token 寫入備份目錄
-		newExpiresAt := newTokenInfo.ExpiresAt.UTC().Format("2006-01-02T15:04:05.000Z")
-		if err := backup.WriteBackupToken(name, newTokenInfo.AccessToken, newExpiresAt); err != nil {
-			return Result{Success: false, Message: "Token 刷新成功但寫入失敗: " + err.Error()}
+			return Result{Success: false, Message: fmt.Sprintf("Token 刷新失敗，無法切換: %v", err), ErrorCode: ErrorCodeTokenRefresh}
 		}
 	}
 
 	// 執行恢復操作（將備份的 Token 複製到 SSO 目錄）
-	if err := backup.RestoreBackup(name); err != nil {
-		return Result{Success: false, Message: fmt.Sprintf("恢復 Token 失敗: %v", err)}
+	// Machine ID 透過 softreset 寫入 custom-machine-id，所有平台皆不需要管理員權限
+	a.emitProgress(OperationSwitch, StageRestoringToken)
+	if err := restoreBackupFunc(name); err != nil {
+		return errorResult(fmt.Sprintf("恢復 Token 失敗: %v", err), err)
+	}
+
+	// 確保 extension.js 已 patch，Kiro 才會讀取自訂的 Machine ID
+	a.emitProgress(OperationSwitch, softreset.StagePatching)
+	if err := patchExtensionFunc(); err != nil && err != softreset.ErrExtensionNotFound {
+		println("Warning: Failed to patch extension.js:", err.Error())
 	}
 
+	a.emitProgress(OperationSwitch, StageDone)
 	return Result{Success: true, Message: "切換成功"}
 }
 
+// SwitchPreview 切換帳號的預覽（不會寫入任何檔案）
+type SwitchPreview struct {
+	Name          string `json:"name"`
+	MachineID     string `json:"machineId"`     // 切換後使用的 Machine ID
+	KiroRunning   bool   `json:"kiroRunning"`   // Kiro 是否正在運行
+	RequiresAdmin bool   `json:"requiresAdmin"` // patch extension.js 是否需要管理員權限
+	Provider      string `json:"provider"`      // Token 提供者（Github、Google、BuilderId 等）
+	TokenExpired  bool   `json:"tokenExpired"`  // Token 是否已過期（切換時會先刷新）
+	ExpiresAt     string `json:"expiresAt"`     // Token 過期時間（RFC3339），空字串表示未知
+}
+
+// PreviewSwitch 預覽切換至指定備份將會發生的變更（唯讀，供確認對話框使用）
+func (a *App) PreviewSwitch(name string) (*SwitchPreview, error) {
+	if name == "" {
+		return nil, backup.ErrInvalidBackupName
+	}
+
+	machineID, err := backup.ReadBackupMachineID(name)
+	if err != nil {
+		return nil, err
+	}
+	token, err := backup.ReadBackupToken(name)
+	if err != nil {
+		return nil, err
+	}
+
+	preview := &SwitchPreview{
+		Name:         name,
+		MachineID:    machineID.MachineID,
+		KiroRunning:  kiroprocess.IsKiroRunning(),
+		Provider:     token.Provider,
+		TokenExpired: awssso.IsTokenExpired(token),
+	}
+	if expiresAt, err := backup.ParseTokenExpiry(token); err == nil && !expiresAt.IsZero() {
+		preview.ExpiresAt = expiresAt.Format(time.RFC3339)
+	}
+	// 找不到 extension.js 時切換也不會 patch，視為不需要管理員權限
+	preview.RequiresAdmin, _ = softreset.PatchRequiresAdmin()
+
+	return preview, nil
+}
+
 
 
 // DeleteBackup 刪除備份
@@ -406,6 +576,77 @@ func (a *App) DeleteBackup(name string) Result {
 	return Result{Success: true, Message: "刪除成功"}
 }
 
+// RenameBackup 重新命名備份（保留文件夾歸屬）
+func (a *App) RenameBackup(oldName, newName string) Result {
+	if oldName == backup.OriginalBackupName {
+		return Result{Success: false, Message: "不能重新命名原始備份"}
+	}
+
+	if err := backup.RenameBackup(oldName, newName); err != nil {
+		return Result{Success: false, Message: err.Error()}
+	}
+
+	return Result{Success: true, Message: "重新命名成功"}
+}
+
+// DuplicateBackup 複製備份至新名稱
+// inheritFolder: true 表示新備份放入與來源相同的文件夾，false 表示放在未分類
+func (a *App) DuplicateBackup(srcName, newName string, inheritFolder bool) Result {
+	if err := backup.DuplicateBackupWithFolder(srcName, newName, inheritFolder); err != nil {
+		return Result{Success: false, Message: err.Error()}
+	}
+
+	return Result{Success: true, Message: "複製成功"}
+}
+
+// RefreshAllBackups 批次刷新所有快照的 Token（跳過原始備份）
+func (a *App) RefreshAllBackups() Result {
+	results, err := backup.RefreshAllBackups(backup.DefaultRefreshConcurrency)
+	if err != nil {
+		return Result{Success: false, Message: fmt.Sprintf("讀取快照列表失敗: %v", err)}
+	}
+	if len(results) == 0 {
+		return Result{Success: true, Message: "沒有需要刷新的快照"}
+	}
+
+	var failed []string
+	for name, outcome := range results {
+		if outcome.Err != nil {
+			failed = append(failed, fmt.Sprintf("%s: %v", name, outcome.Err))
+		}
+	}
+
+	if len(failed) > 0 {
+		sort.Strings(failed)
+		return Result{
+			Success: false,
+			Message: fmt.Sprintf("已刷新 %d 個快照，%d 個失敗\n%s", len(results)-len(failed), len(failed), strings.Join(failed, "\n")),
+		}
+	}
+
+	return Result{Success: true, Message: fmt.Sprintf("已刷新 %d 個快照", len(results))}
+}
+
+// CheckBackupRefresh 檢查指定備份的 refresh token 是否仍可使用（只試刷新，不寫回備份）
+func (a *App) CheckBackupRefresh(name string) Result {
+	if name == "" {
+		return Result{Success: false, Message: "備份名稱不能為空", ErrorCode: ErrorCodeInvalidInput}
+	}
+
+	info, err := backup.CheckBackupRefresh(name)
+	if err != nil {
+		if errors.Is(err, tokenrefresh.ErrTokenExpired) {
+			return errorResult("Token 已失效，請重新登入", err)
+		}
+		return errorResult(fmt.Sprintf("Token 刷新失敗: %v", err), err)
+	}
+
+	return Result{
+		Success: true,
+		Message: fmt.Sprintf("Token 有效，刷新後將於 %s 過期", info.ExpiresAt.Local().Format("2006-01-02 15:04")),
+	}
+}
+
 // RegenerateMachineID 為指定備份生成新的機器碼
 func (a *App) RegenerateMachineID(name string) Result {
 	if name == "" {
@@ -523,16 +764,9 @@ func (a *App) EnsureOriginalBackup() Result {
 
 // onSecondInstanceLaunch 處理第二個實例啟動 (deep link 回調)
 func (a *App) onSecondInstanceLaunch(data options.SecondInstanceData) {
-	// 檢查是否有 deep link URL
-	for _, arg := range data.Args {
-		if strings.HasPrefix(arg, "kiro://") {
-			// 解析並處理 deep link
-			result, err := deeplink.HandleDeepLinkCallback(arg)
-			if err == nil {
-				deeplink.SendCallback(result)
-			}
-			break
-		}
+	// 檢查是否有 deep link URL，轉交給等待中的登入流程
+	if rawURL, ok := deeplink.FindDeepLinkArg(data.Args); ok {
+		deeplink.ForwardDeepLink(rawURL)
 	}
 
 	// 聚焦視窗
@@ -545,6 +779,42 @@ func (a *App) IsDeepLinkSupported() bool {
 	return deeplink.IsDeepLinkSupported()
 }
 
+// IsURLSchemeRegistered 檢查 kiro:// URL Scheme 是否已註冊
+func (a *App) IsURLSchemeRegistered() bool {
+	registered, err := deeplink.IsURLSchemeRegistered()
+	return err == nil && registered
+}
+
+// EnableBrowserLogin 將 kiro:// URL Scheme 註冊到本程式（HKCU，無需管理員權限）
+// 重複呼叫會覆寫為當前執行檔路徑
+func (a *App) EnableBrowserLogin() Result {
+	if !deeplink.IsDeepLinkSupported() {
+		return Result{Success: false, Message: "此平台不支援瀏覽器登入"}
+	}
+
+	exePath, err := os.Executable()
+	if err != nil {
+		return Result{Success: false, Message: fmt.Sprintf("取得執行檔路徑失敗: %v", err)}
+	}
+
+	if err := deeplink.RegisterURLScheme(exePath); err != nil {
+		return Result{Success: false, Message: fmt.Sprintf("註冊 URL Scheme 失敗: %v", err)}
+	}
+	return Result{Success: true, Message: "已啟用瀏覽器登入"}
+}
+
+// DisableBrowserLogin 移除 kiro:// URL Scheme 註冊
+func (a *App) DisableBrowserLogin() Result {
+	if !deeplink.IsDeepLinkSupported() {
+		return Result{Success: false, Message: "此平台不支援瀏覽器登入"}
+	}
+
+	if err := deeplink.UnregisterURLScheme(); err != nil {
+		return Result{Success: false, Message: fmt.Sprintf("移除 URL Scheme 失敗: %v", err)}
+	}
+	return Result{Success: true, Message: "已停用瀏覽器登入"}
+}
+
 // GetAppInfo 取得應用資訊
 func (a *App) GetAppInfo() map[string]string {
 	return map[string]string{
@@ -639,6 +909,27 @@ func (a *App) GetCurrentUsageInfo() *CurrentUsageInfo {
 	}
 }
 
+// RefreshCurrentToken 刷新當前登入帳號的 Token 並寫回 kiro-auth-token.json
+func (a *App) RefreshCurrentToken() Result {
+	hashedMachineID := machineid.HashMachineID(a.GetCurrentMachineID())
+	if _, err := tokenrefresh.RefreshCurrentToken(hashedMachineID); err != nil {
+		return Result{Success: false, Message: err.Error()}
+	}
+
+	return Result{Success: true, Message: "Token 刷新成功"}
+}
+
+// GetTokenRefreshStats 取得 Token 刷新次數統計（診斷用）
+func (a *App) GetTokenRefreshStats() tokenrefresh.RefreshStats {
+	return tokenrefresh.GetRefreshStats()
+}
+
+// ResetTokenRefreshStats 將 Token 刷新次數統計歸零
+func (a *App) ResetTokenRefreshStats() Result {
+	tokenrefresh.ResetRefreshStats()
+	return Result{Success: true, Message: "已重置刷新統計"}
+}
+
 // findBackupByMachineID 根據 Machine ID 查找對應的備份名稱
 func (a *App) findBackupByMachineID(machineID string) string {
 	backups, err := backup.ListBackups()
@@ -682,24 +973,122 @@ func (a *App) GetKiroProcesses() []kiroprocess.ProcessInfo {
 // SoftResetStatus 重置狀態（前端用）
 type SoftResetStatus struct {
 	IsPatched       bool   `json:"isPatched"`
+	PatchCorrupted  bool   `json:"patchCorrupted"`
+	KiroUpdated     bool   `json:"kiroUpdated"`
+	PatchState      string `json:"patchState"`
 	HasCustomID     bool   `json:"hasCustomId"`
 	CustomMachineID string `json:"customMachineId"`
 	ExtensionPath   string `json:"extensionPath"`
 	IsSupported     bool   `json:"isSupported"`
+	// Installs 每個 Kiro 安裝各自的 patch 狀態
+	Installs []softreset.InstallPatchStatus `json:"installs"`
+}
+
+// SoftResetResponse 一鍵新機的結果（前端用）
+// MachineID 為完整的新 Machine ID，Message 中只顯示前 8 碼
+type SoftResetResponse struct {
+	Success   bool   `json:"success"`
+	Message   string `json:"message"`
+	ErrorCode string `json:"errorCode,omitempty"`
+	MachineID string `json:"machineId,omitempty"` // 成功時的新 Machine ID（原始值）
+}
+
+// newSoftResetResponse 以 Result 與新的 Machine ID 組成 SoftResetResponse
+func newSoftResetResponse(result Result, machineID string) SoftResetResponse {
+	return SoftResetResponse{
+		Success:   result.Success,
+		Message:   result.Message,
+		ErrorCode: result.ErrorCode,
+		MachineID: machineID,
+	}
 }
 
 // SoftResetToNewMachine 一鍵新機（跨平台，不需要管理員權限）
-// V4 Patch 支援動態讀取 Machine ID，無需重啟 Kiro IDE
-func (a *App) SoftResetToNewMachine() Result {
-	result, err := softreset.SoftResetEnvironment()
+// 執行前會強制關閉 Kiro IDE，避免寫入 Machine ID 與 patch extension.js 時 Kiro 仍在讀取
+func (a *App) SoftResetToNewMachine() SoftResetResponse {
+	return a.softReset(softreset.GenerateNewMachineID())
+}
+
+// softReset 關閉 Kiro 後以指定的 Machine ID 執行一鍵新機
+// 各階段透過 operation:progress 事件通知前端，結束時發送 operation:done
+func (a *App) softReset(id string) SoftResetResponse {
+	// 與切換共用全域鎖，避免同時改寫 Machine ID
+	if !globalSwitchMu.TryLock() {
+		return newSoftResetResponse(a.emitDone(OperationSoftReset, switchBusyResult()), "")
+	}
+	defer globalSwitchMu.Unlock()
+
+	result, machineID := a.runSoftReset(id)
+	return newSoftResetResponse(a.emitDone(OperationSoftReset, result), machineID)
+}
+
+// runSoftReset 執行一鍵新機並發送 operation:progress，成功時同時返回新的 Machine ID
+func (a *App) runSoftReset(id string) (Result, string) {
+	a.emitProgress(OperationSoftReset, StageClosingKiro)
+	if result := closeKiro(); result != nil {
+		return *result, ""
+	}
+
+	result, err := softResetFunc(id, func(stage string) {
+		a.emitProgress(OperationSoftReset, stage)
+	})
 	if err != nil {
-		return Result{Success: false, Message: err.Error()}
+		if errors.Is(err, softreset.ErrInvalidMachineID) {
+			return Result{Success: false, Message: "Machine ID 格式無效，請輸入 64 位十六進位字串或 UUID", ErrorCode: ErrorCodeInvalidInput}, ""
+		}
+		if errors.Is(err, softreset.ErrKiroRunning) {
+			return Result{Success: false, Message: "Kiro 執行中，無法修改 extension.js，請先關閉 Kiro 後重試", ErrorCode: ErrorCodeKiroRunning}, ""
+		}
+		return errorResult(err.Error(), err), ""
 	}
 
+	a.emitProgress(OperationSoftReset, StageDone)
 	return Result{
 		Success: true,
 		Message: fmt.Sprintf("重置成功！新 Machine ID: %s", result.NewMachineID[:8]+"..."),
+	}, result.NewMachineID
+}
+
+// SetMachineID 手動設定 Machine ID（64 位十六進位或 UUID）
+// 關閉 Kiro 並確保 extension.js 已 patch 後才寫入自訂 Machine ID 與 storage.json，
+// patch 失敗時不會留下只寫入一半的狀態
+func (a *App) SetMachineID(id string) Result {
+	// 與切換共用全域鎖，避免同時改寫 Machine ID
+	if !globalSwitchMu.TryLock() {
+		return switchBusyResult()
+	}
+	defer globalSwitchMu.Unlock()
+
+	if err := machineid.ValidateRawMachineID(id); err != nil {
+		return Result{Success: false, Message: "Machine ID 格式無效，請輸入 64 位十六進位字串或 UUID", ErrorCode: ErrorCodeInvalidInput}
+	}
+
+	if result := closeKiro(); result != nil {
+		return *result
+	}
+
+	if err := patchExtensionFunc(); err != nil {
+		if errors.Is(err, softreset.ErrKiroRunning) {
+			return Result{Success: false, Message: "Kiro 執行中，無法修改 extension.js，請先關閉 Kiro 後重試", ErrorCode: ErrorCodeKiroRunning}
+		}
+		return errorResult(fmt.Sprintf("Patch extension.js 失敗: %v", err), err)
 	}
+
+	if err := softreset.SetCustomMachineIDRaw(id); err != nil {
+		return errorResult(fmt.Sprintf("設定 Machine ID 失敗: %v", err), err)
+	}
+
+	return Result{Success: true, Message: "已設定 Machine ID"}
+}
+
+// PreviewSoftReset 預覽一鍵新機將會修改的內容（唯讀，供確認對話框使用）
+func (a *App) PreviewSoftReset() (*softreset.SoftResetPreview, error) {
+	return softreset.PreviewSoftReset()
+}
+
+// SoftResetToMachineID 以預覽時顯示的 Machine ID 執行一鍵新機
+func (a *App) SoftResetToMachineID(id string) SoftResetResponse {
+	return a.softReset(id)
 }
 
 // GetSoftResetStatus 取得重置狀態
@@ -716,34 +1105,65 @@ func (a *App) GetSoftResetStatus() SoftResetStatus {
 	}
 
 	status.IsPatched = softStatus.IsPatched
+	status.PatchCorrupted = softStatus.PatchCorrupted
+	status.KiroUpdated = softStatus.KiroUpdated
+	status.PatchState = softStatus.PatchState
 	status.HasCustomID = softStatus.HasCustomID
 	status.CustomMachineID = softStatus.CustomMachineID
 	status.ExtensionPath = softStatus.ExtensionPath
+	status.Installs = softStatus.Installs
 
 	return status
 }
 
+// kiroCloseTimeout 關閉 Kiro 後等待進程結束的時間上限
+const kiroCloseTimeout = 5 * time.Second
+
+// closeKiro 強制關閉執行中的 Kiro，並等待進程結束後才返回，避免寫入檔案時 Kiro 仍在讀取
+// 成功（或 Kiro 未執行）時返回 nil，否則返回失敗結果
+func closeKiro() *Result {
+	if !kiroprocess.IsKiroRunning() {
+		return nil
+	}
+
+	if _, err := kiroprocess.KillKiroProcessesAndWait(kiroCloseTimeout); err != nil {
+		if errors.Is(err, kiroprocess.ErrKillTimeout) {
+			return &Result{Success: false, Message: "無法關閉 Kiro，請手動關閉後重試", ErrorCode: ErrorCodeKiroRunning}
+		}
+		return &Result{Success: false, Message: fmt.Sprintf("關閉 Kiro 失敗: %v", err), ErrorCode: ErrorCodeKiroRunning}
+	}
+	return nil
+}
+
 // RestoreSoftReset 還原重置（恢復系統原始 Machine ID）
 func (a *App) RestoreSoftReset() Result {
+	// 與切換共用全域鎖，避免同時改寫 Machine ID 與 Token
+	if !globalSwitchMu.TryLock() {
+		return a.emitDone(OperationRestoreOriginal, switchBusyResult())
+	}
+	defer globalSwitchMu.Unlock()
+
+	return a.emitDone(OperationRestoreOriginal, a.restoreOriginal())
+}
+
+// restoreOriginal 還原原始 Machine ID 並恢復對應的備份，各階段發送 operation:progress
+func (a *App) restoreOriginal() Result {
 	// 檢測並強制關閉 Kiro
-	if kiroprocess.IsKiroRunning() {
-		killed, err := kiroprocess.KillKiroProcesses()
-		if err != nil {
-			return Result{Success: false, Message: fmt.Sprintf("關閉 Kiro 失敗: %v", err)}
-		}
-		if killed == 0 && kiroprocess.IsKiroRunning() {
-			return Result{Success: false, Message: "無法關閉 Kiro，請手動關閉後重試"}
-		}
+	a.emitProgress(OperationRestoreOriginal, StageClosingKiro)
+	if result := closeKiro(); result != nil {
+		return *result
 	}
 
 	// 執行還原（刪除自訂 Machine ID、還原 extension.js）
+	a.emitProgress(OperationRestoreOriginal, StageSettingMachineID)
 	if err := softreset.RestoreOriginalMachineID(); err != nil {
-		return Result{Success: false, Message: err.Error()}
+		return errorResult(err.Error(), err)
 	}
 
 	// 取得系統原始 Machine ID（原始 UUID，用於比對備份）
 	originalMachineID, err := machineid.GetRawMachineId()
 	if err != nil {
+		a.emitProgress(OperationRestoreOriginal, StageDone)
 		return Result{Success: true, Message: "已還原為系統原始 Machine ID（無法讀取機器碼）"}
 	}
 
@@ -754,7 +1174,9 @@ func (a *App) RestoreSoftReset() Result {
 			backupMID, err := backup.ReadBackupMachineID(b.Name)
 			if err == nil && backupMID.MachineID == originalMachineID {
 				// 找到匹配的備份，恢復 SSO cache（token）
+				a.emitProgress(OperationRestoreOriginal, StageRestoringToken)
 				if err := backup.RestoreBackup(b.Name); err == nil {
+					a.emitProgress(OperationRestoreOriginal, StageDone)
 					return Result{
 						Success: true,
 						Message: fmt.Sprintf("已還原為系統原始 Machine ID，並恢復帳號「%s」", b.Name),
@@ -765,24 +1187,19 @@ func (a *App) RestoreSoftReset() Result {
 		}
 	}
 
+	a.emitProgress(OperationRestoreOriginal, StageDone)
 	return Result{Success: true, Message: "已還原為系統原始 Machine ID"}
 }
 
 // RepatchExtension 重新 Patch extension.js（Kiro 更新後使用）
 func (a *App) RepatchExtension() Result {
 	// 檢測並強制關閉 Kiro
-	if kiroprocess.IsKiroRunning() {
-		killed, err := kiroprocess.KillKiroProcesses()
-		if err != nil {
-			return Result{Success: false, Message: fmt.Sprintf("關閉 Kiro 失敗: %v", err)}
-		}
-		if killed == 0 && kiroprocess.IsKiroRunning() {
-			return Result{Success: false, Message: "無法關閉 Kiro，請手動關閉後重試"}
-		}
+	if result := closeKiro(); result != nil {
+		return *result
 	}
 
 	if err := softreset.PatchExtensionJS(); err != nil {
-		return Result{Success: false, Message: err.Error()}
+		return errorResult(err.Error(), err)
 	}
 
 	return Result{Success: true, Message: "Patch 成功"}
@@ -791,18 +1208,45 @@ func (a *App) RepatchExtension() Result {
 // UnpatchExtension 移除 Patch（還原 extension.js）
 func (a *App) UnpatchExtension() Result {
 	// 檢測並強制關閉 Kiro
-	if kiroprocess.IsKiroRunning() {
-		killed, err := kiroprocess.KillKiroProcesses()
-		if err != nil {
-			return Result{Success: false, Message: fmt.Sprintf("關閉 Kiro 失敗: %v", err)}
-		}
-		if killed == 0 && kiroprocess.IsKiroRunning() {
-			return Result{Success: false, Message: "無法關閉 Kiro，請手動關閉後重試"}
-		}
+	if result := closeKiro(); result != nil {
+		return *result
 	}
 
 	if err := softreset.UnpatchExtensionJS(); err != nil {
-		return Result{Success: false, Message: err.Error()}
+		return errorResult(err.Error(), err)
+	}
+
+	return Result{Success: true, Message: "已移除 Patch"}
+}
+
+// ListKiroInstallPaths 列出所有偵測到的 Kiro 安裝路徑
+func (a *App) ListKiroInstallPaths() []string {
+	return kiropath.ListKiroInstallPaths()
+}
+
+// RepatchExtensionAt 對指定的 Kiro 安裝重新 Patch extension.js
+func (a *App) RepatchExtensionAt(installPath string) Result {
+	// 檢測並強制關閉 Kiro
+	if result := closeKiro(); result != nil {
+		return *result
+	}
+
+	if err := softreset.PatchExtensionJSAt(installPath); err != nil {
+		return errorResult(err.Error(), err)
+	}
+
+	return Result{Success: true, Message: "Patch 成功"}
+}
+
+// UnpatchExtensionAt 移除指定 Kiro 安裝的 Patch
+func (a *App) UnpatchExtensionAt(installPath string) Result {
+	// 檢測並強制關閉 Kiro
+	if result := closeKiro(); result != nil {
+		return *result
+	}
+
+	if err := softreset.UnpatchExtensionJSAt(installPath); err != nil {
+		return errorResult(err.Error(), err)
 	}
 
 	return Result{Success: true, Message: "已移除 Patch"}
@@ -822,22 +1266,35 @@ type RefreshIntervalDTO struct {
 
 // AutoSwitchSettingsDTO 前端用自動切換設定結構
 type AutoSwitchSettingsDTO struct {
-	Enabled            bool                 `json:"enabled"`
-	BalanceThreshold   float64              `json:"balanceThreshold"`
-	MinTargetBalance   float64              `json:"minTargetBalance"`
-	FolderIds          []string             `json:"folderIds"`
-	SubscriptionTypes  []string             `json:"subscriptionTypes"`
-	RefreshIntervals   []RefreshIntervalDTO `json:"refreshIntervals"`
-	NotifyOnSwitch     bool                 `json:"notifyOnSwitch"`
-	NotifyOnLowBalance bool                 `json:"notifyOnLowBalance"`
+	Enabled                 bool                    `json:"enabled"`
+	BalanceThreshold        float64                 `json:"balanceThreshold"`
+	MinTargetBalance        float64                 `json:"minTargetBalance"`
+	WarnThreshold           float64                 `json:"warnThreshold"`
+	FolderIds               []string                `json:"folderIds"`
+	FolderScoped            bool                    `json:"folderScoped"`
+	SubscriptionTypes       []string                `json:"subscriptionTypes"`
+	PreferSubscriptionOrder []string                `json:"preferSubscriptionOrder"`
+	Blacklist               []string                `json:"blacklist"`
+	RefreshIntervals        []RefreshIntervalDTO    `json:"refreshIntervals"`
+	CheckInterval           int                     `json:"checkInterval"` // 固定檢查間隔（秒），0 表示使用分級規則
+	MaxSwitchesPerDay       int                     `json:"maxSwitchesPerDay"`
+	MinSwitchInterval       int                     `json:"minSwitchInterval"` // 最短切換間隔（秒），0 表示不限制
+	ActiveHoursStart        string                  `json:"activeHoursStart"`
+	ActiveHoursEnd          string                  `json:"activeHoursEnd"`
+	ActiveWindows           []autoswitch.TimeWindow `json:"activeWindows"` // 依星期設定的允許時段
+	DryRun                  bool                    `json:"dryRun"`
+	WebhookURL              string                  `json:"webhookUrl"`
+	NotifyOnSwitch          bool                    `json:"notifyOnSwitch"`
+	NotifyOnLowBalance      bool                    `json:"notifyOnLowBalance"`
 }
 
 // AutoSwitchStatus 監控狀態（前端用）
 type AutoSwitchStatus struct {
-	Status            string  `json:"status"`            // "stopped", "running", "cooldown"
+	Status            string  `json:"status"` // "stopped", "running", "cooldown", "paused", "idle"
 	LastBalance       float64 `json:"lastBalance"`
 	CooldownRemaining int     `json:"cooldownRemaining"` // 秒
 	SwitchCount       int     `json:"switchCount"`
+	LastSwitchTarget  string  `json:"lastSwitchTarget"` // 最後一次自動切換的目標快照
 }
 
 // AppSettings 應用設定（前端用）
@@ -846,6 +1303,8 @@ type AppSettings struct {
 	KiroVersion           string  `json:"kiroVersion"`           // Kiro IDE 版本號
 	UseAutoDetect         bool    `json:"useAutoDetect"`         // 是否使用自動偵測版本號
 	CustomKiroInstallPath string  `json:"customKiroInstallPath"` // 自定義 Kiro 安裝路徑
+	AutoPruneBackups      bool    `json:"autoPruneBackups"`      // 是否於啟動時自動清理舊備份
+	AutoPruneKeepCount    int     `json:"autoPruneKeepCount"`    // 自動清理時保留的備份數量
 }
 
 // WindowSize 視窗尺寸結構
@@ -862,23 +1321,141 @@ func (a *App) GetSettings() AppSettings {
 		KiroVersion:           s.KiroVersion,
 		UseAutoDetect:         s.UseAutoDetect,
 		CustomKiroInstallPath: s.CustomKiroInstallPath,
+		AutoPruneBackups:      s.AutoPruneBackups,
+		AutoPruneKeepCount:    s.AutoPruneKeepCount,
 	}
 }
 
 // SaveSettings 儲存全域設定
+// 以當前設定為基礎更新，避免覆蓋視窗尺寸、自動切換等其他欄位
 func (a *App) SaveSettings(appSettings AppSettings) Result {
-	s := &settings.Settings{
-		LowBalanceThreshold:   appSettings.LowBalanceThreshold,
-		KiroVersion:           appSettings.KiroVersion,
-		UseAutoDetect:         appSettings.UseAutoDetect,
-		CustomKiroInstallPath: appSettings.CustomKiroInstallPath,
-	}
-	if err := settings.SaveSettings(s); err != nil {
+	s := *settings.GetCurrentSettings()
+	s.LowBalanceThreshold = appSettings.LowBalanceThreshold
+	s.KiroVersion = appSettings.KiroVersion
+	s.UseAutoDetect = appSettings.UseAutoDetect
+	s.CustomKiroInstallPath = appSettings.CustomKiroInstallPath
+	s.AutoPruneBackups = appSettings.AutoPruneBackups
+	s.AutoPruneKeepCount = appSettings.AutoPruneKeepCount
+	if err := settings.SaveSettings(&s); err != nil {
 		return Result{Success: false, Message: fmt.Sprintf("儲存設定失敗: %v", err)}
 	}
 	return Result{Success: true, Message: "設定已儲存"}
 }
 
+// RefreshEndpoints 自定義 Token 刷新端點（前端用），空字串表示使用內建端點
+type RefreshEndpoints struct {
+	Social string `json:"social"`
+	IdC    string `json:"idc"`
+}
+
+// GetRefreshEndpoints 取得自定義 Token 刷新端點
+func (a *App) GetRefreshEndpoints() RefreshEndpoints {
+	s := settings.GetCurrentSettings()
+	return RefreshEndpoints{
+		Social: s.SocialRefreshURLOverride,
+		IdC:    s.IdCRefreshURLOverride,
+	}
+}
+
+// SaveRefreshEndpoints 儲存自定義 Token 刷新端點（需為 https URL）
+// Kiro 更換認證主機時可直接調整，不需重新編譯
+func (a *App) SaveRefreshEndpoints(endpoints RefreshEndpoints) Result {
+	social := strings.TrimSpace(endpoints.Social)
+	idc := strings.TrimSpace(endpoints.IdC)
+	for _, endpoint := range []string{social, idc} {
+		if err := settings.ValidateRefreshEndpoint(endpoint); err != nil {
+			return Result{Success: false, Message: fmt.Sprintf("刷新端點必須是 https URL: %s", endpoint)}
+		}
+	}
+
+	s := *settings.GetCurrentSettings()
+	s.SocialRefreshURLOverride = social
+	s.IdCRefreshURLOverride = idc
+	if err := settings.SaveSettings(&s); err != nil {
+		return Result{Success: false, Message: fmt.Sprintf("儲存設定失敗: %v", err)}
+	}
+	return Result{Success: true, Message: "刷新端點已儲存"}
+}
+
+// ListSettingsProfiles 列出所有設定檔名稱（default 排在第一個）
+func (a *App) ListSettingsProfiles() ([]string, error) {
+	return settings.ListProfiles()
+}
+
+// GetActiveSettingsProfile 取得使用中的設定檔名稱
+func (a *App) GetActiveSettingsProfile() string {
+	return settings.GetActiveProfile()
+}
+
+// SaveSettingsAsProfile 將目前的設定另存為指定名稱的設定檔
+func (a *App) SaveSettingsAsProfile(name string) Result {
+	current := *settings.GetCurrentSettings()
+	if err := settings.SaveProfile(name, &current); err != nil {
+		if errors.Is(err, settings.ErrInvalidProfileName) {
+			return Result{Success: false, Message: "設定檔名稱無效"}
+		}
+		return Result{Success: false, Message: fmt.Sprintf("儲存設定檔失敗: %v", err)}
+	}
+	return Result{Success: true, Message: fmt.Sprintf("已儲存設定檔: %s", name)}
+}
+
+// SetActiveSettingsProfile 切換使用中的設定檔
+func (a *App) SetActiveSettingsProfile(name string) Result {
+	if err := settings.SetActiveProfile(name); err != nil {
+		switch {
+		case errors.Is(err, settings.ErrInvalidProfileName):
+			return Result{Success: false, Message: "設定檔名稱無效"}
+		case errors.Is(err, settings.ErrProfileNotFound):
+			return Result{Success: false, Message: "設定檔不存在"}
+		default:
+			return Result{Success: false, Message: fmt.Sprintf("切換設定檔失敗: %v", err)}
+		}
+	}
+	return Result{Success: true, Message: fmt.Sprintf("已切換至設定檔: %s", name)}
+}
+
+// GetBackupRoot 取得目前使用的備份根目錄
+func (a *App) GetBackupRoot() string {
+	root, err := backup.GetBackupRootPath()
+	if err != nil {
+		return ""
+	}
+	return root
+}
+
+// SetBackupRoot 設定備份根目錄，path 為空字串時恢復預設位置
+// moveExisting 為 true 時一併將既有備份搬移至新位置
+func (a *App) SetBackupRoot(path string, moveExisting bool) Result {
+	var err error
+	switch {
+	case moveExisting && path == "":
+		var defaultRoot string
+		if defaultRoot, err = backup.GetDefaultBackupRootPath(); err == nil {
+			err = backup.MoveBackupsRoot(defaultRoot)
+		}
+	case moveExisting:
+		err = backup.MoveBackupsRoot(path)
+	default:
+		err = backup.SetBackupRootOverride(path)
+	}
+
+	if err != nil {
+		switch {
+		case errors.Is(err, backup.ErrInvalidBackupRoot):
+			return Result{Success: false, Message: "備份目錄必須是可寫入的絕對路徑"}
+		case errors.Is(err, backup.ErrBackupExists):
+			return Result{Success: false, Message: fmt.Sprintf("新目錄已有同名備份: %v", err)}
+		default:
+			return Result{Success: false, Message: fmt.Sprintf("設定備份目錄失敗: %v", err)}
+		}
+	}
+
+	if path == "" {
+		return Result{Success: true, Message: "已恢復預設備份目錄"}
+	}
+	return Result{Success: true, Message: "備份目錄已更新"}
+}
+
 // GetWindowSize 取得已保存的視窗尺寸
 func (a *App) GetWindowSize() WindowSize {
 	s := settings.GetCurrentSettings()
@@ -890,16 +1467,10 @@ func (a *App) GetWindowSize() WindowSize {
 
 // SaveWindowSize 保存視窗尺寸
 func (a *App) SaveWindowSize(width, height int) Result {
-	s := settings.GetCurrentSettings()
-	newSettings := &settings.Settings{
-		LowBalanceThreshold:   s.LowBalanceThreshold,
-		KiroVersion:           s.KiroVersion,
-		UseAutoDetect:         s.UseAutoDetect,
-		CustomKiroInstallPath: s.CustomKiroInstallPath,
-		WindowWidth:           width,
-		WindowHeight:          height,
-	}
-	if err := settings.SaveSettings(newSettings); err != nil {
+	newSettings := *settings.GetCurrentSettings()
+	newSettings.WindowWidth = width
+	newSettings.WindowHeight = height
+	if err := settings.SaveSettings(&newSettings); err != nil {
 		return Result{Success: false, Message: fmt.Sprintf("保存視窗尺寸失敗: %v", err)}
 	}
 	return Result{Success: true, Message: "視窗尺寸已保存"}
@@ -1033,21 +1604,21 @@ type OAuthLoginResult struct {
 }
 
 // StartSocialLogin 啟動 Social 登入流程
-// 參數: provider 為 "Github" 或 "Google"
-// 設定 5 分鐘超時，自動開啟瀏覽器
+// 參數: provider 為已註冊的提供者（預設 "Github" 或 "Google"）
+// 設定 5 分鐘超時，自動開啟瀏覽器，可透過 CancelOAuthLogin 取消
 // Windows 平台使用 Deep Link 模式，其他平台使用本地 Callback Server 模式
 func (a *App) StartSocialLogin(provider string) OAuthLoginResult {
 	// 驗證 provider
-	if provider != oauthlogin.ProviderGithub && provider != oauthlogin.ProviderGoogle {
+	if err := oauthlogin.ValidateProvider(provider); err != nil {
 		return OAuthLoginResult{
 			Success: false,
-			Message: fmt.Sprintf("不支援的登入提供者: %s，請使用 Github 或 Google", provider),
+			Message: fmt.Sprintf("不支援的登入提供者: %s，請使用 %s", provider, strings.Join(oauthlogin.RegisteredProviders(), " 或 ")),
 		}
 	}
 
-	// 建立帶超時的 context
-	ctx, cancel := context.WithTimeout(a.ctx, 5*time.Minute)
-	defer cancel()
+	// 建立帶超時且可取消的 context
+	ctx, done := a.beginOAuthLogin(5 * time.Minute)
+	defer done()
 
 	// 配置 Social 登入
 	config := oauthlogin.SocialLoginCoordinatorConfig{
@@ -1067,6 +1638,32 @@ func (a *App) StartSocialLogin(provider string) OAuthLoginResult {
 		result, err = oauthlogin.SocialLogin(ctx, config)
 	}
 
+	return socialLoginResult(result, err)
+}
+
+// HasPendingSocialLogin 檢查是否有冷啟動時收到、尚未完成的 Social 登入回調
+func (a *App) HasPendingSocialLogin() bool {
+	return deeplink.GetPendingDeepLink() != nil
+}
+
+// ResumeSocialLogin 以冷啟動時收到的 deep link 回調恢復 Social 登入
+// 依回調的 state 載入前一個行程保存的 PKCE 參數並完成 Token 交換
+func (a *App) ResumeSocialLogin() OAuthLoginResult {
+	if deeplink.GetPendingDeepLink() == nil {
+		return OAuthLoginResult{Success: false, Message: "沒有待完成的登入"}
+	}
+
+	ctx, done := a.beginOAuthLogin(5 * time.Minute)
+	defer done()
+
+	result, err := oauthlogin.ResumeSocialLoginWithDeepLink(ctx, oauthlogin.SocialLoginCoordinatorConfig{
+		Timeout: 5 * time.Minute,
+	})
+	return socialLoginResult(result, err)
+}
+
+// socialLoginResult 將 Social 登入結果轉換為前端使用的 OAuthLoginResult
+func socialLoginResult(result *oauthlogin.LoginResult, err error) OAuthLoginResult {
 	if err != nil {
 		// 處理 OAuth 錯誤
 		if oauthErr, ok := err.(*oauthlogin.OAuthError); ok {
@@ -1096,16 +1693,65 @@ func (a *App) StartSocialLogin(provider string) OAuthLoginResult {
 	}
 }
 
+// beginOAuthLogin 建立可由 CancelOAuthLogin 取消的登入 context
+// 返回的 done 必須在登入結束時呼叫以釋放資源
+func (a *App) beginOAuthLogin(timeout time.Duration) (context.Context, func()) {
+	ctx, cancel := context.WithTimeout(a.ctx, timeout)
+
+	oauthLoginMu.Lock()
+	if oauthLoginCancel != nil {
+		// 同時只允許一個登入流程，取消前一個
+		oauthLoginCancel()
+	}
+	oauthLoginCancel = cancel
+	oauthLoginSeq++
+	seq := oauthLoginSeq
+	oauthLoginMu.Unlock()
+
+	return ctx, func() {
+		cancel()
+		oauthLoginMu.Lock()
+		defer oauthLoginMu.Unlock()
+		// 僅清除自己的取消函數，避免覆蓋後續開始的登入
+		if oauthLoginSeq == seq {
+			oauthLoginCancel = nil
+		}
+	}
+}
+
+// CancelOAuthLogin 取消進行中的 OAuth 登入流程
+// 進行中的 Start*Login 會立即返回「登入已取消」
+func (a *App) CancelOAuthLogin() Result {
+	oauthLoginMu.Lock()
+	defer oauthLoginMu.Unlock()
+
+	if oauthLoginCancel == nil {
+		return Result{Success: false, Message: "沒有進行中的登入"}
+	}
+	oauthLoginCancel()
+	oauthLoginCancel = nil
+	return Result{Success: true, Message: "已取消登入"}
+}
+
+// IdCDeviceCodeEvent IdC 設備授權資訊事件（"idc-device-code"）
+// 在開始輪詢前推送，讓前端顯示 userCode 或以 verificationUriComplete 產生 QR code
+type IdCDeviceCodeEvent struct {
+	UserCode                string `json:"userCode"`
+	VerificationUri         string `json:"verificationUri"`
+	VerificationUriComplete string `json:"verificationUriComplete"`
+	ExpiresIn               int    `json:"expiresIn"`
+}
+
 // IdCStartURL Kiro IdC 登入起始 URL
 const IdCStartURL = "https://view.awsapps.com/start"
 
 // StartIdCLogin 啟動 IdC 登入流程
-// 設定 5 分鐘超時，自動開啟瀏覽器
-// 返回結果包含 userCode 和 verificationUri 供前端顯示
+// 設定 5 分鐘超時，自動開啟瀏覽器，可透過 CancelOAuthLogin 取消
+// 輪詢前推送 "idc-device-code" 事件，返回結果也包含 userCode 和 verificationUri 供前端顯示
 func (a *App) StartIdCLogin() OAuthLoginResult {
-	// 建立帶超時的 context
-	ctx, cancel := context.WithTimeout(a.ctx, 5*time.Minute)
-	defer cancel()
+	// 建立帶超時且可取消的 context
+	ctx, done := a.beginOAuthLogin(5 * time.Minute)
+	defer done()
 
 	// 配置 IdC 登入
 	config := oauthlogin.IdCLoginCoordinatorConfig{
@@ -1114,37 +1760,60 @@ func (a *App) StartIdCLogin() OAuthLoginResult {
 		Timeout:     5 * time.Minute,
 		OpenBrowser: true,
 	}
+	// 重用已註冊的客戶端，避免每次登入都重新註冊
+	if cachePath, err := oauthlogin.GetIdCClientCachePath(); err == nil {
+		config.ClientCachePath = cachePath
+	}
 
-	// 執行登入
-	result, err := oauthlogin.IdCLogin(ctx, config)
+	// 註冊並啟動設備授權，先將 userCode 推送給前端顯示
+	authResp, creds, err := oauthlogin.IdCBeginLogin(ctx, config)
 	if err != nil {
-		// 處理 OAuth 錯誤
-		if oauthErr, ok := err.(*oauthlogin.OAuthError); ok {
-			switch oauthErr.Code {
-			case oauthlogin.ErrCodeTimeout:
-				return OAuthLoginResult{Success: false, Message: "登入超時，請重試"}
-			case oauthlogin.ErrCodeCancelled:
-				return OAuthLoginResult{Success: false, Message: "登入已取消"}
-			default:
-				return OAuthLoginResult{Success: false, Message: fmt.Sprintf("登入失敗: %s", oauthErr.Message)}
-			}
-		}
-		return OAuthLoginResult{Success: false, Message: fmt.Sprintf("登入失敗: %v", err)}
+		return idcLoginErrorResult(err)
+	}
+	wailsRuntime.EventsEmit(a.ctx, "idc-device-code", IdCDeviceCodeEvent{
+		UserCode:                authResp.UserCode,
+		VerificationUri:         authResp.VerificationUri,
+		VerificationUriComplete: authResp.VerificationUriComplete,
+		ExpiresIn:               authResp.ExpiresIn,
+	})
+
+	// 輪詢 Token 完成登入
+	result, err := oauthlogin.IdCCompleteLoginWithConfig(ctx, config, creds, authResp)
+	if err != nil {
+		return idcLoginErrorResult(err)
 	}
 
 	// 返回成功結果（包含 IdC 專用欄位）
 	return OAuthLoginResult{
-		Success:      true,
-		Message:      "登入成功",
-		AccessToken:  result.AccessToken,
-		RefreshToken: result.RefreshToken,
-		ExpiresAt:    result.ExpiresAt.Format(time.RFC3339),
-		Provider:     result.Provider,
-		AuthMethod:   result.AuthMethod,
-		ClientId:     result.ClientId,
-		ClientSecret: result.ClientSecret,
-		ClientIdHash: result.ClientIdHash,
+		Success:         true,
+		Message:         "登入成功",
+		AccessToken:     result.AccessToken,
+		RefreshToken:    result.RefreshToken,
+		ExpiresAt:       result.ExpiresAt.Format(time.RFC3339),
+		Provider:        result.Provider,
+		AuthMethod:      result.AuthMethod,
+		ClientId:        result.ClientId,
+		ClientSecret:    result.ClientSecret,
+		ClientIdHash:    result.ClientIdHash,
+		UserCode:        authResp.UserCode,
+		VerificationUri: authResp.VerificationUri,
+	}
+}
+
+// idcLoginErrorResult 將 IdC 登入錯誤轉換為前端結果
+func idcLoginErrorResult(err error) OAuthLoginResult {
+	// 處理 OAuth 錯誤
+	if oauthErr, ok := err.(*oauthlogin.OAuthError); ok {
+		switch oauthErr.Code {
+		case oauthlogin.ErrCodeTimeout:
+			return OAuthLoginResult{Success: false, Message: "登入超時，請重試"}
+		case oauthlogin.ErrCodeCancelled:
+			return OAuthLoginResult{Success: false, Message: "登入已取消"}
+		default:
+			return OAuthLoginResult{Success: false, Message: fmt.Sprintf("登入失敗: %s", oauthErr.Message)}
+		}
 	}
+	return OAuthLoginResult{Success: false, Message: fmt.Sprintf("登入失敗: %v", err)}
 }
 
 // CreateSnapshotFromOAuth 從 OAuth 登入結果建立環境快照
@@ -1161,7 +1830,7 @@ func (a *App) CreateSnapshotFromOAuth(name string, data OAuthLoginResult) Result
 	}
 
 	// 解析過期時間
-	expiresAt, err := time.Parse(time.RFC3339, data.ExpiresAt)
+	expiresAt, err := awssso.ParseExpiresAt(data.ExpiresAt)
 	if err != nil {
 		return Result{Success: false, Message: fmt.Sprintf("無效的過期時間格式: %v", err)}
 	}
@@ -1187,7 +1856,7 @@ func (a *App) CreateSnapshotFromOAuth(name string, data OAuthLoginResult) Result
 }
 
 // ValidateSnapshotName 驗證快照名稱是否有效
-// 規則：不可為空、不可包含非法字元、不可與現有快照重複
+// 規則：不可為空、不可包含非法字元、不可使用保留名稱、不可以 . 或空白結尾、不可過長、不可與現有快照重複
 func (a *App) ValidateSnapshotName(name string) Result {
 	if err := backup.ValidateSnapshotName(name); err != nil {
 		return Result{Success: false, Message: err.Error()}
@@ -1321,14 +1990,26 @@ func (a *App) GetAutoSwitchSettings() AutoSwitchSettingsDTO {
 			}
 		}
 		return AutoSwitchSettingsDTO{
-			Enabled:            defaults.Enabled,
-			BalanceThreshold:   defaults.BalanceThreshold,
-			MinTargetBalance:   defaults.MinTargetBalance,
-			FolderIds:          defaults.FolderIds,
-			SubscriptionTypes:  defaults.SubscriptionTypes,
-			RefreshIntervals:   refreshIntervalsDTO,
-			NotifyOnSwitch:     defaults.NotifyOnSwitch,
-			NotifyOnLowBalance: defaults.NotifyOnLowBalance,
+			Enabled:                 defaults.Enabled,
+			BalanceThreshold:        defaults.BalanceThreshold,
+			MinTargetBalance:        defaults.MinTargetBalance,
+			WarnThreshold:           defaults.WarnThreshold,
+			FolderIds:               defaults.FolderIds,
+			FolderScoped:            defaults.FolderScoped,
+			SubscriptionTypes:       defaults.SubscriptionTypes,
+			PreferSubscriptionOrder: defaults.PreferSubscriptionOrder,
+			Blacklist:               defaults.Blacklist,
+			RefreshIntervals:        refreshIntervalsDTO,
+			CheckInterval:           int(defaults.CheckInterval.Seconds()),
+			MaxSwitchesPerDay:       defaults.MaxSwitchesPerDay,
+			MinSwitchInterval:       int(defaults.MinSwitchInterval.Seconds()),
+			ActiveHoursStart:        defaults.ActiveHoursStart,
+			ActiveHoursEnd:          defaults.ActiveHoursEnd,
+			ActiveWindows:           defaults.ActiveWindows,
+			DryRun:                  defaults.DryRun,
+			WebhookURL:              defaults.WebhookURL,
+			NotifyOnSwitch:          defaults.NotifyOnSwitch,
+			NotifyOnLowBalance:      defaults.NotifyOnLowBalance,
 		}
 	}
 	// 轉換已保存的 RefreshIntervals 為 DTO
@@ -1341,14 +2022,26 @@ func (a *App) GetAutoSwitchSettings() AutoSwitchSettingsDTO {
 		}
 	}
 	return AutoSwitchSettingsDTO{
-		Enabled:            s.AutoSwitch.Enabled,
-		BalanceThreshold:   s.AutoSwitch.BalanceThreshold,
-		MinTargetBalance:   s.AutoSwitch.MinTargetBalance,
-		FolderIds:          s.AutoSwitch.FolderIds,
-		SubscriptionTypes:  s.AutoSwitch.SubscriptionTypes,
-		RefreshIntervals:   refreshIntervalsDTO,
-		NotifyOnSwitch:     s.AutoSwitch.NotifyOnSwitch,
-		NotifyOnLowBalance: s.AutoSwitch.NotifyOnLowBalance,
+		Enabled:                 s.AutoSwitch.Enabled,
+		BalanceThreshold:        s.AutoSwitch.BalanceThreshold,
+		MinTargetBalance:        s.AutoSwitch.MinTargetBalance,
+		WarnThreshold:           s.AutoSwitch.WarnThreshold,
+		FolderIds:               s.AutoSwitch.FolderIds,
+		FolderScoped:            s.AutoSwitch.FolderScoped,
+		SubscriptionTypes:       s.AutoSwitch.SubscriptionTypes,
+		PreferSubscriptionOrder: s.AutoSwitch.PreferSubscriptionOrder,
+		Blacklist:               s.AutoSwitch.Blacklist,
+		RefreshIntervals:        refreshIntervalsDTO,
+		CheckInterval:           int(s.AutoSwitch.CheckInterval.Seconds()),
+		MaxSwitchesPerDay:       s.AutoSwitch.MaxSwitchesPerDay,
+		MinSwitchInterval:       int(s.AutoSwitch.MinSwitchInterval.Seconds()),
+		ActiveHoursStart:        s.AutoSwitch.ActiveHoursStart,
+		ActiveHoursEnd:          s.AutoSwitch.ActiveHoursEnd,
+		ActiveWindows:           s.AutoSwitch.ActiveWindows,
+		DryRun:                  s.AutoSwitch.DryRun,
+		WebhookURL:              s.AutoSwitch.WebhookURL,
+		NotifyOnSwitch:          s.AutoSwitch.NotifyOnSwitch,
+		NotifyOnLowBalance:      s.AutoSwitch.NotifyOnLowBalance,
 	}
 }
 
@@ -1374,28 +2067,33 @@ func (a *App) SaveAutoSwitchSettings(dto AutoSwitchSettingsDTO) Result {
 
 	// 轉換 DTO 為 AutoSwitchSettings
 	autoSwitchSettings := &autoswitch.AutoSwitchSettings{
-		Enabled:            dto.Enabled,
-		BalanceThreshold:   dto.BalanceThreshold,
-		MinTargetBalance:   dto.MinTargetBalance,
-		FolderIds:          dto.FolderIds,
-		SubscriptionTypes:  dto.SubscriptionTypes,
-		RefreshIntervals:   refreshIntervals,
-		NotifyOnSwitch:     dto.NotifyOnSwitch,
-		NotifyOnLowBalance: dto.NotifyOnLowBalance,
+		Enabled:                 dto.Enabled,
+		BalanceThreshold:        dto.BalanceThreshold,
+		MinTargetBalance:        dto.MinTargetBalance,
+		WarnThreshold:           dto.WarnThreshold,
+		FolderIds:               dto.FolderIds,
+		FolderScoped:            dto.FolderScoped,
+		SubscriptionTypes:       dto.SubscriptionTypes,
+		PreferSubscriptionOrder: dto.PreferSubscriptionOrder,
+		Blacklist:               dto.Blacklist,
+		RefreshIntervals:        refreshIntervals,
+		CheckInterval:           time.Duration(dto.CheckInterval) * time.Second,
+		MaxSwitchesPerDay:       dto.MaxSwitchesPerDay,
+		MinSwitchInterval:       time.Duration(dto.MinSwitchInterval) * time.Second,
+		ActiveHoursStart:        dto.ActiveHoursStart,
+		ActiveHoursEnd:          dto.ActiveHoursEnd,
+		ActiveWindows:           dto.ActiveWindows,
+		DryRun:                  dto.DryRun,
+		WebhookURL:              dto.WebhookURL,
+		NotifyOnSwitch:          dto.NotifyOnSwitch,
+		NotifyOnLowBalance:      dto.NotifyOnLowBalance,
 	}
 
 	// 更新設定
-	newSettings := &settings.Settings{
-		LowBalanceThreshold:   s.LowBalanceThreshold,
-		KiroVersion:           s.KiroVersion,
-		UseAutoDetect:         s.UseAutoDetect,
-		CustomKiroInstallPath: s.CustomKiroInstallPath,
-		WindowWidth:           s.WindowWidth,
-		WindowHeight:          s.WindowHeight,
-		AutoSwitch:            autoSwitchSettings,
-	}
+	newSettings := *s
+	newSettings.AutoSwitch = autoSwitchSettings
 
-	if err := settings.SaveSettings(newSettings); err != nil {
+	if err := settings.SaveSettings(&newSettings); err != nil {
 		return Result{Success: false, Message: fmt.Sprintf("儲存設定失敗: %v", err)}
 	}
 
@@ -1410,6 +2108,16 @@ func (a *App) SaveAutoSwitchSettings(dto AutoSwitchSettingsDTO) Result {
 	return Result{Success: true, Message: "自動切換設定已儲存"}
 }
 
+// autoSwitchTo 自動切換監控器使用的切換函數
+// 監控器在呼叫前已取得 SwitchMu（globalSwitchMu），因此直接執行切換流程而不再次加鎖
+func (a *App) autoSwitchTo(ctx context.Context, targetName string) error {
+	result := a.switchToBackupLocked(targetName)
+	if !result.Success {
+		return fmt.Errorf("%s", result.Message)
+	}
+	return nil
+}
+
 // StartAutoSwitchMonitor 啟動監控
 func (a *App) StartAutoSwitchMonitor() Result {
 	s := settings.GetCurrentSettings()
@@ -1423,9 +2131,13 @@ func (a *App) StartAutoSwitchMonitor() Result {
 	// 如果監控器已存在且正在運行，直接返回
 	if autoSwitchMonitor != nil {
 		status := autoSwitchMonitor.GetStatus()
-		if status == autoswitch.StatusRunning || status == autoswitch.StatusCooldown {
+		if status == autoswitch.StatusRunning || status == autoswitch.StatusCooldown || status == autoswitch.StatusIdle {
 			return Result{Success: true, Message: "監控已在運行中"}
 		}
+		if status == autoswitch.StatusPaused {
+			autoSwitchMonitor.Resume()
+			return Result{Success: true, Message: "監控已恢復"}
+		}
 	}
 
 	// 建立監控器
@@ -1445,19 +2157,13 @@ func (a *App) StartAutoSwitchMonitor() Result {
 			}
 
 			// 刷新餘額
-			result := a.RefreshBackupUsage(backupName)
+			result := a.refreshBackupUsage(ctx, backupName)
 			if !result.Success {
 				return 0, fmt.Errorf("%s", result.Message)
 			}
 			return result.Balance, nil
 		},
-		SwitchFunc: func(ctx context.Context, targetName string) error {
-			result := a.SwitchToBackup(targetName)
-			if !result.Success {
-				return fmt.Errorf("%s", result.Message)
-			}
-			return nil
-		},
+		SwitchFunc: a.autoSwitchTo,
 		GetCurrentName: func() string {
 			return a.GetCurrentEnvironmentName()
 		},
@@ -1479,7 +2185,7 @@ func (a *App) StartAutoSwitchMonitor() Result {
 		},
 		ValidateCandidate: func(ctx context.Context, candidateName string) (float64, error) {
 			// 切換前驗證候選快照餘額
-			result := a.RefreshBackupUsage(candidateName)
+			result := a.refreshBackupUsage(ctx, candidateName)
 			if !result.Success {
 				return 0, fmt.Errorf("%s", result.Message)
 			}
@@ -1487,7 +2193,7 @@ func (a *App) StartAutoSwitchMonitor() Result {
 		},
 		ConfirmAfterSwitch: func(ctx context.Context, targetName string) (float64, error) {
 			// 切換後確認目標餘額狀態
-			result := a.RefreshBackupUsage(targetName)
+			result := a.refreshBackupUsage(ctx, targetName)
 			if !result.Success {
 				return 0, fmt.Errorf("%s", result.Message)
 			}
@@ -1514,6 +2220,34 @@ func (a *App) StopAutoSwitchMonitor() Result {
 	return Result{Success: true, Message: "監控已停止"}
 }
 
+// PauseAutoSwitchMonitor 暫停監控（保留設定、最後餘額與冷卻期狀態）
+func (a *App) PauseAutoSwitchMonitor() Result {
+	autoSwitchMonitorMu.RLock()
+	monitor := autoSwitchMonitor
+	autoSwitchMonitorMu.RUnlock()
+
+	if monitor == nil || monitor.GetStatus() == autoswitch.StatusStopped {
+		return Result{Success: false, Message: "監控未啟動"}
+	}
+
+	monitor.Pause()
+	return Result{Success: true, Message: "監控已暫停"}
+}
+
+// ResumeAutoSwitchMonitor 恢復已暫停的監控
+func (a *App) ResumeAutoSwitchMonitor() Result {
+	autoSwitchMonitorMu.RLock()
+	monitor := autoSwitchMonitor
+	autoSwitchMonitorMu.RUnlock()
+
+	if monitor == nil || monitor.GetStatus() != autoswitch.StatusPaused {
+		return Result{Success: false, Message: "監控未暫停"}
+	}
+
+	monitor.Resume()
+	return Result{Success: true, Message: "監控已恢復"}
+}
+
 // GetAutoSwitchStatus 取得監控狀態
 func (a *App) GetAutoSwitchStatus() AutoSwitchStatus {
 	autoSwitchMonitorMu.RLock()
@@ -1533,8 +2267,9 @@ func (a *App) GetAutoSwitchStatus() AutoSwitchStatus {
 	return AutoSwitchStatus{
 		Status:            string(status),
 		LastBalance:       monitor.GetLastBalance(),
-		CooldownRemaining: 0, // TODO: 從 SafetyState 取得
-		SwitchCount:       0, // TODO: 從 SafetyState 取得
+		CooldownRemaining: int(monitor.GetCooldownRemaining().Seconds()),
+		SwitchCount:       monitor.GetSwitchCount(),
+		LastSwitchTarget:  monitor.GetLastSwitchTarget(),
 	}
 }
 
@@ -2,9 +2,16 @@ package main
 
 import (
 	"context"
+	"fmt"
+	"os"
+	"sync"
 	"testing"
+	"time"
 
 	"kiro-manager/autoswitch"
+	"kiro-manager/backup"
+	"kiro-manager/kiroprocess"
+	"kiro-manager/softreset"
 )
 
 // TestAutoSwitchSettingsDTO_DefaultValues 驗證 DTO 預設值
@@ -238,4 +245,186 @@ func TestSwitchToBackup_NonExistentBackup(t *testing.T) {
 	if result.Message != "備份不存在" {
 		t.Errorf("Expected non-existent backup message, got: %s", result.Message)
 	}
+	if result.ErrorCode != ErrorCodeBackupNotFound {
+		t.Errorf("Expected ErrorCode %s, got: %s", ErrorCodeBackupNotFound, result.ErrorCode)
+	}
+}
+
+// TestErrorCodeFor 驗證錯誤類型對應的 ErrorCode
+func TestErrorCodeFor(t *testing.T) {
+	testCases := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{"permission denied", &os.PathError{Op: "open", Path: "extension.js", Err: os.ErrPermission}, ErrorCodeNeedsAdmin},
+		{"wrapped permission denied", fmt.Errorf("failed to write token: %w", os.ErrPermission), ErrorCodeNeedsAdmin},
+		{"backup not found", fmt.Errorf("%w: test", backup.ErrBackupNotFound), ErrorCodeBackupNotFound},
+		{"empty token file", backup.ErrEmptyTokenFile, ErrorCodeInvalidBackup},
+		{"kiro running", softreset.ErrKiroRunning, ErrorCodeKiroRunning},
+		{"other", fmt.Errorf("disk full"), ErrorCodeUnknown},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result := errorResult("失敗", tc.err)
+			if result.Success {
+				t.Error("errorResult should not be successful")
+			}
+			if result.ErrorCode != tc.want {
+				t.Errorf("ErrorCode = %s, want %s", result.ErrorCode, tc.want)
+			}
+		})
+	}
+}
+
+// TestSwitchToBackup_SerializesWithAutoSwitch 驗證自動切換進行中時手動切換會被拒絕，
+// 且監控器持有全域鎖時仍能完成自己的切換
+func TestSwitchToBackup_SerializesWithAutoSwitch(t *testing.T) {
+	target := "auto-switch-serialize-target"
+	writeSwitchTestBackup(t, target, "auto-switch-machine-id", time.Now().Add(time.Hour))
+	calls := stubSwitchOps(t, nil)
+
+	// 讓自動切換停在恢復階段，模擬切換進行中
+	restoring := make(chan struct{})
+	release := make(chan struct{})
+	var once sync.Once
+	restoreBackupFunc = func(name string) error {
+		once.Do(func() { close(restoring) })
+		<-release
+		*calls = append(*calls, "restore:"+name)
+		return nil
+	}
+
+	app := &App{ctx: context.Background()}
+	config := autoswitch.DefaultAutoSwitchSettings()
+	config.Enabled = true
+	config.BalanceThreshold = 5
+	config.MinTargetBalance = 50
+	monitor := autoswitch.NewMonitor(autoswitch.MonitorConfig{
+		Config:         config,
+		SwitchMu:       &globalSwitchMu,
+		RefreshFunc:    func(ctx context.Context) (float64, error) { return 1, nil },
+		SwitchFunc:     app.autoSwitchTo,
+		GetCurrentName: func() string { return "auto-switch-serialize-current" },
+		GetCandidates: func() []autoswitch.CandidateSnapshot {
+			return []autoswitch.CandidateSnapshot{{Name: target, Balance: 100}}
+		},
+	})
+
+	type checkOutcome struct {
+		result *autoswitch.CheckResult
+		err    error
+	}
+	done := make(chan checkOutcome, 1)
+	go func() {
+		result, err := monitor.ForceCheck(context.Background())
+		done <- checkOutcome{result, err}
+	}()
+
+	select {
+	case <-restoring:
+	case <-time.After(5 * time.Second):
+		t.Fatal("auto-switch did not reach the restore step")
+	}
+
+	// 自動切換持有鎖時，手動切換與重置都應立即返回忙碌
+	if result := app.SwitchToBackup(target); result.Success || result.ErrorCode != ErrorCodeBusy {
+		t.Errorf("manual switch during auto-switch: %+v, want busy", result)
+	}
+	if result := app.RestoreSoftReset(); result.Success || result.ErrorCode != ErrorCodeBusy {
+		t.Errorf("restore during auto-switch: %+v, want busy", result)
+	}
+	close(release)
+
+	outcome := <-done
+	if outcome.err != nil || outcome.result == nil || !outcome.result.Switched || outcome.result.Target != target {
+		t.Fatalf("auto-switch should complete while holding the lock: %+v, %v", outcome.result, outcome.err)
+	}
+
+	// 自動切換結束後手動切換可以取得鎖
+	if result := app.SwitchToBackup(target); !result.Success {
+		t.Errorf("manual switch after auto-switch failed: %s", result.Message)
+	}
+	if len(*calls) != 2 {
+		t.Errorf("expected exactly two restores (auto then manual), got %v", *calls)
+	}
+}
+
+// assertAllBusy 驗證所有會改寫 Machine ID 或 Token 的操作都返回 BUSY
+func assertAllBusy(t *testing.T, app *App) {
+	t.Helper()
+	const machineID = "11111111-2222-3333-4444-555555555555"
+	operations := map[string]func() Result{
+		"SwitchToBackup":        func() Result { return app.SwitchToBackup("busy-test-backup") },
+		"RestoreSoftReset":      app.RestoreSoftReset,
+		"SoftResetToNewMachine": func() Result { return softResetAsResult(app.SoftResetToNewMachine()) },
+		"SoftResetToMachineID":  func() Result { return softResetAsResult(app.SoftResetToMachineID(machineID)) },
+		"SetMachineID":          func() Result { return app.SetMachineID(machineID) },
+	}
+	for name, op := range operations {
+		if result := op(); result.Success || result.ErrorCode != ErrorCodeBusy {
+			t.Errorf("%s while another operation is running: %+v, want ErrorCode %s", name, result, ErrorCodeBusy)
+		}
+	}
+}
+
+// softResetAsResult 將 SoftResetResponse 轉為 Result，方便與其他操作一起檢查
+func softResetAsResult(r SoftResetResponse) Result {
+	return Result{Success: r.Success, Message: r.Message, ErrorCode: r.ErrorCode}
+}
+
+// TestSwitchToBackup_ConcurrentCallIsBusy 驗證切換進行中（例如連點兩次）時第二次呼叫返回 BUSY
+func TestSwitchToBackup_ConcurrentCallIsBusy(t *testing.T) {
+	name := "busy-switch-test"
+	writeSwitchTestBackup(t, name, "busy-machine-id", time.Now().Add(time.Hour))
+	stubSwitchOps(t, nil)
+
+	restoring := make(chan struct{})
+	release := make(chan struct{})
+	restoreBackupFunc = func(string) error {
+		close(restoring)
+		<-release
+		return nil
+	}
+
+	app := &App{ctx: context.Background()}
+	first := make(chan Result, 1)
+	go func() { first <- app.SwitchToBackup(name) }()
+	<-restoring
+
+	assertAllBusy(t, app)
+	close(release)
+
+	if result := <-first; !result.Success {
+		t.Errorf("first SwitchToBackup should succeed, got: %s", result.Message)
+	}
+}
+
+// TestSoftResetToNewMachine_ConcurrentCallIsBusy 驗證一鍵新機進行中時其他操作返回 BUSY
+func TestSoftResetToNewMachine_ConcurrentCallIsBusy(t *testing.T) {
+	if kiroprocess.IsKiroRunning() {
+		t.Skip("Kiro is running, skipping this test")
+	}
+	resetting := make(chan struct{})
+	release := make(chan struct{})
+	previous := softResetFunc
+	softResetFunc = func(newID string, progress softreset.ProgressFunc) (*softreset.SoftResetResult, error) {
+		close(resetting)
+		<-release
+		return &softreset.SoftResetResult{NewMachineID: newID}, nil
+	}
+	t.Cleanup(func() { softResetFunc = previous })
+
+	app := &App{ctx: context.Background()}
+	first := make(chan SoftResetResponse, 1)
+	go func() { first <- app.SoftResetToNewMachine() }()
+	<-resetting
+
+	assertAllBusy(t, app)
+	close(release)
+
+	if result := <-first; !result.Success {
+		t.Errorf("first SoftResetToNewMachine should succeed, got: %s", result.Message)
+	}
 }
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+
+	wailsRuntime "github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// 長時間操作的事件名稱
+const (
+	EventOperationProgress = "operation:progress" // 操作進入新階段
+	EventOperationDone     = "operation:done"     // 操作結束（成功或失敗）
+)
+
+// 操作名稱與 App 層的進度階段（softreset 內部的階段見 softreset.Stage*）
+const (
+	OperationSoftReset       = "softreset"
+	OperationSwitch          = "switch"
+	OperationRestoreOriginal = "restore-original"
+
+	StageClosingKiro      = "closing kiro"
+	StageRefreshingToken  = "refreshing token"
+	StageSettingMachineID = "setting machine id"
+	StageRestoringToken   = "restoring token"
+	StageDone             = "done"
+)
+
+// OperationProgress operation:progress 事件內容
+type OperationProgress struct {
+	Operation string `json:"operation"`
+	Stage     string `json:"stage"`
+}
+
+// OperationDone operation:done 事件內容
+type OperationDone struct {
+	Operation string `json:"operation"`
+	Success   bool   `json:"success"`
+	Message   string `json:"message"`
+}
+
+// Emitter 發送事件到前端，測試時可替換為記錄事件的實作
+type Emitter interface {
+	Emit(event string, data interface{})
+}
+
+// wailsEmitter 透過 Wails runtime 發送事件
+type wailsEmitter struct {
+	ctx context.Context
+}
+
+// Emit 實作 Emitter 介面
+func (e wailsEmitter) Emit(event string, data interface{}) {
+	wailsRuntime.EventsEmit(e.ctx, event, data)
+}
+
+// emitProgress 發送 operation:progress 事件（未設定 Emitter 時略過）
+func (a *App) emitProgress(operation, stage string) {
+	if a.emitter != nil {
+		a.emitter.Emit(EventOperationProgress, OperationProgress{Operation: operation, Stage: stage})
+	}
+}
+
+// emitDone 發送 operation:done 事件並返回 result，方便在 return 時使用
+func (a *App) emitDone(operation string, result Result) Result {
+	if a.emitter != nil {
+		a.emitter.Emit(EventOperationDone, OperationDone{Operation: operation, Success: result.Success, Message: result.Message})
+	}
+	return result
+}
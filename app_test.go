@@ -1,10 +1,20 @@
 package main
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"kiro-manager/backup"
+	"kiro-manager/kiroprocess"
+	"kiro-manager/machineid"
+	"kiro-manager/softreset"
+	"kiro-manager/tokenrefresh"
 )
 
 // TestDeleteFolder_WithActiveSnapshot_MoveToUncategorized 測試當 deleteSnapshots=false 且文件夾包含活躍快照時，應該返回錯誤
@@ -187,3 +197,380 @@ func TestDeleteFolder_WithoutActiveSnapshot_MoveToUncategorized(t *testing.T) {
 		}
 	}
 }
+
+// TestGetBackupList_TokenExpiry 測試備份列表的 Token 過期狀態：已過期、未過期、無法解析
+func TestGetBackupList_TokenExpiry(t *testing.T) {
+	future := time.Now().Add(time.Hour).UTC()
+	cases := map[string]string{
+		"expiry-test-valid":   future.Format("2006-01-02T15:04:05.000Z"),
+		"expiry-test-expired": time.Now().Add(-time.Hour).UTC().Format(time.RFC3339),
+		"expiry-test-unknown": "not-a-date",
+	}
+	for name, expiresAt := range cases {
+		backupPath, err := backup.GetBackupPath(name)
+		if err != nil {
+			t.Fatalf("GetBackupPath failed: %v", err)
+		}
+		if err := os.MkdirAll(backupPath, 0755); err != nil {
+			t.Fatalf("MkdirAll failed: %v", err)
+		}
+		defer os.RemoveAll(backupPath)
+
+		data, _ := json.Marshal(map[string]string{"accessToken": "a", "refreshToken": "r", "expiresAt": expiresAt})
+		if err := os.WriteFile(filepath.Join(backupPath, backup.KiroAuthTokenFile), data, 0644); err != nil {
+			t.Fatalf("WriteFile failed: %v", err)
+		}
+	}
+
+	items, err := NewApp().GetBackupList()
+	if err != nil {
+		t.Fatalf("GetBackupList failed: %v", err)
+	}
+	got := make(map[string]BackupItem)
+	for _, item := range items {
+		got[item.Name] = item
+	}
+
+	if item := got["expiry-test-valid"]; item.ExpiryKnownAndPassed || item.ExpiresAt != future.Format(time.RFC3339) {
+		t.Errorf("valid token: unexpected item %+v", item)
+	}
+	if item := got["expiry-test-expired"]; !item.ExpiryKnownAndPassed || item.ExpiresAt == "" {
+		t.Errorf("expired token: unexpected item %+v", item)
+	}
+	if item := got["expiry-test-unknown"]; item.ExpiryKnownAndPassed || item.ExpiresAt != "" {
+		t.Errorf("unknown expiry should not be reported as expired: %+v", item)
+	}
+}
+
+// writeSwitchTestBackup 建立含 Machine ID 與 Token 的測試備份，返回 Token 檔案路徑與內容
+func writeSwitchTestBackup(t *testing.T, name, machineID string, expiresAt time.Time) (string, []byte) {
+	t.Helper()
+	backupPath, err := backup.GetBackupPath(name)
+	if err != nil {
+		t.Fatalf("GetBackupPath failed: %v", err)
+	}
+	if err := os.MkdirAll(backupPath, 0755); err != nil {
+		t.Fatalf("MkdirAll failed: %v", err)
+	}
+	t.Cleanup(func() { os.RemoveAll(backupPath) })
+
+	midData, _ := json.Marshal(backup.MachineIDBackup{MachineID: machineID, BackupTime: time.Now().Format(time.RFC3339)})
+	if err := os.WriteFile(filepath.Join(backupPath, backup.MachineIDFileName), midData, 0644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	tokenData, _ := json.Marshal(map[string]string{
+		"accessToken":  "a",
+		"refreshToken": "r",
+		"provider":     "Github",
+		"expiresAt":    expiresAt.Format(time.RFC3339),
+	})
+	tokenPath := filepath.Join(backupPath, backup.KiroAuthTokenFile)
+	if err := os.WriteFile(tokenPath, tokenData, 0644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	return tokenPath, tokenData
+}
+
+// TestPreviewSwitch_ValidSnapshot 測試預覽返回備份的 Machine ID、Provider 與過期狀態，且不修改備份
+func TestPreviewSwitch_ValidSnapshot(t *testing.T) {
+	name := "preview-switch-test"
+	machineID := "0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+	expiresAt := time.Now().Add(-time.Hour).UTC().Truncate(time.Second)
+	tokenPath, tokenData := writeSwitchTestBackup(t, name, machineID, expiresAt)
+
+	preview, err := NewApp().PreviewSwitch(name)
+	if err != nil {
+		t.Fatalf("PreviewSwitch failed: %v", err)
+	}
+	if preview.Name != name || preview.MachineID != machineID || preview.Provider != "Github" {
+		t.Errorf("unexpected preview: %+v", preview)
+	}
+	if !preview.TokenExpired || preview.ExpiresAt != expiresAt.Format(time.RFC3339) {
+		t.Errorf("expired token should be reported: %+v", preview)
+	}
+
+	// 預覽不可刷新或改寫備份的 Token
+	if after, _ := os.ReadFile(tokenPath); string(after) != string(tokenData) {
+		t.Error("PreviewSwitch should not modify the backup token")
+	}
+}
+
+// TestPreviewSwitch_NonExistentSnapshot 測試不存在的備份返回 ErrBackupNotFound
+func TestPreviewSwitch_NonExistentSnapshot(t *testing.T) {
+	preview, err := NewApp().PreviewSwitch("preview-switch-missing-12345")
+	if !errors.Is(err, backup.ErrBackupNotFound) {
+		t.Errorf("PreviewSwitch error = %v, want ErrBackupNotFound", err)
+	}
+	if preview != nil {
+		t.Errorf("PreviewSwitch should return nil preview, got %+v", preview)
+	}
+}
+
+// stubSwitchOps 替換切換流程的刷新、恢復與 patch 操作，返回呼叫順序紀錄
+func stubSwitchOps(t *testing.T, refreshErr error) *[]string {
+	t.Helper()
+	var calls []string
+	previousRefresh, previousRestore, previousPatch := refreshBackupFunc, restoreBackupFunc, patchExtensionFunc
+	refreshBackupFunc = func(name string) (*tokenrefresh.TokenInfo, error) {
+		calls = append(calls, "refresh:"+name)
+		if refreshErr != nil {
+			return nil, refreshErr
+		}
+		return &tokenrefresh.TokenInfo{AccessToken: "new", ExpiresAt: time.Now().Add(time.Hour)}, nil
+	}
+	restoreBackupFunc = func(name string) error {
+		calls = append(calls, "restore:"+name)
+		return nil
+	}
+	patchExtensionFunc = func() error { return nil }
+	t.Cleanup(func() {
+		refreshBackupFunc, restoreBackupFunc, patchExtensionFunc = previousRefresh, previousRestore, previousPatch
+	})
+	return &calls
+}
+
+// TestSwitchToBackup_ValidTokenSkipsRefresh 測試 Token 未過期時直接恢復，不刷新
+func TestSwitchToBackup_ValidTokenSkipsRefresh(t *testing.T) {
+	name := "switch-valid-token-test"
+	writeSwitchTestBackup(t, name, "switch-machine-id", time.Now().Add(time.Hour))
+	calls := stubSwitchOps(t, nil)
+
+	result := NewApp().SwitchToBackup(name)
+	if !result.Success {
+		t.Fatalf("SwitchToBackup failed: %s", result.Message)
+	}
+	if len(*calls) != 1 || (*calls)[0] != "restore:"+name {
+		t.Errorf("calls = %v, want [restore:%s]", *calls, name)
+	}
+}
+
+// TestSwitchToBackup_ExpiredTokenRefreshesBeforeRestore 測試 Token 過期時先刷新再恢復
+func TestSwitchToBackup_ExpiredTokenRefreshesBeforeRestore(t *testing.T) {
+	name := "switch-expired-token-test"
+	writeSwitchTestBackup(t, name, "switch-machine-id", time.Now().Add(-time.Hour))
+	calls := stubSwitchOps(t, nil)
+
+	result := NewApp().SwitchToBackup(name)
+	if !result.Success {
+		t.Fatalf("SwitchToBackup failed: %s", result.Message)
+	}
+	if len(*calls) != 2 || (*calls)[0] != "refresh:"+name || (*calls)[1] != "restore:"+name {
+		t.Errorf("calls = %v, want [refresh:%s restore:%s]", *calls, name, name)
+	}
+}
+
+// TestSwitchToBackup_ExpiredRefreshToken 測試 refresh token 已失效時提示重新登入，且不恢復備份
+func TestSwitchToBackup_ExpiredRefreshToken(t *testing.T) {
+	name := "switch-relogin-test"
+	writeSwitchTestBackup(t, name, "switch-machine-id", time.Now().Add(-time.Hour))
+	calls := stubSwitchOps(t, tokenrefresh.MapHTTPError(401, ""))
+
+	result := NewApp().SwitchToBackup(name)
+	if result.Success {
+		t.Fatal("SwitchToBackup should fail when the refresh token is revoked")
+	}
+	if result.ErrorCode != ErrorCodeTokenExpired || result.Message != "此帳號的 Token 已失效，請重新登入後再切換" {
+		t.Errorf("unexpected result: %+v", result)
+	}
+	if len(*calls) != 1 || (*calls)[0] != "refresh:"+name {
+		t.Errorf("calls = %v, restore should not run after a failed refresh", *calls)
+	}
+}
+
+// recordingEmitter 記錄發送的事件，用於驗證事件順序
+type recordingEmitter struct {
+	events []string
+	done   *OperationDone
+}
+
+func (e *recordingEmitter) Emit(event string, data interface{}) {
+	switch payload := data.(type) {
+	case OperationProgress:
+		e.events = append(e.events, event+":"+payload.Stage)
+	case OperationDone:
+		e.events = append(e.events, event)
+		e.done = &payload
+	}
+}
+
+// stubSoftReset 替換一鍵新機的實際操作，依序回報 softreset 的進度階段
+func stubSoftReset(t *testing.T, err error) {
+	t.Helper()
+	previous := softResetFunc
+	softResetFunc = func(newID string, progress softreset.ProgressFunc) (*softreset.SoftResetResult, error) {
+		progress(softreset.StageGeneratingID)
+		if err != nil {
+			return nil, err
+		}
+		progress(softreset.StagePatching)
+		return &softreset.SoftResetResult{NewMachineID: newID, Patched: true}, nil
+	}
+	t.Cleanup(func() { softResetFunc = previous })
+}
+
+// setupSetMachineIDTest 以暫存目錄作為 HOME 並建立 storage.json，patch 操作由 patchErr 決定結果
+func setupSetMachineIDTest(t *testing.T, patchErr error) string {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(home, ".config"))
+	t.Setenv("APPDATA", filepath.Join(home, "AppData", "Roaming"))
+
+	storagePath, err := softreset.GetStorageJSONPath()
+	if err != nil {
+		t.Fatalf("GetStorageJSONPath failed: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(storagePath), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(storagePath, []byte(`{"telemetry.machineId":"old-id"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	previousPatch := patchExtensionFunc
+	patchExtensionFunc = func() error { return patchErr }
+	t.Cleanup(func() { patchExtensionFunc = previousPatch })
+	return storagePath
+}
+
+// TestSetMachineID_UpdatesIDFilesAndStorage 測試設定 Machine ID 時同時改寫 storage.json
+func TestSetMachineID_UpdatesIDFilesAndStorage(t *testing.T) {
+	storagePath := setupSetMachineIDTest(t, nil)
+	id := "12345678-1234-4234-8234-123456789abc"
+
+	result := NewApp().SetMachineID(id)
+	if !result.Success {
+		t.Fatalf("SetMachineID failed: %s", result.Message)
+	}
+
+	if raw, err := softreset.ReadCustomMachineIDRaw(); err != nil || raw != id {
+		t.Errorf("raw machine ID = %q, %v, want %q", raw, err, id)
+	}
+	data, err := os.ReadFile(storagePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var storage map[string]string
+	if err := json.Unmarshal(data, &storage); err != nil {
+		t.Fatal(err)
+	}
+	if storage["telemetry.machineId"] != machineid.HashMachineID(id) {
+		t.Errorf("storage.json machineId = %q, want hashed ID", storage["telemetry.machineId"])
+	}
+}
+
+// TestSetMachineID_PatchFailureLeavesIDUnchanged 測試 patch 失敗時不寫入 Machine ID 與 storage.json
+func TestSetMachineID_PatchFailureLeavesIDUnchanged(t *testing.T) {
+	storagePath := setupSetMachineIDTest(t, softreset.ErrKiroRunning)
+
+	result := NewApp().SetMachineID("12345678-1234-4234-8234-123456789abc")
+	if result.Success || result.ErrorCode != ErrorCodeKiroRunning {
+		t.Fatalf("SetMachineID = %+v, want KIRO_RUNNING failure", result)
+	}
+
+	if _, err := softreset.ReadCustomMachineIDRaw(); !errors.Is(err, softreset.ErrCustomIDNotFound) {
+		t.Errorf("expected no custom machine ID, got %v", err)
+	}
+	data, err := os.ReadFile(storagePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "old-id") {
+		t.Errorf("storage.json should be unchanged, got %s", data)
+	}
+}
+
+// TestSetMachineID_InvalidInput 測試格式無效時返回 INVALID_INPUT
+func TestSetMachineID_InvalidInput(t *testing.T) {
+	setupSetMachineIDTest(t, nil)
+
+	result := NewApp().SetMachineID("not-a-machine-id")
+	if result.Success || result.ErrorCode != ErrorCodeInvalidInput {
+		t.Errorf("SetMachineID = %+v, want INVALID_INPUT failure", result)
+	}
+}
+
+// TestSoftResetToNewMachine_EmitsProgress 測試一鍵新機依序發送進度與完成事件
+func TestSoftResetToNewMachine_EmitsProgress(t *testing.T) {
+	if kiroprocess.IsKiroRunning() {
+		t.Skip("Kiro is running, skipping this test")
+	}
+	stubSoftReset(t, nil)
+	emitter := &recordingEmitter{}
+	app := &App{ctx: context.Background(), emitter: emitter}
+
+	result := app.SoftResetToNewMachine()
+	if !result.Success {
+		t.Fatalf("SoftResetToNewMachine failed: %s", result.Message)
+	}
+
+	want := []string{
+		"operation:progress:closing kiro",
+		"operation:progress:generating id",
+		"operation:progress:patching",
+		"operation:progress:done",
+		"operation:done",
+	}
+	if strings.Join(emitter.events, ",") != strings.Join(want, ",") {
+		t.Errorf("events = %v, want %v", emitter.events, want)
+	}
+	if emitter.done == nil || emitter.done.Operation != OperationSoftReset || !emitter.done.Success || emitter.done.Message != result.Message {
+		t.Errorf("unexpected operation:done payload: %+v", emitter.done)
+	}
+	// 結果帶有完整的新 Machine ID，不只是訊息中截斷的前 8 碼
+	if len(result.MachineID) != 36 || !strings.Contains(result.Message, result.MachineID[:8]) {
+		t.Errorf("unexpected MachineID %q for message %q", result.MachineID, result.Message)
+	}
+}
+
+// TestSoftResetToMachineID_EmitsDoneOnFailure 測試失敗時不發送 done 階段，但仍以 operation:done 回報失敗
+func TestSoftResetToMachineID_EmitsDoneOnFailure(t *testing.T) {
+	if kiroprocess.IsKiroRunning() {
+		t.Skip("Kiro is running, skipping this test")
+	}
+	stubSoftReset(t, softreset.ErrInvalidMachineID)
+	emitter := &recordingEmitter{}
+	app := &App{ctx: context.Background(), emitter: emitter}
+
+	result := app.SoftResetToMachineID("not-a-machine-id")
+	if result.Success || result.ErrorCode != ErrorCodeInvalidInput || result.MachineID != "" {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+
+	want := []string{"operation:progress:closing kiro", "operation:progress:generating id", "operation:done"}
+	if strings.Join(emitter.events, ",") != strings.Join(want, ",") {
+		t.Errorf("events = %v, want %v", emitter.events, want)
+	}
+	if emitter.done == nil || emitter.done.Success {
+		t.Errorf("operation:done should report failure: %+v", emitter.done)
+	}
+}
+
+// TestSwitchToBackup_EmitsProgress 測試切換依序發送刷新、恢復、patch 與完成事件
+func TestSwitchToBackup_EmitsProgress(t *testing.T) {
+	name := "switch-progress-test"
+	writeSwitchTestBackup(t, name, "switch-machine-id", time.Now().Add(-time.Hour))
+	stubSwitchOps(t, nil)
+	emitter := &recordingEmitter{}
+	app := &App{ctx: context.Background(), emitter: emitter}
+
+	result := app.SwitchToBackup(name)
+	if !result.Success {
+		t.Fatalf("SwitchToBackup failed: %s", result.Message)
+	}
+
+	want := []string{
+		"operation:progress:refreshing token",
+		"operation:progress:restoring token",
+		"operation:progress:patching",
+		"operation:progress:done",
+		"operation:done",
+	}
+	if strings.Join(emitter.events, ",") != strings.Join(want, ",") {
+		t.Errorf("events = %v, want %v", emitter.events, want)
+	}
+	if emitter.done == nil || emitter.done.Operation != OperationSwitch || !emitter.done.Success {
+		t.Errorf("unexpected operation:done payload: %+v", emitter.done)
+	}
+}
@@ -0,0 +1,39 @@
+package autoswitch
+
+import "time"
+
+// Clock 監控器使用的時間來源，測試時可替換為假時鐘
+type Clock interface {
+	// Now 取得當前時間
+	Now() time.Time
+	// After 等待 d 後送出當前時間
+	After(d time.Duration) <-chan time.Time
+	// NewTicker 建立每隔 d 觸發一次的 Ticker
+	NewTicker(d time.Duration) Ticker
+}
+
+// Ticker 週期觸發器（對應 time.Ticker）
+type Ticker interface {
+	// C 觸發通道
+	C() <-chan time.Time
+	// Reset 停止並以新的週期重新計時
+	Reset(d time.Duration)
+	// Stop 停止觸發
+	Stop()
+}
+
+// realClock 使用系統時間的 Clock
+type realClock struct{}
+
+func (realClock) Now() time.Time                         { return time.Now() }
+func (realClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+func (realClock) NewTicker(d time.Duration) Ticker       { return realTicker{time.NewTicker(d)} }
+
+// realTicker 包裝 time.Ticker
+type realTicker struct {
+	t *time.Ticker
+}
+
+func (r realTicker) C() <-chan time.Time   { return r.t.C }
+func (r realTicker) Reset(d time.Duration) { r.t.Reset(d) }
+func (r realTicker) Stop()                 { r.t.Stop() }
@@ -11,17 +11,48 @@ type AutoSwitchSettings struct {
 	// BalanceThreshold 觸發閾值（絕對值）
 	// 當餘額 <= 此值時觸發自動切換
 	BalanceThreshold float64 `json:"balanceThreshold"`
+	// WarnThreshold 預警閾值（絕對值）
+	// 餘額由高於此值降至 (BalanceThreshold, WarnThreshold] 時預警一次，0 表示不預警
+	WarnThreshold float64 `json:"warnThreshold"`
 	// MinTargetBalance 目標最低餘額
 	// 只切換至餘額 >= 此值的快照
 	MinTargetBalance float64 `json:"minTargetBalance"`
 	// FolderIds 限定文件夾 ID 列表
 	// 空列表表示不限制
 	FolderIds []string `json:"folderIds"`
+	// FolderScoped 是否只切換至與當前快照相同文件夾的快照
+	FolderScoped bool `json:"folderScoped"`
 	// SubscriptionTypes 限定訂閱類型列表
 	// 空列表表示不限制
 	SubscriptionTypes []string `json:"subscriptionTypes"`
+	// PreferSubscriptionOrder 候選排序的訂閱類型優先順序（例如 ["Pro", "Free"]）
+	// 優先順序為主要排序鍵，餘額為次要排序鍵；不在列表中的類型排在最後
+	PreferSubscriptionOrder []string `json:"preferSubscriptionOrder"`
+	// Blacklist 永不自動切換的快照名稱列表
+	Blacklist []string `json:"blacklist"`
 	// RefreshIntervals 刷新頻率分級規則
 	RefreshIntervals []RefreshInterval `json:"refreshIntervals"`
+	// CheckInterval 固定檢查間隔，覆蓋 RefreshIntervals 分級規則
+	// 0 表示使用分級規則，小於 MinCheckInterval 時以 MinCheckInterval 計
+	CheckInterval time.Duration `json:"checkInterval"`
+	// MaxSwitchesPerDay 滾動 24 小時內最多自動切換次數
+	// 0 表示不限制
+	MaxSwitchesPerDay int `json:"maxSwitchesPerDay"`
+	// MinSwitchInterval 兩次自動切換之間的最短間隔（與冷卻期無關）
+	// 0 表示不限制
+	MinSwitchInterval time.Duration `json:"minSwitchInterval"`
+	// ActiveHoursStart 允許自動切換的開始時間（"HH:MM"，本地時間）
+	// ActiveHoursEnd 允許自動切換的結束時間（不含）；早於開始時間表示跨夜
+	// 任一為空表示全天允許
+	ActiveHoursStart string `json:"activeHoursStart"`
+	ActiveHoursEnd   string `json:"activeHoursEnd"`
+	// ActiveWindows 依星期設定的允許時段，符合任一時段即允許
+	// 空列表表示不限制；與 ActiveHours 同時設定時兩者都需符合
+	ActiveWindows []TimeWindow `json:"activeWindows"`
+	// DryRun 試運行模式：完整執行偵測與候選排序，但不實際切換，只發送通知
+	DryRun bool `json:"dryRun"`
+	// WebhookURL 每則通知都以 JSON POST 推送至此 URL，空字串表示不推送
+	WebhookURL string `json:"webhookUrl"`
 	// NotifyOnSwitch 切換時是否通知
 	NotifyOnSwitch bool `json:"notifyOnSwitch"`
 	// NotifyOnLowBalance 低餘額時是否預警
@@ -39,6 +70,122 @@ type RefreshInterval struct {
 	Interval time.Duration `json:"interval"`
 }
 
+// TimeWindow 依星期設定的允許時段（本地時間）
+type TimeWindow struct {
+	// Weekdays 適用的星期（0 = 週日），空列表表示每天
+	// 跨夜時段以開始時間所在的星期為準
+	Weekdays []time.Weekday `json:"weekdays"`
+	// Start 開始時間（"HH:MM"）
+	Start string `json:"start"`
+	// End 結束時間（不含）；早於開始時間表示跨夜，與開始時間相同表示全天
+	End string `json:"end"`
+}
+
+// MinCheckInterval 固定檢查間隔下限，避免過於頻繁地請求餘額端點
+const MinCheckInterval = 30 * time.Second
+
+// minCheckInterval 實際使用的檢查間隔下限（可在測試中替換）
+var minCheckInterval = MinCheckInterval
+
+// EffectiveCheckInterval 取得下一次檢查的等待時間
+// 設定了 CheckInterval 時使用它（不低於 MinCheckInterval），否則依餘額套用分級規則
+func (s *AutoSwitchSettings) EffectiveCheckInterval(balance float64) time.Duration {
+	if s.CheckInterval > 0 {
+		if s.CheckInterval < minCheckInterval {
+			return minCheckInterval
+		}
+		return s.CheckInterval
+	}
+	return GetRefreshInterval(s.RefreshIntervals, balance)
+}
+
+// IsWithinActiveHours 檢查指定時間是否在允許自動切換的時段內
+// 同時檢查每日的 ActiveHours 與依星期設定的 ActiveWindows
+// 未設定或格式錯誤時視為全天允許；結束時間早於開始時間時為跨夜時段
+func (s *AutoSwitchSettings) IsWithinActiveHours(t time.Time) bool {
+	if !s.isWithinActiveWindow(t) {
+		return false
+	}
+
+	start, okStart := parseClock(s.ActiveHoursStart)
+	end, okEnd := parseClock(s.ActiveHoursEnd)
+	if !okStart || !okEnd || start == end {
+		return true
+	}
+
+	now := t.Hour()*60 + t.Minute()
+	if start < end {
+		return now >= start && now < end
+	}
+	// 跨夜時段，例如 22:00 ~ 06:00
+	return now >= start || now < end
+}
+
+// isWithinActiveWindow 檢查指定時間是否落在任一 ActiveWindows 時段內
+// 沒有設定（或全部格式錯誤）時視為不限制
+func (s *AutoSwitchSettings) isWithinActiveWindow(now time.Time) bool {
+	configured := false
+	for _, window := range s.ActiveWindows {
+		within, ok := window.contains(now)
+		if !ok {
+			continue
+		}
+		if within {
+			return true
+		}
+		configured = true
+	}
+	return !configured
+}
+
+// contains 檢查指定時間是否在時段內；格式錯誤時 ok 為 false
+func (w TimeWindow) contains(t time.Time) (within bool, ok bool) {
+	start, okStart := parseClock(w.Start)
+	end, okEnd := parseClock(w.End)
+	if !okStart || !okEnd {
+		return false, false
+	}
+
+	now := t.Hour()*60 + t.Minute()
+	switch {
+	case start == end:
+		return w.appliesTo(t.Weekday()), true
+	case start < end:
+		return w.appliesTo(t.Weekday()) && now >= start && now < end, true
+	default:
+		// 跨夜時段：開始當天的晚上，或隔天凌晨（以前一天的星期判斷）
+		if now >= start {
+			return w.appliesTo(t.Weekday()), true
+		}
+		if now < end {
+			return w.appliesTo(t.AddDate(0, 0, -1).Weekday()), true
+		}
+		return false, true
+	}
+}
+
+// appliesTo 檢查時段是否適用於指定星期
+func (w TimeWindow) appliesTo(day time.Weekday) bool {
+	if len(w.Weekdays) == 0 {
+		return true
+	}
+	for _, weekday := range w.Weekdays {
+		if weekday == day {
+			return true
+		}
+	}
+	return false
+}
+
+// parseClock 解析 "HH:MM" 為當日分鐘數
+func parseClock(value string) (int, bool) {
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, false
+	}
+	return t.Hour()*60 + t.Minute(), true
+}
+
 // DefaultRefreshIntervals 預設刷新頻率分級規則
 // 根據 BDD 規格：
 // - 餘額 >= 100: 5 分鐘
@@ -76,6 +223,7 @@ func DefaultAutoSwitchSettings() *AutoSwitchSettings {
 		MinTargetBalance:   50,
 		FolderIds:          []string{},
 		SubscriptionTypes:  []string{},
+		Blacklist:          []string{},
 		RefreshIntervals:   DefaultRefreshIntervals(),
 		NotifyOnSwitch:     true,
 		NotifyOnLowBalance: true,
@@ -93,6 +241,15 @@ func (s *AutoSwitchSettings) Clone() *AutoSwitchSettings {
 		Enabled:            s.Enabled,
 		BalanceThreshold:   s.BalanceThreshold,
 		MinTargetBalance:   s.MinTargetBalance,
+		WarnThreshold:      s.WarnThreshold,
+		FolderScoped:       s.FolderScoped,
+		CheckInterval:      s.CheckInterval,
+		MaxSwitchesPerDay:  s.MaxSwitchesPerDay,
+		MinSwitchInterval:  s.MinSwitchInterval,
+		ActiveHoursStart:   s.ActiveHoursStart,
+		ActiveHoursEnd:     s.ActiveHoursEnd,
+		DryRun:             s.DryRun,
+		WebhookURL:         s.WebhookURL,
 		NotifyOnSwitch:     s.NotifyOnSwitch,
 		NotifyOnLowBalance: s.NotifyOnLowBalance,
 	}
@@ -109,6 +266,30 @@ func (s *AutoSwitchSettings) Clone() *AutoSwitchSettings {
 		copy(clone.SubscriptionTypes, s.SubscriptionTypes)
 	}
 
+	// 深拷貝 PreferSubscriptionOrder
+	if s.PreferSubscriptionOrder != nil {
+		clone.PreferSubscriptionOrder = make([]string, len(s.PreferSubscriptionOrder))
+		copy(clone.PreferSubscriptionOrder, s.PreferSubscriptionOrder)
+	}
+
+	// 深拷貝 Blacklist
+	if s.Blacklist != nil {
+		clone.Blacklist = make([]string, len(s.Blacklist))
+		copy(clone.Blacklist, s.Blacklist)
+	}
+
+	// 深拷貝 ActiveWindows（含各時段的 Weekdays）
+	if s.ActiveWindows != nil {
+		clone.ActiveWindows = make([]TimeWindow, len(s.ActiveWindows))
+		for i, window := range s.ActiveWindows {
+			clone.ActiveWindows[i] = window
+			if window.Weekdays != nil {
+				clone.ActiveWindows[i].Weekdays = make([]time.Weekday, len(window.Weekdays))
+				copy(clone.ActiveWindows[i].Weekdays, window.Weekdays)
+			}
+		}
+	}
+
 	// 深拷貝 RefreshIntervals
 	if s.RefreshIntervals != nil {
 		clone.RefreshIntervals = make([]RefreshInterval, len(s.RefreshIntervals))
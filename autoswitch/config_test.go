@@ -84,6 +84,62 @@ func TestRefreshIntervalSelection(t *testing.T) {
 	}
 }
 
+// TestEffectiveCheckInterval 驗證固定檢查間隔覆蓋分級規則並受下限限制
+func TestEffectiveCheckInterval(t *testing.T) {
+	s := DefaultAutoSwitchSettings()
+
+	// 未設定時使用分級規則
+	if got := s.EffectiveCheckInterval(120); got != 5*time.Minute {
+		t.Errorf("expected tiered 5m, got %v", got)
+	}
+
+	s.CheckInterval = 45 * time.Second
+	if got := s.EffectiveCheckInterval(120); got != 45*time.Second {
+		t.Errorf("expected 45s, got %v", got)
+	}
+
+	// 過小的間隔應被限制為下限
+	s.CheckInterval = time.Millisecond
+	if got := s.EffectiveCheckInterval(120); got != MinCheckInterval {
+		t.Errorf("expected clamp to %v, got %v", MinCheckInterval, got)
+	}
+}
+
+// TestIsWithinActiveHours 驗證允許時段判斷（含跨夜）
+func TestIsWithinActiveHours(t *testing.T) {
+	at := func(hour, minute int) time.Time {
+		return time.Date(2025, 1, 1, hour, minute, 0, 0, time.Local)
+	}
+
+	testCases := []struct {
+		name     string
+		start    string
+		end      string
+		t        time.Time
+		expected bool
+	}{
+		{"unset", "", "", at(3, 0), true},
+		{"invalid", "9am", "18:00", at(3, 0), true},
+		{"day inside", "09:00", "18:00", at(12, 0), true},
+		{"day start boundary", "09:00", "18:00", at(9, 0), true},
+		{"day end boundary", "09:00", "18:00", at(18, 0), false},
+		{"day before", "09:00", "18:00", at(8, 59), false},
+		{"overnight late", "22:00", "06:00", at(23, 30), true},
+		{"overnight early", "22:00", "06:00", at(5, 59), true},
+		{"overnight outside", "22:00", "06:00", at(12, 0), false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			s := &AutoSwitchSettings{ActiveHoursStart: tc.start, ActiveHoursEnd: tc.end}
+			if got := s.IsWithinActiveHours(tc.t); got != tc.expected {
+				t.Errorf("IsWithinActiveHours(%s) with %q~%q = %v, want %v",
+					tc.t.Format("15:04"), tc.start, tc.end, got, tc.expected)
+			}
+		})
+	}
+}
+
 // TestDefaultAutoSwitchSettings 驗證預設設定
 func TestDefaultAutoSwitchSettings(t *testing.T) {
 	settings := DefaultAutoSwitchSettings()
@@ -148,14 +204,18 @@ func TestAutoSwitchSettingsStructure(t *testing.T) {
 // TestAutoSwitchSettingsClone 驗證設定深拷貝
 func TestAutoSwitchSettingsClone(t *testing.T) {
 	original := &AutoSwitchSettings{
-		Enabled:            true,
-		BalanceThreshold:   10,
-		MinTargetBalance:   100,
-		FolderIds:          []string{"folder1", "folder2"},
-		SubscriptionTypes:  []string{"Pro", "Team"},
-		RefreshIntervals:   DefaultRefreshIntervals(),
-		NotifyOnSwitch:     true,
-		NotifyOnLowBalance: false,
+		Enabled:                 true,
+		BalanceThreshold:        10,
+		MinTargetBalance:        100,
+		FolderIds:               []string{"folder1", "folder2"},
+		FolderScoped:            true,
+		SubscriptionTypes:       []string{"Pro", "Team"},
+		Blacklist:               []string{"shared"},
+		PreferSubscriptionOrder: []string{"Pro", "Free"},
+		RefreshIntervals:        DefaultRefreshIntervals(),
+		ActiveWindows:           []TimeWindow{{Weekdays: []time.Weekday{time.Monday}, Start: "09:00", End: "18:00"}},
+		NotifyOnSwitch:          true,
+		NotifyOnLowBalance:      false,
 	}
 
 	clone := original.Clone()
@@ -170,6 +230,9 @@ func TestAutoSwitchSettingsClone(t *testing.T) {
 	if clone.MinTargetBalance != original.MinTargetBalance {
 		t.Errorf("MinTargetBalance mismatch: got %v, want %v", clone.MinTargetBalance, original.MinTargetBalance)
 	}
+	if clone.FolderScoped != original.FolderScoped {
+		t.Errorf("FolderScoped mismatch: got %v, want %v", clone.FolderScoped, original.FolderScoped)
+	}
 	if clone.NotifyOnSwitch != original.NotifyOnSwitch {
 		t.Errorf("NotifyOnSwitch mismatch: got %v, want %v", clone.NotifyOnSwitch, original.NotifyOnSwitch)
 	}
@@ -188,6 +251,21 @@ func TestAutoSwitchSettingsClone(t *testing.T) {
 		t.Error("SubscriptionTypes is not a deep copy")
 	}
 
+	original.PreferSubscriptionOrder[0] = "modified"
+	if clone.PreferSubscriptionOrder[0] == "modified" {
+		t.Error("PreferSubscriptionOrder is not a deep copy")
+	}
+
+	original.Blacklist[0] = "modified"
+	if clone.Blacklist[0] == "modified" {
+		t.Error("Blacklist is not a deep copy")
+	}
+
+	original.ActiveWindows[0].Weekdays[0] = time.Sunday
+	if clone.ActiveWindows[0].Weekdays[0] == time.Sunday {
+		t.Error("ActiveWindows is not a deep copy")
+	}
+
 	// 驗證 nil 處理
 	var nilSettings *AutoSwitchSettings
 	nilClone := nilSettings.Clone()
@@ -236,3 +314,47 @@ func TestAutoSwitchSettingsClone_NilSlices(t *testing.T) {
 		t.Error("RefreshIntervals should be nil")
 	}
 }
+
+// TestIsWithinActiveWindow 驗證依星期設定的允許時段（含跨夜與星期交界）
+func TestIsWithinActiveWindow(t *testing.T) {
+	// 2025-01-06 為週一
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2025, 1, day, hour, minute, 0, 0, time.Local)
+	}
+	workdays := []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday}
+
+	testCases := []struct {
+		name     string
+		windows  []TimeWindow
+		t        time.Time
+		expected bool
+	}{
+		{"unset", nil, at(5, 3, 0), true},
+		{"invalid only", []TimeWindow{{Start: "9am", End: "18:00"}}, at(5, 3, 0), true},
+		{"workday inside", []TimeWindow{{Weekdays: workdays, Start: "09:00", End: "18:00"}}, at(6, 10, 0), true},
+		{"workday after end", []TimeWindow{{Weekdays: workdays, Start: "09:00", End: "18:00"}}, at(6, 18, 0), false},
+		{"weekend same hours", []TimeWindow{{Weekdays: workdays, Start: "09:00", End: "18:00"}}, at(5, 10, 0), false},
+		{"every day", []TimeWindow{{Start: "09:00", End: "18:00"}}, at(5, 10, 0), true},
+		{"whole day", []TimeWindow{{Weekdays: []time.Weekday{time.Sunday}, Start: "00:00", End: "00:00"}}, at(5, 23, 59), true},
+		{"overnight start day", []TimeWindow{{Weekdays: []time.Weekday{time.Friday}, Start: "22:00", End: "02:00"}}, at(10, 23, 0), true},
+		{"overnight spills into saturday", []TimeWindow{{Weekdays: []time.Weekday{time.Friday}, Start: "22:00", End: "02:00"}}, at(11, 1, 0), true},
+		{"overnight not from thursday", []TimeWindow{{Weekdays: []time.Weekday{time.Friday}, Start: "22:00", End: "02:00"}}, at(10, 1, 0), false},
+		{"sunday night spills into monday", []TimeWindow{{Weekdays: []time.Weekday{time.Sunday}, Start: "20:00", End: "06:00"}}, at(6, 5, 0), true},
+		{"any window matches", []TimeWindow{
+			{Weekdays: workdays, Start: "09:00", End: "12:00"},
+			{Weekdays: []time.Weekday{time.Saturday}, Start: "13:00", End: "15:00"},
+		}, at(11, 14, 0), true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			s := &AutoSwitchSettings{ActiveWindows: tc.windows}
+			if got := s.isWithinActiveWindow(tc.t); got != tc.expected {
+				t.Errorf("isWithinActiveWindow(%s) = %v, want %v", tc.t.Format("Mon 15:04"), got, tc.expected)
+			}
+			if got := s.IsWithinActiveHours(tc.t); got != tc.expected {
+				t.Errorf("IsWithinActiveHours(%s) = %v, want %v", tc.t.Format("Mon 15:04"), got, tc.expected)
+			}
+		})
+	}
+}
@@ -2,6 +2,9 @@ package autoswitch
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"net/http"
 	"sync"
 	"time"
 )
@@ -33,6 +36,16 @@ type ValidateCandidateFunc func(ctx context.Context, candidateName string) (floa
 // 返回：確認後的餘額和錯誤
 type ConfirmAfterSwitchFunc func(ctx context.Context, targetName string) (float64, error)
 
+// ErrNoConfig 監控器未設定自動切換設定
+var ErrNoConfig = errors.New("auto-switch config is not set")
+
+// CheckResult 單次檢查結果
+type CheckResult struct {
+	Balance  float64 `json:"balance"`  // 刷新後的當前餘額
+	Switched bool    `json:"switched"` // 是否已切換
+	Target   string  `json:"target"`   // 選定的目標快照（試運行時亦會填入），空字串表示未選定
+}
+
 // MonitorStatus 監控狀態
 type MonitorStatus string
 
@@ -40,6 +53,8 @@ const (
 	StatusStopped  MonitorStatus = "stopped"
 	StatusRunning  MonitorStatus = "running"
 	StatusCooldown MonitorStatus = "cooldown"
+	StatusPaused   MonitorStatus = "paused"
+	StatusIdle     MonitorStatus = "idle" // 運行中但不在允許時段內，只監控不切換
 )
 
 // 重試相關常數
@@ -66,9 +81,17 @@ type Monitor struct {
 	getCandidates      GetCandidatesFunc
 	validateCandidate  ValidateCandidateFunc
 	confirmAfterSwitch ConfirmAfterSwitchFunc
+	clock              Clock
+	webhookClient      *http.Client
 	mu                 sync.RWMutex
+	checkMu            sync.Mutex // 序列化 checkAndSwitch（背景循環與 ForceCheck）
 	status             MonitorStatus
 	lastBalance        float64
+	lastSwitchTarget   string
+	configChanged      chan struct{} // UpdateConfig 時通知，讓等待中的循環套用新間隔
+	resumed            chan struct{} // Resume 時通知暫停中的循環
+	outsideActiveHours bool          // 上次檢查時是否在允許時段外
+	belowWarnThreshold bool          // 上次檢查時餘額是否已低於預警閾值
 	wg                 sync.WaitGroup
 }
 
@@ -83,13 +106,18 @@ type MonitorConfig struct {
 	GetCandidates      GetCandidatesFunc
 	ValidateCandidate  ValidateCandidateFunc  // 切換前驗證候選快照餘額
 	ConfirmAfterSwitch ConfirmAfterSwitchFunc // 切換後確認目標餘額狀態
+	Clock              Clock                  // 時間來源（可選，預設為系統時間）
 }
 
 // NewMonitor 建立新的監控器
 func NewMonitor(cfg MonitorConfig) *Monitor {
+	clock := cfg.Clock
+	if clock == nil {
+		clock = realClock{}
+	}
 	return &Monitor{
 		config:             cfg.Config,
-		safety:             NewSafetyState(),
+		safety:             newSafetyStateWithClock(clock.Now),
 		switchMu:           cfg.SwitchMu,
 		notifier:           cfg.Notifier,
 		refreshFunc:        cfg.RefreshFunc,
@@ -98,20 +126,30 @@ func NewMonitor(cfg MonitorConfig) *Monitor {
 		getCandidates:      cfg.GetCandidates,
 		validateCandidate:  cfg.ValidateCandidate,
 		confirmAfterSwitch: cfg.ConfirmAfterSwitch,
+		clock:              clock,
+		webhookClient:      &http.Client{},
 		status:             StatusStopped,
+		configChanged:      make(chan struct{}, 1),
+		resumed:            make(chan struct{}, 1),
 	}
 }
 
 // Start 啟動監控
 func (m *Monitor) Start() {
 	m.mu.Lock()
-	if m.status == StatusRunning {
+	if m.status != StatusStopped {
 		m.mu.Unlock()
 		return
 	}
 
 	m.ctx, m.cancel = context.WithCancel(context.Background())
 	m.status = StatusRunning
+
+	// 清除停止期間累積的設定變更通知
+	select {
+	case <-m.configChanged:
+	default:
+	}
 	m.mu.Unlock()
 
 	m.wg.Add(1)
@@ -135,11 +173,52 @@ func (m *Monitor) Stop() {
 	m.wg.Wait()
 }
 
+// Pause 暫停監控
+// 監控 Goroutine 保持運行，設定、最後餘額與冷卻期狀態皆保留
+func (m *Monitor) Pause() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.status != StatusRunning {
+		return
+	}
+	m.status = StatusPaused
+
+	// 清除尚未被消費的恢復通知
+	select {
+	case <-m.resumed:
+	default:
+	}
+}
+
+// Resume 恢復已暫停的監控
+// 仍在冷卻期內時，等冷卻期結束後才執行下一次檢查
+func (m *Monitor) Resume() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.status != StatusPaused {
+		return
+	}
+	m.status = StatusRunning
+
+	select {
+	case m.resumed <- struct{}{}:
+	default:
+	}
+}
+
 // UpdateConfig 更新設定
+// 正在等待下一次檢查的循環會依新的檢查間隔重新計算剩餘等待時間
 func (m *Monitor) UpdateConfig(config *AutoSwitchSettings) {
 	m.mu.Lock()
-	defer m.mu.Unlock()
 	m.config = config
+	m.mu.Unlock()
+
+	select {
+	case m.configChanged <- struct{}{}:
+	default:
+	}
 }
 
 // GetStatus 取得監控狀態
@@ -147,6 +226,11 @@ func (m *Monitor) GetStatus() MonitorStatus {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 
+	// 不在允許時段內時閒置，不會切換
+	if m.status == StatusRunning && m.outsideActiveHours {
+		return StatusIdle
+	}
+
 	// 檢查是否在冷卻期
 	if m.status == StatusRunning && m.safety.GetCooldownRemaining() > 0 {
 		return StatusCooldown
@@ -162,6 +246,24 @@ func (m *Monitor) GetLastBalance() float64 {
 	return m.lastBalance
 }
 
+// GetLastSwitchTarget 取得最後一次自動切換的目標快照名稱
+// 返回空字串表示尚未自動切換過
+func (m *Monitor) GetLastSwitchTarget() string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.lastSwitchTarget
+}
+
+// GetCooldownRemaining 取得冷卻期剩餘時間
+func (m *Monitor) GetCooldownRemaining() time.Duration {
+	return m.safety.GetCooldownRemaining()
+}
+
+// GetSwitchCount 取得當前計數週期內的切換次數
+func (m *Monitor) GetSwitchCount() int {
+	return m.safety.GetSwitchCount()
+}
+
 // PanicRecoveryDelay panic 恢復後的等待時間
 const PanicRecoveryDelay = 5 * time.Second
 
@@ -189,7 +291,7 @@ func (m *Monitor) monitorLoop() {
 			select {
 			case <-ctx.Done():
 				return
-			case <-time.After(PanicRecoveryDelay):
+			case <-m.clock.After(PanicRecoveryDelay):
 				continue
 			}
 		}
@@ -217,102 +319,195 @@ func (m *Monitor) monitorIteration() {
 	m.mu.RLock()
 	config := m.config
 	ctx := m.ctx
+	paused := m.status == StatusPaused
 	m.mu.RUnlock()
 
+	if paused {
+		// 暫停中，等待恢復
+		select {
+		case <-ctx.Done():
+			return
+		case <-m.resumed:
+		}
+		// 恢復時仍在冷卻期內，等冷卻期結束再檢查
+		if remaining := m.safety.GetCooldownRemaining(); remaining > 0 {
+			select {
+			case <-ctx.Done():
+			case <-m.clock.After(remaining):
+			}
+		}
+		return
+	}
+
 	if config == nil || !config.Enabled {
 		// 設定為空或未啟用，等待後重試
 		select {
 		case <-ctx.Done():
 			return
-		case <-time.After(1 * time.Second):
+		case <-m.clock.After(1 * time.Second):
 			return
 		}
 	}
 
-	// 刷新餘額
-	balance, err := m.refreshFunc(ctx)
+	result, err := m.runCheck(ctx, config)
 	if err != nil {
 		// 刷新失敗，等待後重試
 		select {
 		case <-ctx.Done():
 			return
-		case <-time.After(30 * time.Second):
+		case <-m.clock.After(30 * time.Second):
 			return
 		}
 	}
 
+	m.waitNextCheck(ctx, config, result.Balance)
+}
+
+// waitNextCheck 從本次檢查開始計時，等待檢查間隔結束
+// 等待期間設定變更時，依新設定的間隔重新計算剩餘等待時間，不會因此提前刷新
+func (m *Monitor) waitNextCheck(ctx context.Context, config *AutoSwitchSettings, balance float64) {
+	checkedAt := m.clock.Now()
+	ticker := m.clock.NewTicker(config.EffectiveCheckInterval(balance))
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C():
+			return
+		case <-m.configChanged:
+			m.mu.RLock()
+			config = m.config
+			m.mu.RUnlock()
+
+			if config == nil || !config.Enabled {
+				return
+			}
+			remaining := checkedAt.Add(config.EffectiveCheckInterval(balance)).Sub(m.clock.Now())
+			if remaining <= 0 {
+				return
+			}
+			ticker.Reset(remaining)
+		}
+	}
+}
+
+// ForceCheck 立即執行一次刷新、評估與必要時的切換，不等待檢查間隔
+// 與背景循環共用冷卻期與切換鎖，不會重複切換；未啟用自動切換時只刷新餘額
+func (m *Monitor) ForceCheck(ctx context.Context) (*CheckResult, error) {
+	m.mu.RLock()
+	config := m.config
+	m.mu.RUnlock()
+
+	if config == nil {
+		return nil, ErrNoConfig
+	}
+	return m.runCheck(ctx, config)
+}
+
+// runCheck 刷新餘額並依設定決定是否切換
+func (m *Monitor) runCheck(ctx context.Context, config *AutoSwitchSettings) (*CheckResult, error) {
+	// 刷新餘額
+	balance, err := m.refreshFunc(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to refresh balance: %w", err)
+	}
+
 	m.mu.Lock()
 	m.lastBalance = balance
 	m.mu.Unlock()
 
+	result := &CheckResult{Balance: balance}
+	if !config.Enabled {
+		return result, nil
+	}
+
+	// 允許時段檢查（每次檢查都評估，進出時段時各通知一次）
+	m.checkActiveHours(ctx, config)
+
+	// 預警閾值檢查（只在由上往下穿越時通知）
+	m.checkLowWarning(ctx, config, balance)
+
 	// 檢查是否需要切換
 	if balance <= config.BalanceThreshold {
-		m.checkAndSwitch(ctx, balance)
+		result.Target, result.Switched = m.checkAndSwitch(ctx, balance)
 	} else if balance <= config.BalanceThreshold*2 && config.NotifyOnLowBalance {
 		// 餘額接近閾值，發送預警
-		if m.notifier != nil {
-			m.notifier(ctx, NewLowBalanceNotification(balance, config.BalanceThreshold))
-		}
+		m.notify(ctx, config, NewLowBalanceNotification(balance, config.BalanceThreshold))
 	}
 
-	// 計算下一次刷新間隔
-	interval := GetRefreshInterval(config.RefreshIntervals, balance)
-
-	select {
-	case <-ctx.Done():
-		return
-	case <-time.After(interval):
-		return
-	}
+	return result, nil
 }
 
 // checkAndSwitch 檢查並執行切換
-func (m *Monitor) checkAndSwitch(ctx context.Context, currentBalance float64) {
+// 返回選定的目標快照名稱與是否實際切換（試運行時 switched 為 false）
+func (m *Monitor) checkAndSwitch(ctx context.Context, currentBalance float64) (target string, switched bool) {
+	// 與 ForceCheck 互斥，避免背景循環與手動檢查重複切換
+	m.checkMu.Lock()
+	defer m.checkMu.Unlock()
+
 	// 在切換開始時複製設定快照，確保整個切換過程使用一致的設定
 	m.mu.RLock()
 	configSnapshot := m.config.Clone()
 	m.mu.RUnlock()
 
+	// 檢查允許時段 - 使用設定快照（進出時段的通知由 runCheck 每次檢查時發送）
+	if !configSnapshot.IsWithinActiveHours(m.clock.Now()) {
+		return "", false
+	}
+
 	// 檢查安全狀態
 	canSwitch, reason := m.safety.CanSwitch()
 	if !canSwitch {
 		// 發送通知
-		if m.notifier != nil {
-			if m.safety.GetSwitchCount() >= MaxSwitchPerHour {
-				m.notifier(ctx, NewMaxSwitchNotification())
-			} else {
-				remaining := int(m.safety.GetCooldownRemaining().Seconds())
-				m.notifier(ctx, NewCooldownNotification(remaining))
-			}
+		if m.safety.GetSwitchCount() >= MaxSwitchPerHour {
+			m.notify(ctx, configSnapshot, NewMaxSwitchNotification())
+		} else {
+			remaining := int(m.safety.GetCooldownRemaining().Seconds())
+			m.notify(ctx, configSnapshot, NewCooldownNotification(remaining))
 		}
 		_ = reason // 已在通知中使用
-		return
+		return "", false
+	}
+
+	// 檢查最短切換間隔 - 使用設定快照
+	if configSnapshot.MinSwitchInterval > 0 {
+		if last := m.safety.GetLastSwitchTime(); !last.IsZero() {
+			if elapsed := m.clock.Now().Sub(last); elapsed < configSnapshot.MinSwitchInterval {
+				remaining := int((configSnapshot.MinSwitchInterval - elapsed).Seconds())
+				m.notify(ctx, configSnapshot, NewMinSwitchIntervalNotification(remaining))
+				return "", false
+			}
+		}
+	}
+
+	// 檢查每日切換上限 - 使用設定快照
+	if m.safety.DailyCapReached(configSnapshot.MaxSwitchesPerDay) {
+		m.notify(ctx, configSnapshot, NewDailyCapReachedNotification(configSnapshot.MaxSwitchesPerDay))
+		return "", false
 	}
 
 	// 取得候選快照
 	candidates := m.getCandidates()
 	if len(candidates) == 0 {
-		if m.notifier != nil {
-			m.notifier(ctx, NewNoCandidatesNotification())
-		}
-		return
+		m.notify(ctx, configSnapshot, NewNoCandidatesNotification())
+		return "", false
 	}
 
 	// 篩選候選 - 使用設定快照
 	currentName := m.getCurrentName()
 	filtered := FilterCandidates(configSnapshot, currentName, candidates)
 	if len(filtered) == 0 {
-		if m.notifier != nil {
-			m.notifier(ctx, NewNoCandidatesNotification())
-		}
-		return
+		m.notify(ctx, configSnapshot, NewNoCandidatesNotification())
+		return "", false
 	}
 
 	// 嘗試取得全域切換鎖
 	if m.switchMu != nil {
 		if !m.switchMu.TryLock() {
 			// 正在切換中，跳過
-			return
+			return "", false
 		}
 		defer m.switchMu.Unlock()
 	}
@@ -320,6 +515,8 @@ func (m *Monitor) checkAndSwitch(ctx context.Context, currentBalance float64) {
 	// 按餘額排序候選（SelectBestCandidate 已經做了，但我們需要遍歷所有候選做 fallback）
 	// 嘗試每個候選，直到成功或全部失敗
 	for _, candidate := range filtered {
+		targetBalance := candidate.Balance
+
 		// 驗證候選快照餘額（帶重試）
 		if m.validateCandidate != nil {
 			validatedBalance, err := m.validateCandidateWithRetry(ctx, candidate.Name)
@@ -331,24 +528,33 @@ func (m *Monitor) checkAndSwitch(ctx context.Context, currentBalance float64) {
 			if validatedBalance < configSnapshot.MinTargetBalance {
 				continue
 			}
+			targetBalance = validatedBalance
+		}
+
+		// 試運行：不實際切換，但照常記錄切換以套用冷卻期等安全限制
+		if configSnapshot.DryRun {
+			m.safety.RecordSwitch()
+			m.notify(ctx, configSnapshot, NewDryRunNotification(currentName, candidate.Name, currentBalance, targetBalance))
+			return candidate.Name, false
 		}
 
 		// 執行切換
 		err := m.switchFunc(ctx, candidate.Name)
 		if err != nil {
-			if m.notifier != nil {
-				m.notifier(ctx, NewSwitchFailNotification(err.Error()))
-			}
+			m.notify(ctx, configSnapshot, NewSwitchFailNotification(err.Error()))
 			// 切換失敗，嘗試下一個候選
 			continue
 		}
 
 		// 記錄切換
 		m.safety.RecordSwitch()
+		m.mu.Lock()
+		m.lastSwitchTarget = candidate.Name
+		m.mu.Unlock()
 
 		// 發送成功通知 - 使用設定快照
-		if m.notifier != nil && configSnapshot.NotifyOnSwitch {
-			m.notifier(ctx, NewSwitchNotification(currentName, candidate.Name))
+		if configSnapshot.NotifyOnSwitch {
+			m.notify(ctx, configSnapshot, NewSwitchNotification(currentName, candidate.Name))
 		}
 
 		// 切換後確認（異步執行，不阻塞）
@@ -361,12 +567,48 @@ func (m *Monitor) checkAndSwitch(ctx context.Context, currentBalance float64) {
 		}
 
 		// 切換成功，退出循環
-		return
+		return candidate.Name, true
 	}
 
 	// 所有候選都失敗
-	if m.notifier != nil {
-		m.notifier(ctx, NewNoCandidatesNotification())
+	m.notify(ctx, configSnapshot, NewNoCandidatesNotification())
+	return "", false
+}
+
+// checkLowWarning 餘額由高於預警閾值降至 (BalanceThreshold, WarnThreshold] 時發送一次預警
+func (m *Monitor) checkLowWarning(ctx context.Context, config *AutoSwitchSettings, balance float64) {
+	if config.WarnThreshold <= 0 {
+		return
+	}
+
+	below := balance <= config.WarnThreshold
+
+	m.mu.Lock()
+	crossed := below && !m.belowWarnThreshold
+	m.belowWarnThreshold = below
+	m.mu.Unlock()
+
+	if crossed && balance > config.BalanceThreshold {
+		m.notify(ctx, config, NewLowWarningNotification(balance, config.WarnThreshold))
+	}
+}
+
+// checkActiveHours 檢查當前是否在允許時段內
+// 進入或離開允許時段時各發送一次通知；實際是否允許切換由 checkAndSwitch 判斷
+func (m *Monitor) checkActiveHours(ctx context.Context, config *AutoSwitchSettings) {
+	active := config.IsWithinActiveHours(m.clock.Now())
+
+	m.mu.Lock()
+	changed := m.outsideActiveHours == active
+	m.outsideActiveHours = !active
+	m.mu.Unlock()
+
+	if changed {
+		if active {
+			m.notify(ctx, config, NewActiveHoursResumedNotification())
+		} else {
+			m.notify(ctx, config, NewOutsideActiveHoursNotification(config.ActiveHoursStart, config.ActiveHoursEnd))
+		}
 	}
 }
 
@@ -385,7 +627,7 @@ func (m *Monitor) validateCandidateWithRetry(ctx context.Context, candidateName
 			select {
 			case <-ctx.Done():
 				return 0, ctx.Err()
-			case <-time.After(ValidateRetryInterval):
+			case <-m.clock.After(ValidateRetryInterval):
 			}
 		}
 	}
@@ -398,7 +640,7 @@ func (m *Monitor) confirmAfterSwitchAsync(ctx context.Context, targetName string
 	select {
 	case <-ctx.Done():
 		return
-	case <-time.After(ConfirmAfterSwitchDelay):
+	case <-m.clock.After(ConfirmAfterSwitchDelay):
 	}
 
 	balance, err := m.confirmAfterSwitch(ctx, targetName)
@@ -2,11 +2,129 @@ package autoswitch
 
 import (
 	"context"
+	"errors"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 )
 
+// fakeClock 可手動推進的假時鐘
+type fakeClock struct {
+	mu      sync.Mutex
+	now     time.Time
+	waiters []*fakeWaiter
+}
+
+// fakeWaiter 等待中的 After 或 Ticker
+type fakeWaiter struct {
+	clock  *fakeClock
+	at     time.Time
+	period time.Duration // 0 表示 After（只觸發一次）
+	ch     chan time.Time
+}
+
+func newFakeClock(now time.Time) *fakeClock {
+	return &fakeClock{now: now}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	w := &fakeWaiter{clock: c, at: c.now.Add(d), ch: make(chan time.Time, 1)}
+	if d <= 0 {
+		w.ch <- c.now
+		return w.ch
+	}
+	c.waiters = append(c.waiters, w)
+	return w.ch
+}
+
+func (c *fakeClock) NewTicker(d time.Duration) Ticker {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	w := &fakeWaiter{clock: c, at: c.now.Add(d), period: d, ch: make(chan time.Time, 1)}
+	c.waiters = append(c.waiters, w)
+	return w
+}
+
+// Advance 推進時間並觸發到期的 After 與 Ticker
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+
+	pending := c.waiters[:0]
+	for _, w := range c.waiters {
+		if w.at.After(c.now) {
+			pending = append(pending, w)
+			continue
+		}
+		select {
+		case w.ch <- c.now:
+		default:
+		}
+		if w.period > 0 {
+			for !w.at.After(c.now) {
+				w.at = w.at.Add(w.period)
+			}
+			pending = append(pending, w)
+		}
+	}
+	c.waiters = pending
+}
+
+// Set 將時間設定為 t（只能往後）
+func (c *fakeClock) Set(t time.Time) {
+	c.Advance(t.Sub(c.Now()))
+}
+
+// BlockUntil 等待至少 n 個 After 或 Ticker 註冊
+func (c *fakeClock) BlockUntil(t *testing.T, n int) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		c.mu.Lock()
+		count := len(c.waiters)
+		c.mu.Unlock()
+		if count >= n {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %d clock waiters, got %d", n, count)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func (w *fakeWaiter) C() <-chan time.Time { return w.ch }
+
+func (w *fakeWaiter) Reset(d time.Duration) {
+	w.Stop()
+	w.clock.mu.Lock()
+	defer w.clock.mu.Unlock()
+	w.at = w.clock.now.Add(d)
+	w.period = d
+	w.clock.waiters = append(w.clock.waiters, w)
+}
+
+func (w *fakeWaiter) Stop() {
+	w.clock.mu.Lock()
+	defer w.clock.mu.Unlock()
+	for i, other := range w.clock.waiters {
+		if other == w {
+			w.clock.waiters = append(w.clock.waiters[:i], w.clock.waiters[i+1:]...)
+			return
+		}
+	}
+}
+
 // TestMonitorStartStop 驗證啟動/停止
 func TestMonitorStartStop(t *testing.T) {
 	m := NewMonitor(MonitorConfig{
@@ -198,13 +316,703 @@ func TestMonitorNoCandidates(t *testing.T) {
 	}
 }
 
+// TestMonitorFolderScopedNoCandidates 驗證 FolderScoped 時不會切換到其他文件夾
+func TestMonitorFolderScopedNoCandidates(t *testing.T) {
+	var notifications []*Notification
+	var mu sync.Mutex
+
+	config := DefaultAutoSwitchSettings()
+	config.Enabled = true
+	config.BalanceThreshold = 5
+	config.FolderScoped = true
+
+	m := NewMonitor(MonitorConfig{
+		Config: config,
+		RefreshFunc: func(ctx context.Context) (float64, error) {
+			return 3, nil
+		},
+		SwitchFunc: func(ctx context.Context, name string) error {
+			t.Errorf("unexpected switch to %s outside current folder", name)
+			return nil
+		},
+		GetCurrentName: func() string { return "帳號A" },
+		GetCandidates: func() []CandidateSnapshot {
+			return []CandidateSnapshot{
+				{Name: "帳號A", Balance: 3, FolderId: "folder-1"},
+				{Name: "帳號B", Balance: 150, FolderId: "folder-2"},
+				{Name: "帳號C", Balance: 80, FolderId: "folder-2"},
+			}
+		},
+		Notifier: func(ctx context.Context, n *Notification) {
+			mu.Lock()
+			notifications = append(notifications, n)
+			mu.Unlock()
+		},
+	})
+
+	m.Start()
+	time.Sleep(100 * time.Millisecond)
+	m.Stop()
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	// 同文件夾沒有候選時應通知而非跨文件夾切換
+	found := false
+	for _, n := range notifications {
+		if n.Type == NotifyNoCandidates {
+			found = true
+			break
+		}
+	}
+	if !found {
+		t.Error("expected no candidates notification")
+	}
+}
+
+// setMinCheckInterval 暫時降低檢查間隔下限以便測試
+func setMinCheckInterval(t *testing.T, d time.Duration) {
+	t.Helper()
+	original := minCheckInterval
+	minCheckInterval = d
+	t.Cleanup(func() { minCheckInterval = original })
+}
+
+// countingMonitor 建立只計算 RefreshFunc 調用次數的監控器（餘額高於閾值，不觸發切換）
+func countingMonitor(config *AutoSwitchSettings, calls *int32) *Monitor {
+	return NewMonitor(MonitorConfig{
+		Config: config,
+		RefreshFunc: func(ctx context.Context) (float64, error) {
+			atomic.AddInt32(calls, 1)
+			return 500, nil
+		},
+		SwitchFunc:     func(ctx context.Context, name string) error { return nil },
+		GetCurrentName: func() string { return "帳號A" },
+		GetCandidates:  func() []CandidateSnapshot { return nil },
+	})
+}
+
+// TestMonitorCheckInterval 驗證固定檢查間隔決定刷新頻率
+func TestMonitorCheckInterval(t *testing.T) {
+	setMinCheckInterval(t, 10*time.Millisecond)
+
+	config := DefaultAutoSwitchSettings()
+	config.Enabled = true
+	config.CheckInterval = 50 * time.Millisecond
+
+	var calls int32
+	m := countingMonitor(config, &calls)
+	m.Start()
+	time.Sleep(275 * time.Millisecond)
+	m.Stop()
+
+	// 約 275ms / 50ms ≈ 6 次，容許排程誤差
+	if got := atomic.LoadInt32(&calls); got < 4 || got > 7 {
+		t.Errorf("expected about 6 refreshes, got %d", got)
+	}
+}
+
+// TestMonitorCheckIntervalClamped 驗證過小的檢查間隔被限制為下限
+func TestMonitorCheckIntervalClamped(t *testing.T) {
+	setMinCheckInterval(t, 100*time.Millisecond)
+
+	config := DefaultAutoSwitchSettings()
+	config.Enabled = true
+	config.CheckInterval = time.Nanosecond
+
+	var calls int32
+	m := countingMonitor(config, &calls)
+	m.Start()
+	time.Sleep(250 * time.Millisecond)
+	m.Stop()
+
+	// 未限制時會刷新上萬次；限制為 100ms 時應約 3 次
+	if got := atomic.LoadInt32(&calls); got > 4 {
+		t.Errorf("expected interval clamped to 100ms (about 3 refreshes), got %d", got)
+	}
+}
+
+// TestMonitorUpdateConfigResetsInterval 驗證 UpdateConfig 立即套用新的檢查間隔
+func TestMonitorUpdateConfigResetsInterval(t *testing.T) {
+	setMinCheckInterval(t, 10*time.Millisecond)
+
+	config := DefaultAutoSwitchSettings()
+	config.Enabled = true
+	config.CheckInterval = time.Hour
+
+	var calls int32
+	m := countingMonitor(config, &calls)
+	m.Start()
+	time.Sleep(50 * time.Millisecond)
+
+	if got := atomic.LoadInt32(&calls); got != 1 {
+		t.Fatalf("expected 1 refresh before update, got %d", got)
+	}
+
+	// 間隔不變的設定更新不會提前觸發刷新
+	for i := 0; i < 3; i++ {
+		m.UpdateConfig(config.Clone())
+		time.Sleep(10 * time.Millisecond)
+	}
+	if got := atomic.LoadInt32(&calls); got != 1 {
+		t.Fatalf("expected config updates not to trigger a refresh, got %d", got)
+	}
+
+	newConfig := config.Clone()
+	newConfig.CheckInterval = 20 * time.Millisecond
+	m.UpdateConfig(newConfig)
+	time.Sleep(150 * time.Millisecond)
+	m.Stop()
+
+	// 不重新計算等待時間時會停在 1 小時的間隔上
+	if got := atomic.LoadInt32(&calls); got < 3 {
+		t.Errorf("expected new interval to apply to the pending wait, got %d refreshes", got)
+	}
+}
+
+// TestMonitorDailySwitchCap 驗證達到每日切換上限後拒絕切換並發送通知
+func TestMonitorDailySwitchCap(t *testing.T) {
+	var notifications []*Notification
+	var switches []string
+
+	config := DefaultAutoSwitchSettings()
+	config.Enabled = true
+	config.BalanceThreshold = 5
+	config.MinTargetBalance = 50
+	config.MaxSwitchesPerDay = 2
+
+	m := NewMonitor(MonitorConfig{
+		Config: config,
+		SwitchFunc: func(ctx context.Context, name string) error {
+			switches = append(switches, name)
+			return nil
+		},
+		GetCurrentName: func() string { return "帳號A" },
+		GetCandidates: func() []CandidateSnapshot {
+			return []CandidateSnapshot{{Name: "帳號B", Balance: 150}}
+		},
+		Notifier: func(ctx context.Context, n *Notification) {
+			notifications = append(notifications, n)
+		},
+	})
+
+	for i := 0; i < 3; i++ {
+		// 清除冷卻期與每小時計數，只保留每日統計
+		m.safety.mu.Lock()
+		m.safety.LastSwitchTime = time.Time{}
+		m.safety.SwitchCount = 0
+		m.safety.mu.Unlock()
+
+		m.checkAndSwitch(context.Background(), 3)
+	}
+
+	if len(switches) != 2 {
+		t.Fatalf("expected 2 switches before cap, got %d", len(switches))
+	}
+	last := notifications[len(notifications)-1]
+	if last.Type != NotifyDailyCapReached {
+		t.Errorf("expected %s notification for third attempt, got %s", NotifyDailyCapReached, last.Type)
+	}
+	if last.Data["maxSwitchesPerDay"] != 2 {
+		t.Errorf("expected maxSwitchesPerDay=2 in notification data, got %v", last.Data["maxSwitchesPerDay"])
+	}
+}
+
+// TestMonitorMinSwitchInterval 驗證最短切換間隔內的兩次低餘額週期只切換一次
+func TestMonitorMinSwitchInterval(t *testing.T) {
+	var notifications []*Notification
+	var switches []string
+
+	config := DefaultAutoSwitchSettings()
+	config.Enabled = true
+	config.BalanceThreshold = 5
+	config.MinTargetBalance = 50
+	config.MinSwitchInterval = time.Hour
+
+	m := NewMonitor(MonitorConfig{
+		Config: config,
+		SwitchFunc: func(ctx context.Context, name string) error {
+			switches = append(switches, name)
+			return nil
+		},
+		GetCurrentName: func() string { return "帳號A" },
+		GetCandidates: func() []CandidateSnapshot {
+			return []CandidateSnapshot{{Name: "帳號B", Balance: 150}}
+		},
+		Notifier: func(ctx context.Context, n *Notification) {
+			notifications = append(notifications, n)
+		},
+	})
+
+	m.checkAndSwitch(context.Background(), 3)
+
+	// 將上次切換時間移到冷卻期之外，但仍在最短切換間隔內
+	m.safety.mu.Lock()
+	m.safety.LastSwitchTime = time.Now().Add(-CooldownPeriod - time.Minute)
+	m.safety.mu.Unlock()
+
+	m.checkAndSwitch(context.Background(), 3)
+
+	if len(switches) != 1 {
+		t.Fatalf("expected 1 switch within MinSwitchInterval, got %d", len(switches))
+	}
+	last := notifications[len(notifications)-1]
+	if last.Type != NotifyMinSwitchInterval {
+		t.Errorf("expected %s notification, got %s", NotifyMinSwitchInterval, last.Type)
+	}
+}
+
+// TestMonitorDryRun 驗證試運行模式完整執行篩選與驗證，但不呼叫 SwitchFunc
+func TestMonitorDryRun(t *testing.T) {
+	var notifications []*Notification
+	switchCalled := false
+	var validated []string
+
+	config := DefaultAutoSwitchSettings()
+	config.Enabled = true
+	config.BalanceThreshold = 5
+	config.MinTargetBalance = 50
+	config.DryRun = true
+
+	m := NewMonitor(MonitorConfig{
+		Config: config,
+		SwitchFunc: func(ctx context.Context, name string) error {
+			switchCalled = true
+			return nil
+		},
+		GetCurrentName: func() string { return "帳號A" },
+		GetCandidates: func() []CandidateSnapshot {
+			return []CandidateSnapshot{
+				{Name: "帳號B", Balance: 150},
+				{Name: "帳號C", Balance: 80},
+			}
+		},
+		ValidateCandidate: func(ctx context.Context, name string) (float64, error) {
+			validated = append(validated, name)
+			return 140, nil
+		},
+		Notifier: func(ctx context.Context, n *Notification) {
+			notifications = append(notifications, n)
+		},
+	})
+
+	m.checkAndSwitch(context.Background(), 3)
+
+	if switchCalled {
+		t.Error("SwitchFunc should not be called in dry-run mode")
+	}
+	if len(validated) != 1 || validated[0] != "帳號B" {
+		t.Errorf("expected 帳號B to be validated, got %v", validated)
+	}
+	if len(notifications) != 1 || notifications[0].Type != NotifyDryRun {
+		t.Fatalf("expected a single %s notification, got %v", NotifyDryRun, notifications)
+	}
+	data := notifications[0].Data
+	if data["from"] != "帳號A" || data["to"] != "帳號B" {
+		t.Errorf("unexpected from/to: %v", data)
+	}
+	if data["currentBalance"] != 3.0 || data["targetBalance"] != 140.0 {
+		t.Errorf("unexpected balances: %v", data)
+	}
+
+	// 冷卻期照常生效
+	if m.safety.GetCooldownRemaining() <= 0 {
+		t.Error("expected cooldown to apply after a dry-run switch")
+	}
+}
+
+// TestMonitorPauseResume 驗證暫停/恢復的狀態轉換並保留最後餘額
+func TestMonitorPauseResume(t *testing.T) {
+	setMinCheckInterval(t, 10*time.Millisecond)
+
+	config := DefaultAutoSwitchSettings()
+	config.Enabled = true
+	config.CheckInterval = 20 * time.Millisecond
+
+	var calls int32
+	m := countingMonitor(config, &calls)
+
+	// 未啟動時暫停無效
+	m.Pause()
+	if m.GetStatus() != StatusStopped {
+		t.Errorf("expected status=%s, got %s", StatusStopped, m.GetStatus())
+	}
+
+	m.Start()
+	defer m.Stop()
+	time.Sleep(50 * time.Millisecond)
+
+	m.Pause()
+	if m.GetStatus() != StatusPaused {
+		t.Errorf("expected status=%s after pause, got %s", StatusPaused, m.GetStatus())
+	}
+	if m.GetLastBalance() != 500 {
+		t.Errorf("expected last balance kept while paused, got %f", m.GetLastBalance())
+	}
+
+	// 暫停中再次啟動不應離開暫停狀態
+	m.Start()
+	if m.GetStatus() != StatusPaused {
+		t.Errorf("expected status=%s after start while paused, got %s", StatusPaused, m.GetStatus())
+	}
+
+	m.Resume()
+	if m.GetStatus() != StatusRunning {
+		t.Errorf("expected status=%s after resume, got %s", StatusRunning, m.GetStatus())
+	}
+
+	before := atomic.LoadInt32(&calls)
+	time.Sleep(100 * time.Millisecond)
+	if atomic.LoadInt32(&calls) <= before {
+		t.Error("expected refreshes to continue after resume")
+	}
+}
+
+// TestMonitorPausedSkipsRefresh 驗證暫停中不會呼叫 RefreshFunc
+func TestMonitorPausedSkipsRefresh(t *testing.T) {
+	setMinCheckInterval(t, 10*time.Millisecond)
+
+	config := DefaultAutoSwitchSettings()
+	config.Enabled = true
+	config.CheckInterval = 10 * time.Millisecond
+
+	var calls int32
+	m := countingMonitor(config, &calls)
+	m.Start()
+	defer m.Stop()
+	time.Sleep(30 * time.Millisecond)
+
+	m.Pause()
+	time.Sleep(20 * time.Millisecond) // 等待進行中的迭代結束
+	paused := atomic.LoadInt32(&calls)
+	time.Sleep(100 * time.Millisecond)
+
+	if got := atomic.LoadInt32(&calls); got != paused {
+		t.Errorf("expected no refreshes while paused, got %d more", got-paused)
+	}
+}
+
+// TestMonitorResumeWithinCooldown 驗證冷卻期內恢復不會立即檢查
+func TestMonitorResumeWithinCooldown(t *testing.T) {
+	setMinCheckInterval(t, 10*time.Millisecond)
+
+	config := DefaultAutoSwitchSettings()
+	config.Enabled = true
+	config.CheckInterval = 10 * time.Millisecond
+
+	var calls int32
+	m := countingMonitor(config, &calls)
+	m.Start()
+	defer m.Stop()
+	time.Sleep(30 * time.Millisecond)
+
+	m.Pause()
+	time.Sleep(20 * time.Millisecond)
+	m.safety.RecordSwitch() // 進入冷卻期
+	paused := atomic.LoadInt32(&calls)
+
+	m.Resume()
+	time.Sleep(100 * time.Millisecond)
+
+	if got := atomic.LoadInt32(&calls); got != paused {
+		t.Errorf("expected no refresh right after resume within cooldown, got %d", got-paused)
+	}
+}
+
+// TestMonitorActiveHours 驗證允許時段外不切換，且進出時段時各通知一次
+func TestMonitorActiveHours(t *testing.T) {
+	var notifications []*Notification
+	var switches []string
+	clock := newFakeClock(time.Date(2025, 1, 1, 23, 0, 0, 0, time.Local))
+	balance := 3.0
+
+	config := DefaultAutoSwitchSettings()
+	config.Enabled = true
+	config.BalanceThreshold = 5
+	config.MinTargetBalance = 50
+	config.ActiveHoursStart = "09:00"
+	config.ActiveHoursEnd = "18:00"
+
+	m := NewMonitor(MonitorConfig{
+		Config:      config,
+		Clock:       clock,
+		RefreshFunc: func(ctx context.Context) (float64, error) { return balance, nil },
+		SwitchFunc: func(ctx context.Context, name string) error {
+			switches = append(switches, name)
+			return nil
+		},
+		GetCurrentName: func() string { return "帳號A" },
+		GetCandidates: func() []CandidateSnapshot {
+			return []CandidateSnapshot{{Name: "帳號B", Balance: 150}}
+		},
+		Notifier: func(ctx context.Context, n *Notification) {
+			notifications = append(notifications, n)
+		},
+	})
+
+	// 時段外連續兩次檢查：不切換，只通知一次
+	m.runCheck(context.Background(), config)
+	clock.Advance(time.Hour)
+	m.runCheck(context.Background(), config)
+
+	if len(switches) != 0 {
+		t.Fatalf("expected no switch outside active hours, got %v", switches)
+	}
+	if len(notifications) != 1 || notifications[0].Type != NotifyOutsideActiveHours {
+		t.Fatalf("expected a single %s notification, got %v", NotifyOutsideActiveHours, notifications)
+	}
+
+	// 進入時段後恢復切換
+	clock.Set(time.Date(2025, 1, 2, 10, 0, 0, 0, time.Local))
+	m.runCheck(context.Background(), config)
+
+	if len(switches) != 1 {
+		t.Errorf("expected switch inside active hours, got %v", switches)
+	}
+	if notifications[1].Type != NotifyActiveHoursResumed {
+		t.Errorf("expected %s notification, got %s", NotifyActiveHoursResumed, notifications[1].Type)
+	}
+}
+
+// TestMonitorActiveHoursTransitionAboveThreshold 驗證餘額高於閾值時仍在進出時段當下通知
+func TestMonitorActiveHoursTransitionAboveThreshold(t *testing.T) {
+	var notifications []*Notification
+	clock := newFakeClock(time.Date(2025, 1, 1, 17, 0, 0, 0, time.Local))
+
+	config := DefaultAutoSwitchSettings()
+	config.Enabled = true
+	config.BalanceThreshold = 5
+	config.ActiveHoursStart = "09:00"
+	config.ActiveHoursEnd = "18:00"
+
+	m := NewMonitor(MonitorConfig{
+		Config:      config,
+		Clock:       clock,
+		RefreshFunc: func(ctx context.Context) (float64, error) { return 100, nil },
+		Notifier: func(ctx context.Context, n *Notification) {
+			notifications = append(notifications, n)
+		},
+	})
+
+	m.runCheck(context.Background(), config)
+	if len(notifications) != 0 {
+		t.Fatalf("expected no notification inside active hours, got %v", notifications)
+	}
+
+	clock.Advance(2 * time.Hour)
+	m.runCheck(context.Background(), config)
+	if len(notifications) != 1 || notifications[0].Type != NotifyOutsideActiveHours {
+		t.Fatalf("expected %s notification at transition, got %v", NotifyOutsideActiveHours, notifications)
+	}
+}
+
+// TestMonitorMockedClockGuards 驗證冷卻期、最短切換間隔與允許時段使用同一個注入時鐘
+func TestMonitorMockedClockGuards(t *testing.T) {
+	var switches []string
+	clock := newFakeClock(time.Date(2025, 1, 1, 10, 0, 0, 0, time.Local))
+
+	config := DefaultAutoSwitchSettings()
+	config.Enabled = true
+	config.BalanceThreshold = 5
+	config.MinTargetBalance = 50
+	config.MinSwitchInterval = 30 * time.Minute
+	config.ActiveHoursStart = "09:00"
+	config.ActiveHoursEnd = "18:00"
+
+	m := NewMonitor(MonitorConfig{
+		Config: config,
+		Clock:  clock,
+		SwitchFunc: func(ctx context.Context, name string) error {
+			switches = append(switches, name)
+			return nil
+		},
+		GetCurrentName: func() string { return "帳號A" },
+		GetCandidates: func() []CandidateSnapshot {
+			return []CandidateSnapshot{{Name: "帳號B", Balance: 150}}
+		},
+	})
+
+	m.checkAndSwitch(context.Background(), 3)
+
+	// 冷卻期已過但仍在最短切換間隔內
+	clock.Advance(10 * time.Minute)
+	m.checkAndSwitch(context.Background(), 3)
+
+	// 超過最短切換間隔
+	clock.Advance(25 * time.Minute)
+	m.checkAndSwitch(context.Background(), 3)
+
+	// 超過間隔但已在允許時段外
+	clock.Set(time.Date(2025, 1, 1, 20, 0, 0, 0, time.Local))
+	m.checkAndSwitch(context.Background(), 3)
+
+	if len(switches) != 2 {
+		t.Errorf("expected 2 switches under the mocked clock, got %v", switches)
+	}
+}
+
+// TestMonitorStatusAccessors 驗證切換後可取得最後目標、冷卻期與切換次數
+func TestMonitorStatusAccessors(t *testing.T) {
+	config := DefaultAutoSwitchSettings()
+	config.Enabled = true
+	config.BalanceThreshold = 5
+	config.MinTargetBalance = 50
+
+	m := NewMonitor(MonitorConfig{
+		Config:         config,
+		SwitchFunc:     func(ctx context.Context, name string) error { return nil },
+		GetCurrentName: func() string { return "帳號A" },
+		GetCandidates: func() []CandidateSnapshot {
+			return []CandidateSnapshot{{Name: "帳號B", Balance: 150}}
+		},
+	})
+
+	if m.GetLastSwitchTarget() != "" || m.GetSwitchCount() != 0 || m.GetCooldownRemaining() != 0 {
+		t.Fatal("expected empty status before any switch")
+	}
+
+	m.checkAndSwitch(context.Background(), 3)
+
+	if got := m.GetLastSwitchTarget(); got != "帳號B" {
+		t.Errorf("expected last switch target 帳號B, got %q", got)
+	}
+	if got := m.GetSwitchCount(); got != 1 {
+		t.Errorf("expected switch count 1, got %d", got)
+	}
+	if m.GetCooldownRemaining() <= 0 {
+		t.Error("expected cooldown after switch")
+	}
+}
+
+// TestMonitorLowWarning 驗證只在由上往下穿越預警閾值時通知一次
+func TestMonitorLowWarning(t *testing.T) {
+	setMinCheckInterval(t, time.Millisecond)
+
+	var notifications []*Notification
+	balances := []float64{50, 15, 12, 30, 18, 3}
+	idx := 0
+
+	config := DefaultAutoSwitchSettings()
+	config.Enabled = true
+	config.BalanceThreshold = 5
+	config.WarnThreshold = 20
+	config.CheckInterval = time.Millisecond
+
+	m := NewMonitor(MonitorConfig{
+		Config: config,
+		RefreshFunc: func(ctx context.Context) (float64, error) {
+			b := balances[idx]
+			idx++
+			return b, nil
+		},
+		SwitchFunc:     func(ctx context.Context, name string) error { return nil },
+		GetCurrentName: func() string { return "帳號A" },
+		GetCandidates:  func() []CandidateSnapshot { return nil },
+		Notifier: func(ctx context.Context, n *Notification) {
+			if n.Type == NotifyLowWarning {
+				notifications = append(notifications, n)
+			}
+		},
+	})
+	m.ctx = context.Background()
+
+	for range balances {
+		m.monitorIteration()
+	}
+
+	// 50→15 穿越一次，30→18 再穿越一次；12 與 3 不重複通知
+	if len(notifications) != 2 {
+		t.Fatalf("expected 2 warnings, got %d", len(notifications))
+	}
+	if notifications[0].Data["currentBalance"] != 15.0 || notifications[1].Data["currentBalance"] != 18.0 {
+		t.Errorf("unexpected warning balances: %v, %v", notifications[0].Data, notifications[1].Data)
+	}
+}
+
+// TestMonitorForceCheck 驗證 ForceCheck 在餘額低於閾值時立即切換，且遵守冷卻期
+func TestMonitorForceCheck(t *testing.T) {
+	var mu sync.Mutex
+	var switches []string
+
+	config := DefaultAutoSwitchSettings()
+	config.Enabled = true
+	config.BalanceThreshold = 5
+	config.MinTargetBalance = 50
+
+	m := NewMonitor(MonitorConfig{
+		Config: config,
+		RefreshFunc: func(ctx context.Context) (float64, error) {
+			return 3, nil
+		},
+		SwitchFunc: func(ctx context.Context, name string) error {
+			mu.Lock()
+			switches = append(switches, name)
+			mu.Unlock()
+			return nil
+		},
+		GetCurrentName: func() string { return "帳號A" },
+		GetCandidates: func() []CandidateSnapshot {
+			return []CandidateSnapshot{{Name: "帳號B", Balance: 150}}
+		},
+	})
+
+	result, err := m.ForceCheck(context.Background())
+	if err != nil {
+		t.Fatalf("ForceCheck failed: %v", err)
+	}
+	if result.Balance != 3 || !result.Switched || result.Target != "帳號B" {
+		t.Errorf("unexpected result: %+v", result)
+	}
+	if m.GetLastBalance() != 3 {
+		t.Errorf("expected last balance 3, got %f", m.GetLastBalance())
+	}
+
+	// 冷卻期內並發檢查不應再切換
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if r, err := m.ForceCheck(context.Background()); err != nil || r.Switched {
+				t.Errorf("expected no switch during cooldown, got %+v, %v", r, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if len(switches) != 1 {
+		t.Errorf("expected exactly 1 switch, got %v", switches)
+	}
+}
+
+// TestMonitorForceCheck_Errors 驗證 ForceCheck 的錯誤情況
+func TestMonitorForceCheck_Errors(t *testing.T) {
+	m := NewMonitor(MonitorConfig{})
+	if _, err := m.ForceCheck(context.Background()); err != ErrNoConfig {
+		t.Errorf("expected ErrNoConfig, got %v", err)
+	}
+
+	refreshErr := errors.New("network down")
+	m = NewMonitor(MonitorConfig{
+		Config: DefaultAutoSwitchSettings(),
+		RefreshFunc: func(ctx context.Context) (float64, error) {
+			return 0, refreshErr
+		},
+	})
+	if _, err := m.ForceCheck(context.Background()); !errors.Is(err, refreshErr) {
+		t.Errorf("expected wrapped refresh error, got %v", err)
+	}
+}
+
 // TestMonitorCooldown 驗證冷卻期狀態
 func TestMonitorCooldown(t *testing.T) {
 	config := DefaultAutoSwitchSettings()
 	config.Enabled = true
+	clock := newFakeClock(time.Date(2025, 1, 1, 10, 0, 0, 0, time.Local))
 
 	m := NewMonitor(MonitorConfig{
 		Config: config,
+		Clock:  clock,
 		RefreshFunc: func(ctx context.Context) (float64, error) {
 			return 100, nil
 		},
@@ -216,7 +1024,7 @@ func TestMonitorCooldown(t *testing.T) {
 	})
 
 	m.Start()
-	time.Sleep(10 * time.Millisecond)
+	defer m.Stop()
 
 	// 模擬切換後進入冷卻期
 	m.safety.RecordSwitch()
@@ -226,7 +1034,17 @@ func TestMonitorCooldown(t *testing.T) {
 		t.Errorf("expected status=%s during cooldown, got %s", StatusCooldown, status)
 	}
 
-	m.Stop()
+	// 冷卻期結束前仍為冷卻中
+	clock.Advance(CooldownPeriod - time.Second)
+	if status := m.GetStatus(); status != StatusCooldown {
+		t.Errorf("expected status=%s before cooldown ends, got %s", StatusCooldown, status)
+	}
+
+	// 冷卻期結束後恢復為運行中
+	clock.Advance(time.Second)
+	if status := m.GetStatus(); status != StatusRunning {
+		t.Errorf("expected status=%s after cooldown, got %s", StatusRunning, status)
+	}
 }
 
 // TestMonitorConcurrentSwitch 驗證並發切換保護
@@ -392,25 +1210,23 @@ func TestMonitorPanicRecovery(t *testing.T) {
 
 // TestMonitorPanicRecoveryWithRecoveryDelay 驗證 panic 後的恢復延遲
 func TestMonitorPanicRecoveryWithRecoveryDelay(t *testing.T) {
-	if testing.Short() {
-		t.Skip("skipping test in short mode")
-	}
-
 	var panicTimes []time.Time
 	var mu sync.Mutex
 
 	config := DefaultAutoSwitchSettings()
 	config.Enabled = true
+	clock := newFakeClock(time.Date(2025, 1, 1, 10, 0, 0, 0, time.Local))
 
 	panicCount := 0
 	m := NewMonitor(MonitorConfig{
 		Config: config,
+		Clock:  clock,
 		RefreshFunc: func(ctx context.Context) (float64, error) {
 			mu.Lock()
 			panicCount++
 			count := panicCount
 			if count <= 2 {
-				panicTimes = append(panicTimes, time.Now())
+				panicTimes = append(panicTimes, clock.Now())
 			}
 			mu.Unlock()
 
@@ -428,26 +1244,28 @@ func TestMonitorPanicRecoveryWithRecoveryDelay(t *testing.T) {
 	})
 
 	m.Start()
+	defer m.Stop()
 
-	// 等待足夠時間讓兩次 panic 和恢復發生
-	// 每次恢復需要等待 5 秒
-	time.Sleep(6 * time.Second)
+	// 每次 panic 後都在等待恢復延遲，推進時鐘讓循環重試
+	for i := 0; i < 2; i++ {
+		clock.BlockUntil(t, 1)
+		clock.Advance(PanicRecoveryDelay)
+	}
 
-	m.Stop()
+	// 第三次調用正常返回後進入檢查間隔等待
+	clock.BlockUntil(t, 1)
 
 	mu.Lock()
 	defer mu.Unlock()
 
-	// 驗證至少發生了兩次 panic
-	if len(panicTimes) < 2 {
-		t.Errorf("expected at least 2 panics, got %d", len(panicTimes))
-		return
+	// 驗證發生了兩次 panic 並已恢復
+	if len(panicTimes) != 2 || panicCount < 3 {
+		t.Fatalf("expected 2 panics followed by a successful check, got %d panics and %d calls", len(panicTimes), panicCount)
 	}
 
-	// 驗證兩次 panic 之間的間隔約為 5 秒
-	interval := panicTimes[1].Sub(panicTimes[0])
-	if interval < 4*time.Second || interval > 6*time.Second {
-		t.Errorf("expected recovery delay ~5s, got %v", interval)
+	// 驗證兩次 panic 之間的間隔為恢復延遲
+	if interval := panicTimes[1].Sub(panicTimes[0]); interval != PanicRecoveryDelay {
+		t.Errorf("expected recovery delay %v, got %v", PanicRecoveryDelay, interval)
 	}
 }
 
@@ -551,6 +1369,74 @@ func TestMonitorConfigSnapshot(t *testing.T) {
 	t.Logf("Switched to: %s (expected: 帳號B with snapshot)", switchedTo)
 }
 
+// TestMonitorBlacklistSnapshot 驗證週期中修改黑名單不影響本次篩選
+// checkAndSwitch 開始時捕獲的黑名單排除 帳號B，週期中移除後仍應切換到 帳號C
+func TestMonitorBlacklistSnapshot(t *testing.T) {
+	var mu sync.Mutex
+	var switchedTo string
+
+	config := DefaultAutoSwitchSettings()
+	config.Enabled = true
+	config.BalanceThreshold = 5
+	config.MinTargetBalance = 30
+	config.Blacklist = []string{"帳號B"}
+
+	getCandidatesCalled := make(chan struct{})
+	configModified := make(chan struct{})
+	var once sync.Once
+
+	m := NewMonitor(MonitorConfig{
+		Config: config,
+		RefreshFunc: func(ctx context.Context) (float64, error) {
+			return 3, nil
+		},
+		SwitchFunc: func(ctx context.Context, name string) error {
+			mu.Lock()
+			if switchedTo == "" {
+				switchedTo = name
+			}
+			mu.Unlock()
+			return nil
+		},
+		GetCurrentName: func() string { return "帳號A" },
+		GetCandidates: func() []CandidateSnapshot {
+			once.Do(func() {
+				close(getCandidatesCalled)
+				<-configModified
+			})
+			return []CandidateSnapshot{
+				{Name: "帳號B", Balance: 150, SubscriptionType: "Pro"},
+				{Name: "帳號C", Balance: 80, SubscriptionType: "Pro"},
+			}
+		},
+		Notifier: func(ctx context.Context, n *Notification) {},
+	})
+
+	m.Start()
+
+	select {
+	case <-getCandidatesCalled:
+	case <-time.After(2 * time.Second):
+		t.Fatal("timeout waiting for getCandidates")
+	}
+
+	// 週期中移除黑名單
+	newConfig := config.Clone()
+	newConfig.Blacklist = nil
+	m.UpdateConfig(newConfig)
+	close(configModified)
+
+	time.Sleep(200 * time.Millisecond)
+	m.Stop()
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if switchedTo != "帳號C" {
+		t.Errorf("expected switch to 帳號C using blacklist captured at cycle start, got %q", switchedTo)
+	}
+}
+
 // TestMonitorConfigSnapshotDuringSwitch 驗證切換過程中設定修改不影響當前切換
 // 這個測試驗證：當 Enabled 在切換過程中被設為 false，當前切換仍應完成
 func TestMonitorConfigSnapshotDuringSwitch(t *testing.T) {
@@ -1010,3 +1896,60 @@ func TestMonitorRetryOnNetworkError(t *testing.T) {
 		t.Errorf("expected switchedTo='帳號C' after retries, got '%s'", switchedTo)
 	}
 }
+
+// TestMonitorActiveWindows 驗證依星期設定的時段外閒置不切換，進入時段後恢復
+func TestMonitorActiveWindows(t *testing.T) {
+	var switches []string
+	// 2025-01-10 為週五，時段為週一至週五 09:00 ~ 18:00
+	clock := newFakeClock(time.Date(2025, 1, 10, 17, 30, 0, 0, time.Local))
+
+	config := DefaultAutoSwitchSettings()
+	config.Enabled = true
+	config.BalanceThreshold = 5
+	config.MinTargetBalance = 50
+	config.ActiveWindows = []TimeWindow{{
+		Weekdays: []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday},
+		Start:    "09:00",
+		End:      "18:00",
+	}}
+
+	m := NewMonitor(MonitorConfig{
+		Config:      config,
+		Clock:       clock,
+		RefreshFunc: func(ctx context.Context) (float64, error) { return 3, nil },
+		SwitchFunc: func(ctx context.Context, name string) error {
+			switches = append(switches, name)
+			return nil
+		},
+		GetCurrentName: func() string { return "帳號A" },
+		GetCandidates: func() []CandidateSnapshot {
+			return []CandidateSnapshot{{Name: "帳號B", Balance: 150}}
+		},
+	})
+	m.status = StatusRunning
+
+	// 週五晚上與週六整天都在時段外
+	for _, at := range []time.Time{
+		time.Date(2025, 1, 10, 18, 0, 0, 0, time.Local),
+		time.Date(2025, 1, 11, 10, 0, 0, 0, time.Local),
+	} {
+		clock.Set(at)
+		m.runCheck(context.Background(), config)
+		if len(switches) != 0 {
+			t.Fatalf("expected no switch at %s, got %v", at.Format("Mon 15:04"), switches)
+		}
+		if status := m.GetStatus(); status != StatusIdle {
+			t.Errorf("expected %s at %s, got %s", StatusIdle, at.Format("Mon 15:04"), status)
+		}
+	}
+
+	// 週一上午回到時段內
+	clock.Set(time.Date(2025, 1, 13, 9, 0, 0, 0, time.Local))
+	m.runCheck(context.Background(), config)
+	if len(switches) != 1 {
+		t.Errorf("expected switch inside active window, got %v", switches)
+	}
+	if status := m.GetStatus(); status == StatusIdle {
+		t.Errorf("expected monitor to leave %s inside active window", StatusIdle)
+	}
+}
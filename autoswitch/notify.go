@@ -8,13 +8,19 @@ import (
 type NotifyType string
 
 const (
-	NotifySwitch       NotifyType = "switch"        // 切換成功
-	NotifySwitchFail   NotifyType = "switch_fail"   // 切換失敗
-	NotifyLowBalance   NotifyType = "low_balance"   // 低餘額預警
-	NotifyCooldown     NotifyType = "cooldown"      // 冷卻期
-	NotifyMaxSwitch    NotifyType = "max_switch"    // 達到切換上限
-	NotifyCooldownEnd  NotifyType = "cooldown_end"  // 冷卻期結束
-	NotifyNoCandidates NotifyType = "no_candidates" // 無候選快照
+	NotifySwitch             NotifyType = "switch"               // 切換成功
+	NotifySwitchFail         NotifyType = "switch_fail"          // 切換失敗
+	NotifyLowBalance         NotifyType = "low_balance"          // 低餘額預警
+	NotifyCooldown           NotifyType = "cooldown"             // 冷卻期
+	NotifyMaxSwitch          NotifyType = "max_switch"           // 達到切換上限
+	NotifyCooldownEnd        NotifyType = "cooldown_end"         // 冷卻期結束
+	NotifyNoCandidates       NotifyType = "no_candidates"        // 無候選快照
+	NotifyDailyCapReached    NotifyType = "daily_cap_reached"    // 達到每日切換上限
+	NotifyMinSwitchInterval  NotifyType = "min_switch_interval"  // 未達最短切換間隔
+	NotifyDryRun             NotifyType = "dry_run"              // 試運行（未實際切換）
+	NotifyOutsideActiveHours NotifyType = "outside_active_hours" // 進入非允許時段
+	NotifyActiveHoursResumed NotifyType = "active_hours_resumed" // 回到允許時段
+	NotifyLowWarning         NotifyType = "low_warning"          // 餘額降至預警閾值
 )
 
 // Notification 通知結構
@@ -105,3 +111,82 @@ func NewNoCandidatesNotification() *Notification {
 		Message: "無符合條件的候選快照",
 	}
 }
+
+// NewDailyCapReachedNotification 建立達到每日切換上限通知
+func NewDailyCapReachedNotification(maxPerDay int) *Notification {
+	return &Notification{
+		Type:    NotifyDailyCapReached,
+		Title:   "Kiro Manager",
+		Message: "已達每日切換上限，24 小時內暫停自動切換",
+		Data: map[string]interface{}{
+			"maxSwitchesPerDay": maxPerDay,
+		},
+	}
+}
+
+// NewMinSwitchIntervalNotification 建立未達最短切換間隔通知
+func NewMinSwitchIntervalNotification(remainingSeconds int) *Notification {
+	return &Notification{
+		Type:    NotifyMinSwitchInterval,
+		Title:   "Kiro Manager",
+		Message: "距上次切換時間過短，暫不自動切換",
+		Data: map[string]interface{}{
+			"remainingSeconds": remainingSeconds,
+		},
+	}
+}
+
+// NewDryRunNotification 建立試運行通知，說明原本會切換的目標與餘額
+func NewDryRunNotification(fromName, toName string, currentBalance, targetBalance float64) *Notification {
+	return &Notification{
+		Type:    NotifyDryRun,
+		Title:   "Kiro Manager",
+		Message: "試運行：將自動切換至 " + toName,
+		Data: map[string]interface{}{
+			"from":           fromName,
+			"to":             toName,
+			"currentBalance": currentBalance,
+			"targetBalance":  targetBalance,
+		},
+	}
+}
+
+// NewOutsideActiveHoursNotification 建立進入非允許時段通知
+// 只設定了 ActiveWindows 時 start / end 為空，訊息不顯示時間範圍
+func NewOutsideActiveHoursNotification(start, end string) *Notification {
+	message := "目前不在允許時段，暫停自動切換"
+	if start != "" && end != "" {
+		message = "目前不在允許時段（" + start + " ~ " + end + "），暫停自動切換"
+	}
+	return &Notification{
+		Type:    NotifyOutsideActiveHours,
+		Title:   "Kiro Manager",
+		Message: message,
+		Data: map[string]interface{}{
+			"start": start,
+			"end":   end,
+		},
+	}
+}
+
+// NewActiveHoursResumedNotification 建立回到允許時段通知
+func NewActiveHoursResumedNotification() *Notification {
+	return &Notification{
+		Type:    NotifyActiveHoursResumed,
+		Title:   "Kiro Manager",
+		Message: "已進入允許時段，恢復自動切換",
+	}
+}
+
+// NewLowWarningNotification 建立餘額降至預警閾值通知
+func NewLowWarningNotification(currentBalance float64, warnThreshold float64) *Notification {
+	return &Notification{
+		Type:    NotifyLowWarning,
+		Title:   "Kiro Manager",
+		Message: "餘額已降至預警閾值",
+		Data: map[string]interface{}{
+			"currentBalance": currentBalance,
+			"warnThreshold":  warnThreshold,
+		},
+	}
+}
@@ -14,6 +14,12 @@ func TestNotificationTypes(t *testing.T) {
 		NotifyMaxSwitch,
 		NotifyCooldownEnd,
 		NotifyNoCandidates,
+		NotifyDailyCapReached,
+		NotifyMinSwitchInterval,
+		NotifyDryRun,
+		NotifyOutsideActiveHours,
+		NotifyActiveHoursResumed,
+		NotifyLowWarning,
 	}
 
 	// 驗證所有類型都是非空字串
@@ -137,6 +143,42 @@ func TestNewNoCandidatesNotification(t *testing.T) {
 	}
 }
 
+// TestNewDailyCapReachedNotification 驗證每日切換上限通知
+func TestNewDailyCapReachedNotification(t *testing.T) {
+	n := NewDailyCapReachedNotification(5)
+
+	if n.Type != NotifyDailyCapReached {
+		t.Errorf("expected type=%s, got %s", NotifyDailyCapReached, n.Type)
+	}
+	if n.Data["maxSwitchesPerDay"] != 5 {
+		t.Errorf("expected maxSwitchesPerDay=5, got %v", n.Data["maxSwitchesPerDay"])
+	}
+}
+
+// TestNewMinSwitchIntervalNotification 驗證最短切換間隔通知
+func TestNewMinSwitchIntervalNotification(t *testing.T) {
+	n := NewMinSwitchIntervalNotification(120)
+
+	if n.Type != NotifyMinSwitchInterval {
+		t.Errorf("expected type=%s, got %s", NotifyMinSwitchInterval, n.Type)
+	}
+	if n.Data["remainingSeconds"] != 120 {
+		t.Errorf("expected remainingSeconds=120, got %v", n.Data["remainingSeconds"])
+	}
+}
+
+// TestNewDryRunNotification 驗證試運行通知
+func TestNewDryRunNotification(t *testing.T) {
+	n := NewDryRunNotification("帳號A", "帳號B", 3, 150)
+
+	if n.Type != NotifyDryRun {
+		t.Errorf("expected type=%s, got %s", NotifyDryRun, n.Type)
+	}
+	if n.Data["to"] != "帳號B" || n.Data["targetBalance"] != 150.0 {
+		t.Errorf("unexpected data: %v", n.Data)
+	}
+}
+
 // TestNotificationStructure 驗證通知結構
 func TestNotificationStructure(t *testing.T) {
 	n := &Notification{
@@ -13,6 +13,8 @@ const (
 	MaxSwitchPerHour = 3
 	// CountResetPeriod 計數重置週期
 	CountResetPeriod = 1 * time.Hour
+	// DailySwitchWindow 每日切換上限的滾動統計窗口
+	DailySwitchWindow = 24 * time.Hour
 )
 
 // SafetyState 安全狀態
@@ -20,14 +22,30 @@ type SafetyState struct {
 	LastSwitchTime time.Time
 	SwitchCount    int
 	CountResetTime time.Time
+	switchTimes    []time.Time      // 滾動 24 小時窗口內的切換時間
+	now            func() time.Time // 取得當前時間（預設 time.Now）
 	mu             sync.Mutex
 }
 
 // NewSafetyState 建立新的安全狀態
 func NewSafetyState() *SafetyState {
+	return newSafetyStateWithClock(time.Now)
+}
+
+// newSafetyStateWithClock 建立使用指定時鐘的安全狀態
+func newSafetyStateWithClock(now func() time.Time) *SafetyState {
 	return &SafetyState{
-		CountResetTime: time.Now(),
+		CountResetTime: now(),
+		now:            now,
+	}
+}
+
+// clock 取得當前時間
+func (s *SafetyState) clock() time.Time {
+	if s.now == nil {
+		return time.Now()
 	}
+	return s.now()
 }
 
 // CanSwitch 檢查是否可以執行切換
@@ -36,7 +54,7 @@ func (s *SafetyState) CanSwitch() (bool, string) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	now := time.Now()
+	now := s.clock()
 
 	// 檢查計數是否需要重置
 	if now.Sub(s.CountResetTime) >= CountResetPeriod {
@@ -66,7 +84,7 @@ func (s *SafetyState) RecordSwitch() {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	now := time.Now()
+	now := s.clock()
 
 	// 檢查計數是否需要重置
 	if now.Sub(s.CountResetTime) >= CountResetPeriod {
@@ -76,6 +94,43 @@ func (s *SafetyState) RecordSwitch() {
 
 	s.LastSwitchTime = now
 	s.SwitchCount++
+	s.switchTimes = append(s.pruneSwitchTimes(now), now)
+}
+
+// DailySwitchCount 取得滾動 24 小時窗口內的切換次數
+func (s *SafetyState) DailySwitchCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.switchTimes = s.pruneSwitchTimes(s.clock())
+	return len(s.switchTimes)
+}
+
+// DailyCapReached 檢查滾動 24 小時窗口內的切換次數是否已達上限
+// maxPerDay <= 0 表示不限制
+func (s *SafetyState) DailyCapReached(maxPerDay int) bool {
+	if maxPerDay <= 0 {
+		return false
+	}
+	return s.DailySwitchCount() >= maxPerDay
+}
+
+// pruneSwitchTimes 移除超出滾動窗口的切換時間（呼叫者需持有鎖）
+func (s *SafetyState) pruneSwitchTimes(now time.Time) []time.Time {
+	cutoff := now.Add(-DailySwitchWindow)
+	i := 0
+	for i < len(s.switchTimes) && !s.switchTimes[i].After(cutoff) {
+		i++
+	}
+	return s.switchTimes[i:]
+}
+
+// GetLastSwitchTime 取得最後一次切換時間
+// 返回零值表示尚未切換過
+func (s *SafetyState) GetLastSwitchTime() time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.LastSwitchTime
 }
 
 // GetCooldownRemaining 取得冷卻期剩餘時間
@@ -88,7 +143,7 @@ func (s *SafetyState) GetCooldownRemaining() time.Duration {
 		return 0
 	}
 
-	elapsed := time.Since(s.LastSwitchTime)
+	elapsed := s.clock().Sub(s.LastSwitchTime)
 	if elapsed >= CooldownPeriod {
 		return 0
 	}
@@ -102,7 +157,7 @@ func (s *SafetyState) GetSwitchCount() int {
 	defer s.mu.Unlock()
 
 	// 檢查計數是否需要重置
-	if time.Since(s.CountResetTime) >= CountResetPeriod {
+	if s.clock().Sub(s.CountResetTime) >= CountResetPeriod {
 		return 0
 	}
 
@@ -116,7 +171,8 @@ func (s *SafetyState) ResetForTesting() {
 
 	s.LastSwitchTime = time.Time{}
 	s.SwitchCount = 0
-	s.CountResetTime = time.Now()
+	s.CountResetTime = s.clock()
+	s.switchTimes = nil
 }
 
 // formatCooldownMessage 格式化冷卻期訊息
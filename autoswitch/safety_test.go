@@ -264,3 +264,44 @@ func TestFormatDuration(t *testing.T) {
 		})
 	}
 }
+
+// TestDailySwitchCap 驗證滾動 24 小時切換上限
+func TestDailySwitchCap(t *testing.T) {
+	state := NewSafetyState()
+
+	// 0 表示不限制
+	if state.DailyCapReached(0) {
+		t.Error("expected no cap when maxPerDay=0")
+	}
+
+	state.RecordSwitch()
+	state.RecordSwitch()
+	if state.DailySwitchCount() != 2 {
+		t.Errorf("expected daily count=2, got %d", state.DailySwitchCount())
+	}
+	if !state.DailyCapReached(2) {
+		t.Error("expected cap reached at 2 switches")
+	}
+	if state.DailyCapReached(3) {
+		t.Error("expected cap not reached with maxPerDay=3")
+	}
+}
+
+// TestDailySwitchCap_WindowClears 驗證超出 24 小時的切換不再計入
+func TestDailySwitchCap_WindowClears(t *testing.T) {
+	state := NewSafetyState()
+
+	state.mu.Lock()
+	state.switchTimes = []time.Time{
+		time.Now().Add(-DailySwitchWindow - time.Minute),
+		time.Now().Add(-DailySwitchWindow + time.Hour),
+	}
+	state.mu.Unlock()
+
+	if got := state.DailySwitchCount(); got != 1 {
+		t.Errorf("expected 1 switch inside window, got %d", got)
+	}
+	if state.DailyCapReached(2) {
+		t.Error("expected cap not reached after old switch left the window")
+	}
+}
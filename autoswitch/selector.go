@@ -18,12 +18,31 @@ type CandidateSnapshot struct {
 //   - currentName: 當前快照名稱（會被排除）
 //   - allSnapshots: 所有可用快照
 //
-// 返回：符合條件的候選快照列表（按餘額降序排列）
+// 返回：符合條件的候選快照列表（按訂閱類型優先順序、餘額降序排列）
+//
+// 啟用 FolderScoped 時只保留與當前快照相同文件夾的候選；
+// 當前快照不在 allSnapshots 中時無法判斷文件夾，返回空列表
 func FilterCandidates(config *AutoSwitchSettings, currentName string, allSnapshots []CandidateSnapshot) []CandidateSnapshot {
 	if config == nil || len(allSnapshots) == 0 {
 		return nil
 	}
 
+	// 取得當前快照所屬文件夾
+	var currentFolderId string
+	if config.FolderScoped {
+		found := false
+		for _, snapshot := range allSnapshots {
+			if snapshot.Name == currentName {
+				currentFolderId = snapshot.FolderId
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil
+		}
+	}
+
 	var candidates []CandidateSnapshot
 
 	for _, snapshot := range allSnapshots {
@@ -32,6 +51,11 @@ func FilterCandidates(config *AutoSwitchSettings, currentName string, allSnapsho
 			continue
 		}
 
+		// 排除黑名單中的快照
+		if containsString(config.Blacklist, snapshot.Name) {
+			continue
+		}
+
 		// 檢查最低餘額要求
 		if snapshot.Balance < config.MinTargetBalance {
 			continue
@@ -44,6 +68,11 @@ func FilterCandidates(config *AutoSwitchSettings, currentName string, allSnapsho
 			}
 		}
 
+		// 檢查是否與當前快照同一文件夾
+		if config.FolderScoped && snapshot.FolderId != currentFolderId {
+			continue
+		}
+
 		// 檢查訂閱類型篩選
 		if len(config.SubscriptionTypes) > 0 {
 			if !containsString(config.SubscriptionTypes, snapshot.SubscriptionType) {
@@ -54,24 +83,40 @@ func FilterCandidates(config *AutoSwitchSettings, currentName string, allSnapsho
 		candidates = append(candidates, snapshot)
 	}
 
-	// 按餘額降序排列
+	// 按訂閱類型優先順序排列，同順序時按餘額降序
 	sort.Slice(candidates, func(i, j int) bool {
+		ri := subscriptionRank(config.PreferSubscriptionOrder, candidates[i].SubscriptionType)
+		rj := subscriptionRank(config.PreferSubscriptionOrder, candidates[j].SubscriptionType)
+		if ri != rj {
+			return ri < rj
+		}
 		return candidates[i].Balance > candidates[j].Balance
 	})
 
 	return candidates
 }
 
-// SelectBestCandidate 選擇餘額最高的候選
+// SelectBestCandidate 選擇排序最前的候選
 // 返回 nil 表示沒有可用候選
 func SelectBestCandidate(candidates []CandidateSnapshot) *CandidateSnapshot {
 	if len(candidates) == 0 {
 		return nil
 	}
-	// 假設已按餘額降序排列，返回第一個
+	// 假設已由 FilterCandidates 排序，返回第一個
 	return &candidates[0]
 }
 
+// subscriptionRank 取得訂閱類型在優先順序中的排名（越小越優先）
+// 不在列表中的類型排在最後
+func subscriptionRank(order []string, subscriptionType string) int {
+	for i, s := range order {
+		if s == subscriptionType {
+			return i
+		}
+	}
+	return len(order)
+}
+
 // containsString 檢查字串切片是否包含指定字串
 func containsString(slice []string, str string) bool {
 	for _, s := range slice {
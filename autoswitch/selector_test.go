@@ -195,3 +195,174 @@ func TestFilterCandidates_SortedByBalance(t *testing.T) {
 		}
 	}
 }
+
+// TestFilterCandidates_FolderScoped 驗證只保留與當前快照同文件夾的候選
+func TestFilterCandidates_FolderScoped(t *testing.T) {
+	config := &AutoSwitchSettings{
+		Enabled:      true,
+		FolderScoped: true,
+	}
+
+	candidates := FilterCandidates(config, "帳號C", testSnapshots())
+	if len(candidates) != 1 || candidates[0].Name != "帳號D" {
+		t.Fatalf("expected only 帳號D, got %v", candidates)
+	}
+
+	// 同文件夾沒有符合條件的候選時不應跨文件夾
+	config.MinTargetBalance = 50
+	candidates = FilterCandidates(config, "帳號C", testSnapshots())
+	if len(candidates) != 0 {
+		t.Errorf("expected no candidates, got %v", candidates)
+	}
+
+	// 當前快照不在列表中時無法判斷文件夾
+	candidates = FilterCandidates(config, "未知帳號", testSnapshots())
+	if len(candidates) != 0 {
+		t.Errorf("expected no candidates for unknown current, got %v", candidates)
+	}
+}
+
+// TestFilterCandidates_Blacklist 驗證黑名單中的快照不會成為候選
+func TestFilterCandidates_Blacklist(t *testing.T) {
+	config := &AutoSwitchSettings{
+		Enabled:   true,
+		Blacklist: []string{"帳號E", "帳號C"},
+	}
+
+	candidates := FilterCandidates(config, "帳號A", testSnapshots())
+	if len(candidates) != 2 {
+		t.Fatalf("expected 2 candidates, got %v", candidates)
+	}
+	for _, c := range candidates {
+		if c.Name == "帳號E" || c.Name == "帳號C" {
+			t.Errorf("blacklisted snapshot %s should be excluded", c.Name)
+		}
+	}
+	if best := SelectBestCandidate(candidates); best == nil || best.Name != "帳號B" {
+		t.Errorf("expected best candidate 帳號B, got %v", best)
+	}
+}
+
+// TestFilterCandidates_FolderIdsTwoFolders 驗證候選快照分屬兩個文件夾時只考慮限定文件夾中的快照
+func TestFilterCandidates_FolderIdsTwoFolders(t *testing.T) {
+	snapshots := []CandidateSnapshot{
+		{Name: "工作1", Balance: 80, FolderId: "work"},
+		{Name: "工作2", Balance: 40, FolderId: "work"},
+		{Name: "個人1", Balance: 300, FolderId: "personal"},
+	}
+	config := &AutoSwitchSettings{
+		Enabled:   true,
+		FolderIds: []string{"work"},
+	}
+
+	// 個人1 餘額最高但不在 work，不應被選中
+	candidates := FilterCandidates(config, "", snapshots)
+	if len(candidates) != 2 {
+		t.Fatalf("expected 2 candidates in work, got %v", candidates)
+	}
+	for _, c := range candidates {
+		if c.FolderId != "work" {
+			t.Errorf("candidate %s from %s should be excluded", c.Name, c.FolderId)
+		}
+	}
+	if best := SelectBestCandidate(candidates); best == nil || best.Name != "工作1" {
+		t.Errorf("expected best candidate 工作1, got %v", best)
+	}
+}
+
+// TestFilterCandidates_EmptyFolderIds 驗證空的文件夾限制保留所有候選快照
+func TestFilterCandidates_EmptyFolderIds(t *testing.T) {
+	for _, folderIds := range [][]string{nil, {}} {
+		config := &AutoSwitchSettings{
+			Enabled:   true,
+			FolderIds: folderIds,
+		}
+
+		candidates := FilterCandidates(config, "帳號A", testSnapshots())
+		if len(candidates) != 4 {
+			t.Errorf("FolderIds %v: expected all 4 other candidates, got %d", folderIds, len(candidates))
+		}
+	}
+}
+
+// TestFilterCandidates_PreferSubscriptionOrder 驗證訂閱類型優先順序為主要排序鍵
+func TestFilterCandidates_PreferSubscriptionOrder(t *testing.T) {
+	config := &AutoSwitchSettings{
+		Enabled:                 true,
+		PreferSubscriptionOrder: []string{"Pro", "Free"},
+	}
+
+	candidates := FilterCandidates(config, "", testSnapshots())
+
+	// Pro 依餘額排序在前，其次 Free，不在列表中的 Enterprise 排在最後
+	expected := []string{"帳號B", "帳號C", "帳號A", "帳號E", "帳號D"}
+	if len(candidates) != len(expected) {
+		t.Fatalf("expected %d candidates, got %d", len(expected), len(candidates))
+	}
+	for i, name := range expected {
+		if candidates[i].Name != name {
+			t.Errorf("position %d: expected %s, got %s", i, name, candidates[i].Name)
+		}
+	}
+}
+
+// TestFilterCandidates_PreferSubscriptionOrderOverridesBalance 驗證優先類型即使餘額較低也排在前面
+func TestFilterCandidates_PreferSubscriptionOrderOverridesBalance(t *testing.T) {
+	config := &AutoSwitchSettings{
+		Enabled:                 true,
+		PreferSubscriptionOrder: []string{"Free"},
+	}
+
+	candidates := FilterCandidates(config, "", testSnapshots())
+	if best := SelectBestCandidate(candidates); best == nil || best.Name != "帳號A" {
+		t.Errorf("expected Free account 帳號A first, got %v", best)
+	}
+}
+
+// TestFilterCandidates_PreferSubscriptionOrderTiers 驗證高優先類型勝過高餘額，同類型內依餘額排序
+func TestFilterCandidates_PreferSubscriptionOrderTiers(t *testing.T) {
+	config := &AutoSwitchSettings{
+		Enabled:                 true,
+		MinTargetBalance:        50,
+		PreferSubscriptionOrder: []string{"Pro+", "Pro", "Free"},
+	}
+	snapshots := []CandidateSnapshot{
+		{Name: "Free高", Balance: 500, SubscriptionType: "Free"},
+		{Name: "ProPlus低", Balance: 60, SubscriptionType: "Pro+"},
+		{Name: "ProPlus高", Balance: 90, SubscriptionType: "Pro+"},
+		{Name: "ProPlus不足", Balance: 40, SubscriptionType: "Pro+"}, // 低於 MinTargetBalance
+		{Name: "Pro", Balance: 300, SubscriptionType: "Pro"},
+	}
+
+	candidates := FilterCandidates(config, "", snapshots)
+
+	expected := []string{"ProPlus高", "ProPlus低", "Pro", "Free高"}
+	if len(candidates) != len(expected) {
+		t.Fatalf("expected %d candidates, got %v", len(expected), candidates)
+	}
+	for i, name := range expected {
+		if candidates[i].Name != name {
+			t.Errorf("position %d: expected %s, got %s", i, name, candidates[i].Name)
+		}
+	}
+}
+
+// TestFilterCandidates_BlacklistCleared 驗證排除最高餘額快照後改選下一個，清空黑名單後恢復
+func TestFilterCandidates_BlacklistCleared(t *testing.T) {
+	config := &AutoSwitchSettings{
+		Enabled:          true,
+		MinTargetBalance: 50,
+		Blacklist:        []string{"帳號E"},
+	}
+
+	best := SelectBestCandidate(FilterCandidates(config, "帳號A", testSnapshots()))
+	if best == nil || best.Name != "帳號B" {
+		t.Fatalf("expected next candidate 帳號B while 帳號E is excluded, got %v", best)
+	}
+
+	config.Blacklist = nil
+	best = SelectBestCandidate(FilterCandidates(config, "帳號A", testSnapshots()))
+	if best == nil || best.Name != "帳號E" {
+		t.Errorf("expected 帳號E after clearing blacklist, got %v", best)
+	}
+}
@@ -0,0 +1,111 @@
+package autoswitch
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+)
+
+// WebhookTimeout Webhook 請求逾時時間
+const WebhookTimeout = 5 * time.Second
+
+// WebhookPayload Webhook 推送內容
+type WebhookPayload struct {
+	Type           NotifyType             `json:"type"`
+	Message        string                 `json:"message"`
+	From           string                 `json:"from,omitempty"`
+	To             string                 `json:"to,omitempty"`
+	CurrentBalance float64                `json:"currentBalance"`
+	TargetBalance  *float64               `json:"targetBalance,omitempty"`
+	Data           map[string]interface{} `json:"data,omitempty"`
+	Timestamp      time.Time              `json:"timestamp"`
+}
+
+// newWebhookPayload 由通知建立 Webhook 推送內容
+// 通知本身未附帶當前餘額時使用 lastBalance
+func newWebhookPayload(n *Notification, lastBalance float64, now time.Time) WebhookPayload {
+	payload := WebhookPayload{
+		Type:           n.Type,
+		Message:        n.Message,
+		CurrentBalance: lastBalance,
+		Data:           n.Data,
+		Timestamp:      now.UTC(),
+	}
+	if from, ok := n.Data["from"].(string); ok {
+		payload.From = from
+	}
+	if to, ok := n.Data["to"].(string); ok {
+		payload.To = to
+	}
+	if balance, ok := n.Data["currentBalance"].(float64); ok {
+		payload.CurrentBalance = balance
+	}
+	if balance, ok := n.Data["targetBalance"].(float64); ok {
+		payload.TargetBalance = &balance
+	}
+	return payload
+}
+
+// postWebhook 將推送內容以 JSON POST 至指定 URL
+func postWebhook(ctx context.Context, client *http.Client, url string, payload WebhookPayload) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal webhook payload: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, WebhookTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send webhook: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned HTTP %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// notify 發送通知到 Notifier，並在 config 設定了 WebhookURL 時非同步推送 Webhook
+// config 為呼叫端使用的設定快照，確保同一次檢查的通知使用一致的 WebhookURL
+func (m *Monitor) notify(ctx context.Context, config *AutoSwitchSettings, n *Notification) {
+	if m.notifier != nil {
+		m.notifier(ctx, n)
+	}
+
+	if config == nil || config.WebhookURL == "" {
+		return
+	}
+	url := config.WebhookURL
+
+	m.mu.RLock()
+	lastBalance := m.lastBalance
+	// 使用監控器的生命週期 context，避免呼叫端（例如 ForceCheck）返回後取消請求
+	postCtx := m.ctx
+	if postCtx == nil || m.status == StatusStopped {
+		postCtx = context.Background()
+	}
+	m.mu.RUnlock()
+
+	// 非同步推送，不阻塞監控循環；監控停止時會中止請求，單次請求受 WebhookTimeout 限制
+	payload := newWebhookPayload(n, lastBalance, m.clock.Now())
+	m.wg.Add(1)
+	go func() {
+		defer m.wg.Done()
+		if err := postWebhook(postCtx, m.webhookClient, url, payload); err != nil {
+			log.Printf("Auto-switch webhook failed: %v", err)
+		}
+	}()
+}
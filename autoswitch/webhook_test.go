@@ -0,0 +1,172 @@
+package autoswitch
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// TestMonitorWebhookSwitchPayload 驗證切換通知推送至 Webhook 的內容
+func TestMonitorWebhookSwitchPayload(t *testing.T) {
+	received := make(chan map[string]interface{}, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			t.Errorf("expected POST, got %s", r.Method)
+		}
+		if ct := r.Header.Get("Content-Type"); ct != "application/json" {
+			t.Errorf("expected Content-Type application/json, got %q", ct)
+		}
+		var payload map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			t.Errorf("failed to decode payload: %v", err)
+		}
+		received <- payload
+	}))
+	defer server.Close()
+
+	config := DefaultAutoSwitchSettings()
+	config.Enabled = true
+	config.BalanceThreshold = 5
+	config.MinTargetBalance = 50
+	config.NotifyOnSwitch = true
+	config.WebhookURL = server.URL
+
+	clock := newFakeClock(time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC))
+	m := NewMonitor(MonitorConfig{
+		Config:         config,
+		Clock:          clock,
+		SwitchFunc:     func(ctx context.Context, name string) error { return nil },
+		GetCurrentName: func() string { return "帳號A" },
+		GetCandidates: func() []CandidateSnapshot {
+			return []CandidateSnapshot{{Name: "帳號B", Balance: 150}}
+		},
+	})
+	m.lastBalance = 3
+
+	m.checkAndSwitch(context.Background(), 3)
+
+	var payload map[string]interface{}
+	select {
+	case payload = <-received:
+	case <-time.After(2 * time.Second):
+		t.Fatal("timeout waiting for webhook")
+	}
+	m.wg.Wait()
+
+	if payload["type"] != string(NotifySwitch) {
+		t.Errorf("type = %v, want %s", payload["type"], NotifySwitch)
+	}
+	if payload["from"] != "帳號A" || payload["to"] != "帳號B" {
+		t.Errorf("unexpected from/to: %v / %v", payload["from"], payload["to"])
+	}
+	if payload["currentBalance"] != 3.0 {
+		t.Errorf("currentBalance = %v, want 3", payload["currentBalance"])
+	}
+	if payload["timestamp"] != "2025-01-01T12:00:00Z" {
+		t.Errorf("timestamp = %v", payload["timestamp"])
+	}
+}
+
+// TestMonitorWebhookFailureDoesNotBlock 驗證 Webhook 失敗不影響通知與切換
+func TestMonitorWebhookFailureDoesNotBlock(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	config := DefaultAutoSwitchSettings()
+	config.Enabled = true
+	config.BalanceThreshold = 5
+	config.MinTargetBalance = 50
+	config.NotifyOnSwitch = true
+	config.WebhookURL = server.URL
+
+	var notifications []*Notification
+	switched := false
+	m := NewMonitor(MonitorConfig{
+		Config: config,
+		SwitchFunc: func(ctx context.Context, name string) error {
+			switched = true
+			return nil
+		},
+		GetCurrentName: func() string { return "帳號A" },
+		GetCandidates: func() []CandidateSnapshot {
+			return []CandidateSnapshot{{Name: "帳號B", Balance: 150}}
+		},
+		Notifier: func(ctx context.Context, n *Notification) {
+			notifications = append(notifications, n)
+		},
+	})
+
+	m.checkAndSwitch(context.Background(), 3)
+	m.wg.Wait()
+
+	if !switched {
+		t.Error("expected switch despite webhook failure")
+	}
+	if len(notifications) != 1 || notifications[0].Type != NotifySwitch {
+		t.Errorf("expected switch notification, got %v", notifications)
+	}
+}
+
+// TestPostWebhook_ErrorStatus 驗證非 2xx 回應返回錯誤
+func TestPostWebhook_ErrorStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	payload := newWebhookPayload(NewNoCandidatesNotification(), 1, time.Now())
+	if err := postWebhook(context.Background(), server.Client(), server.URL, payload); err == nil {
+		t.Error("expected error for HTTP 502")
+	}
+}
+
+// TestMonitorWebhookSurvivesCallerCancel 驗證 ForceCheck 呼叫端返回後取消 ctx 不會中止 Webhook 推送
+func TestMonitorWebhookSurvivesCallerCancel(t *testing.T) {
+	received := make(chan NotifyType, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// 延遲回應，確保呼叫端已取消 ctx
+		time.Sleep(50 * time.Millisecond)
+		var payload WebhookPayload
+		json.NewDecoder(r.Body).Decode(&payload)
+		received <- payload.Type
+	}))
+	defer server.Close()
+
+	config := DefaultAutoSwitchSettings()
+	config.Enabled = true
+	config.BalanceThreshold = 5
+	config.MinTargetBalance = 50
+	config.NotifyOnSwitch = true
+	config.WebhookURL = server.URL
+
+	m := NewMonitor(MonitorConfig{
+		Config:         config,
+		RefreshFunc:    func(ctx context.Context) (float64, error) { return 3, nil },
+		SwitchFunc:     func(ctx context.Context, name string) error { return nil },
+		GetCurrentName: func() string { return "帳號A" },
+		GetCandidates: func() []CandidateSnapshot {
+			return []CandidateSnapshot{{Name: "帳號B", Balance: 150}}
+		},
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	if _, err := m.ForceCheck(ctx); err != nil {
+		t.Fatalf("ForceCheck failed: %v", err)
+	}
+	cancel()
+
+	select {
+	case typ := <-received:
+		if typ != NotifySwitch {
+			t.Errorf("type = %v, want %s", typ, NotifySwitch)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("timeout waiting for webhook")
+	}
+	m.wg.Wait()
+}
@@ -3,9 +3,13 @@ package awssso
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
+	"strconv"
 	"time"
+
+	"kiro-manager/internal/fsutil"
 )
 
 const (
@@ -15,6 +19,8 @@ const (
 var (
 	ErrCacheNotFound = errors.New("sso cache directory not found")
 	ErrTokenNotFound = errors.New("kiro auth token not found")
+	// ErrInvalidExpiresAt expiresAt 不是任何已知的時間格式
+	ErrInvalidExpiresAt = errors.New("invalid expiresAt format")
 )
 
 // KiroAuthToken 代表 Kiro 的認證 token 結構
@@ -97,6 +103,87 @@ func ReadKiroAuthToken() (*KiroAuthToken, error) {
 	return &token, nil
 }
 
+// orderedKiroAuthToken 用於確保 JSON 輸出時 key 的順序
+// 順序: accessToken, refreshToken, profileArn, expiresAt, authMethod, provider, clientIdHash, region, tokenType, startUrl
+type orderedKiroAuthToken struct {
+	AccessToken  string `json:"accessToken"`
+	RefreshToken string `json:"refreshToken"`
+	ProfileArn   string `json:"profileArn,omitempty"`
+	ExpiresAt    string `json:"expiresAt"`
+	AuthMethod   string `json:"authMethod,omitempty"`
+	Provider     string `json:"provider,omitempty"`
+	ClientIdHash string `json:"clientIdHash,omitempty"` // IdC 特有欄位
+	Region       string `json:"region,omitempty"`       // IdC 特有欄位
+	TokenType    string `json:"tokenType,omitempty"`    // 可選欄位
+	StartURL     string `json:"startUrl,omitempty"`     // 可選欄位
+}
+
+// UpdateKiroAuthToken 將刷新後的 accessToken、expiresAt 寫回當前的 kiro-auth-token.json
+func UpdateKiroAuthToken(accessToken string, expiresAt string) error {
+	tokenPath, err := GetKiroAuthTokenPath()
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(tokenPath); os.IsNotExist(err) {
+		return ErrTokenNotFound
+	}
+	return UpdateTokenFile(tokenPath, accessToken, expiresAt)
+}
+
+// UpdateTokenFile 更新指定路徑的 token 檔案
+// 保留原有欄位，僅更新 accessToken、expiresAt
+// 確保 JSON key 順序: accessToken, refreshToken, profileArn, expiresAt, authMethod, provider
+func UpdateTokenFile(tokenPath string, accessToken string, expiresAt string) error {
+	// 讀取現有 token 檔案以保留原始欄位
+	data, err := os.ReadFile(tokenPath)
+	if err != nil {
+		return fmt.Errorf("failed to read existing token file: %w", err)
+	}
+
+	// 先解析到 map 以讀取原始值
+	var tokenMap map[string]interface{}
+	if err := json.Unmarshal(data, &tokenMap); err != nil {
+		return fmt.Errorf("failed to parse existing token file: %w", err)
+	}
+
+	// 使用有序結構體來確保 key 順序
+	orderedToken := orderedKiroAuthToken{
+		AccessToken:  accessToken,
+		RefreshToken: getStringFromMap(tokenMap, "refreshToken"),
+		ProfileArn:   getStringFromMap(tokenMap, "profileArn"),
+		ExpiresAt:    expiresAt,
+		AuthMethod:   getStringFromMap(tokenMap, "authMethod"),
+		Provider:     getStringFromMap(tokenMap, "provider"),
+		ClientIdHash: getStringFromMap(tokenMap, "clientIdHash"), // IdC 特有欄位
+		Region:       getStringFromMap(tokenMap, "region"),       // IdC 特有欄位
+		TokenType:    getStringFromMap(tokenMap, "tokenType"),    // 可選欄位
+		StartURL:     getStringFromMap(tokenMap, "startUrl"),     // 可選欄位
+	}
+
+	// 將更新後的 token 寫回檔案
+	updatedData, err := json.MarshalIndent(orderedToken, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal updated token: %w", err)
+	}
+
+	// 先寫入暫存檔再 rename，避免程式中止時留下截斷的 token 檔案
+	if err := fsutil.WriteFileAtomic(tokenPath, updatedData, 0644); err != nil {
+		return fmt.Errorf("failed to write token file: %w", err)
+	}
+
+	return nil
+}
+
+// getStringFromMap 從 map 中安全地取得字串值
+func getStringFromMap(m map[string]interface{}, key string) string {
+	if v, ok := m[key]; ok {
+		if s, ok := v.(string); ok {
+			return s
+		}
+	}
+	return ""
+}
+
 // ListCacheFiles 列出 SSO 快取目錄中的所有 JSON 檔案
 func ListCacheFiles() ([]string, error) {
 	cachePath, err := GetSSOCachePath()
@@ -172,20 +259,51 @@ func ReadCacheFileRaw(filename string) (map[string]interface{}, error) {
 
 
 // IsTokenExpired 檢查 token 是否已過期
+// 無法解析過期時間時視為已過期
 func IsTokenExpired(token *KiroAuthToken) bool {
-	if token == nil || token.ExpiresAt == "" {
+	expiresAt, ok := TokenExpiresAt(token)
+	if !ok {
 		return true
 	}
 
-	// 解析 ISO 8601 格式的時間字串
-	expiresAt, err := time.Parse(time.RFC3339, token.ExpiresAt)
+	return time.Now().After(expiresAt)
+}
+
+// TokenExpiresAt 解析 token 的過期時間（格式見 ParseExpiresAt），無法解析時 ok 為 false
+func TokenExpiresAt(token *KiroAuthToken) (expiresAt time.Time, ok bool) {
+	if token == nil || token.ExpiresAt == "" {
+		return time.Time{}, false
+	}
+
+	expiresAt, err := ParseExpiresAt(token.ExpiresAt)
 	if err != nil {
-		// 嘗試其他可能的格式
-		expiresAt, err = time.Parse("2006-01-02T15:04:05.000Z", token.ExpiresAt)
-		if err != nil {
-			return true
+		return time.Time{}, false
+	}
+	return expiresAt, true
+}
+
+// expiresAtLayouts 不同 Kiro 版本寫入 expiresAt 的時間格式
+var expiresAtLayouts = []string{
+	time.RFC3339Nano,           // 2006-01-02T15:04:05Z07:00，可含小數秒
+	"2006-01-02T15:04:05.000Z", // Kiro 與 CalculateExpiresAtString 使用的 UTC 毫秒格式
+}
+
+// ParseExpiresAt 解析 expiresAt 字串
+// 支援 RFC3339（可含毫秒）與 Unix 時間戳（13 位以上視為毫秒，否則為秒）
+// 無法解析時返回 ErrInvalidExpiresAt
+func ParseExpiresAt(raw string) (time.Time, error) {
+	for _, layout := range expiresAtLayouts {
+		if t, err := time.Parse(layout, raw); err == nil {
+			return t, nil
 		}
 	}
 
-	return time.Now().After(expiresAt)
+	if epoch, err := strconv.ParseInt(raw, 10, 64); err == nil && epoch > 0 {
+		if len(raw) >= 13 {
+			return time.UnixMilli(epoch).UTC(), nil
+		}
+		return time.Unix(epoch, 0).UTC(), nil
+	}
+
+	return time.Time{}, fmt.Errorf("%w: %q", ErrInvalidExpiresAt, raw)
 }
@@ -0,0 +1,55 @@
+package awssso
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParseExpiresAt(t *testing.T) {
+	want := time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)
+	testCases := []struct {
+		name string
+		raw  string
+		want time.Time
+	}{
+		{"RFC3339", "2030-01-02T03:04:05Z", want},
+		{"RFC3339 with offset", "2030-01-02T11:04:05+08:00", want},
+		{"milliseconds", "2030-01-02T03:04:05.000Z", want},
+		{"milliseconds non-zero", "2030-01-02T03:04:05.250Z", want.Add(250 * time.Millisecond)},
+		{"epoch millis", "1893553445000", want},
+		{"epoch seconds", "1893553445", want},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := ParseExpiresAt(tc.raw)
+			if err != nil {
+				t.Fatalf("ParseExpiresAt(%q) error = %v", tc.raw, err)
+			}
+			if !got.Equal(tc.want) {
+				t.Errorf("ParseExpiresAt(%q) = %v, want %v", tc.raw, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestParseExpiresAt_Invalid(t *testing.T) {
+	for _, raw := range []string{"", "not-a-date", "2030-01-02", "-1", "0"} {
+		if _, err := ParseExpiresAt(raw); !errors.Is(err, ErrInvalidExpiresAt) {
+			t.Errorf("ParseExpiresAt(%q) error = %v, want ErrInvalidExpiresAt", raw, err)
+		}
+	}
+}
+
+func TestTokenExpiresAt_Formats(t *testing.T) {
+	if _, ok := TokenExpiresAt(&KiroAuthToken{ExpiresAt: "1893553445000"}); !ok {
+		t.Error("TokenExpiresAt should accept epoch millis")
+	}
+	if IsTokenExpired(&KiroAuthToken{ExpiresAt: "2030-01-02T03:04:05.000Z"}) {
+		t.Error("token expiring in 2030 should not be expired")
+	}
+	if !IsTokenExpired(&KiroAuthToken{ExpiresAt: "garbage"}) {
+		t.Error("unparseable expiresAt should be treated as expired")
+	}
+}
@@ -1,18 +1,29 @@
 package backup
 
 import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
+	"sync"
 	"time"
+	"unicode/utf8"
 
 	"kiro-manager/awssso"
+	"kiro-manager/internal/fsutil"
 	"kiro-manager/machineid"
+	"kiro-manager/oauthlogin"
+	"kiro-manager/settings"
 	"kiro-manager/softreset"
+	"kiro-manager/tokenrefresh"
 )
 
 const (
@@ -20,6 +31,7 @@ const (
 	MachineIDFileName   = "machine-id.json"
 	KiroAuthTokenFile   = "kiro-auth-token.json"
 	UsageCacheFileName  = "usage-cache.json"
+	MetaFileName        = "meta.json"
 )
 
 var (
@@ -27,6 +39,9 @@ var (
 	ErrBackupExists      = errors.New("backup already exists")
 	ErrInvalidBackupName = errors.New("invalid backup name")
 	ErrNoTokenToBackup   = errors.New("no kiro auth token to backup")
+	ErrOriginalBackup    = errors.New("cannot modify original backup")
+	// ErrEmptyTokenFile token 檔案存在但內容為空（例如寫入中斷）
+	ErrEmptyTokenFile = errors.New("kiro auth token file is empty")
 )
 
 // MachineIDBackup 代表備份的 Machine ID 結構
@@ -42,6 +57,16 @@ type BackupInfo struct {
 	BackupTime time.Time `json:"backupTime"`
 	HasToken   bool      `json:"hasToken"`
 	HasMachineID bool    `json:"hasMachineId"`
+	Tags       []string  `json:"tags,omitempty"`
+	Note       string    `json:"note,omitempty"`
+	// TokenExpiresAt token 的過期時間，沒有 token 或無法解析時為零值
+	TokenExpiresAt time.Time `json:"tokenExpiresAt"`
+}
+
+// BackupMeta 備份的使用者註記（標籤與備註）
+type BackupMeta struct {
+	Tags []string `json:"tags"`
+	Note string   `json:"note"`
 }
 
 // UsageCache 餘額緩存結構
@@ -54,8 +79,17 @@ type UsageCache struct {
 	CachedAt          time.Time `json:"cachedAt"`
 }
 
-// GetBackupRootPath 取得備份根目錄（執行檔同層的 backups 資料夾）
+// GetBackupRootPath 取得備份根目錄
+// 設定了 BackupRootOverride（絕對路徑）時使用該目錄，否則為執行檔同層的 backups 資料夾
 func GetBackupRootPath() (string, error) {
+	if override := settings.GetBackupRootOverride(); override != "" && filepath.IsAbs(override) {
+		return override, nil
+	}
+	return GetDefaultBackupRootPath()
+}
+
+// GetDefaultBackupRootPath 取得預設的備份根目錄（執行檔同層的 backups 資料夾）
+func GetDefaultBackupRootPath() (string, error) {
 	execPath, err := os.Executable()
 	if err != nil {
 		return "", err
@@ -127,10 +161,11 @@ func ListBackups() ([]BackupInfo, error) {
 			Path: backupPath,
 		}
 
-		// 檢查是否有 token 檔案
+		// 檢查是否有 token 檔案並讀取過期時間
 		tokenPath := filepath.Join(backupPath, KiroAuthTokenFile)
 		if _, err := os.Stat(tokenPath); err == nil {
 			info.HasToken = true
+			info.TokenExpiresAt = readTokenExpiry(tokenPath)
 		}
 
 		// 檢查是否有 machine-id 檔案並讀取備份時間
@@ -145,6 +180,12 @@ func ListBackups() ([]BackupInfo, error) {
 			}
 		}
 
+		// 讀取標籤與備註（meta.json 損毀時忽略）
+		if meta, err := readMetaFile(backupPath); err == nil {
+			info.Tags = meta.Tags
+			info.Note = meta.Note
+		}
+
 		backups = append(backups, info)
 	}
 
@@ -205,6 +246,10 @@ func CreateBackup(name string) error {
 		os.RemoveAll(backupPath)
 		return ErrNoTokenToBackup
 	}
+	if err := checkTokenFileNotEmpty(tokenSrcPath); err != nil {
+		os.RemoveAll(backupPath)
+		return err
+	}
 
 	tokenDstPath := filepath.Join(backupPath, KiroAuthTokenFile)
 	if err := copyFile(tokenSrcPath, tokenDstPath); err != nil {
@@ -259,6 +304,43 @@ func CreateBackup(name string) error {
 	return nil
 }
 
+// CreateBackupWithUsage 創建備份並一併寫入餘額緩存（usage-cache.json）
+// cache 為 nil 時等同 CreateBackup；寫入緩存失敗時會刪除已創建的備份
+func CreateBackupWithUsage(name string, cache *UsageCache) error {
+	if err := CreateBackup(name); err != nil {
+		return err
+	}
+	if cache == nil {
+		return nil
+	}
+
+	if err := WriteUsageCache(name, cache); err != nil {
+		if backupPath, pathErr := GetBackupPath(name); pathErr == nil {
+			os.RemoveAll(backupPath)
+		}
+		return err
+	}
+
+	return nil
+}
+
+// checkTokenFileNotEmpty 檔案內容為空或只有空白時返回 ErrEmptyTokenFile
+func checkTokenFileNotEmpty(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read token file: %w", err)
+	}
+	if isBlank(data) {
+		return ErrEmptyTokenFile
+	}
+	return nil
+}
+
+// isBlank 內容為空或只包含空白字元
+func isBlank(data []byte) bool {
+	return len(bytes.TrimSpace(data)) == 0
+}
+
 // isIdCAuth 判斷是否為 IdC 認證類型
 func isIdCAuth(authMethod string) bool {
 	if authMethod == "" {
@@ -290,8 +372,24 @@ func copyFile(src, dst string) error {
 }
 
 
-// RestoreBackup 恢復指定的備份
+// RestoreOptions 指定 RestoreBackupSelective 要恢復的項目
+type RestoreOptions struct {
+	RestoreToken     bool // 恢復 kiro-auth-token.json
+	RestoreMachineID bool // 恢復 custom-machine-id 與 custom-machine-id-raw
+	RestoreIdCCreds  bool // 恢復 IdC 的 clientIdHash 文件（clientId/clientSecret）
+}
+
+// RestoreBackup 恢復指定的備份（Token、Machine ID 與 IdC 憑證）
 func RestoreBackup(name string) error {
+	return RestoreBackupSelective(name, RestoreOptions{
+		RestoreToken:     true,
+		RestoreMachineID: true,
+		RestoreIdCCreds:  true,
+	})
+}
+
+// RestoreBackupSelective 依 opts 恢復指定備份的部分內容，未指定的項目保持目前狀態
+func RestoreBackupSelective(name string, opts RestoreOptions) error {
 	if name == "" {
 		return ErrInvalidBackupName
 	}
@@ -305,11 +403,34 @@ func RestoreBackup(name string) error {
 		return err
 	}
 
-	// 恢復 kiro-auth-token.json
+	if opts.RestoreToken {
+		if err := restoreBackupToken(backupPath); err != nil {
+			return err
+		}
+	}
+
+	if opts.RestoreIdCCreds {
+		restoreBackupIdCCreds(name, backupPath)
+	}
+
+	if opts.RestoreMachineID {
+		if err := restoreBackupMachineID(name); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// restoreBackupToken 將備份的 kiro-auth-token.json 複製至 SSO cache
+func restoreBackupToken(backupPath string) error {
 	tokenSrcPath := filepath.Join(backupPath, KiroAuthTokenFile)
 	if _, err := os.Stat(tokenSrcPath); os.IsNotExist(err) {
 		return fmt.Errorf("backup token file not found")
 	}
+	if err := checkTokenFileNotEmpty(tokenSrcPath); err != nil {
+		return err
+	}
 
 	tokenDstPath, err := awssso.GetKiroAuthTokenPath()
 	if err != nil {
@@ -326,6 +447,11 @@ func RestoreBackup(name string) error {
 		return fmt.Errorf("failed to restore token: %w", err)
 	}
 
+	return nil
+}
+
+// restoreBackupIdCCreds 恢復 IdC 備份的 clientIdHash 文件，失敗時只記錄警告
+func restoreBackupIdCCreds(name, backupPath string) {
 	// 讀取備份的 token 以檢查是否需要恢復 IdC 的 clientIdHash 文件
 	token, err := ReadBackupToken(name)
 	if err == nil && token != nil {
@@ -345,8 +471,11 @@ func RestoreBackup(name string) error {
 			}
 		}
 	}
+}
 
-	// 恢復 Machine ID（寫入 custom-machine-id 和 custom-machine-id-raw）
+// restoreBackupMachineID 恢復 Machine ID（寫入 custom-machine-id 和 custom-machine-id-raw）
+// 備份沒有 Machine ID 時略過
+func restoreBackupMachineID(name string) error {
 	machineIDBackup, err := ReadBackupMachineID(name)
 	if err == nil && machineIDBackup != nil && machineIDBackup.MachineID != "" {
 		rawMachineID := machineIDBackup.MachineID
@@ -391,6 +520,235 @@ func DeleteBackup(name string) error {
 	return nil
 }
 
+// RenameBackup 重新命名備份，並保留其文件夾歸屬
+// newName 需通過 ValidateSnapshotName 驗證，原始備份（original）不可重新命名
+func RenameBackup(oldName, newName string) error {
+	if oldName == "" {
+		return ErrInvalidBackupName
+	}
+
+	if oldName == OriginalBackupName || newName == OriginalBackupName {
+		return ErrOriginalBackup
+	}
+
+	if !BackupExists(oldName) {
+		return ErrBackupNotFound
+	}
+
+	if err := ValidateSnapshotName(newName); err != nil {
+		return err
+	}
+
+	oldPath, err := GetBackupPath(oldName)
+	if err != nil {
+		return err
+	}
+
+	newPath, err := GetBackupPath(newName)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Rename(oldPath, newPath); err != nil {
+		return fmt.Errorf("failed to rename backup directory: %w", err)
+	}
+
+	// 遷移 folders.json 中的 assignment，失敗時還原資料夾名稱
+	if err := renameSnapshotAssignment(oldName, newName); err != nil {
+		os.Rename(newPath, oldPath)
+		return fmt.Errorf("failed to migrate folder assignment: %w", err)
+	}
+
+	return nil
+}
+
+// DuplicateBackup 複製備份至新名稱（不繼承文件夾歸屬）
+// 會複製來源備份資料夾中的所有檔案，包含 IdC 的 {clientIdHash}.json
+func DuplicateBackup(srcName, newName string) error {
+	return DuplicateBackupWithFolder(srcName, newName, false)
+}
+
+// DuplicateBackupWithFolder 複製備份至新名稱
+// inheritFolder 為 true 時，新備份會分配到與來源相同的文件夾
+// 任何複製失敗都會清理已建立的新備份資料夾
+func DuplicateBackupWithFolder(srcName, newName string, inheritFolder bool) error {
+	if srcName == "" {
+		return ErrInvalidBackupName
+	}
+
+	if !BackupExists(srcName) {
+		return ErrBackupNotFound
+	}
+
+	if err := ValidateSnapshotName(newName); err != nil {
+		return err
+	}
+
+	srcPath, err := GetBackupPath(srcName)
+	if err != nil {
+		return err
+	}
+
+	dstPath, err := GetBackupPath(newName)
+	if err != nil {
+		return err
+	}
+
+	if err := copyDir(srcPath, dstPath); err != nil {
+		os.RemoveAll(dstPath)
+		return fmt.Errorf("failed to duplicate backup: %w", err)
+	}
+
+	if inheritFolder {
+		folderId, err := GetSnapshotFolderId(srcName)
+		if err != nil {
+			os.RemoveAll(dstPath)
+			return fmt.Errorf("failed to read folder assignment: %w", err)
+		}
+		if folderId != "" {
+			if err := AssignSnapshotToFolder(newName, folderId); err != nil {
+				os.RemoveAll(dstPath)
+				return fmt.Errorf("failed to assign folder: %w", err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// copyDir 遞迴複製資料夾內的所有檔案
+func copyDir(src, dst string) error {
+	return filepath.WalkDir(src, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		relPath, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, relPath)
+
+		if d.IsDir() {
+			return os.MkdirAll(target, 0755)
+		}
+		return copyFile(path, target)
+	})
+}
+
+// PrunePolicy 備份清理策略，MaxAge 與 MaxCount 為 0 時表示不限制
+type PrunePolicy struct {
+	MaxAge          time.Duration // 刪除 BackupTime 早於此時間的備份（無備份時間者不會因超齡被刪除）
+	MaxCount        int           // 只保留最新的 MaxCount 個備份
+	IncludeFoldered bool          // 是否一併清理已分配到文件夾的快照
+}
+
+// PruneBackups 依 policy 清理舊備份
+// 原始備份（original）永遠保留；已分配到文件夾的快照除非設定 IncludeFoldered，否則同樣保留
+// 受保護的備份不計入 MaxCount，被刪除的備份會一併從 folders.json 移除 assignment
+// 返回被刪除的備份名稱列表
+func PruneBackups(policy PrunePolicy) ([]string, error) {
+	if policy.MaxAge < 0 || policy.MaxCount < 0 {
+		return nil, fmt.Errorf("prune policy cannot be negative")
+	}
+
+	backups, err := ListBackups()
+	if err != nil {
+		return nil, err
+	}
+
+	var assignments map[string]string
+	if !policy.IncludeFoldered {
+		folders, err := LoadFolders()
+		if err != nil {
+			return nil, fmt.Errorf("failed to load folders: %w", err)
+		}
+		assignments = folders.Assignments
+	}
+
+	var candidates []BackupInfo
+	for _, b := range backups {
+		if b.Name == OriginalBackupName || assignments[b.Name] != "" {
+			continue
+		}
+		candidates = append(candidates, b)
+	}
+
+	// 按備份時間降序排列（無備份時間者排在最後）
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].BackupTime.After(candidates[j].BackupTime)
+	})
+
+	cutoff := time.Now().Add(-policy.MaxAge)
+	deleted := []string{}
+	for i, b := range candidates {
+		overCount := policy.MaxCount > 0 && i >= policy.MaxCount
+		tooOld := policy.MaxAge > 0 && !b.BackupTime.IsZero() && b.BackupTime.Before(cutoff)
+		if !overCount && !tooOld {
+			continue
+		}
+		if err := DeleteBackup(b.Name); err != nil {
+			return deleted, fmt.Errorf("failed to delete backup %s: %w", b.Name, err)
+		}
+		deleted = append(deleted, b.Name)
+	}
+
+	return deleted, nil
+}
+
+// GetBackupsDiskUsage 統計備份根目錄下所有備份的檔案大小總和（bytes）
+// 只計算備份資料夾內的檔案，根目錄的 folders.json 等檔案不計入
+func GetBackupsDiskUsage() (total int64, perBackup map[string]int64, err error) {
+	rootPath, err := GetBackupRootPath()
+	if err != nil {
+		return 0, nil, err
+	}
+	return backupsDiskUsageAt(rootPath)
+}
+
+// backupsDiskUsageAt 統計 rootPath 下各備份資料夾的檔案大小
+func backupsDiskUsageAt(rootPath string) (int64, map[string]int64, error) {
+	perBackup := map[string]int64{}
+	entries, err := os.ReadDir(rootPath)
+	if os.IsNotExist(err) {
+		return 0, perBackup, nil
+	}
+	if err != nil {
+		return 0, nil, err
+	}
+
+	var total int64
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+
+		var size int64
+		err := filepath.WalkDir(filepath.Join(rootPath, entry.Name()), func(path string, d os.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if !d.Type().IsRegular() {
+				return nil
+			}
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			size += info.Size()
+			return nil
+		})
+		if err != nil {
+			return 0, nil, fmt.Errorf("failed to measure backup %s: %w", entry.Name(), err)
+		}
+
+		perBackup[entry.Name()] = size
+		total += size
+	}
+
+	return total, perBackup, nil
+}
+
 // GetBackupInfo 取得指定備份的詳細資訊
 func GetBackupInfo(name string) (*BackupInfo, error) {
 	if name == "" {
@@ -411,10 +769,11 @@ func GetBackupInfo(name string) (*BackupInfo, error) {
 		Path: backupPath,
 	}
 
-	// 檢查 token 檔案
+	// 檢查 token 檔案並讀取過期時間
 	tokenPath := filepath.Join(backupPath, KiroAuthTokenFile)
 	if _, err := os.Stat(tokenPath); err == nil {
 		info.HasToken = true
+		info.TokenExpiresAt = readTokenExpiry(tokenPath)
 	}
 
 	// 檢查 machine-id 檔案
@@ -429,6 +788,12 @@ func GetBackupInfo(name string) (*BackupInfo, error) {
 		}
 	}
 
+	// 讀取標籤與備註
+	if meta, err := readMetaFile(backupPath); err == nil {
+		info.Tags = meta.Tags
+		info.Note = meta.Note
+	}
+
 	return info, nil
 }
 
@@ -535,6 +900,30 @@ func EnsureOriginalBackup() (bool, error) {
 	return true, nil
 }
 
+// ParseTokenExpiry 解析 token 的過期時間
+// 支援 RFC3339 與 Kiro 寫入的 UTC 毫秒格式（2006-01-02T15:04:05.000Z），格式細節見 awssso.ParseExpiresAt
+// token 為 nil 或 expiresAt 為空時返回零值且不視為錯誤；無法解析時返回 awssso.ErrInvalidExpiresAt
+func ParseTokenExpiry(token *awssso.KiroAuthToken) (time.Time, error) {
+	if token == nil || token.ExpiresAt == "" {
+		return time.Time{}, nil
+	}
+	return awssso.ParseExpiresAt(token.ExpiresAt)
+}
+
+// readTokenExpiry 讀取 token 檔案的過期時間，讀取或解析失敗時返回零值
+func readTokenExpiry(tokenPath string) time.Time {
+	data, err := os.ReadFile(tokenPath)
+	if err != nil {
+		return time.Time{}
+	}
+	var token awssso.KiroAuthToken
+	if err := json.Unmarshal(data, &token); err != nil {
+		return time.Time{}
+	}
+	expiresAt, _ := ParseTokenExpiry(&token)
+	return expiresAt
+}
+
 // ReadBackupToken 讀取備份中的 kiro-auth-token.json
 func ReadBackupToken(name string) (*awssso.KiroAuthToken, error) {
 	if name == "" {
@@ -555,6 +944,9 @@ func ReadBackupToken(name string) (*awssso.KiroAuthToken, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to read token file: %w", err)
 	}
+	if isBlank(data) {
+		return nil, ErrEmptyTokenFile
+	}
 
 	var token awssso.KiroAuthToken
 	if err := json.Unmarshal(data, &token); err != nil {
@@ -609,6 +1001,122 @@ func ReadBackupIdCCredentials(name string, clientIdHash string) (clientID, clien
 	return cacheFile.ClientID, cacheFile.ClientSecret, nil
 }
 
+// CleanupOrphanBackupFiles 刪除備份目錄中不再使用的 JSON 檔案
+// 保留 machine-id、token、usage-cache、meta 四個固定檔案，以及 token 目前 clientIdHash 對應的憑證檔案；
+// 其餘 *.json（例如重新登入後遺留的舊 clientIdHash 檔案）會被刪除，返回被刪除的檔名
+func CleanupOrphanBackupFiles(name string) ([]string, error) {
+	if name == "" {
+		return nil, ErrInvalidBackupName
+	}
+
+	// 讀不到 token 時無法判斷哪個憑證檔案仍在使用，不刪除任何檔案
+	token, err := ReadBackupToken(name)
+	if err != nil {
+		return nil, err
+	}
+
+	backupPath, err := GetBackupPath(name)
+	if err != nil {
+		return nil, err
+	}
+
+	keep := map[string]bool{
+		MachineIDFileName:  true,
+		KiroAuthTokenFile:  true,
+		UsageCacheFileName: true,
+		MetaFileName:       true,
+	}
+	if token.ClientIdHash != "" {
+		keep[token.ClientIdHash+".json"] = true
+	}
+
+	entries, err := os.ReadDir(backupPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read backup directory: %w", err)
+	}
+
+	var removed []string
+	for _, entry := range entries {
+		fileName := entry.Name()
+		if entry.IsDir() || filepath.Ext(fileName) != ".json" || keep[fileName] {
+			continue
+		}
+		if err := os.Remove(filepath.Join(backupPath, fileName)); err != nil {
+			return removed, fmt.Errorf("failed to remove %s: %w", fileName, err)
+		}
+		removed = append(removed, fileName)
+	}
+
+	return removed, nil
+}
+
+// FindDuplicates 找出屬於同一帳號的備份
+// 以 refreshToken 的 SHA256 作為帳號識別，返回識別 -> 備份名稱（已排序）的映射，只包含兩個以上備份的群組
+// 沒有 token 或 refreshToken 的備份會被略過；此函數只做分析，不修改任何備份
+func FindDuplicates() (map[string][]string, error) {
+	backups, err := ListBackups()
+	if err != nil {
+		return nil, err
+	}
+
+	groups := map[string][]string{}
+	for _, b := range backups {
+		if !b.HasToken {
+			continue
+		}
+		token, err := ReadBackupToken(b.Name)
+		if err != nil || token.RefreshToken == "" {
+			continue
+		}
+		sum := sha256.Sum256([]byte(token.RefreshToken))
+		identity := hex.EncodeToString(sum[:])
+		groups[identity] = append(groups[identity], b.Name)
+	}
+
+	duplicates := map[string][]string{}
+	for identity, names := range groups {
+		if len(names) < 2 {
+			continue
+		}
+		sort.Strings(names)
+		duplicates[identity] = names
+	}
+
+	return duplicates, nil
+}
+
+// FindDuplicateMachineIDs 找出使用相同 Machine ID 的快照
+// 返回 Machine ID -> 快照名稱（已排序）的映射，只包含兩個以上快照的群組；原始備份（original）不列入
+func FindDuplicateMachineIDs() (map[string][]string, error) {
+	backups, err := ListBackups()
+	if err != nil {
+		return nil, err
+	}
+
+	groups := map[string][]string{}
+	for _, b := range backups {
+		if b.Name == OriginalBackupName || !b.HasMachineID {
+			continue
+		}
+		mid, err := ReadBackupMachineID(b.Name)
+		if err != nil || mid.MachineID == "" {
+			continue
+		}
+		groups[mid.MachineID] = append(groups[mid.MachineID], b.Name)
+	}
+
+	duplicates := map[string][]string{}
+	for machineID, names := range groups {
+		if len(names) < 2 {
+			continue
+		}
+		sort.Strings(names)
+		duplicates[machineID] = names
+	}
+
+	return duplicates, nil
+}
+
 // ReadUsageCache 讀取備份的餘額緩存
 func ReadUsageCache(name string) (*UsageCache, error) {
 	if name == "" {
@@ -666,29 +1174,86 @@ func WriteUsageCache(name string, cache *UsageCache) error {
 	}
 
 	cachePath := filepath.Join(backupPath, UsageCacheFileName)
-	if err := os.WriteFile(cachePath, cacheData, 0644); err != nil {
+	if err := fsutil.WriteFileAtomic(cachePath, cacheData, 0644); err != nil {
 		return fmt.Errorf("failed to write usage cache: %w", err)
 	}
 
 	return nil
 }
 
+// ReadBackupMeta 讀取備份的標籤與備註
+// 備份沒有 meta.json 時返回空的 BackupMeta
+func ReadBackupMeta(name string) (*BackupMeta, error) {
+	if name == "" {
+		return nil, ErrInvalidBackupName
+	}
 
-// orderedKiroAuthToken 用於確保 JSON 輸出時 key 的順序
-// 順序: accessToken, refreshToken, profileArn, expiresAt, authMethod, provider, clientIdHash, region, tokenType, startUrl
-type orderedKiroAuthToken struct {
-	AccessToken  string `json:"accessToken"`
-	RefreshToken string `json:"refreshToken"`
-	ProfileArn   string `json:"profileArn,omitempty"`
-	ExpiresAt    string `json:"expiresAt"`
-	AuthMethod   string `json:"authMethod,omitempty"`
-	Provider     string `json:"provider,omitempty"`
-	ClientIdHash string `json:"clientIdHash,omitempty"` // IdC 特有欄位
-	Region       string `json:"region,omitempty"`       // IdC 特有欄位
-	TokenType    string `json:"tokenType,omitempty"`    // 可選欄位
-	StartURL     string `json:"startUrl,omitempty"`     // 可選欄位
+	if !BackupExists(name) {
+		return nil, ErrBackupNotFound
+	}
+
+	backupPath, err := GetBackupPath(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return readMetaFile(backupPath)
 }
 
+// WriteBackupMeta 寫入備份的標籤與備註
+func WriteBackupMeta(name string, meta *BackupMeta) error {
+	if name == "" {
+		return ErrInvalidBackupName
+	}
+
+	if meta == nil {
+		return fmt.Errorf("meta cannot be nil")
+	}
+
+	if !BackupExists(name) {
+		return ErrBackupNotFound
+	}
+
+	backupPath, err := GetBackupPath(name)
+	if err != nil {
+		return err
+	}
+
+	metaData, err := json.MarshalIndent(meta, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal backup meta: %w", err)
+	}
+
+	metaPath := filepath.Join(backupPath, MetaFileName)
+	if err := fsutil.WriteFileAtomic(metaPath, metaData, 0644); err != nil {
+		return fmt.Errorf("failed to write backup meta: %w", err)
+	}
+
+	return nil
+}
+
+// readMetaFile 讀取備份目錄中的 meta.json，檔案不存在時返回空的 BackupMeta
+func readMetaFile(backupPath string) (*BackupMeta, error) {
+	data, err := os.ReadFile(filepath.Join(backupPath, MetaFileName))
+	if os.IsNotExist(err) {
+		return &BackupMeta{Tags: []string{}}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read backup meta file: %w", err)
+	}
+
+	var meta BackupMeta
+	if err := json.Unmarshal(data, &meta); err != nil {
+		return nil, fmt.Errorf("failed to parse backup meta file: %w", err)
+	}
+	if meta.Tags == nil {
+		meta.Tags = []string{}
+	}
+
+	return &meta, nil
+}
+
+
 // WriteBackupToken 將刷新後的 Token 寫入備份檔案
 // 保留原有欄位，僅更新 accessToken、expiresAt
 // 確保 JSON key 順序: accessToken, refreshToken, profileArn, expiresAt, authMethod, provider
@@ -708,54 +1273,183 @@ func WriteBackupToken(name string, accessToken string, expiresAt string) error {
 	}
 
 	tokenPath := filepath.Join(backupPath, KiroAuthTokenFile)
+	return awssso.UpdateTokenFile(tokenPath, accessToken, expiresAt)
+}
 
-	// 讀取現有 token 檔案以保留原始欄位
-	data, err := os.ReadFile(tokenPath)
+// refreshAccessToken 實際執行 Token 刷新的函數
+// 預設使用 tokenrefresh.RefreshAccessTokenWithCredentialsContext，測試時可替換為 mock
+var refreshAccessToken = tokenrefresh.RefreshAccessTokenWithCredentialsContext
+
+// RefreshAndWriteBackup 刷新備份的 Token 並寫回備份檔案
+// 流程：
+// 1. 讀取備份的 kiro-auth-token.json
+// 2. 讀取 machine-id.json 並計算 SHA256 雜湊值
+// 3. IdC 認證時從備份目錄讀取 clientId/clientSecret
+// 4. 刷新 Token 並寫回新的 accessToken、expiresAt
+//
+// 刷新失敗時原樣返回 *tokenrefresh.RefreshError
+func RefreshAndWriteBackup(name string) (*tokenrefresh.TokenInfo, error) {
+	return RefreshAndWriteBackupContext(context.Background(), name)
+}
+
+// RefreshAndWriteBackupContext 與 RefreshAndWriteBackup 相同，但 ctx 取消時會中止進行中的刷新請求
+func RefreshAndWriteBackupContext(ctx context.Context, name string) (*tokenrefresh.TokenInfo, error) {
+	tokenInfo, err := refreshBackupToken(ctx, name)
 	if err != nil {
-		return fmt.Errorf("failed to read existing token file: %w", err)
+		return nil, err
 	}
 
-	// 先解析到 map 以讀取原始值
-	var tokenMap map[string]interface{}
-	if err := json.Unmarshal(data, &tokenMap); err != nil {
-		return fmt.Errorf("failed to parse existing token file: %w", err)
+	expiresAt := tokenInfo.ExpiresAt.UTC().Format("2006-01-02T15:04:05.000Z")
+	if err := WriteBackupToken(name, tokenInfo.AccessToken, expiresAt); err != nil {
+		return nil, err
 	}
 
-	// 使用有序結構體來確保 key 順序
-	orderedToken := orderedKiroAuthToken{
-		AccessToken:  accessToken,
-		RefreshToken: getStringFromMap(tokenMap, "refreshToken"),
-		ProfileArn:   getStringFromMap(tokenMap, "profileArn"),
-		ExpiresAt:    expiresAt,
-		AuthMethod:   getStringFromMap(tokenMap, "authMethod"),
-		Provider:     getStringFromMap(tokenMap, "provider"),
-		ClientIdHash: getStringFromMap(tokenMap, "clientIdHash"), // IdC 特有欄位
-		Region:       getStringFromMap(tokenMap, "region"),       // IdC 特有欄位
-		TokenType:    getStringFromMap(tokenMap, "tokenType"),    // 可選欄位
-		StartURL:     getStringFromMap(tokenMap, "startUrl"),     // 可選欄位
+	return tokenInfo, nil
+}
+
+// CheckBackupRefresh 以備份的 refresh token 試刷新，確認是否仍可使用
+// 新的 Token 只返回於記憶體中，不會寫回備份檔案
+// refresh token 已失效（401/403）時返回的錯誤可以 errors.Is(err, tokenrefresh.ErrTokenExpired) 判斷
+func CheckBackupRefresh(name string) (*tokenrefresh.TokenInfo, error) {
+	return refreshBackupToken(context.Background(), name)
+}
+
+// refreshBackupToken 讀取備份的 Token、Machine ID 與 IdC 憑證並刷新，不寫入任何檔案
+func refreshBackupToken(ctx context.Context, name string) (*tokenrefresh.TokenInfo, error) {
+	token, err := ReadBackupToken(name)
+	if err != nil {
+		return nil, err
 	}
 
-	// 將更新後的 token 寫回檔案
-	updatedData, err := json.MarshalIndent(orderedToken, "", "  ")
+	mid, err := ReadBackupMachineID(name)
 	if err != nil {
-		return fmt.Errorf("failed to marshal updated token: %w", err)
+		return nil, err
 	}
+	hashedMachineID := machineid.HashMachineID(mid.MachineID)
 
-	if err := os.WriteFile(tokenPath, updatedData, 0644); err != nil {
-		return fmt.Errorf("failed to write token file: %w", err)
+	// IdC 認證使用備份目錄中的 clientId/clientSecret，而非系統的 SSO cache
+	var clientID, clientSecret string
+	if tokenrefresh.DetectAuthType(token) == "idc" && token.ClientIdHash != "" {
+		clientID, clientSecret, err = ReadBackupIdCCredentials(name, token.ClientIdHash)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read idc credentials: %w", err)
+		}
 	}
 
-	return nil
+	return refreshAccessToken(ctx, token, hashedMachineID, clientID, clientSecret)
 }
 
-// getStringFromMap 從 map 中安全地取得字串值
-func getStringFromMap(m map[string]interface{}, key string) string {
-	if v, ok := m[key]; ok {
-		if s, ok := v.(string); ok {
-			return s
+// DefaultRefreshConcurrency 批次刷新時預設同時進行的刷新數量
+const DefaultRefreshConcurrency = 4
+
+// RefreshOutcome 批次刷新中單一備份的結果
+type RefreshOutcome struct {
+	ExpiresAt time.Time // 刷新成功後的新過期時間
+	Err       error     // 刷新失敗的錯誤（成功時為 nil）
+}
+
+// RefreshAllBackups 批次刷新所有備份的 Token
+// 跳過原始備份與沒有 token 的備份，最多同時執行 concurrency 個刷新
+// 單一備份刷新失敗不會中止其他備份，結果以備份名稱為 key 返回
+// 無法讀取備份列表時返回錯誤
+func RefreshAllBackups(concurrency int) (map[string]*RefreshOutcome, error) {
+	return RefreshAllBackupsContext(context.Background(), concurrency)
+}
+
+// RefreshAllBackupsContext 與 RefreshAllBackups 相同，但 ctx 取消時會中止進行中的刷新
+func RefreshAllBackupsContext(ctx context.Context, concurrency int) (map[string]*RefreshOutcome, error) {
+	names, err := listRefreshableBackups(func(token *awssso.KiroAuthToken) bool { return true })
+	if err != nil {
+		return nil, err
+	}
+
+	return refreshBackups(ctx, names, concurrency), nil
+}
+
+// RefreshExpiringBackups 只刷新在 within 時間內即將過期（或已過期）的備份 Token
+// 過期時間無法解析的備份視為已過期；跳過原始備份與沒有 token 的備份
+func RefreshExpiringBackups(within time.Duration) (map[string]*RefreshOutcome, error) {
+	return RefreshExpiringBackupsContext(context.Background(), within)
+}
+
+// RefreshExpiringBackupsContext 與 RefreshExpiringBackups 相同，但 ctx 取消時會中止進行中的刷新
+func RefreshExpiringBackupsContext(ctx context.Context, within time.Duration) (map[string]*RefreshOutcome, error) {
+	deadline := time.Now().Add(within)
+	names, err := listRefreshableBackups(func(token *awssso.KiroAuthToken) bool {
+		expiresAt, err := ParseTokenExpiry(token)
+		return err != nil || expiresAt.IsZero() || expiresAt.Before(deadline)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return refreshBackups(ctx, names, DefaultRefreshConcurrency), nil
+}
+
+// listRefreshableBackups 列出可刷新的備份名稱（排除原始備份與沒有 token 的備份）
+// filter 返回 false 的備份也會被排除
+func listRefreshableBackups(filter func(token *awssso.KiroAuthToken) bool) ([]string, error) {
+	backups, err := ListBackups()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list backups: %w", err)
+	}
+
+	var names []string
+	for _, b := range backups {
+		if b.Name == OriginalBackupName || !b.HasToken {
+			continue
 		}
+		token, err := ReadBackupToken(b.Name)
+		if err != nil || !filter(token) {
+			continue
+		}
+		names = append(names, b.Name)
+	}
+
+	return names, nil
+}
+
+// refreshBackups 以最多 concurrency 個 worker 並行刷新指定的備份
+func refreshBackups(ctx context.Context, names []string, concurrency int) map[string]*RefreshOutcome {
+	results := make(map[string]*RefreshOutcome)
+
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+	if concurrency > len(names) {
+		concurrency = len(names)
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	jobs := make(chan string)
+
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for name := range jobs {
+				outcome := &RefreshOutcome{}
+				if tokenInfo, err := RefreshAndWriteBackupContext(ctx, name); err != nil {
+					outcome.Err = err
+				} else {
+					outcome.ExpiresAt = tokenInfo.ExpiresAt
+				}
+
+				mu.Lock()
+				results[name] = outcome
+				mu.Unlock()
+			}
+		}()
+	}
+
+	for _, name := range names {
+		jobs <- name
 	}
-	return ""
+	close(jobs)
+	wg.Wait()
+
+	return results
 }
 
 // UpdateBackupMachineID 更新備份中的 Machine ID
@@ -823,13 +1517,40 @@ type IdCCreds struct {
 // illegalSnapshotNameChars 快照名稱中不允許的字元
 var illegalSnapshotNameChars = []rune{'/', '\\', ':', '*', '?', '"', '<', '>', '|'}
 
+// MaxSnapshotNameLength 快照名稱最大長度（字元數）
+const MaxSnapshotNameLength = 100
+
+// windowsReservedNames Windows 保留的裝置名稱（不分大小寫，含副檔名亦不可用）
+var windowsReservedNames = []string{
+	"CON", "PRN", "AUX", "NUL",
+	"COM1", "COM2", "COM3", "COM4", "COM5", "COM6", "COM7", "COM8", "COM9",
+	"LPT1", "LPT2", "LPT3", "LPT4", "LPT5", "LPT6", "LPT7", "LPT8", "LPT9",
+}
+
 // ValidateSnapshotName 驗證快照名稱是否有效
 // 返回 nil 表示有效，否則返回錯誤
 // 規則：
 // - 不可為空
 // - 不可包含非法字元：/ \ : * ? " < > |
+// - 不可使用保留名稱 original 或 Windows 裝置名稱（CON、NUL、COM1 等）
+// - 不可以 . 或空白結尾
+// - 不可超過 MaxSnapshotNameLength 個字元
 // - 不可與現有快照重複
 func ValidateSnapshotName(name string) error {
+	if err := validateSnapshotNameFormat(name); err != nil {
+		return err
+	}
+
+	// 規則 9.3: 不可與現有快照重複
+	if BackupExists(name) {
+		return ErrBackupExists
+	}
+
+	return nil
+}
+
+// validateSnapshotNameFormat 驗證快照名稱格式（不檢查是否重複）
+func validateSnapshotNameFormat(name string) error {
 	// 規則 9.1: 不可為空
 	if name == "" {
 		return ErrInvalidBackupName
@@ -844,9 +1565,26 @@ func ValidateSnapshotName(name string) error {
 		}
 	}
 
-	// 規則 9.3: 不可與現有快照重複
-	if BackupExists(name) {
-		return ErrBackupExists
+	if n := utf8.RuneCountInString(name); n > MaxSnapshotNameLength {
+		return fmt.Errorf("%w: name is %d characters long (max %d)", ErrInvalidBackupName, n, MaxSnapshotNameLength)
+	}
+
+	// Windows 會自動移除結尾的 . 與空白，導致資料夾名稱與快照名稱不一致
+	if strings.HasSuffix(name, ".") || strings.HasSuffix(name, " ") {
+		return fmt.Errorf("%w: must not end with a dot or space", ErrInvalidBackupName)
+	}
+
+	// 原始備份名稱保留給 CreateOriginalBackup 使用
+	if strings.EqualFold(name, OriginalBackupName) {
+		return fmt.Errorf("%w: %q is reserved for the original backup", ErrInvalidBackupName, name)
+	}
+
+	// CON.txt 等帶副檔名的裝置名稱在 Windows 上同樣無法使用
+	base, _, _ := strings.Cut(name, ".")
+	for _, reserved := range windowsReservedNames {
+		if strings.EqualFold(strings.TrimRight(base, " "), reserved) {
+			return fmt.Errorf("%w: %q is a reserved device name on Windows", ErrInvalidBackupName, name)
+		}
 	}
 
 	return nil
@@ -974,3 +1712,27 @@ func CreateBackupFromOAuth(name string, data *OAuthBackupData) error {
 
 	return nil
 }
+
+// CreateBackupFromLoginResult 從 oauthlogin 登入結果直接建立環境快照
+// 自動轉換為 OAuthBackupData 後委派給 CreateBackupFromOAuth
+func CreateBackupFromLoginResult(name string, lr *oauthlogin.LoginResult) error {
+	if lr == nil {
+		return fmt.Errorf("login result cannot be nil")
+	}
+	return CreateBackupFromOAuth(name, oauthBackupDataFromLoginResult(lr))
+}
+
+// oauthBackupDataFromLoginResult 將 LoginResult 轉換為 OAuthBackupData
+func oauthBackupDataFromLoginResult(lr *oauthlogin.LoginResult) *OAuthBackupData {
+	return &OAuthBackupData{
+		AccessToken:  lr.AccessToken,
+		RefreshToken: lr.RefreshToken,
+		ExpiresAt:    lr.ExpiresAt,
+		ProfileArn:   lr.ProfileArn,
+		Provider:     lr.Provider,
+		AuthMethod:   lr.AuthMethod,
+		ClientId:     lr.ClientId,
+		ClientSecret: lr.ClientSecret,
+		ClientIdHash: lr.ClientIdHash,
+	}
+}
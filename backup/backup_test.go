@@ -1,12 +1,25 @@
 package backup
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"math/rand"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"testing/quick"
+	"time"
+
+	"kiro-manager/awssso"
+	"kiro-manager/machineid"
+	"kiro-manager/oauthlogin"
+	"kiro-manager/softreset"
+	"kiro-manager/tokenrefresh"
 )
 
 // generateRandomString 生成指定長度的隨機字串
@@ -670,6 +683,50 @@ func TestValidateSnapshotName_IllegalCharacters(t *testing.T) {
 	}
 }
 
+// TestValidateSnapshotNameFormat_ReservedAndLength 測試保留名稱、結尾字元與長度限制
+func TestValidateSnapshotNameFormat_ReservedAndLength(t *testing.T) {
+	testCases := []struct {
+		name    string
+		input   string
+		wantErr string // 錯誤訊息應包含的片段，空字串表示有效
+	}{
+		{"original", "original", "reserved for the original backup"},
+		{"original mixed case", "Original", "reserved for the original backup"},
+		{"device name", "CON", "reserved device name"},
+		{"device name lower case", "nul", "reserved device name"},
+		{"device name with extension", "com1.txt", "reserved device name"},
+		{"printer port", "LPT9", "reserved device name"},
+		{"trailing dot", "backup.", "must not end with a dot or space"},
+		{"trailing space", "backup ", "must not end with a dot or space"},
+		{"too long", strings.Repeat("a", MaxSnapshotNameLength+1), "characters long"},
+		{"too long multibyte", strings.Repeat("備", MaxSnapshotNameLength+1), "characters long"},
+		{"illegal character", "a|b", "illegal character"},
+		{"max length", strings.Repeat("備", MaxSnapshotNameLength), ""},
+		{"device prefix", "CONSOLE", ""},
+		{"com without digit", "COM", ""},
+		{"original prefix", "original-2", ""},
+		{"inner dot", "backup.name", ""},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := validateSnapshotNameFormat(tc.input)
+			if tc.wantErr == "" {
+				if err != nil {
+					t.Errorf("expected %q to be valid, got %v", tc.input, err)
+				}
+				return
+			}
+			if !errors.Is(err, ErrInvalidBackupName) {
+				t.Fatalf("expected ErrInvalidBackupName for %q, got %v", tc.input, err)
+			}
+			if !strings.Contains(err.Error(), tc.wantErr) {
+				t.Errorf("expected error for %q to contain %q, got %q", tc.input, tc.wantErr, err.Error())
+			}
+		})
+	}
+}
+
 // ============================================================================
 // Helper functions for testing (to be implemented in backup.go)
 // ============================================================================
@@ -1094,3 +1151,1240 @@ func TestWriteBackupToken_SocialTokenUnaffected(t *testing.T) {
 		t.Errorf("profileArn changed: got %v", updatedToken["profileArn"])
 	}
 }
+
+// ============================================================================
+// RefreshAndWriteBackup 測試
+// ============================================================================
+
+// setupTestBackupFiles 在備份根目錄建立測試用備份
+// files 為檔名 -> 內容（會以 JSON 序列化寫入）
+func setupTestBackupFiles(t *testing.T, name string, files map[string]interface{}) {
+	t.Helper()
+
+	backupPath, err := GetBackupPath(name)
+	if err != nil {
+		t.Fatalf("GetBackupPath failed: %v", err)
+	}
+	if err := os.MkdirAll(backupPath, 0755); err != nil {
+		t.Fatalf("Failed to create backup dir: %v", err)
+	}
+	t.Cleanup(func() { os.RemoveAll(backupPath) })
+
+	for fileName, content := range files {
+		data, _ := json.MarshalIndent(content, "", "  ")
+		if err := os.WriteFile(filepath.Join(backupPath, fileName), data, 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", fileName, err)
+		}
+	}
+}
+
+// mockRefreshAccessToken 替換 refreshAccessToken 並在測試結束時還原
+func mockRefreshAccessToken(t *testing.T, fn func(ctx context.Context, token *awssso.KiroAuthToken, machineId, clientID, clientSecret string) (*tokenrefresh.TokenInfo, error)) {
+	t.Helper()
+	original := refreshAccessToken
+	refreshAccessToken = fn
+	t.Cleanup(func() { refreshAccessToken = original })
+}
+
+// TestRefreshAndWriteBackup_Social 測試 Social 快照刷新並寫回
+func TestRefreshAndWriteBackup_Social(t *testing.T) {
+	name := "test_refresh_social_backup"
+	rawMachineID := "11111111-2222-3333-4444-555555555555"
+	setupTestBackupFiles(t, name, map[string]interface{}{
+		KiroAuthTokenFile: map[string]interface{}{
+			"accessToken":  "old-access-token",
+			"refreshToken": "social-refresh-token",
+			"profileArn":   "arn:aws:kiro::123456789012:profile/social",
+			"expiresAt":    "2025-01-01T00:00:00.000Z",
+			"authMethod":   "social",
+			"provider":     "Github",
+		},
+		MachineIDFileName: MachineIDBackup{MachineID: rawMachineID, BackupTime: time.Now().Format(time.RFC3339)},
+	})
+
+	expiresAt := time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)
+	var gotMachineID, gotClientID, gotClientSecret, gotRefreshToken string
+	mockRefreshAccessToken(t, func(ctx context.Context, token *awssso.KiroAuthToken, machineId, clientID, clientSecret string) (*tokenrefresh.TokenInfo, error) {
+		gotRefreshToken = token.RefreshToken
+		gotMachineID = machineId
+		gotClientID = clientID
+		gotClientSecret = clientSecret
+		return &tokenrefresh.TokenInfo{AccessToken: "new-social-access-token", ExpiresAt: expiresAt, ExpiresIn: 3600}, nil
+	})
+
+	info, err := RefreshAndWriteBackup(name)
+	if err != nil {
+		t.Fatalf("RefreshAndWriteBackup failed: %v", err)
+	}
+	if info.AccessToken != "new-social-access-token" {
+		t.Errorf("Expected new access token, got %q", info.AccessToken)
+	}
+
+	if gotRefreshToken != "social-refresh-token" {
+		t.Errorf("Expected refresh token 'social-refresh-token', got %q", gotRefreshToken)
+	}
+	if gotMachineID != machineid.HashMachineID(rawMachineID) {
+		t.Errorf("Expected hashed machine id, got %q", gotMachineID)
+	}
+	if gotClientID != "" || gotClientSecret != "" {
+		t.Errorf("Social refresh should not receive IdC credentials, got %q/%q", gotClientID, gotClientSecret)
+	}
+
+	token, err := ReadBackupToken(name)
+	if err != nil {
+		t.Fatalf("ReadBackupToken failed: %v", err)
+	}
+	if token.AccessToken != "new-social-access-token" {
+		t.Errorf("accessToken not persisted: got %q", token.AccessToken)
+	}
+	if token.ExpiresAt != "2030-01-02T03:04:05.000Z" {
+		t.Errorf("expiresAt not persisted: got %q", token.ExpiresAt)
+	}
+	if token.RefreshToken != "social-refresh-token" || token.Provider != "Github" {
+		t.Errorf("Original fields changed: %+v", token)
+	}
+}
+
+// TestRefreshAndWriteBackup_IdC 測試 IdC 快照使用備份目錄中的憑證刷新
+func TestRefreshAndWriteBackup_IdC(t *testing.T) {
+	name := "test_refresh_idc_backup"
+	clientIdHash := "abcdef0123456789"
+	setupTestBackupFiles(t, name, map[string]interface{}{
+		KiroAuthTokenFile: map[string]interface{}{
+			"accessToken":  "old-idc-access-token",
+			"refreshToken": "idc-refresh-token",
+			"expiresAt":    "2025-01-01T00:00:00.000Z",
+			"authMethod":   "IdC",
+			"provider":     "BuilderId",
+			"clientIdHash": clientIdHash,
+			"region":       "us-east-1",
+		},
+		MachineIDFileName:     MachineIDBackup{MachineID: "idc-machine-id"},
+		clientIdHash + ".json": IdCCreds{ClientId: "backup-client-id", ClientSecret: "backup-client-secret"},
+	})
+
+	var gotClientID, gotClientSecret string
+	mockRefreshAccessToken(t, func(ctx context.Context, token *awssso.KiroAuthToken, machineId, clientID, clientSecret string) (*tokenrefresh.TokenInfo, error) {
+		gotClientID = clientID
+		gotClientSecret = clientSecret
+		return &tokenrefresh.TokenInfo{AccessToken: "new-idc-access-token", ExpiresAt: time.Now().Add(time.Hour), TokenType: "Bearer"}, nil
+	})
+
+	if _, err := RefreshAndWriteBackup(name); err != nil {
+		t.Fatalf("RefreshAndWriteBackup failed: %v", err)
+	}
+
+	if gotClientID != "backup-client-id" || gotClientSecret != "backup-client-secret" {
+		t.Errorf("Expected backup IdC credentials, got %q/%q", gotClientID, gotClientSecret)
+	}
+
+	token, err := ReadBackupToken(name)
+	if err != nil {
+		t.Fatalf("ReadBackupToken failed: %v", err)
+	}
+	if token.AccessToken != "new-idc-access-token" {
+		t.Errorf("accessToken not persisted: got %q", token.AccessToken)
+	}
+	if token.ClientIdHash != clientIdHash || token.Region != "us-east-1" {
+		t.Errorf("IdC fields changed: %+v", token)
+	}
+}
+
+// TestRefreshAndWriteBackup_RefreshErrorUnchanged 測試刷新錯誤原樣返回且不寫入
+func TestRefreshAndWriteBackup_RefreshErrorUnchanged(t *testing.T) {
+	name := "test_refresh_error_backup"
+	setupTestBackupFiles(t, name, map[string]interface{}{
+		KiroAuthTokenFile: map[string]interface{}{
+			"accessToken":  "old-access-token",
+			"refreshToken": "social-refresh-token",
+			"expiresAt":    "2025-01-01T00:00:00.000Z",
+			"authMethod":   "social",
+		},
+		MachineIDFileName: MachineIDBackup{MachineID: "error-machine-id"},
+	})
+
+	refreshErr := tokenrefresh.MapHTTPError(401, "")
+	mockRefreshAccessToken(t, func(ctx context.Context, token *awssso.KiroAuthToken, machineId, clientID, clientSecret string) (*tokenrefresh.TokenInfo, error) {
+		return nil, refreshErr
+	})
+
+	_, err := RefreshAndWriteBackup(name)
+	if err != refreshErr {
+		t.Fatalf("Expected RefreshError to be returned unchanged, got %v", err)
+	}
+
+	token, _ := ReadBackupToken(name)
+	if token.AccessToken != "old-access-token" {
+		t.Errorf("Token should not be written on refresh failure, got %q", token.AccessToken)
+	}
+}
+
+// TestCheckBackupRefresh_DoesNotWrite 測試試刷新返回新 Token 但不寫回備份
+func TestCheckBackupRefresh_DoesNotWrite(t *testing.T) {
+	name := "test_check_refresh_backup"
+	clientIdHash := "0123456789abcdef"
+	setupTestBackupFiles(t, name, map[string]interface{}{
+		KiroAuthTokenFile: map[string]interface{}{
+			"accessToken":  "old-idc-access-token",
+			"refreshToken": "idc-refresh-token",
+			"expiresAt":    "2025-01-01T00:00:00.000Z",
+			"authMethod":   "IdC",
+			"provider":     "BuilderId",
+			"clientIdHash": clientIdHash,
+		},
+		MachineIDFileName:     MachineIDBackup{MachineID: "check-machine-id"},
+		clientIdHash + ".json": IdCCreds{ClientId: "backup-client-id", ClientSecret: "backup-client-secret"},
+	})
+
+	var gotClientID string
+	mockRefreshAccessToken(t, func(ctx context.Context, token *awssso.KiroAuthToken, machineId, clientID, clientSecret string) (*tokenrefresh.TokenInfo, error) {
+		gotClientID = clientID
+		return &tokenrefresh.TokenInfo{AccessToken: "new-idc-access-token", ExpiresAt: time.Now().Add(time.Hour)}, nil
+	})
+
+	info, err := CheckBackupRefresh(name)
+	if err != nil {
+		t.Fatalf("CheckBackupRefresh failed: %v", err)
+	}
+	if info.AccessToken != "new-idc-access-token" {
+		t.Errorf("Expected new access token, got %q", info.AccessToken)
+	}
+	if gotClientID != "backup-client-id" {
+		t.Errorf("Expected backup IdC credentials, got %q", gotClientID)
+	}
+
+	token, _ := ReadBackupToken(name)
+	if token.AccessToken != "old-idc-access-token" || token.ExpiresAt != "2025-01-01T00:00:00.000Z" {
+		t.Errorf("CheckBackupRefresh should not write the backup token, got %+v", token)
+	}
+}
+
+// TestCheckBackupRefresh_TokenExpired 測試 401 以 ErrTokenExpired 返回
+func TestCheckBackupRefresh_TokenExpired(t *testing.T) {
+	name := "test_check_refresh_expired_backup"
+	setupTestBackupFiles(t, name, map[string]interface{}{
+		KiroAuthTokenFile: map[string]interface{}{
+			"accessToken":  "old-access-token",
+			"refreshToken": "revoked-refresh-token",
+			"authMethod":   "social",
+		},
+		MachineIDFileName: MachineIDBackup{MachineID: "check-machine-id"},
+	})
+
+	mockRefreshAccessToken(t, func(ctx context.Context, token *awssso.KiroAuthToken, machineId, clientID, clientSecret string) (*tokenrefresh.TokenInfo, error) {
+		return nil, tokenrefresh.MapHTTPError(401, "")
+	})
+
+	if _, err := CheckBackupRefresh(name); !errors.Is(err, tokenrefresh.ErrTokenExpired) {
+		t.Errorf("Expected ErrTokenExpired, got %v", err)
+	}
+}
+
+// TestCleanupOrphanBackupFiles 測試只刪除舊的 clientIdHash 檔案，保留固定檔案與目前使用的憑證
+func TestCleanupOrphanBackupFiles(t *testing.T) {
+	name := "test_cleanup_orphan_backup"
+	currentHash := "current0123456789"
+	staleHash := "stale0123456789"
+	setupTestBackupFiles(t, name, map[string]interface{}{
+		KiroAuthTokenFile: map[string]interface{}{
+			"accessToken":  "access-token",
+			"refreshToken": "refresh-token",
+			"authMethod":   "IdC",
+			"clientIdHash": currentHash,
+		},
+		MachineIDFileName:     MachineIDBackup{MachineID: "cleanup-machine-id"},
+		UsageCacheFileName:    UsageCache{Balance: 10},
+		MetaFileName:          BackupMeta{Note: "keep"},
+		currentHash + ".json": IdCCreds{ClientId: "current-client", ClientSecret: "current-secret"},
+		staleHash + ".json":   IdCCreds{ClientId: "stale-client", ClientSecret: "stale-secret"},
+	})
+	backupPath, _ := GetBackupPath(name)
+	// 非 JSON 檔案不受影響
+	os.WriteFile(filepath.Join(backupPath, "notes.txt"), []byte("keep"), 0644)
+
+	removed, err := CleanupOrphanBackupFiles(name)
+	if err != nil {
+		t.Fatalf("CleanupOrphanBackupFiles failed: %v", err)
+	}
+	if len(removed) != 1 || removed[0] != staleHash+".json" {
+		t.Errorf("removed = %v, want [%s.json]", removed, staleHash)
+	}
+
+	for _, fileName := range []string{KiroAuthTokenFile, MachineIDFileName, UsageCacheFileName, MetaFileName, currentHash + ".json", "notes.txt"} {
+		if _, err := os.Stat(filepath.Join(backupPath, fileName)); err != nil {
+			t.Errorf("%s should be kept: %v", fileName, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(backupPath, staleHash+".json")); !os.IsNotExist(err) {
+		t.Errorf("stale clientIdHash file should be removed, stat err = %v", err)
+	}
+}
+
+// TestCleanupOrphanBackupFiles_NoToken 測試讀不到 token 時不刪除任何檔案
+func TestCleanupOrphanBackupFiles_NoToken(t *testing.T) {
+	name := "test_cleanup_orphan_no_token"
+	setupTestBackupFiles(t, name, map[string]interface{}{
+		MachineIDFileName:   MachineIDBackup{MachineID: "cleanup-machine-id"},
+		"stale0123456.json": IdCCreds{ClientId: "stale-client"},
+	})
+
+	if _, err := CleanupOrphanBackupFiles(name); err == nil {
+		t.Error("expected error when the backup has no token")
+	}
+	backupPath, _ := GetBackupPath(name)
+	if _, err := os.Stat(filepath.Join(backupPath, "stale0123456.json")); err != nil {
+		t.Errorf("files should be kept when the token is missing: %v", err)
+	}
+}
+
+// TestRefreshAndWriteBackup_BackupNotFound 測試備份不存在
+func TestRefreshAndWriteBackup_BackupNotFound(t *testing.T) {
+	_, err := RefreshAndWriteBackup("non_existent_backup_xyz123")
+	if err != ErrBackupNotFound {
+		t.Errorf("Expected ErrBackupNotFound, got %v", err)
+	}
+}
+
+// ============================================================================
+// RenameBackup 測試
+// ============================================================================
+
+// TestRenameBackup_PreservesFolderAssignment 測試重新命名後保留文件夾歸屬
+func TestRenameBackup_PreservesFolderAssignment(t *testing.T) {
+	foldersPath, _ := GetFoldersPath()
+	os.Remove(foldersPath)
+	defer os.Remove(foldersPath)
+
+	oldName := "test_rename_old"
+	newName := "test_rename_new"
+	setupTestBackupFiles(t, oldName, map[string]interface{}{
+		KiroAuthTokenFile: map[string]interface{}{"accessToken": "token", "refreshToken": "refresh"},
+		MachineIDFileName: MachineIDBackup{MachineID: "rename-machine-id"},
+	})
+	newPath, _ := GetBackupPath(newName)
+	t.Cleanup(func() { os.RemoveAll(newPath) })
+
+	folder, err := CreateFolder("重新命名測試")
+	if err != nil {
+		t.Fatalf("CreateFolder failed: %v", err)
+	}
+	if err := AssignSnapshotToFolder(oldName, folder.ID); err != nil {
+		t.Fatalf("AssignSnapshotToFolder failed: %v", err)
+	}
+
+	if err := RenameBackup(oldName, newName); err != nil {
+		t.Fatalf("RenameBackup failed: %v", err)
+	}
+
+	if BackupExists(oldName) {
+		t.Error("old backup should no longer exist")
+	}
+	if !BackupExists(newName) {
+		t.Fatal("new backup should exist")
+	}
+
+	mid, err := ReadBackupMachineID(newName)
+	if err != nil || mid.MachineID != "rename-machine-id" {
+		t.Errorf("backup content not preserved: %v, %+v", err, mid)
+	}
+
+	data, _ := LoadFolders()
+	if _, exists := data.Assignments[oldName]; exists {
+		t.Error("old assignment should be removed")
+	}
+	if data.Assignments[newName] != folder.ID {
+		t.Errorf("expected new name assigned to folder %s, got %q", folder.ID, data.Assignments[newName])
+	}
+}
+
+// TestRenameBackup_Unassigned 測試未分類快照重新命名後仍為未分類
+func TestRenameBackup_Unassigned(t *testing.T) {
+	foldersPath, _ := GetFoldersPath()
+	os.Remove(foldersPath)
+	defer os.Remove(foldersPath)
+
+	setupTestBackupFiles(t, "test_rename_unassigned_old", map[string]interface{}{
+		MachineIDFileName: MachineIDBackup{MachineID: "id"},
+	})
+	newPath, _ := GetBackupPath("test_rename_unassigned_new")
+	t.Cleanup(func() { os.RemoveAll(newPath) })
+
+	if err := RenameBackup("test_rename_unassigned_old", "test_rename_unassigned_new"); err != nil {
+		t.Fatalf("RenameBackup failed: %v", err)
+	}
+
+	folderId, _ := GetSnapshotFolderId("test_rename_unassigned_new")
+	if folderId != "" {
+		t.Errorf("expected unassigned snapshot, got folder %q", folderId)
+	}
+}
+
+// TestRenameBackup_NotFound 測試來源備份不存在
+func TestRenameBackup_NotFound(t *testing.T) {
+	err := RenameBackup("non_existent_backup_xyz123", "test_rename_target")
+	if err != ErrBackupNotFound {
+		t.Errorf("Expected ErrBackupNotFound, got %v", err)
+	}
+}
+
+// TestRenameBackup_TargetExists 測試目標名稱已存在
+func TestRenameBackup_TargetExists(t *testing.T) {
+	setupTestBackupFiles(t, "test_rename_src", map[string]interface{}{})
+	setupTestBackupFiles(t, "test_rename_dst", map[string]interface{}{})
+
+	err := RenameBackup("test_rename_src", "test_rename_dst")
+	if err != ErrBackupExists {
+		t.Errorf("Expected ErrBackupExists, got %v", err)
+	}
+	if !BackupExists("test_rename_src") {
+		t.Error("source backup should be untouched")
+	}
+}
+
+// TestRenameBackup_InvalidNewName 測試新名稱包含非法字元
+func TestRenameBackup_InvalidNewName(t *testing.T) {
+	setupTestBackupFiles(t, "test_rename_invalid", map[string]interface{}{})
+
+	err := RenameBackup("test_rename_invalid", "bad/name")
+	if !errors.Is(err, ErrInvalidBackupName) {
+		t.Errorf("Expected ErrInvalidBackupName, got %v", err)
+	}
+}
+
+// TestRenameBackup_Original 測試拒絕重新命名原始備份
+func TestRenameBackup_Original(t *testing.T) {
+	if err := RenameBackup(OriginalBackupName, "test_rename_original"); err != ErrOriginalBackup {
+		t.Errorf("Expected ErrOriginalBackup, got %v", err)
+	}
+	setupTestBackupFiles(t, "test_rename_to_original", map[string]interface{}{})
+	if err := RenameBackup("test_rename_to_original", OriginalBackupName); err != ErrOriginalBackup {
+		t.Errorf("Expected ErrOriginalBackup, got %v", err)
+	}
+}
+
+// ============================================================================
+// DuplicateBackup 測試
+// ============================================================================
+
+// TestDuplicateBackup_CopiesAllFiles 測試複製備份包含所有檔案
+func TestDuplicateBackup_CopiesAllFiles(t *testing.T) {
+	clientIdHash := "dup0123456789abcdef"
+	setupTestBackupFiles(t, "test_dup_src", map[string]interface{}{
+		KiroAuthTokenFile: map[string]interface{}{
+			"accessToken":  "dup-token",
+			"refreshToken": "dup-refresh",
+			"authMethod":   "IdC",
+			"clientIdHash": clientIdHash,
+		},
+		MachineIDFileName:      MachineIDBackup{MachineID: "dup-machine-id"},
+		UsageCacheFileName:     UsageCache{SubscriptionTitle: "KIRO PRO", Balance: 100},
+		clientIdHash + ".json": IdCCreds{ClientId: "dup-client", ClientSecret: "dup-secret"},
+	})
+	dstPath, _ := GetBackupPath("test_dup_dst")
+	t.Cleanup(func() { os.RemoveAll(dstPath) })
+
+	if err := DuplicateBackup("test_dup_src", "test_dup_dst"); err != nil {
+		t.Fatalf("DuplicateBackup failed: %v", err)
+	}
+
+	srcPath, _ := GetBackupPath("test_dup_src")
+	for _, fileName := range []string{KiroAuthTokenFile, MachineIDFileName, UsageCacheFileName, clientIdHash + ".json"} {
+		srcData, _ := os.ReadFile(filepath.Join(srcPath, fileName))
+		dstData, err := os.ReadFile(filepath.Join(dstPath, fileName))
+		if err != nil {
+			t.Errorf("%s not copied: %v", fileName, err)
+			continue
+		}
+		if string(srcData) != string(dstData) {
+			t.Errorf("%s content mismatch", fileName)
+		}
+	}
+
+	clientID, clientSecret, err := ReadBackupIdCCredentials("test_dup_dst", clientIdHash)
+	if err != nil || clientID != "dup-client" || clientSecret != "dup-secret" {
+		t.Errorf("IdC credentials not duplicated: %v, %q/%q", err, clientID, clientSecret)
+	}
+}
+
+// TestDuplicateBackup_FolderAssignment 測試文件夾歸屬僅在指定時繼承
+func TestDuplicateBackup_FolderAssignment(t *testing.T) {
+	foldersPath, _ := GetFoldersPath()
+	os.Remove(foldersPath)
+	defer os.Remove(foldersPath)
+
+	setupTestBackupFiles(t, "test_dup_folder_src", map[string]interface{}{
+		MachineIDFileName: MachineIDBackup{MachineID: "id"},
+	})
+	for _, name := range []string{"test_dup_folder_plain", "test_dup_folder_inherit"} {
+		path, _ := GetBackupPath(name)
+		t.Cleanup(func() { os.RemoveAll(path) })
+	}
+
+	folder, _ := CreateFolder("複製測試")
+	AssignSnapshotToFolder("test_dup_folder_src", folder.ID)
+
+	if err := DuplicateBackup("test_dup_folder_src", "test_dup_folder_plain"); err != nil {
+		t.Fatalf("DuplicateBackup failed: %v", err)
+	}
+	if folderId, _ := GetSnapshotFolderId("test_dup_folder_plain"); folderId != "" {
+		t.Errorf("duplicate should not inherit folder by default, got %q", folderId)
+	}
+
+	if err := DuplicateBackupWithFolder("test_dup_folder_src", "test_dup_folder_inherit", true); err != nil {
+		t.Fatalf("DuplicateBackupWithFolder failed: %v", err)
+	}
+	if folderId, _ := GetSnapshotFolderId("test_dup_folder_inherit"); folderId != folder.ID {
+		t.Errorf("expected inherited folder %s, got %q", folder.ID, folderId)
+	}
+}
+
+// TestDuplicateBackup_Errors 測試錯誤情況
+func TestDuplicateBackup_Errors(t *testing.T) {
+	if err := DuplicateBackup("non_existent_backup_xyz123", "test_dup_target"); err != ErrBackupNotFound {
+		t.Errorf("Expected ErrBackupNotFound, got %v", err)
+	}
+
+	setupTestBackupFiles(t, "test_dup_err_src", map[string]interface{}{})
+	setupTestBackupFiles(t, "test_dup_err_dst", map[string]interface{}{})
+
+	if err := DuplicateBackup("test_dup_err_src", "test_dup_err_dst"); err != ErrBackupExists {
+		t.Errorf("Expected ErrBackupExists, got %v", err)
+	}
+	if err := DuplicateBackup("test_dup_err_src", "bad:name"); !errors.Is(err, ErrInvalidBackupName) {
+		t.Errorf("Expected ErrInvalidBackupName, got %v", err)
+	}
+}
+
+// TestDuplicateBackup_CleanupOnFailure 測試複製失敗時清理新資料夾
+func TestDuplicateBackup_CleanupOnFailure(t *testing.T) {
+	setupTestBackupFiles(t, "test_dup_broken_src", map[string]interface{}{
+		KiroAuthTokenFile: map[string]interface{}{"accessToken": "token"},
+	})
+	srcPath, _ := GetBackupPath("test_dup_broken_src")
+
+	// 建立指向不存在檔案的符號連結，使複製失敗
+	if err := os.Symlink(filepath.Join(srcPath, "missing"), filepath.Join(srcPath, "zz-broken.json")); err != nil {
+		t.Skipf("symlink not supported: %v", err)
+	}
+
+	err := DuplicateBackup("test_dup_broken_src", "test_dup_broken_dst")
+	if err == nil {
+		dstPath, _ := GetBackupPath("test_dup_broken_dst")
+		os.RemoveAll(dstPath)
+		t.Fatal("Expected copy failure")
+	}
+	if BackupExists("test_dup_broken_dst") {
+		t.Error("partial duplicate directory should be removed")
+	}
+}
+
+// ============================================================================
+// PruneBackups 測試
+// ============================================================================
+
+// TestPruneBackups_KeepsNewest 測試只保留最新的 N 個備份並保護原始備份
+func TestPruneBackups_KeepsNewest(t *testing.T) {
+	foldersPath, _ := GetFoldersPath()
+	os.Remove(foldersPath)
+	defer os.Remove(foldersPath)
+
+	now := time.Now()
+	// original 備份時間最舊，但永遠保留
+	setupTestBackupFiles(t, OriginalBackupName, map[string]interface{}{
+		MachineIDFileName: MachineIDBackup{MachineID: "original-id", BackupTime: now.Add(-100 * time.Hour).Format(time.RFC3339)},
+	})
+	ages := map[string]time.Duration{
+		"test_prune_newest": 1 * time.Hour,
+		"test_prune_middle": 2 * time.Hour,
+		"test_prune_old":    3 * time.Hour,
+		"test_prune_oldest": 4 * time.Hour,
+	}
+	for name, age := range ages {
+		setupTestBackupFiles(t, name, map[string]interface{}{
+			MachineIDFileName: MachineIDBackup{MachineID: name, BackupTime: now.Add(-age).Format(time.RFC3339)},
+		})
+	}
+
+	folder, _ := CreateFolder("清理測試")
+	AssignSnapshotToFolder("test_prune_oldest", folder.ID)
+
+	deleted, err := PruneBackups(PrunePolicy{MaxCount: 2, IncludeFoldered: true})
+	if err != nil {
+		t.Fatalf("PruneBackups failed: %v", err)
+	}
+
+	if len(deleted) != 2 || deleted[0] != "test_prune_old" || deleted[1] != "test_prune_oldest" {
+		t.Errorf("unexpected deleted list: %v", deleted)
+	}
+	for _, name := range []string{OriginalBackupName, "test_prune_newest", "test_prune_middle"} {
+		if !BackupExists(name) {
+			t.Errorf("%s should be kept", name)
+		}
+	}
+	for _, name := range []string{"test_prune_old", "test_prune_oldest"} {
+		if BackupExists(name) {
+			t.Errorf("%s should be deleted", name)
+		}
+	}
+
+	if folderId, _ := GetSnapshotFolderId("test_prune_oldest"); folderId != "" {
+		t.Errorf("pruned snapshot assignment should be removed, got %q", folderId)
+	}
+}
+
+// TestPruneBackups_NothingToPrune 測試備份數量未超過上限時不刪除
+func TestPruneBackups_NothingToPrune(t *testing.T) {
+	setupTestBackupFiles(t, "test_prune_single", map[string]interface{}{
+		MachineIDFileName: MachineIDBackup{MachineID: "single", BackupTime: time.Now().Format(time.RFC3339)},
+	})
+
+	deleted, err := PruneBackups(PrunePolicy{MaxCount: 5})
+	if err != nil {
+		t.Fatalf("PruneBackups failed: %v", err)
+	}
+	if len(deleted) != 0 {
+		t.Errorf("expected nothing deleted, got %v", deleted)
+	}
+	if !BackupExists("test_prune_single") {
+		t.Error("backup should be kept")
+	}
+}
+
+// TestPruneBackups_MaxAge 測試刪除超過保留期限的備份，無備份時間者保留
+func TestPruneBackups_MaxAge(t *testing.T) {
+	now := time.Now()
+	for name, age := range map[string]time.Duration{
+		"test_prune_age_fresh": 1 * time.Hour,
+		"test_prune_age_stale": 5 * time.Hour,
+		"test_prune_age_old":   10 * time.Hour,
+	} {
+		setupTestBackupFiles(t, name, map[string]interface{}{
+			MachineIDFileName: MachineIDBackup{MachineID: name, BackupTime: now.Add(-age).Format(time.RFC3339)},
+		})
+	}
+	setupTestBackupFiles(t, "test_prune_age_unknown", map[string]interface{}{
+		MachineIDFileName: MachineIDBackup{MachineID: "unknown"},
+	})
+
+	deleted, err := PruneBackups(PrunePolicy{MaxAge: 3 * time.Hour})
+	if err != nil {
+		t.Fatalf("PruneBackups failed: %v", err)
+	}
+	if len(deleted) != 2 || deleted[0] != "test_prune_age_stale" || deleted[1] != "test_prune_age_old" {
+		t.Errorf("unexpected deleted list: %v", deleted)
+	}
+	for _, name := range []string{"test_prune_age_fresh", "test_prune_age_unknown"} {
+		if !BackupExists(name) {
+			t.Errorf("%s should be kept", name)
+		}
+	}
+}
+
+// TestPruneBackups_ProtectsOriginalAndFoldered 測試原始備份與已分配文件夾的快照不會被清理
+func TestPruneBackups_ProtectsOriginalAndFoldered(t *testing.T) {
+	foldersPath, _ := GetFoldersPath()
+	os.Remove(foldersPath)
+	defer os.Remove(foldersPath)
+
+	old := time.Now().Add(-100 * time.Hour).Format(time.RFC3339)
+	for _, name := range []string{OriginalBackupName, "test_prune_foldered", "test_prune_unfoldered"} {
+		setupTestBackupFiles(t, name, map[string]interface{}{
+			MachineIDFileName: MachineIDBackup{MachineID: name, BackupTime: old},
+		})
+	}
+	folder, _ := CreateFolder("保護測試")
+	AssignSnapshotToFolder("test_prune_foldered", folder.ID)
+
+	deleted, err := PruneBackups(PrunePolicy{MaxAge: time.Hour})
+	if err != nil {
+		t.Fatalf("PruneBackups failed: %v", err)
+	}
+	if len(deleted) != 1 || deleted[0] != "test_prune_unfoldered" {
+		t.Errorf("unexpected deleted list: %v", deleted)
+	}
+	for _, name := range []string{OriginalBackupName, "test_prune_foldered"} {
+		if !BackupExists(name) {
+			t.Errorf("%s should be kept", name)
+		}
+	}
+	if folderId, _ := GetSnapshotFolderId("test_prune_foldered"); folderId != folder.ID {
+		t.Errorf("protected snapshot assignment should be kept, got %q", folderId)
+	}
+}
+
+// TestPruneBackups_NegativePolicy 測試負數的清理策略
+func TestPruneBackups_NegativePolicy(t *testing.T) {
+	if _, err := PruneBackups(PrunePolicy{MaxCount: -1}); err == nil {
+		t.Error("expected error for negative MaxCount")
+	}
+	if _, err := PruneBackups(PrunePolicy{MaxAge: -time.Hour}); err == nil {
+		t.Error("expected error for negative MaxAge")
+	}
+}
+
+// setupRestoreTestBackup 在臨時 HOME 下建立含 Token 與 Machine ID 的備份，返回目前 Token 路徑
+func setupRestoreTestBackup(t *testing.T, name string) string {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+
+	setupTestBackupFiles(t, name, map[string]interface{}{
+		KiroAuthTokenFile: map[string]string{"accessToken": "backup-token"},
+		MachineIDFileName: MachineIDBackup{MachineID: "backup-machine-id"},
+	})
+	tokenPath, err := awssso.GetKiroAuthTokenPath()
+	if err != nil {
+		t.Fatalf("GetKiroAuthTokenPath failed: %v", err)
+	}
+	return tokenPath
+}
+
+// TestRestoreBackupSelective_TokenOnly 測試只恢復 Token 時保留目前的 Machine ID
+func TestRestoreBackupSelective_TokenOnly(t *testing.T) {
+	tokenPath := setupRestoreTestBackup(t, "test_restore_token_only")
+
+	if err := RestoreBackupSelective("test_restore_token_only", RestoreOptions{RestoreToken: true}); err != nil {
+		t.Fatalf("RestoreBackupSelective failed: %v", err)
+	}
+	if data, err := os.ReadFile(tokenPath); err != nil || !strings.Contains(string(data), "backup-token") {
+		t.Errorf("token should be restored, got %q (%v)", data, err)
+	}
+	if _, err := softreset.ReadCustomMachineIDRaw(); !errors.Is(err, softreset.ErrCustomIDNotFound) {
+		t.Errorf("machine id should not be restored, ReadCustomMachineIDRaw error = %v", err)
+	}
+}
+
+// TestRestoreBackupSelective_MachineIDOnly 測試只恢復 Machine ID 時不覆寫目前的 Token
+func TestRestoreBackupSelective_MachineIDOnly(t *testing.T) {
+	tokenPath := setupRestoreTestBackup(t, "test_restore_machine_id_only")
+
+	if err := RestoreBackupSelective("test_restore_machine_id_only", RestoreOptions{RestoreMachineID: true}); err != nil {
+		t.Fatalf("RestoreBackupSelective failed: %v", err)
+	}
+	if _, err := os.Stat(tokenPath); !os.IsNotExist(err) {
+		t.Errorf("token should not be restored, Stat error = %v", err)
+	}
+	if raw, err := softreset.ReadCustomMachineIDRaw(); err != nil || raw != "backup-machine-id" {
+		t.Errorf("ReadCustomMachineIDRaw() = %q, %v, want backup-machine-id", raw, err)
+	}
+}
+
+// TestFindDuplicates 測試以 refreshToken 將同一帳號的備份分組，略過沒有 token 的備份
+func TestFindDuplicates(t *testing.T) {
+	for name, refreshToken := range map[string]string{
+		"test_dup_work_b": "shared-refresh-token",
+		"test_dup_work_a": "shared-refresh-token",
+		"test_dup_home":   "home-refresh-token",
+	} {
+		setupTestBackupFiles(t, name, map[string]interface{}{
+			KiroAuthTokenFile: awssso.KiroAuthToken{AccessToken: "a", RefreshToken: refreshToken},
+		})
+	}
+	setupTestBackupFiles(t, "test_dup_no_token", map[string]interface{}{
+		MachineIDFileName: MachineIDBackup{MachineID: "no-token"},
+	})
+
+	duplicates, err := FindDuplicates()
+	if err != nil {
+		t.Fatalf("FindDuplicates failed: %v", err)
+	}
+	if len(duplicates) != 1 {
+		t.Fatalf("expected a single duplicate group, got %v", duplicates)
+	}
+	for identity, names := range duplicates {
+		if identity == "shared-refresh-token" {
+			t.Error("identity should not expose the raw refresh token")
+		}
+		if len(names) != 2 || names[0] != "test_dup_work_a" || names[1] != "test_dup_work_b" {
+			t.Errorf("unexpected duplicate group: %v", names)
+		}
+	}
+}
+
+// TestFindDuplicateMachineIDs 測試依 Machine ID 將快照分組，原始備份不列入
+func TestFindDuplicateMachineIDs(t *testing.T) {
+	for name, machineID := range map[string]string{
+		OriginalBackupName:    "shared-machine-id",
+		"test_dup_mid_b":      "shared-machine-id",
+		"test_dup_mid_a":      "shared-machine-id",
+		"test_dup_mid_unique": "unique-machine-id",
+	} {
+		setupTestBackupFiles(t, name, map[string]interface{}{
+			MachineIDFileName: MachineIDBackup{MachineID: machineID},
+		})
+	}
+
+	duplicates, err := FindDuplicateMachineIDs()
+	if err != nil {
+		t.Fatalf("FindDuplicateMachineIDs failed: %v", err)
+	}
+	if len(duplicates) != 1 {
+		t.Fatalf("expected a single duplicate group, got %v", duplicates)
+	}
+	names := duplicates["shared-machine-id"]
+	if len(names) != 2 || names[0] != "test_dup_mid_a" || names[1] != "test_dup_mid_b" {
+		t.Errorf("unexpected duplicate group: %v", names)
+	}
+}
+
+// TestParseTokenExpiry 測試解析 RFC3339、毫秒 Z 格式與空值，無法解析時返回錯誤
+func TestParseTokenExpiry(t *testing.T) {
+	want := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	testCases := []struct {
+		expiresAt string
+		want      time.Time
+	}{
+		{"2026-01-02T03:04:05Z", want},
+		{"2026-01-02T11:04:05+08:00", want},
+		{"2026-01-02T03:04:05.000Z", want},
+		{"", time.Time{}},
+	}
+	for _, tc := range testCases {
+		got, err := ParseTokenExpiry(&awssso.KiroAuthToken{ExpiresAt: tc.expiresAt})
+		if err != nil {
+			t.Errorf("ParseTokenExpiry(%q) error = %v", tc.expiresAt, err)
+			continue
+		}
+		if !got.Equal(tc.want) {
+			t.Errorf("ParseTokenExpiry(%q) = %v, want %v", tc.expiresAt, got, tc.want)
+		}
+	}
+
+	if _, err := ParseTokenExpiry(&awssso.KiroAuthToken{ExpiresAt: "next tuesday"}); !errors.Is(err, awssso.ErrInvalidExpiresAt) {
+		t.Errorf("ParseTokenExpiry(unparseable) error = %v, want ErrInvalidExpiresAt", err)
+	}
+	if got, err := ParseTokenExpiry(nil); err != nil || !got.IsZero() {
+		t.Errorf("ParseTokenExpiry(nil) = %v, %v, want zero time", got, err)
+	}
+}
+
+// TestGetBackupInfo_TokenExpiresAt 測試備份資訊包含以毫秒格式寫入的過期時間
+func TestGetBackupInfo_TokenExpiresAt(t *testing.T) {
+	setupTestBackupFiles(t, "test_info_expiry", map[string]interface{}{
+		KiroAuthTokenFile: awssso.KiroAuthToken{AccessToken: "a", ExpiresAt: "2026-01-02T03:04:05.000Z"},
+	})
+
+	info, err := GetBackupInfo("test_info_expiry")
+	if err != nil {
+		t.Fatalf("GetBackupInfo failed: %v", err)
+	}
+	if want := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC); !info.TokenExpiresAt.Equal(want) {
+		t.Errorf("TokenExpiresAt = %v, want %v", info.TokenExpiresAt, want)
+	}
+}
+
+// setupCurrentAccount 在臨時 HOME 下建立目前登入的 token 與自訂 Machine ID，供 CreateBackup 使用
+func setupCurrentAccount(t *testing.T) {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+
+	tokenPath, err := awssso.GetKiroAuthTokenPath()
+	if err != nil {
+		t.Fatalf("GetKiroAuthTokenPath failed: %v", err)
+	}
+	os.MkdirAll(filepath.Dir(tokenPath), 0755)
+	if err := os.WriteFile(tokenPath, []byte(`{"accessToken":"a","refreshToken":"r","provider":"Github"}`), 0644); err != nil {
+		t.Fatalf("failed to write current token: %v", err)
+	}
+	if err := softreset.WriteCustomMachineIDRaw("current-machine-id"); err != nil {
+		t.Fatalf("WriteCustomMachineIDRaw failed: %v", err)
+	}
+}
+
+// TestCreateBackupWithUsage_WritesUsageCache 測試建立備份時一併寫入可解析的餘額緩存
+func TestCreateBackupWithUsage_WritesUsageCache(t *testing.T) {
+	setupCurrentAccount(t)
+	name := "test_create_with_usage"
+	t.Cleanup(func() { DeleteBackup(name) })
+
+	err := CreateBackupWithUsage(name, &UsageCache{SubscriptionTitle: "KIRO PRO", UsageLimit: 1000, CurrentUsage: 250, Balance: 750})
+	if err != nil {
+		t.Fatalf("CreateBackupWithUsage failed: %v", err)
+	}
+
+	cache, err := ReadUsageCache(name)
+	if err != nil {
+		t.Fatalf("ReadUsageCache failed: %v", err)
+	}
+	if cache == nil || cache.SubscriptionTitle != "KIRO PRO" || cache.Balance != 750 || cache.CachedAt.IsZero() {
+		t.Errorf("unexpected usage cache: %+v", cache)
+	}
+	if mid, err := ReadBackupMachineID(name); err != nil || mid.MachineID != "current-machine-id" {
+		t.Errorf("machine id should be backed up as usual, got %+v (%v)", mid, err)
+	}
+}
+
+// TestCreateBackupWithUsage_NilCache 測試 cache 為 nil 時不建立 usage-cache.json
+func TestCreateBackupWithUsage_NilCache(t *testing.T) {
+	setupCurrentAccount(t)
+	name := "test_create_without_usage"
+	t.Cleanup(func() { DeleteBackup(name) })
+
+	if err := CreateBackupWithUsage(name, nil); err != nil {
+		t.Fatalf("CreateBackupWithUsage failed: %v", err)
+	}
+
+	backupPath, _ := GetBackupPath(name)
+	if _, err := os.Stat(filepath.Join(backupPath, UsageCacheFileName)); !os.IsNotExist(err) {
+		t.Errorf("usage-cache.json should be absent, Stat error = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(backupPath, KiroAuthTokenFile)); err != nil {
+		t.Errorf("token should be backed up: %v", err)
+	}
+}
+
+// emptyTokenContents 空白 token 檔案的測試內容
+var emptyTokenContents = map[string]string{"zero-byte": "", "whitespace": "   "}
+
+// TestReadBackupToken_EmptyFile 測試空白 token 檔案返回 ErrEmptyTokenFile，且恢復時不會複製
+func TestReadBackupToken_EmptyFile(t *testing.T) {
+	for label, content := range emptyTokenContents {
+		t.Run(label, func(t *testing.T) {
+			tokenPath := setupRestoreTestBackup(t, "test_empty_token")
+			backupPath, _ := GetBackupPath("test_empty_token")
+			if err := os.WriteFile(filepath.Join(backupPath, KiroAuthTokenFile), []byte(content), 0644); err != nil {
+				t.Fatalf("failed to write token file: %v", err)
+			}
+
+			if _, err := ReadBackupToken("test_empty_token"); !errors.Is(err, ErrEmptyTokenFile) {
+				t.Errorf("ReadBackupToken() error = %v, want ErrEmptyTokenFile", err)
+			}
+			if err := RestoreBackup("test_empty_token"); !errors.Is(err, ErrEmptyTokenFile) {
+				t.Errorf("RestoreBackup() error = %v, want ErrEmptyTokenFile", err)
+			}
+			if _, err := os.Stat(tokenPath); !os.IsNotExist(err) {
+				t.Error("empty token should not be restored")
+			}
+		})
+	}
+}
+
+// TestCreateBackup_EmptySourceToken 測試目前的 token 檔案為空白時拒絕建立備份
+func TestCreateBackup_EmptySourceToken(t *testing.T) {
+	for label, content := range emptyTokenContents {
+		t.Run(label, func(t *testing.T) {
+			setupCurrentAccount(t)
+			tokenPath, _ := awssso.GetKiroAuthTokenPath()
+			if err := os.WriteFile(tokenPath, []byte(content), 0644); err != nil {
+				t.Fatalf("failed to write current token: %v", err)
+			}
+
+			name := "test_create_empty_token"
+			t.Cleanup(func() { DeleteBackup(name) })
+			if err := CreateBackup(name); !errors.Is(err, ErrEmptyTokenFile) {
+				t.Errorf("CreateBackup() error = %v, want ErrEmptyTokenFile", err)
+			}
+			if BackupExists(name) {
+				t.Error("backup directory should be removed after refusing an empty token")
+			}
+		})
+	}
+}
+
+// TestBackupsDiskUsageAt 測試依備份統計檔案大小，並略過根目錄的檔案
+func TestBackupsDiskUsageAt(t *testing.T) {
+	root := t.TempDir()
+	sizes := map[string][]int{
+		"work": {100, 250},
+		"home": {1024},
+	}
+	for name, files := range sizes {
+		dir := filepath.Join(root, name)
+		os.MkdirAll(dir, 0755)
+		for i, size := range files {
+			os.WriteFile(filepath.Join(dir, fmt.Sprintf("file%d.json", i)), make([]byte, size), 0644)
+		}
+	}
+	// 巢狀資料夾內的檔案也應計入
+	os.MkdirAll(filepath.Join(root, "home", "nested"), 0755)
+	os.WriteFile(filepath.Join(root, "home", "nested", "extra.json"), make([]byte, 10), 0644)
+	os.WriteFile(filepath.Join(root, FoldersFileName), make([]byte, 999), 0644)
+
+	total, perBackup, err := backupsDiskUsageAt(root)
+	if err != nil {
+		t.Fatalf("backupsDiskUsageAt() error = %v", err)
+	}
+	if total != 1384 {
+		t.Errorf("total = %d, want 1384", total)
+	}
+	if len(perBackup) != 2 || perBackup["work"] != 350 || perBackup["home"] != 1034 {
+		t.Errorf("unexpected perBackup: %v", perBackup)
+	}
+}
+
+// TestBackupsDiskUsageAt_MissingRoot 測試備份根目錄不存在時返回 0
+func TestBackupsDiskUsageAt_MissingRoot(t *testing.T) {
+	total, perBackup, err := backupsDiskUsageAt(filepath.Join(t.TempDir(), "missing"))
+	if err != nil || total != 0 || len(perBackup) != 0 {
+		t.Errorf("backupsDiskUsageAt(missing) = %d, %v, %v", total, perBackup, err)
+	}
+}
+
+// TestBackupMeta_WriteAndRead 測試寫入與讀取標籤、備註
+func TestBackupMeta_WriteAndRead(t *testing.T) {
+	setupTestBackupFiles(t, "test_meta", map[string]interface{}{
+		MachineIDFileName: MachineIDBackup{MachineID: "meta-id", BackupTime: time.Now().Format(time.RFC3339)},
+	})
+
+	meta := &BackupMeta{Tags: []string{"company card", "trial"}, Note: "trial expires March"}
+	if err := WriteBackupMeta("test_meta", meta); err != nil {
+		t.Fatalf("WriteBackupMeta failed: %v", err)
+	}
+
+	got, err := ReadBackupMeta("test_meta")
+	if err != nil {
+		t.Fatalf("ReadBackupMeta failed: %v", err)
+	}
+	if len(got.Tags) != 2 || got.Tags[0] != "company card" || got.Tags[1] != "trial" {
+		t.Errorf("unexpected tags: %v", got.Tags)
+	}
+	if got.Note != "trial expires March" {
+		t.Errorf("unexpected note: %q", got.Note)
+	}
+
+	backups, err := ListBackups()
+	if err != nil {
+		t.Fatalf("ListBackups failed: %v", err)
+	}
+	found := false
+	for _, b := range backups {
+		if b.Name == "test_meta" {
+			found = true
+			if len(b.Tags) != 2 || b.Note != "trial expires March" {
+				t.Errorf("ListBackups did not populate meta: %+v", b)
+			}
+		}
+	}
+	if !found {
+		t.Error("test_meta not found in ListBackups")
+	}
+
+	info, err := GetBackupInfo("test_meta")
+	if err != nil {
+		t.Fatalf("GetBackupInfo failed: %v", err)
+	}
+	if info.Note != "trial expires March" {
+		t.Errorf("GetBackupInfo did not populate note: %q", info.Note)
+	}
+}
+
+// TestReadBackupMeta_Missing 測試沒有 meta.json 時返回空的 meta
+func TestReadBackupMeta_Missing(t *testing.T) {
+	setupTestBackupFiles(t, "test_meta_missing", map[string]interface{}{
+		MachineIDFileName: MachineIDBackup{MachineID: "meta-id"},
+	})
+
+	meta, err := ReadBackupMeta("test_meta_missing")
+	if err != nil {
+		t.Fatalf("ReadBackupMeta failed: %v", err)
+	}
+	if meta == nil || len(meta.Tags) != 0 || meta.Note != "" {
+		t.Errorf("expected empty meta, got %+v", meta)
+	}
+}
+
+// TestBackupMeta_Errors 測試標籤、備註讀寫的錯誤情況
+func TestBackupMeta_Errors(t *testing.T) {
+	if _, err := ReadBackupMeta(""); err != ErrInvalidBackupName {
+		t.Errorf("expected ErrInvalidBackupName, got %v", err)
+	}
+	if _, err := ReadBackupMeta("test_meta_nonexistent"); err != ErrBackupNotFound {
+		t.Errorf("expected ErrBackupNotFound, got %v", err)
+	}
+	if err := WriteBackupMeta("test_meta_nonexistent", &BackupMeta{}); err != ErrBackupNotFound {
+		t.Errorf("expected ErrBackupNotFound, got %v", err)
+	}
+
+	setupTestBackupFiles(t, "test_meta_nil", nil)
+	if err := WriteBackupMeta("test_meta_nil", nil); err == nil {
+		t.Error("expected error for nil meta")
+	}
+}
+
+// TestRefreshAllBackups 測試批次刷新：部分成功、部分失敗，並跳過原始備份與沒有 token 的備份
+func TestRefreshAllBackups(t *testing.T) {
+	socialToken := func(refreshToken string) map[string]interface{} {
+		return map[string]interface{}{
+			"accessToken":  "old-access-token",
+			"refreshToken": refreshToken,
+			"expiresAt":    "2025-01-01T00:00:00.000Z",
+			"authMethod":   "social",
+		}
+	}
+	for name, refreshToken := range map[string]string{
+		OriginalBackupName: "original-refresh",
+		"test_batch_ok_1":  "ok-1",
+		"test_batch_ok_2":  "ok-2",
+		"test_batch_fail":  "fail",
+	} {
+		setupTestBackupFiles(t, name, map[string]interface{}{
+			KiroAuthTokenFile: socialToken(refreshToken),
+			MachineIDFileName: MachineIDBackup{MachineID: name},
+		})
+	}
+	setupTestBackupFiles(t, "test_batch_no_token", map[string]interface{}{
+		MachineIDFileName: MachineIDBackup{MachineID: "no-token"},
+	})
+
+	expiresAt := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	refreshErr := &tokenrefresh.RefreshError{Code: 401, Message: "Token 已失效，請重新登入 Kiro"}
+	var running, maxRunning int32
+	var calledOriginal int32
+	mockRefreshAccessToken(t, func(ctx context.Context, token *awssso.KiroAuthToken, machineId, clientID, clientSecret string) (*tokenrefresh.TokenInfo, error) {
+		n := atomic.AddInt32(&running, 1)
+		defer atomic.AddInt32(&running, -1)
+		for {
+			m := atomic.LoadInt32(&maxRunning)
+			if n <= m || atomic.CompareAndSwapInt32(&maxRunning, m, n) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+
+		switch token.RefreshToken {
+		case "original-refresh":
+			atomic.StoreInt32(&calledOriginal, 1)
+		case "fail":
+			return nil, refreshErr
+		}
+		return &tokenrefresh.TokenInfo{AccessToken: "new-" + token.RefreshToken, ExpiresAt: expiresAt}, nil
+	})
+
+	results, err := RefreshAllBackups(2)
+	if err != nil {
+		t.Fatalf("RefreshAllBackups failed: %v", err)
+	}
+
+	if atomic.LoadInt32(&calledOriginal) != 0 {
+		t.Error("original backup should not be refreshed")
+	}
+	if _, ok := results[OriginalBackupName]; ok {
+		t.Error("original backup should not appear in results")
+	}
+	if _, ok := results["test_batch_no_token"]; ok {
+		t.Error("backup without token should not appear in results")
+	}
+	for _, name := range []string{"test_batch_ok_1", "test_batch_ok_2"} {
+		outcome, ok := results[name]
+		if !ok {
+			t.Errorf("%s missing from results", name)
+			continue
+		}
+		if outcome.Err != nil || !outcome.ExpiresAt.Equal(expiresAt) {
+			t.Errorf("%s: unexpected outcome %+v", name, outcome)
+		}
+	}
+	if outcome, ok := results["test_batch_fail"]; !ok || outcome.Err != refreshErr {
+		t.Errorf("test_batch_fail: expected refresh error, got %+v", outcome)
+	}
+	if got := atomic.LoadInt32(&maxRunning); got > 2 {
+		t.Errorf("expected at most 2 concurrent refreshes, got %d", got)
+	}
+
+	if token, err := ReadBackupToken("test_batch_ok_1"); err != nil || token.AccessToken != "new-ok-1" {
+		t.Errorf("successful refresh should be persisted, got %+v (%v)", token, err)
+	}
+	if token, err := ReadBackupToken("test_batch_fail"); err != nil || token.AccessToken != "old-access-token" {
+		t.Errorf("failed refresh should not modify token, got %+v (%v)", token, err)
+	}
+}
+
+// TestRefreshExpiringBackups 測試只刷新即將過期或已過期的備份
+func TestRefreshExpiringBackups(t *testing.T) {
+	now := time.Now().UTC()
+	expiries := map[string]string{
+		"test_expiring_1h":      now.Add(time.Hour).Format("2006-01-02T15:04:05.000Z"),
+		"test_expiring_2d":      now.Add(48 * time.Hour).Format(time.RFC3339),
+		"test_expiring_expired": now.Add(-time.Hour).Format("2006-01-02T15:04:05.000Z"),
+	}
+	for name, expiresAt := range expiries {
+		setupTestBackupFiles(t, name, map[string]interface{}{
+			KiroAuthTokenFile: map[string]interface{}{
+				"accessToken":  "old-access-token",
+				"refreshToken": name,
+				"expiresAt":    expiresAt,
+				"authMethod":   "social",
+			},
+			MachineIDFileName: MachineIDBackup{MachineID: name},
+		})
+	}
+
+	var mu sync.Mutex
+	refreshed := make(map[string]bool)
+	mockRefreshAccessToken(t, func(ctx context.Context, token *awssso.KiroAuthToken, machineId, clientID, clientSecret string) (*tokenrefresh.TokenInfo, error) {
+		mu.Lock()
+		refreshed[token.RefreshToken] = true
+		mu.Unlock()
+		return &tokenrefresh.TokenInfo{AccessToken: "new-access-token", ExpiresAt: now.Add(8 * time.Hour)}, nil
+	})
+
+	results, err := RefreshExpiringBackups(2 * time.Hour)
+	if err != nil {
+		t.Fatalf("RefreshExpiringBackups failed: %v", err)
+	}
+
+	for _, name := range []string{"test_expiring_1h", "test_expiring_expired"} {
+		if !refreshed[name] {
+			t.Errorf("%s should be refreshed", name)
+		}
+		if outcome, ok := results[name]; !ok || outcome.Err != nil {
+			t.Errorf("%s: unexpected outcome %+v", name, outcome)
+		}
+	}
+	if refreshed["test_expiring_2d"] {
+		t.Error("test_expiring_2d should not be refreshed")
+	}
+	if _, ok := results["test_expiring_2d"]; ok {
+		t.Error("test_expiring_2d should not appear in results")
+	}
+}
+
+// TestOAuthBackupDataFromLoginResult 測試 LoginResult 轉換保留 Social 與 IdC 專用欄位
+func TestOAuthBackupDataFromLoginResult(t *testing.T) {
+	expiresAt := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	social := oauthBackupDataFromLoginResult(&oauthlogin.LoginResult{
+		AccessToken:  "social-access",
+		RefreshToken: "social-refresh",
+		ExpiresAt:    expiresAt,
+		ProfileArn:   "arn:aws:codewhisperer:us-east-1:123456789012:profile/TEST",
+		Provider:     "Github",
+		AuthMethod:   "social",
+	})
+	if social.ProfileArn != "arn:aws:codewhisperer:us-east-1:123456789012:profile/TEST" || social.Provider != "Github" {
+		t.Errorf("social fields not mapped: %+v", social)
+	}
+	if social.AccessToken != "social-access" || social.RefreshToken != "social-refresh" || !social.ExpiresAt.Equal(expiresAt) {
+		t.Errorf("token fields not mapped: %+v", social)
+	}
+
+	idc := oauthBackupDataFromLoginResult(&oauthlogin.LoginResult{
+		AccessToken:  "idc-access",
+		RefreshToken: "idc-refresh",
+		ExpiresAt:    expiresAt,
+		Provider:     "BuilderID",
+		AuthMethod:   "IdC",
+		ClientId:     "client-id",
+		ClientSecret: "client-secret",
+		ClientIdHash: "client-hash",
+	})
+	if idc.ClientId != "client-id" || idc.ClientSecret != "client-secret" || idc.ClientIdHash != "client-hash" {
+		t.Errorf("idc fields not mapped: %+v", idc)
+	}
+	if idc.AuthMethod != "IdC" || idc.Provider != "BuilderID" {
+		t.Errorf("auth fields not mapped: %+v", idc)
+	}
+}
+
+// TestCreateBackupFromLoginResult_Nil 測試空登入結果返回錯誤且不建立快照
+func TestCreateBackupFromLoginResult_Nil(t *testing.T) {
+	if err := CreateBackupFromLoginResult("test_login_result_nil", nil); err == nil {
+		t.Error("expected error for nil login result")
+	}
+	if BackupExists("test_login_result_nil") {
+		t.Error("backup should not be created for nil login result")
+	}
+}
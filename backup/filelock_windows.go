@@ -0,0 +1,21 @@
+//go:build windows
+
+package backup
+
+import (
+	"os"
+
+	"golang.org/x/sys/windows"
+)
+
+// lockFile 取得檔案的獨佔鎖（LockFileEx），會阻塞直到取得為止
+func lockFile(f *os.File) error {
+	ol := new(windows.Overlapped)
+	return windows.LockFileEx(windows.Handle(f.Fd()), windows.LOCKFILE_EXCLUSIVE_LOCK, 0, 1, 0, ol)
+}
+
+// unlockFile 釋放檔案鎖
+func unlockFile(f *os.File) error {
+	ol := new(windows.Overlapped)
+	return windows.UnlockFileEx(windows.Handle(f.Fd()), 0, 1, 0, ol)
+}
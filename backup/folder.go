@@ -10,11 +10,14 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"kiro-manager/internal/fsutil"
 )
 
 const (
 	// FoldersFileName 文件夾資料檔案名稱
 	FoldersFileName = "folders.json"
+	// foldersLockFileName 跨行程鎖定 folders.json 使用的鎖檔名稱
+	foldersLockFileName = "folders.json.lock"
 )
 
 var (
@@ -70,6 +73,42 @@ func ValidateFolderName(name string) error {
 // foldersMutex 保護 folders.json 的並發讀寫
 var foldersMutex sync.Mutex
 
+// lockFolders 取得 folders.json 的行程內鎖與跨行程檔案鎖
+// 多個應用程式實例同時執行時，檔案鎖可避免讀取-修改-寫入互相覆蓋
+// 返回的函數用於釋放鎖
+func lockFolders() (func(), error) {
+	foldersMutex.Lock()
+
+	rootPath, err := GetBackupRootPath()
+	if err != nil {
+		foldersMutex.Unlock()
+		return nil, err
+	}
+	if err := os.MkdirAll(rootPath, 0755); err != nil {
+		foldersMutex.Unlock()
+		return nil, err
+	}
+
+	lockFilePath := filepath.Join(rootPath, foldersLockFileName)
+	f, err := os.OpenFile(lockFilePath, os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		foldersMutex.Unlock()
+		return nil, err
+	}
+
+	if err := lockFile(f); err != nil {
+		f.Close()
+		foldersMutex.Unlock()
+		return nil, err
+	}
+
+	return func() {
+		unlockFile(f)
+		f.Close()
+		foldersMutex.Unlock()
+	}, nil
+}
+
 // GetFoldersPath 取得 folders.json 的路徑
 func GetFoldersPath() (string, error) {
 	rootPath, err := GetBackupRootPath()
@@ -82,8 +121,11 @@ func GetFoldersPath() (string, error) {
 // LoadFolders 載入文件夾資料
 // 如果檔案不存在，返回空的 FoldersData
 func LoadFolders() (*FoldersData, error) {
-	foldersMutex.Lock()
-	defer foldersMutex.Unlock()
+	unlock, err := lockFolders()
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
 
 	return loadFoldersInternal()
 }
@@ -125,8 +167,11 @@ func SaveFolders(data *FoldersData) error {
 		return nil
 	}
 
-	foldersMutex.Lock()
-	defer foldersMutex.Unlock()
+	unlock, err := lockFolders()
+	if err != nil {
+		return err
+	}
+	defer unlock()
 
 	return saveFoldersInternal(data)
 }
@@ -149,7 +194,8 @@ func saveFoldersInternal(data *FoldersData) error {
 		return err
 	}
 
-	return os.WriteFile(path, jsonData, 0644)
+	// 先寫入暫存檔再 rename，避免程式中止時留下截斷的檔案
+	return fsutil.WriteFileAtomic(path, jsonData, 0644)
 }
 
 // FolderWithCount 文件夾及其快照數量
@@ -166,8 +212,11 @@ func CreateFolder(name string) (*Folder, error) {
 		return nil, err
 	}
 
-	foldersMutex.Lock()
-	defer foldersMutex.Unlock()
+	unlock, err := lockFolders()
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
 
 	// 載入現有資料
 	data, err := loadFoldersInternal()
@@ -206,8 +255,11 @@ func RenameFolder(id, newName string) error {
 		return err
 	}
 
-	foldersMutex.Lock()
-	defer foldersMutex.Unlock()
+	unlock, err := lockFolders()
+	if err != nil {
+		return err
+	}
+	defer unlock()
 
 	data, err := loadFoldersInternal()
 	if err != nil {
@@ -242,8 +294,11 @@ func RenameFolder(id, newName string) error {
 // deleteSnapshots: true 表示一併刪除快照，false 表示移到未分類
 // 返回被移到未分類的快照名稱列表
 func DeleteFolder(id string, deleteSnapshots bool) ([]string, error) {
-	foldersMutex.Lock()
-	defer foldersMutex.Unlock()
+	unlock, err := lockFolders()
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
 
 	data, err := loadFoldersInternal()
 	if err != nil {
@@ -325,8 +380,11 @@ func ListFolders() ([]FolderWithCount, error) {
 
 // AssignSnapshotToFolder 將快照分配到指定文件夾
 func AssignSnapshotToFolder(snapshotName, folderId string) error {
-	foldersMutex.Lock()
-	defer foldersMutex.Unlock()
+	unlock, err := lockFolders()
+	if err != nil {
+		return err
+	}
+	defer unlock()
 
 	data, err := loadFoldersInternal()
 	if err != nil {
@@ -354,8 +412,11 @@ func AssignSnapshotToFolder(snapshotName, folderId string) error {
 
 // UnassignSnapshot 將快照移至未分類（從 assignments 移除）
 func UnassignSnapshot(snapshotName string) error {
-	foldersMutex.Lock()
-	defer foldersMutex.Unlock()
+	unlock, err := lockFolders()
+	if err != nil {
+		return err
+	}
+	defer unlock()
 
 	data, err := loadFoldersInternal()
 	if err != nil {
@@ -368,6 +429,31 @@ func UnassignSnapshot(snapshotName string) error {
 	return saveFoldersInternal(data)
 }
 
+// renameSnapshotAssignment 將快照的 assignment 從舊名稱遷移至新名稱
+// 快照未分配到任何文件夾時不做任何變更
+func renameSnapshotAssignment(oldName, newName string) error {
+	unlock, err := lockFolders()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	data, err := loadFoldersInternal()
+	if err != nil {
+		return err
+	}
+
+	folderId, ok := data.Assignments[oldName]
+	if !ok {
+		return nil
+	}
+
+	delete(data.Assignments, oldName)
+	data.Assignments[newName] = folderId
+
+	return saveFoldersInternal(data)
+}
+
 // GetSnapshotFolderId 取得快照所屬的文件夾 ID
 // 如果快照未分配到任何文件夾，返回空字串
 func GetSnapshotFolderId(snapshotName string) (string, error) {
@@ -388,8 +474,11 @@ type SnapshotExistsChecker func(snapshotName string) bool
 // checker: 檢查快照是否存在的函數
 // 返回被清理的快照名稱列表
 func CleanupOrphanAssignments(checker SnapshotExistsChecker) ([]string, error) {
-	foldersMutex.Lock()
-	defer foldersMutex.Unlock()
+	unlock, err := lockFolders()
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
 
 	data, err := loadFoldersInternal()
 	if err != nil {
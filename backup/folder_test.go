@@ -1,12 +1,16 @@
 package backup
 
 import (
+	"fmt"
 	"math/rand"
 	"os"
+	"os/exec"
+	"path/filepath"
 	"strings"
 	"sync"
 	"testing"
 	"testing/quick"
+	"time"
 	"unicode"
 
 	"github.com/google/uuid"
@@ -1256,3 +1260,132 @@ func isValidFolderNameChar(r rune) bool {
 	}
 	return !invalidChars[r] && (unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.IsSpace(r) || r == '-' || r == '_')
 }
+
+// foldersLockHelperEnv 子行程模式的環境變數，值為建立文件夾時使用的名稱前綴
+const foldersLockHelperEnv = "KIRO_MANAGER_FOLDERS_LOCK_HELPER"
+
+// TestFoldersLockHelperProcess 由 TestFoldersFileLock_MultiProcess 以子行程方式執行
+func TestFoldersLockHelperProcess(t *testing.T) {
+	prefix := os.Getenv(foldersLockHelperEnv)
+	if prefix == "" {
+		t.Skip("僅在子行程模式下執行")
+	}
+
+	for i := 0; i < 50; i++ {
+		if _, err := CreateFolder(fmt.Sprintf("%s-%02d", prefix, i)); err != nil {
+			t.Fatalf("CreateFolder failed: %v", err)
+		}
+	}
+}
+
+// TestFoldersFileLock_MultiProcess 測試兩個行程同時建立文件夾時不會互相覆蓋
+func TestFoldersFileLock_MultiProcess(t *testing.T) {
+	path, _ := GetFoldersPath()
+	os.Remove(path)
+	defer os.Remove(path)
+
+	var wg sync.WaitGroup
+	outputs := make([][]byte, 2)
+	errs := make([]error, 2)
+	for i, prefix := range []string{"proc-a", "proc-b"} {
+		wg.Add(1)
+		go func(i int, prefix string) {
+			defer wg.Done()
+			cmd := exec.Command(os.Args[0], "-test.run=^TestFoldersLockHelperProcess$")
+			cmd.Env = append(os.Environ(), foldersLockHelperEnv+"="+prefix)
+			outputs[i], errs[i] = cmd.CombinedOutput()
+		}(i, prefix)
+	}
+	wg.Wait()
+
+	for i, err := range errs {
+		if err != nil {
+			t.Fatalf("helper process %d failed: %v\n%s", i, err, outputs[i])
+		}
+	}
+
+	folders, err := ListFolders()
+	if err != nil {
+		t.Fatalf("ListFolders failed: %v", err)
+	}
+	if len(folders) != 100 {
+		t.Errorf("Expected 100 folders, got %d", len(folders))
+	}
+}
+
+// foldersSaveLoopHelperEnv 子行程模式的環境變數，設定時子行程會不斷寫入 folders.json
+const foldersSaveLoopHelperEnv = "KIRO_MANAGER_FOLDERS_SAVE_LOOP_HELPER"
+
+// largeFoldersData 建立大量文件夾與分配的測試資料
+func largeFoldersData(n int) *FoldersData {
+	data := &FoldersData{
+		Folders:     make([]Folder, 0, n),
+		Assignments: make(map[string]string, n),
+	}
+	for i := 0; i < n; i++ {
+		id := uuid.New().String()
+		data.Folders = append(data.Folders, Folder{ID: id, Name: fmt.Sprintf("folder-%05d", i), CreatedAt: "2025-01-01T00:00:00Z", Order: i})
+		data.Assignments[fmt.Sprintf("snapshot-%05d", i)] = id
+	}
+	return data
+}
+
+// TestFoldersSaveLoopHelperProcess 由 TestSaveFolders_InterruptedNeverTruncates 以子行程方式執行
+func TestFoldersSaveLoopHelperProcess(t *testing.T) {
+	if os.Getenv(foldersSaveLoopHelperEnv) == "" {
+		t.Skip("僅在子行程模式下執行")
+	}
+
+	data := largeFoldersData(5000)
+	for {
+		if err := SaveFolders(data); err != nil {
+			t.Fatalf("SaveFolders failed: %v", err)
+		}
+	}
+}
+
+// TestSaveFolders_InterruptedNeverTruncates 測試寫入 folders.json 時程式被強制中止，不會留下空檔案
+func TestSaveFolders_InterruptedNeverTruncates(t *testing.T) {
+	path, _ := GetFoldersPath()
+	os.Remove(path)
+	defer os.Remove(path)
+
+	if err := SaveFolders(largeFoldersData(5000)); err != nil {
+		t.Fatalf("SaveFolders failed: %v", err)
+	}
+
+	for i := 0; i < 5; i++ {
+		cmd := exec.Command(os.Args[0], "-test.run=^TestFoldersSaveLoopHelperProcess$")
+		cmd.Env = append(os.Environ(), foldersSaveLoopHelperEnv+"=1")
+		if err := cmd.Start(); err != nil {
+			t.Fatalf("failed to start helper process: %v", err)
+		}
+		time.Sleep(time.Duration(50+i*37) * time.Millisecond)
+		cmd.Process.Kill()
+		cmd.Wait()
+
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatalf("folders.json missing after interruption: %v", err)
+		}
+		if info.Size() == 0 {
+			t.Fatal("folders.json was left empty after interruption")
+		}
+		data, err := LoadFolders()
+		if err != nil {
+			t.Fatalf("folders.json is corrupted after interruption: %v", err)
+		}
+		if len(data.Folders) != 5000 {
+			t.Errorf("expected 5000 folders, got %d", len(data.Folders))
+		}
+	}
+
+	// 中止的寫入可能留下暫存檔，但不應影響目標檔案
+	rootPath, _ := GetBackupRootPath()
+	entries, _ := os.ReadDir(rootPath)
+	for _, e := range entries {
+		if strings.HasPrefix(e.Name(), "."+FoldersFileName+".tmp-") {
+			os.Remove(filepath.Join(rootPath, e.Name()))
+		}
+	}
+}
@@ -0,0 +1,194 @@
+package backup
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"kiro-manager/settings"
+)
+
+// ErrInvalidBackupRoot 備份根目錄不是可寫入的絕對路徑
+var ErrInvalidBackupRoot = errors.New("backup root must be an absolute, writable directory")
+
+// ValidateBackupRoot 驗證備份根目錄為絕對路徑且可寫入
+// 目錄不存在時會建立
+func ValidateBackupRoot(root string) error {
+	if !filepath.IsAbs(root) {
+		return fmt.Errorf("%w: %s", ErrInvalidBackupRoot, root)
+	}
+	if err := os.MkdirAll(root, 0755); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidBackupRoot, err)
+	}
+
+	// 實際寫入測試檔，避免唯讀目錄（如 Program Files）通過檢查
+	f, err := os.CreateTemp(root, ".write-test-*")
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidBackupRoot, err)
+	}
+	f.Close()
+	os.Remove(f.Name())
+
+	return nil
+}
+
+// SetBackupRootOverride 設定自定義備份根目錄（不搬移既有備份）
+// 傳入空字串時恢復為預設位置
+func SetBackupRootOverride(root string) error {
+	if root != "" {
+		if err := ValidateBackupRoot(root); err != nil {
+			return err
+		}
+	}
+
+	s := *settings.GetCurrentSettings()
+	s.BackupRootOverride = root
+	return settings.SaveSettings(&s)
+}
+
+// MoveBackupsRoot 將既有備份搬移至 newRoot，並設定為新的備份根目錄
+// 目標目錄已有同名項目時不會搬移任何檔案；搬移途中失敗會將已搬移的項目移回
+func MoveBackupsRoot(newRoot string) error {
+	oldRoot, err := GetBackupRootPath()
+	if err != nil {
+		return err
+	}
+
+	// 先檢查巢狀目錄，避免驗證時在舊目錄內建立新目錄
+	if isSubPath(oldRoot, newRoot) {
+		return fmt.Errorf("%w: %s is inside %s", ErrInvalidBackupRoot, newRoot, oldRoot)
+	}
+	if err := ValidateBackupRoot(newRoot); err != nil {
+		return err
+	}
+
+	// 搬移期間阻擋同一行程內的 folders.json 讀寫
+	foldersMutex.Lock()
+	defer foldersMutex.Unlock()
+
+	moved, err := moveBackupsRootAt(oldRoot, newRoot)
+	if err != nil {
+		return err
+	}
+
+	// 新位置即為預設位置時清除覆寫設定
+	override := newRoot
+	if defaultRoot, err := GetDefaultBackupRootPath(); err == nil && samePath(defaultRoot, newRoot) {
+		override = ""
+	}
+
+	s := *settings.GetCurrentSettings()
+	s.BackupRootOverride = override
+	if err := settings.SaveSettings(&s); err != nil {
+		// 設定未更新時搬回原位置，避免備份與設定指向的目錄不一致
+		restoreEntries(newRoot, oldRoot, moved)
+		return err
+	}
+	return nil
+}
+
+// moveBackupsRootAt 將 oldRoot 下的所有備份與 folders.json 搬移至 newRoot
+// 返回已搬移的項目名稱
+func moveBackupsRootAt(oldRoot, newRoot string) ([]string, error) {
+	if samePath(oldRoot, newRoot) {
+		return nil, nil
+	}
+	if isSubPath(oldRoot, newRoot) {
+		return nil, fmt.Errorf("%w: %s is inside %s", ErrInvalidBackupRoot, newRoot, oldRoot)
+	}
+
+	entries, err := os.ReadDir(oldRoot)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	// 鎖檔留在原位置，其餘項目全部搬移
+	var names []string
+	for _, entry := range entries {
+		if entry.Name() == foldersLockFileName {
+			continue
+		}
+		names = append(names, entry.Name())
+	}
+
+	// 先檢查衝突，避免搬移到一半才失敗
+	for _, name := range names {
+		if _, err := os.Lstat(filepath.Join(newRoot, name)); err == nil {
+			return nil, fmt.Errorf("%w: %s", ErrBackupExists, name)
+		}
+	}
+
+	if err := os.MkdirAll(newRoot, 0755); err != nil {
+		return nil, err
+	}
+
+	var moved []string
+	for _, name := range names {
+		if err := moveEntry(filepath.Join(oldRoot, name), filepath.Join(newRoot, name)); err != nil {
+			restoreEntries(newRoot, oldRoot, moved)
+			return nil, fmt.Errorf("failed to move %s: %w", name, err)
+		}
+		moved = append(moved, name)
+	}
+
+	return moved, nil
+}
+
+// restoreEntries 將已搬移的項目移回原位置（盡力而為）
+func restoreEntries(from, to string, names []string) {
+	for _, name := range names {
+		_ = moveEntry(filepath.Join(from, name), filepath.Join(to, name))
+	}
+}
+
+// moveEntry 搬移檔案或資料夾
+// 跨磁碟區無法直接 rename 時改為複製後刪除來源
+func moveEntry(src, dst string) error {
+	if err := os.Rename(src, dst); err == nil {
+		return nil
+	}
+
+	info, err := os.Stat(src)
+	if err != nil {
+		return err
+	}
+
+	if info.IsDir() {
+		err = copyDir(src, dst)
+	} else {
+		err = copyFile(src, dst)
+	}
+	if err != nil {
+		os.RemoveAll(dst)
+		return err
+	}
+
+	return os.RemoveAll(src)
+}
+
+// samePath 比較兩個路徑是否相同（Windows 不分大小寫）
+func samePath(a, b string) bool {
+	a, b = filepath.Clean(a), filepath.Clean(b)
+	if runtime.GOOS == "windows" {
+		return strings.EqualFold(a, b)
+	}
+	return a == b
+}
+
+// isSubPath 檢查 child 是否位於 parent 之下（不含相同路徑）
+func isSubPath(parent, child string) bool {
+	if samePath(parent, child) {
+		return false
+	}
+	rel, err := filepath.Rel(parent, child)
+	if err != nil {
+		return false
+	}
+	return rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
@@ -0,0 +1,88 @@
+package backup
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestValidateBackupRoot(t *testing.T) {
+	if err := ValidateBackupRoot("relative/backups"); !errors.Is(err, ErrInvalidBackupRoot) {
+		t.Errorf("ValidateBackupRoot(relative) error = %v, want ErrInvalidBackupRoot", err)
+	}
+
+	root := filepath.Join(t.TempDir(), "nested", "backups")
+	if err := ValidateBackupRoot(root); err != nil {
+		t.Fatalf("ValidateBackupRoot(%s) error = %v", root, err)
+	}
+	entries, _ := os.ReadDir(root)
+	if len(entries) != 0 {
+		t.Errorf("ValidateBackupRoot should not leave files behind, got %d entries", len(entries))
+	}
+}
+
+// setupTestBackupRoot 建立含兩個備份、folders.json 與鎖檔的備份根目錄
+func setupTestBackupRoot(t *testing.T) string {
+	t.Helper()
+	root := t.TempDir()
+	for _, name := range []string{"work", "home"} {
+		dir := filepath.Join(root, name)
+		os.MkdirAll(dir, 0755)
+		os.WriteFile(filepath.Join(dir, KiroAuthTokenFile), []byte(`{"accessToken":"`+name+`"}`), 0644)
+	}
+	os.WriteFile(filepath.Join(root, FoldersFileName), []byte(`{"folders":[]}`), 0644)
+	os.WriteFile(filepath.Join(root, foldersLockFileName), nil, 0644)
+	return root
+}
+
+func TestMoveBackupsRootAt_MovesBackupsAndFolders(t *testing.T) {
+	oldRoot := setupTestBackupRoot(t)
+	newRoot := filepath.Join(t.TempDir(), "backups")
+
+	moved, err := moveBackupsRootAt(oldRoot, newRoot)
+	if err != nil {
+		t.Fatalf("moveBackupsRootAt() error = %v", err)
+	}
+	if len(moved) != 3 {
+		t.Errorf("moveBackupsRootAt() moved %v, want 2 backups and folders.json", moved)
+	}
+
+	for _, rel := range []string{filepath.Join("work", KiroAuthTokenFile), filepath.Join("home", KiroAuthTokenFile), FoldersFileName} {
+		if _, err := os.Stat(filepath.Join(newRoot, rel)); err != nil {
+			t.Errorf("%s should exist in new root: %v", rel, err)
+		}
+		if _, err := os.Stat(filepath.Join(oldRoot, rel)); !os.IsNotExist(err) {
+			t.Errorf("%s should be removed from old root", rel)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(newRoot, foldersLockFileName)); !os.IsNotExist(err) {
+		t.Error("lock file should stay in the old root")
+	}
+}
+
+func TestMoveBackupsRootAt_ConflictMovesNothing(t *testing.T) {
+	oldRoot := setupTestBackupRoot(t)
+	newRoot := t.TempDir()
+	os.MkdirAll(filepath.Join(newRoot, "work"), 0755)
+
+	if _, err := moveBackupsRootAt(oldRoot, newRoot); !errors.Is(err, ErrBackupExists) {
+		t.Fatalf("moveBackupsRootAt() error = %v, want ErrBackupExists", err)
+	}
+	for _, name := range []string{"work", "home", FoldersFileName} {
+		if _, err := os.Stat(filepath.Join(oldRoot, name)); err != nil {
+			t.Errorf("%s should stay in old root: %v", name, err)
+		}
+	}
+}
+
+func TestMoveBackupsRootAt_RejectsNestedRoot(t *testing.T) {
+	oldRoot := setupTestBackupRoot(t)
+
+	if _, err := moveBackupsRootAt(oldRoot, filepath.Join(oldRoot, "moved")); !errors.Is(err, ErrInvalidBackupRoot) {
+		t.Errorf("moveBackupsRootAt(nested) error = %v, want ErrInvalidBackupRoot", err)
+	}
+	if moved, err := moveBackupsRootAt(oldRoot, oldRoot); err != nil || len(moved) != 0 {
+		t.Errorf("moveBackupsRootAt(same) = %v, %v, want no-op", moved, err)
+	}
+}
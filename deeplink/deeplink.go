@@ -15,9 +15,18 @@ const (
 	// RedirectURI 定義 OAuth 回調的完整 URI
 	RedirectURI = "kiro://kiro.kiroAgent/authenticate-success"
 
+	// CallbackHost 定義 OAuth 回調 URL 的 host
+	CallbackHost = "kiro.kiroAgent"
+
+	// CallbackPath 定義 OAuth 回調 URL 的 path
+	CallbackPath = "/authenticate-success"
+
 	// StateFileName 定義 OAuth State 檔案名稱
 	StateFileName = "kiro-manager-oauth-state.json"
 
+	// StatesDirName 定義依 state 分開保存 OAuth State 的目錄名稱
+	StatesDirName = "kiro-manager-oauth-states"
+
 	// StateExpiry 定義 OAuth State 的過期時間
 	StateExpiry = 5 * time.Minute
 )
@@ -45,6 +54,12 @@ var (
 	// ErrInvalidScheme 表示無效的 URL Scheme
 	ErrInvalidScheme = errors.New("invalid URL scheme")
 
+	// ErrInvalidCallbackTarget 表示回調 URL 的 host 或 path 不是預期的登入回調
+	ErrInvalidCallbackTarget = errors.New("invalid deep link callback target")
+
 	// ErrCallbackTimeout 表示回調超時
 	ErrCallbackTimeout = errors.New("callback timeout")
+
+	// ErrCallbackCancelled 表示等待回調時 context 被取消
+	ErrCallbackCancelled = errors.New("callback cancelled")
 )
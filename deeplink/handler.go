@@ -1,6 +1,7 @@
 package deeplink
 
 import (
+	"context"
 	"fmt"
 	"net/url"
 	"strings"
@@ -42,16 +43,32 @@ func clearPendingDeepLink() {
 	pendingDeepLink = nil
 }
 
+// 依 state 暫存尚未被取用的回調（佇列模式下其他登入的回調）
+var (
+	unmatchedCallbacks = make(map[string]*DeepLinkResult)
+	unmatchedMu        sync.Mutex
+)
+
 // InitCallbackChannel 初始化回調 channel
-// 確保只初始化一次
+// 確保只初始化一次，預設為單一槽位（新回調取代舊回調）
 func InitCallbackChannel() {
+	InitCallbackChannelN(1)
+}
+
+// InitCallbackChannelN 以指定容量初始化回調 channel（佇列模式）
+// 回調依序排隊，僅在佇列已滿時丟棄最舊的回調；size 小於 1 時視為 1
+// 與 InitCallbackChannel 共用同一次初始化
+func InitCallbackChannelN(size int) {
+	if size < 1 {
+		size = 1
+	}
 	callbackOnce.Do(func() {
-		callbackChan = make(chan *DeepLinkResult, 1)
+		callbackChan = make(chan *DeepLinkResult, size)
 	})
 }
 
 // SendCallback 發送回調結果到 channel
-// 非阻塞發送，如果 channel 已滿則丟棄舊的並發送新的
+// 非阻塞發送，如果 channel 已滿則丟棄最舊的並發送新的
 // 若 channel 未初始化（冷啟動場景），保存到 pending
 func SendCallback(result *DeepLinkResult) {
 	callbackMu.Lock()
@@ -66,7 +83,7 @@ func SendCallback(result *DeepLinkResult) {
 	select {
 	case callbackChan <- result:
 	default:
-		// channel 已滿，丟棄舊的結果
+		// channel 已滿，丟棄最舊的結果
 		select {
 		case <-callbackChan:
 		default:
@@ -79,6 +96,12 @@ func SendCallback(result *DeepLinkResult) {
 // 返回結果或超時錯誤
 // 優先檢查 pending 結果（冷啟動場景）
 func WaitForCallback(timeout time.Duration) (*DeepLinkResult, error) {
+	return WaitForCallbackContext(context.Background(), timeout)
+}
+
+// WaitForCallbackContext 與 WaitForCallback 相同，但 ctx 取消時立即返回 ErrCallbackCancelled
+// ctx 的 deadline 先於 timeout 到期時返回 ErrCallbackTimeout
+func WaitForCallbackContext(ctx context.Context, timeout time.Duration) (*DeepLinkResult, error) {
 	// 先檢查是否有 pending 結果（冷啟動場景）
 	if pending := GetPendingDeepLink(); pending != nil {
 		clearPendingDeepLink()
@@ -87,14 +110,83 @@ func WaitForCallback(timeout time.Duration) (*DeepLinkResult, error) {
 
 	InitCallbackChannel()
 
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
 	select {
 	case result := <-callbackChan:
 		return result, nil
-	case <-time.After(timeout):
+	case <-ctx.Done():
+		if ctx.Err() == context.DeadlineExceeded {
+			return nil, ErrCallbackTimeout
+		}
+		return nil, ErrCallbackCancelled
+	case <-timer.C:
 		return nil, ErrCallbackTimeout
 	}
 }
 
+// WaitForStateCallbackContext 等待 state 相符的回調結果
+// 佇列中其他 state 的回調會被暫存，供對應的等待者稍後取用，不會被丟棄
+func WaitForStateCallbackContext(ctx context.Context, state string, timeout time.Duration) (*DeepLinkResult, error) {
+	// 先檢查是否有符合的 pending 結果（冷啟動場景）
+	if pending := GetPendingDeepLink(); pending != nil && pending.State == state {
+		clearPendingDeepLink()
+		return pending, nil
+	}
+	if result := takeUnmatchedCallback(state); result != nil {
+		return result, nil
+	}
+
+	InitCallbackChannel()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	for {
+		select {
+		case result, ok := <-callbackChan:
+			if !ok {
+				return nil, ErrCallbackCancelled
+			}
+			if result.State == state {
+				return result, nil
+			}
+			storeUnmatchedCallback(result)
+			// 其他等待者可能已先暫存了本 state 的回調
+			if result := takeUnmatchedCallback(state); result != nil {
+				return result, nil
+			}
+		case <-ctx.Done():
+			if ctx.Err() == context.DeadlineExceeded {
+				return nil, ErrCallbackTimeout
+			}
+			return nil, ErrCallbackCancelled
+		case <-timer.C:
+			return nil, ErrCallbackTimeout
+		}
+	}
+}
+
+// storeUnmatchedCallback 暫存不屬於當前等待者的回調
+func storeUnmatchedCallback(result *DeepLinkResult) {
+	unmatchedMu.Lock()
+	defer unmatchedMu.Unlock()
+	unmatchedCallbacks[result.State] = result
+}
+
+// takeUnmatchedCallback 取出並移除指定 state 的暫存回調
+func takeUnmatchedCallback(state string) *DeepLinkResult {
+	unmatchedMu.Lock()
+	defer unmatchedMu.Unlock()
+	result, ok := unmatchedCallbacks[state]
+	if !ok {
+		return nil
+	}
+	delete(unmatchedCallbacks, state)
+	return result
+}
+
 // ResetCallbackChannel 重置回調 channel (用於測試)
 func ResetCallbackChannel() {
 	callbackMu.Lock()
@@ -105,6 +197,10 @@ func ResetCallbackChannel() {
 	}
 	callbackChan = nil
 	callbackOnce = sync.Once{}
+
+	unmatchedMu.Lock()
+	unmatchedCallbacks = make(map[string]*DeepLinkResult)
+	unmatchedMu.Unlock()
 }
 
 // DeepLinkResult 定義 Deep Link 解析結果
@@ -132,6 +228,11 @@ func ParseDeepLinkURL(rawURL string) (*DeepLinkResult, error) {
 		return nil, ErrInvalidScheme
 	}
 
+	// 驗證 host 與 path，僅接受標準的登入回調目標
+	if !strings.EqualFold(parsedURL.Host, CallbackHost) || parsedURL.Path != CallbackPath {
+		return nil, ErrInvalidCallbackTarget
+	}
+
 	// 取得查詢參數
 	query := parsedURL.Query()
 
@@ -163,7 +264,7 @@ func ValidateDeepLinkURL(rawURL string) bool {
 // HandleDeepLinkCallback 處理 deep link 回調
 // 1. 先檢查是否有錯誤參數
 // 2. 解析 URL
-// 3. 載入持久化的 State
+// 3. 依 state 載入持久化的記錄
 // 4. 驗證 State 匹配
 // 5. 檢查 State 是否過期
 // 6. 返回結果
@@ -179,8 +280,14 @@ func HandleDeepLinkCallback(rawURL string) (*DeepLinkResult, error) {
 		return nil, err
 	}
 
-	// 3. 載入持久化的 State
-	savedState, err := LoadState()
+	// 3. 依回調的 state 載入對應的持久化記錄
+	savedState, err := LoadStateByValue(result.State)
+	if err == ErrStateNotFound {
+		// 有其他進行中的登入但沒有對應記錄，視為 State 不匹配
+		if _, latestErr := LoadState(); latestErr == nil {
+			return nil, ErrStateMismatch
+		}
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -199,6 +306,27 @@ func HandleDeepLinkCallback(rawURL string) (*DeepLinkResult, error) {
 	return result, nil
 }
 
+// FindDeepLinkArg 從啟動參數中找出第一個 kiro:// URL
+func FindDeepLinkArg(args []string) (string, bool) {
+	for _, arg := range args {
+		if strings.HasPrefix(arg, URLScheme+"://") {
+			return arg, true
+		}
+	}
+	return "", false
+}
+
+// ForwardDeepLink 處理由其他實例轉交的 deep link URL 並送往等待中的登入流程
+// 單一實例鎖由 Wails SingleInstanceLock 提供，第二個實例的啟動參數會轉交給主實例
+func ForwardDeepLink(rawURL string) error {
+	result, err := HandleDeepLinkCallback(rawURL)
+	if err != nil {
+		return err
+	}
+	SendCallback(result)
+	return nil
+}
+
 // ParseDeepLinkError 解析 URL 中的錯誤參數
 // URL 格式: kiro://...?error=access_denied&error_description=...
 func ParseDeepLinkError(rawURL string) (*DeepLinkError, bool) {
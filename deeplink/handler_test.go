@@ -1,6 +1,7 @@
 package deeplink
 
 import (
+	"context"
 	"testing"
 	"time"
 )
@@ -41,6 +42,33 @@ func TestParseDeepLinkURL_InvalidScheme(t *testing.T) {
 	}
 }
 
+// TestParseDeepLinkURL_CallbackTarget 測試僅接受標準的 host 與 path
+func TestParseDeepLinkURL_CallbackTarget(t *testing.T) {
+	tests := []struct {
+		name    string
+		rawURL  string
+		wantErr error
+	}{
+		{"canonical", "kiro://kiro.kiroAgent/authenticate-success?code=x&state=y", nil},
+		{"wrong_host", "kiro://evil.host/authenticate-success?code=x&state=y", ErrInvalidCallbackTarget},
+		{"wrong_path", "kiro://kiro.kiroAgent/whatever?code=x&state=y", ErrInvalidCallbackTarget},
+		{"trailing_path", "kiro://kiro.kiroAgent/authenticate-success/extra?code=x&state=y", ErrInvalidCallbackTarget},
+		{"missing_host", "kiro:///authenticate-success?code=x&state=y", ErrInvalidCallbackTarget},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := ParseDeepLinkURL(tt.rawURL)
+			if err != tt.wantErr {
+				t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+			}
+			if tt.wantErr == nil && (result.Code != "x" || result.State != "y") {
+				t.Errorf("unexpected result: %+v", result)
+			}
+		})
+	}
+}
+
 // TestParseDeepLinkURL_MissingCode 測試缺少 code 返回 ErrMissingCode
 func TestParseDeepLinkURL_MissingCode(t *testing.T) {
 	rawURL := "kiro://kiro.kiroAgent/authenticate-success?state=test_state"
@@ -325,6 +353,28 @@ func TestWaitForCallback_Timeout(t *testing.T) {
 	}
 }
 
+// TestWaitForCallbackContext_Cancel 驗證等待中取消 context 會立即返回 ErrCallbackCancelled
+func TestWaitForCallbackContext_Cancel(t *testing.T) {
+	ResetCallbackChannel()
+	InitCallbackChannel()
+	defer ResetCallbackChannel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	start := time.Now()
+	result, err := WaitForCallbackContext(ctx, time.Minute)
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("expected prompt return after cancel, took %v", elapsed)
+	}
+	if err != ErrCallbackCancelled {
+		t.Errorf("expected ErrCallbackCancelled, got %v", err)
+	}
+	if result != nil {
+		t.Errorf("expected nil result, got %v", result)
+	}
+}
+
 // TestSendCallback_ReplaceOld 驗證新結果替換舊結果
 func TestSendCallback_ReplaceOld(t *testing.T) {
 	ResetCallbackChannel()
@@ -353,6 +403,57 @@ func TestSendCallback_ReplaceOld(t *testing.T) {
 	}
 }
 
+// TestInitCallbackChannelN_QueuesInOrder 驗證佇列模式依序保留多個回調
+func TestInitCallbackChannelN_QueuesInOrder(t *testing.T) {
+	ResetCallbackChannel()
+	InitCallbackChannelN(3)
+	defer ResetCallbackChannel()
+
+	for _, code := range []string{"first", "second", "third"} {
+		SendCallback(&DeepLinkResult{Code: code, State: code + "_state"})
+	}
+
+	for _, want := range []string{"first", "second", "third"} {
+		result, err := WaitForCallback(100 * time.Millisecond)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if result.Code != want {
+			t.Errorf("expected code '%s', got '%s'", want, result.Code)
+		}
+	}
+}
+
+// TestWaitForStateCallbackContext_KeepsUnrelated 驗證依 state 取用回調，不相符的回調保留給其他等待者
+func TestWaitForStateCallbackContext_KeepsUnrelated(t *testing.T) {
+	ResetCallbackChannel()
+	InitCallbackChannelN(2)
+	defer ResetCallbackChannel()
+
+	SendCallback(&DeepLinkResult{Code: "code_a", State: "state_a"})
+	SendCallback(&DeepLinkResult{Code: "code_b", State: "state_b"})
+
+	resultB, err := WaitForStateCallbackContext(context.Background(), "state_b", 100*time.Millisecond)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if resultB.Code != "code_b" {
+		t.Errorf("expected code 'code_b', got '%s'", resultB.Code)
+	}
+
+	resultA, err := WaitForStateCallbackContext(context.Background(), "state_a", 100*time.Millisecond)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if resultA.Code != "code_a" {
+		t.Errorf("expected code 'code_a', got '%s'", resultA.Code)
+	}
+
+	if _, err := WaitForStateCallbackContext(context.Background(), "state_c", 20*time.Millisecond); err != ErrCallbackTimeout {
+		t.Errorf("expected ErrCallbackTimeout, got %v", err)
+	}
+}
+
 // TestSendCallback_NilChannel 驗證 channel 未初始化時不 panic
 func TestSendCallback_NilChannel(t *testing.T) {
 	ResetCallbackChannel()
@@ -486,6 +587,63 @@ func TestHandleDeepLinkCallback_WithErrorDescription(t *testing.T) {
 	}
 }
 
+// TestForwardDeepLink_DeliveredToWaiter 模擬第二個實例轉交 URL，驗證等待中的 WaitForCallback 收到結果
+func TestForwardDeepLink_DeliveredToWaiter(t *testing.T) {
+	ResetCallbackChannel()
+	InitCallbackChannel()
+	defer ResetCallbackChannel()
+
+	testState := &OAuthState{
+		State:     "forward_state",
+		CreatedAt: time.Now(),
+		ExpiresAt: time.Now().Add(5 * time.Minute),
+	}
+	if err := SaveState(testState); err != nil {
+		t.Fatalf("failed to save state: %v", err)
+	}
+	defer ClearState()
+
+	args := []string{"--flag", "kiro://kiro.kiroAgent/authenticate-success?code=forward_code&state=forward_state"}
+	rawURL, ok := FindDeepLinkArg(args)
+	if !ok {
+		t.Fatal("expected deep link arg to be found")
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		errCh <- ForwardDeepLink(rawURL)
+	}()
+
+	result, err := WaitForCallback(1 * time.Second)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if result.Code != "forward_code" || result.State != "forward_state" {
+		t.Errorf("unexpected result: %+v", result)
+	}
+	if err := <-errCh; err != nil {
+		t.Errorf("ForwardDeepLink failed: %v", err)
+	}
+}
+
+// TestForwardDeepLink_Invalid 驗證無效 URL 不會送出回調
+func TestForwardDeepLink_Invalid(t *testing.T) {
+	ResetCallbackChannel()
+	InitCallbackChannel()
+	defer ResetCallbackChannel()
+
+	if _, ok := FindDeepLinkArg([]string{"--flag", "https://example.com"}); ok {
+		t.Error("expected no deep link arg")
+	}
+	if err := ForwardDeepLink("kiro://kiro.kiroAgent/authenticate-success?state=x"); err != ErrMissingCode {
+		t.Errorf("expected ErrMissingCode, got %v", err)
+	}
+	if _, err := WaitForCallback(20 * time.Millisecond); err != ErrCallbackTimeout {
+		t.Errorf("expected no callback, got %v", err)
+	}
+}
+
 // contains 檢查字串是否包含子字串
 func contains(s, substr string) bool {
 	return len(s) >= len(substr) && (s == substr || len(s) > 0 && containsHelper(s, substr))
@@ -0,0 +1,63 @@
+package deeplink
+
+import "fmt"
+
+// Registry 路徑常數
+const (
+	// schemeRegPath 是 URL Scheme 的 Registry 路徑
+	schemeRegPath = `HKCU\Software\Classes\` + URLScheme
+
+	// commandRegPath 是 shell open command 的 Registry 路徑
+	commandRegPath = schemeRegPath + `\shell\open\command`
+
+	// schemeDescription 是 URL Scheme 的描述
+	schemeDescription = "URL:Kiro Manager Protocol"
+)
+
+// registryWriter 抽象 Registry 寫入操作，測試時可替換為假實作
+type registryWriter interface {
+	// SetDefault 設定 key 的 default value
+	SetDefault(regPath, value string) error
+	// SetValue 設定 key 的指定值
+	SetValue(regPath, valueName, value string) error
+	// DeleteKey 刪除 key 及其所有子 key
+	DeleteKey(regPath string) error
+}
+
+// writeURLSchemeEntries 寫入 URL Scheme 所需的 Registry 項目
+func writeURLSchemeEntries(w registryWriter, exePath string) error {
+	if exePath == "" {
+		return fmt.Errorf("%w: executable path is empty", ErrRegistryFailed)
+	}
+
+	// 1. 設定 scheme key 的 default value
+	if err := w.SetDefault(schemeRegPath, schemeDescription); err != nil {
+		return fmt.Errorf("%w: failed to set scheme description: %v", ErrRegistryFailed, err)
+	}
+
+	// 2. 設定 URL Protocol 值（空字串表示這是 URL Protocol）
+	if err := w.SetValue(schemeRegPath, "URL Protocol", ""); err != nil {
+		return fmt.Errorf("%w: failed to set URL Protocol: %v", ErrRegistryFailed, err)
+	}
+
+	// 3. 設定 command key 的 default value
+	commandVal := buildCommandValue(exePath)
+	if err := w.SetDefault(commandRegPath, commandVal); err != nil {
+		return fmt.Errorf("%w: failed to set command: %v", ErrRegistryFailed, err)
+	}
+
+	return nil
+}
+
+// removeURLSchemeEntries 刪除 URL Scheme 的 Registry 項目
+func removeURLSchemeEntries(w registryWriter) error {
+	if err := w.DeleteKey(schemeRegPath); err != nil {
+		return fmt.Errorf("%w: failed to delete scheme key: %v", ErrRegistryFailed, err)
+	}
+	return nil
+}
+
+// buildCommandValue 建構 shell open command 的值
+func buildCommandValue(exePath string) string {
+	return fmt.Sprintf(`"%s" "%%1"`, exePath)
+}
@@ -20,6 +20,18 @@ func EnsureURLSchemeRegistered() error {
 	return ErrNotWindows
 }
 
+// RegisterURLScheme 註冊 kiro:// URL Scheme
+// 非 Windows 平台不支援
+func RegisterURLScheme(exePath string) error {
+	return ErrNotWindows
+}
+
+// UnregisterURLScheme 移除 kiro:// URL Scheme 註冊
+// 非 Windows 平台不支援
+func UnregisterURLScheme() error {
+	return ErrNotWindows
+}
+
 // IsDeepLinkSupported 檢查當前平台是否支援 Deep Link
 // 非 Windows 平台返回 false
 func IsDeepLinkSupported() bool {
@@ -0,0 +1,95 @@
+package deeplink
+
+import (
+	"errors"
+	"testing"
+)
+
+// fakeRegistryWriter 記錄 Registry 寫入操作的假實作
+type fakeRegistryWriter struct {
+	defaults map[string]string
+	values   map[string]string
+	deleted  []string
+	failPath string
+}
+
+func newFakeRegistryWriter() *fakeRegistryWriter {
+	return &fakeRegistryWriter{
+		defaults: make(map[string]string),
+		values:   make(map[string]string),
+	}
+}
+
+func (w *fakeRegistryWriter) SetDefault(regPath, value string) error {
+	if regPath == w.failPath {
+		return errors.New("access denied")
+	}
+	w.defaults[regPath] = value
+	return nil
+}
+
+func (w *fakeRegistryWriter) SetValue(regPath, valueName, value string) error {
+	if regPath == w.failPath {
+		return errors.New("access denied")
+	}
+	w.values[regPath+`\`+valueName] = value
+	return nil
+}
+
+func (w *fakeRegistryWriter) DeleteKey(regPath string) error {
+	if regPath == w.failPath {
+		return errors.New("access denied")
+	}
+	w.deleted = append(w.deleted, regPath)
+	return nil
+}
+
+// TestWriteURLSchemeEntries 驗證寫入的 Registry key 與值
+func TestWriteURLSchemeEntries(t *testing.T) {
+	w := newFakeRegistryWriter()
+	exePath := `C:\Program Files\Kiro Manager\kiro-manager.exe`
+
+	if err := writeURLSchemeEntries(w, exePath); err != nil {
+		t.Fatalf("writeURLSchemeEntries failed: %v", err)
+	}
+
+	if got := w.defaults[`HKCU\Software\Classes\kiro`]; got != "URL:Kiro Manager Protocol" {
+		t.Errorf("scheme description = %q", got)
+	}
+	if got, ok := w.values[`HKCU\Software\Classes\kiro\URL Protocol`]; !ok || got != "" {
+		t.Errorf("URL Protocol = %q, exists = %v", got, ok)
+	}
+	want := `"C:\Program Files\Kiro Manager\kiro-manager.exe" "%1"`
+	if got := w.defaults[`HKCU\Software\Classes\kiro\shell\open\command`]; got != want {
+		t.Errorf("command = %q, want %q", got, want)
+	}
+}
+
+// TestWriteURLSchemeEntries_Errors 驗證空路徑與寫入失敗返回 ErrRegistryFailed
+func TestWriteURLSchemeEntries_Errors(t *testing.T) {
+	if err := writeURLSchemeEntries(newFakeRegistryWriter(), ""); !errors.Is(err, ErrRegistryFailed) {
+		t.Errorf("expected ErrRegistryFailed for empty path, got %v", err)
+	}
+
+	w := newFakeRegistryWriter()
+	w.failPath = commandRegPath
+	if err := writeURLSchemeEntries(w, `C:\kiro-manager.exe`); !errors.Is(err, ErrRegistryFailed) {
+		t.Errorf("expected ErrRegistryFailed, got %v", err)
+	}
+}
+
+// TestRemoveURLSchemeEntries 驗證刪除整個 scheme key
+func TestRemoveURLSchemeEntries(t *testing.T) {
+	w := newFakeRegistryWriter()
+	if err := removeURLSchemeEntries(w); err != nil {
+		t.Fatalf("removeURLSchemeEntries failed: %v", err)
+	}
+	if len(w.deleted) != 1 || w.deleted[0] != `HKCU\Software\Classes\kiro` {
+		t.Errorf("deleted = %v", w.deleted)
+	}
+
+	w.failPath = schemeRegPath
+	if err := removeURLSchemeEntries(w); !errors.Is(err, ErrRegistryFailed) {
+		t.Errorf("expected ErrRegistryFailed, got %v", err)
+	}
+}
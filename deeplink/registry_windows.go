@@ -13,18 +13,6 @@ import (
 	"kiro-manager/internal/cmdutil"
 )
 
-// Registry 路徑常數
-const (
-	// schemeRegPath 是 URL Scheme 的 Registry 路徑
-	schemeRegPath = `HKCU\Software\Classes\` + URLScheme
-
-	// commandRegPath 是 shell open command 的 Registry 路徑
-	commandRegPath = schemeRegPath + `\shell\open\command`
-
-	// schemeDescription 是 URL Scheme 的描述
-	schemeDescription = "URL:Kiro Manager Protocol"
-)
-
 // IsDeepLinkSupported 檢查當前平台是否支援 Deep Link
 // Windows 平台返回 true
 func IsDeepLinkSupported() bool {
@@ -106,25 +94,44 @@ func EnsureURLSchemeRegistered() error {
 	}
 
 	// 註冊 URL Scheme
-	return registerURLScheme(exePath)
+	return RegisterURLScheme(exePath)
 }
 
-// registerURLScheme 寫入 Registry 註冊 URL Scheme
-func registerURLScheme(exePath string) error {
-	// 1. 設定 scheme key 的 default value
-	if err := regAdd(schemeRegPath, schemeDescription); err != nil {
-		return fmt.Errorf("%w: failed to set scheme description: %v", ErrRegistryFailed, err)
-	}
+// RegisterURLScheme 寫入 HKCU\Software\Classes\kiro，將 kiro:// 指向指定執行檔
+func RegisterURLScheme(exePath string) error {
+	return writeURLSchemeEntries(regExeWriter{}, exePath)
+}
 
-	// 2. 設定 URL Protocol 值（空字串表示這是 URL Protocol）
-	if err := regAddValue(schemeRegPath, "URL Protocol", ""); err != nil {
-		return fmt.Errorf("%w: failed to set URL Protocol: %v", ErrRegistryFailed, err)
+// UnregisterURLScheme 刪除 kiro:// 的 Registry 項目
+// 若尚未註冊則直接返回 nil
+func UnregisterURLScheme() error {
+	if registered, _ := IsURLSchemeRegistered(); !registered {
+		return nil
 	}
+	return removeURLSchemeEntries(regExeWriter{})
+}
+
+// regExeWriter 透過 reg.exe 實作 registryWriter
+type regExeWriter struct{}
+
+// SetDefault 設定 key 的 default value
+func (regExeWriter) SetDefault(regPath, value string) error {
+	return regAdd(regPath, value)
+}
+
+// SetValue 設定 key 的指定值
+func (regExeWriter) SetValue(regPath, valueName, value string) error {
+	return regAddValue(regPath, valueName, value)
+}
+
+// DeleteKey 使用 reg delete 刪除 key 及其所有子 key
+func (regExeWriter) DeleteKey(regPath string) error {
+	cmd := exec.Command("reg", "delete", regPath, "/f")
+	cmdutil.HideWindow(cmd)
 
-	// 3. 設定 command key 的 default value
-	commandVal := buildCommandValue(exePath)
-	if err := regAdd(commandRegPath, commandVal); err != nil {
-		return fmt.Errorf("%w: failed to set command: %v", ErrRegistryFailed, err)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("reg delete failed: %s, output: %s", err, string(output))
 	}
 
 	return nil
@@ -186,11 +193,6 @@ func buildRegQueryArgs(regPath string) []string {
 	}
 }
 
-// buildCommandValue 建構 shell open command 的值
-func buildCommandValue(exePath string) string {
-	return fmt.Sprintf(`"%s" "%%1"`, exePath)
-}
-
 // parseRegistryDefaultValue 解析 reg query 輸出，提取 default value
 // 輸出格式範例:
 //
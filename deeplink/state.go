@@ -1,12 +1,19 @@
 package deeplink
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync"
 	"time"
 )
 
+// stateMu 保護 State 檔案的讀寫，避免同一行程內並發登入互相覆寫
+var stateMu sync.Mutex
+
 // OAuthState 定義 OAuth State 結構
 type OAuthState struct {
 	State         string    `json:"state"`
@@ -18,22 +25,48 @@ type OAuthState struct {
 }
 
 // SaveState 將 State 參數持久化到臨時檔案
+// 每個 state 各自保存一份記錄（依 state 值查找），同時更新最近一次登入的記錄
+// 保存前會清除已過期的記錄
 func SaveState(state *OAuthState) error {
-	statePath := getStatePath()
+	stateMu.Lock()
+	defer stateMu.Unlock()
 
 	data, err := json.MarshalIndent(state, "", "  ")
 	if err != nil {
 		return err
 	}
 
-	return os.WriteFile(statePath, data, 0600)
+	sweepExpiredStatesLocked()
+
+	if err := os.MkdirAll(getStatesDir(), 0700); err != nil {
+		return err
+	}
+	if err := os.WriteFile(getStatePathFor(state.State), data, 0600); err != nil {
+		return err
+	}
+
+	return os.WriteFile(getStatePath(), data, 0600)
 }
 
-// LoadState 從臨時檔案讀取 State 參數
+// LoadState 從臨時檔案讀取最近一次保存的 State 參數
 func LoadState() (*OAuthState, error) {
-	statePath := getStatePath()
+	stateMu.Lock()
+	defer stateMu.Unlock()
+
+	return readStateFile(getStatePath())
+}
+
+// LoadStateByValue 依 state 值讀取對應的 State 記錄
+func LoadStateByValue(state string) (*OAuthState, error) {
+	stateMu.Lock()
+	defer stateMu.Unlock()
+
+	return readStateFile(getStatePathFor(state))
+}
 
-	data, err := os.ReadFile(statePath)
+// readStateFile 讀取並解析 State 檔案
+func readStateFile(path string) (*OAuthState, error) {
+	data, err := os.ReadFile(path)
 	if err != nil {
 		if os.IsNotExist(err) {
 			return nil, ErrStateNotFound
@@ -49,11 +82,16 @@ func LoadState() (*OAuthState, error) {
 	return &state, nil
 }
 
-// ClearState 刪除臨時檔案
+// ClearState 刪除所有 State 記錄
 func ClearState() error {
-	statePath := getStatePath()
+	stateMu.Lock()
+	defer stateMu.Unlock()
+
+	if err := os.RemoveAll(getStatesDir()); err != nil {
+		return err
+	}
 
-	err := os.Remove(statePath)
+	err := os.Remove(getStatePath())
 	if err != nil && !os.IsNotExist(err) {
 		return err
 	}
@@ -61,6 +99,60 @@ func ClearState() error {
 	return nil
 }
 
+// ClearStateByValue 僅刪除指定 state 的記錄，不影響其他進行中的登入
+func ClearStateByValue(state string) error {
+	stateMu.Lock()
+	defer stateMu.Unlock()
+
+	err := os.Remove(getStatePathFor(state))
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	// 最近一次的記錄若屬於此 state 一併刪除
+	if latest, err := readStateFile(getStatePath()); err == nil && latest.State == state {
+		if err := os.Remove(getStatePath()); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// SweepExpiredStates 刪除超過 StateExpiry 的 State 記錄
+// 返回刪除的記錄數量
+func SweepExpiredStates() int {
+	stateMu.Lock()
+	defer stateMu.Unlock()
+
+	return sweepExpiredStatesLocked()
+}
+
+// sweepExpiredStatesLocked 刪除已過期或無法解析的記錄，呼叫端需持有 stateMu
+func sweepExpiredStatesLocked() int {
+	entries, err := os.ReadDir(getStatesDir())
+	if err != nil {
+		return 0
+	}
+
+	removed := 0
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+		path := filepath.Join(getStatesDir(), entry.Name())
+		state, err := readStateFile(path)
+		if err == nil && !IsStateExpired(state) {
+			continue
+		}
+		if os.Remove(path) == nil {
+			removed++
+		}
+	}
+
+	return removed
+}
+
 // IsStateExpired 檢查 State 是否已過期
 func IsStateExpired(state *OAuthState) bool {
 	return time.Now().After(state.ExpiresAt)
@@ -74,7 +166,19 @@ func ValidateState(state *OAuthState, expectedState string) error {
 	return nil
 }
 
-// getStatePath 取得臨時檔案路徑
+// getStatePath 取得最近一次 State 的臨時檔案路徑
 func getStatePath() string {
 	return filepath.Join(os.TempDir(), StateFileName)
 }
+
+// getStatesDir 取得依 state 分開保存的記錄目錄
+func getStatesDir() string {
+	return filepath.Join(os.TempDir(), StatesDirName)
+}
+
+// getStatePathFor 取得指定 state 的記錄路徑
+// 以雜湊作為檔名，避免回調中不受信任的 state 值造成路徑穿越
+func getStatePathFor(state string) string {
+	sum := sha256.Sum256([]byte(state))
+	return filepath.Join(getStatesDir(), hex.EncodeToString(sum[:])+".json")
+}
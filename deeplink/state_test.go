@@ -193,3 +193,97 @@ func TestValidateState_Mismatch(t *testing.T) {
 		t.Errorf("ValidateState() error = %v, want %v for mismatched state", err, ErrStateMismatch)
 	}
 }
+
+func TestSaveState_ConcurrentStatesResolveIndependently(t *testing.T) {
+	ClearState()
+	defer ClearState()
+
+	states := []string{"concurrent-state-a", "concurrent-state-b"}
+	errCh := make(chan error, len(states))
+	for _, value := range states {
+		go func(value string) {
+			errCh <- SaveState(&OAuthState{
+				State:        value,
+				Provider:     "github",
+				CodeVerifier: value + "-verifier",
+				CreatedAt:    time.Now(),
+				ExpiresAt:    time.Now().Add(StateExpiry),
+			})
+		}(value)
+	}
+	for range states {
+		if err := <-errCh; err != nil {
+			t.Fatalf("SaveState() error = %v", err)
+		}
+	}
+
+	for _, value := range states {
+		result, err := HandleDeepLinkCallback("kiro://kiro.kiroAgent/authenticate-success?code=code&state=" + value)
+		if err != nil {
+			t.Fatalf("HandleDeepLinkCallback(%s) error = %v", value, err)
+		}
+		if result.State != value {
+			t.Errorf("HandleDeepLinkCallback().State = %v, want %v", result.State, value)
+		}
+
+		saved, err := LoadStateByValue(value)
+		if err != nil {
+			t.Fatalf("LoadStateByValue(%s) error = %v", value, err)
+		}
+		if saved.CodeVerifier != value+"-verifier" {
+			t.Errorf("LoadStateByValue().CodeVerifier = %v, want %v", saved.CodeVerifier, value+"-verifier")
+		}
+	}
+
+	// 清除其中一個不影響另一個
+	if err := ClearStateByValue(states[0]); err != nil {
+		t.Fatalf("ClearStateByValue() error = %v", err)
+	}
+	if _, err := LoadStateByValue(states[0]); err != ErrStateNotFound {
+		t.Errorf("LoadStateByValue() error = %v, want %v", err, ErrStateNotFound)
+	}
+	if _, err := LoadStateByValue(states[1]); err != nil {
+		t.Errorf("LoadStateByValue() error = %v, want nil", err)
+	}
+}
+
+func TestSweepExpiredStates(t *testing.T) {
+	ClearState()
+	defer ClearState()
+
+	expired := &OAuthState{
+		State:     "sweep-expired",
+		CreatedAt: time.Now().Add(-2 * StateExpiry),
+		ExpiresAt: time.Now().Add(-StateExpiry),
+	}
+	valid := &OAuthState{
+		State:     "sweep-valid",
+		CreatedAt: time.Now(),
+		ExpiresAt: time.Now().Add(StateExpiry),
+	}
+	if err := SaveState(expired); err != nil {
+		t.Fatalf("SaveState() error = %v", err)
+	}
+	if err := SaveState(valid); err != nil {
+		t.Fatalf("SaveState() error = %v", err)
+	}
+
+	// 保存新記錄時已清除過期記錄
+	if _, err := LoadStateByValue(expired.State); err != ErrStateNotFound {
+		t.Errorf("expired state should be swept on save, got %v", err)
+	}
+
+	if err := SaveState(&OAuthState{
+		State:     "sweep-expired-late",
+		CreatedAt: time.Now().Add(-2 * StateExpiry),
+		ExpiresAt: time.Now().Add(-StateExpiry),
+	}); err != nil {
+		t.Fatalf("SaveState() error = %v", err)
+	}
+	if removed := SweepExpiredStates(); removed != 1 {
+		t.Errorf("SweepExpiredStates() = %d, want 1", removed)
+	}
+	if _, err := LoadStateByValue(valid.State); err != nil {
+		t.Errorf("valid state should survive sweep, got %v", err)
+	}
+}
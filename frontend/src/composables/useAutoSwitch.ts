@@ -49,9 +49,21 @@ export function useAutoSwitch(): UseAutoSwitchReturn {
     enabled: false,
     balanceThreshold: 5,
     minTargetBalance: 50,
+    warnThreshold: 0,
     folderIds: [],
+    folderScoped: false,
     subscriptionTypes: [],
+    preferSubscriptionOrder: [],
+    blacklist: [],
     refreshIntervals: [],
+    checkInterval: 0,
+    maxSwitchesPerDay: 0,
+    minSwitchInterval: 0,
+    activeHoursStart: '',
+    activeHoursEnd: '',
+    activeWindows: [],
+    dryRun: false,
+    webhookUrl: '',
     notifyOnSwitch: true,
     notifyOnLowBalance: true,
   })
@@ -84,18 +96,31 @@ export function useAutoSwitch(): UseAutoSwitchReturn {
         enabled: settings.enabled,
         balanceThreshold: settings.balanceThreshold,
         minTargetBalance: settings.minTargetBalance,
+        warnThreshold: settings.warnThreshold ?? 0,
         folderIds: settings.folderIds,
+        folderScoped: settings.folderScoped,
         subscriptionTypes: settings.subscriptionTypes,
+        preferSubscriptionOrder: settings.preferSubscriptionOrder ?? [],
+        blacklist: settings.blacklist ?? [],
         refreshIntervals: settings.refreshIntervals,
+        checkInterval: settings.checkInterval ?? 0,
+        maxSwitchesPerDay: settings.maxSwitchesPerDay ?? 0,
+        minSwitchInterval: settings.minSwitchInterval ?? 0,
+        activeHoursStart: settings.activeHoursStart ?? '',
+        activeHoursEnd: settings.activeHoursEnd ?? '',
+        activeWindows: settings.activeWindows ?? [],
+        dryRun: settings.dryRun ?? false,
+        webhookUrl: settings.webhookUrl ?? '',
         notifyOnSwitch: settings.notifyOnSwitch,
         notifyOnLowBalance: settings.notifyOnLowBalance,
       }
       const status = await window.go.main.App.GetAutoSwitchStatus()
       autoSwitchStatus.value = {
-        status: status.status as 'stopped' | 'running' | 'cooldown',
+        status: status.status as AutoSwitchStatus['status'],
         lastBalance: status.lastBalance,
         cooldownRemaining: status.cooldownRemaining,
         switchCount: status.switchCount,
+        lastSwitchTarget: status.lastSwitchTarget,
       }
     } catch (e) {
       console.error('Failed to load auto switch settings:', e)
@@ -144,10 +169,11 @@ export function useAutoSwitch(): UseAutoSwitchReturn {
           // 刷新狀態顯示
           const status = await window.go.main.App.GetAutoSwitchStatus()
           autoSwitchStatus.value = {
-            status: status.status as 'stopped' | 'running' | 'cooldown',
+            status: status.status as AutoSwitchStatus['status'],
             lastBalance: status.lastBalance,
             cooldownRemaining: status.cooldownRemaining,
             switchCount: status.switchCount,
+            lastSwitchTarget: status.lastSwitchTarget,
           }
           return result
         }
@@ -158,10 +184,11 @@ export function useAutoSwitch(): UseAutoSwitchReturn {
       // 刷新狀態顯示
       const status = await window.go.main.App.GetAutoSwitchStatus()
       autoSwitchStatus.value = {
-        status: status.status as 'stopped' | 'running' | 'cooldown',
+        status: status.status as AutoSwitchStatus['status'],
         lastBalance: status.lastBalance,
         cooldownRemaining: status.cooldownRemaining,
         switchCount: status.switchCount,
+        lastSwitchTarget: status.lastSwitchTarget,
       }
       return { success: true, message: '' }
     } finally {
@@ -4,7 +4,7 @@
  * @see App.vue - 原始實作參考
  */
 import { ref, type Ref } from 'vue'
-import type { SoftResetStatus, Result } from '@/types/backup'
+import type { SoftResetStatus, Result, SoftResetResponse } from '@/types/backup'
 import { withTimeout, TimeoutError } from '@/utils/withTimeout'
 
 /** 操作超時時間（毫秒） */
@@ -26,7 +26,7 @@ export interface UseSoftResetReturn {
   getSoftResetStatus: () => Promise<void>
   loadHasUsedReset: () => void
   resetToNew: () => Promise<void>
-  executeReset: () => Promise<Result>
+  executeReset: () => Promise<SoftResetResponse>
   confirmFirstTimeReset: () => Promise<void>
   restoreOriginal: () => Promise<Result>
   regenerateMachineID: (backupName: string) => Promise<Result>
@@ -112,7 +112,7 @@ export function useSoftReset(): UseSoftResetReturn {
    * 執行軟重置
    * @returns 操作結果
    */
-  const executeReset = async (): Promise<Result> => {
+  const executeReset = async (): Promise<SoftResetResponse> => {
     resetting.value = true
     try {
       const result = await window.go.main.App.SoftResetToNewMachine()
@@ -205,12 +205,15 @@ export default {
       running: '监控中',
       stopped: '已停止',
       cooldown: '冷却中',
+      idle: '非允许时段',
     },
     toast: {
       switched: '已自动切换至 {name}',
       switchFailed: '自动切换失败',
       noCandidates: '无符合条件的候选快照',
       maxSwitchReached: '已达切换上限，暂停自动切换 1 小时',
+      dailyCapReached: '已达每日切换上限，24 小时内暂停自动切换',
+      dryRun: '试运行：将自动切换至 {name}',
       lowBalance: '余额即将不足，将自动切换',
     },
     refreshIntervals: {
@@ -205,12 +205,15 @@ export default {
       running: '監控中',
       stopped: '已停止',
       cooldown: '冷卻中',
+      idle: '非允許時段',
     },
     toast: {
       switched: '已自動切換至 {name}',
       switchFailed: '自動切換失敗',
       noCandidates: '無符合條件的候選快照',
       maxSwitchReached: '已達切換上限，暫停自動切換 1 小時',
+      dailyCapReached: '已達每日切換上限，24 小時內暫停自動切換',
+      dryRun: '試運行：將自動切換至 {name}',
       lowBalance: '餘額即將不足，將自動切換',
     },
     refreshIntervals: {
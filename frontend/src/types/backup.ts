@@ -27,6 +27,10 @@ export interface BackupItem {
   isOriginalMachine: boolean
   /** Token 是否已過期 */
   isTokenExpired: boolean
+  /** 過期時間已知且已過（過期時間未知時為 false） */
+  expiryKnownAndPassed?: boolean
+  /** Token 過期時間 (RFC3339 格式)，空字串表示未知 */
+  expiresAt?: string
   /** 訂閱類型名稱 */
   subscriptionTitle: string
   /** 總額度 */
@@ -69,6 +73,17 @@ export interface Result {
   success: boolean
   /** 結果訊息 */
   message: string
+  /** 失敗原因代碼（KIRO_RUNNING、NEEDS_ADMIN、BACKUP_NOT_FOUND 等） */
+  errorCode?: string
+}
+
+/**
+ * 一鍵新機結果
+ * @description SoftResetToNewMachine / SoftResetToMachineID 的返回結構
+ */
+export interface SoftResetResponse extends Result {
+  /** 成功時的完整新 Machine ID（訊息中只顯示前 8 碼） */
+  machineId?: string
 }
 
 /**
@@ -125,33 +140,72 @@ export interface AutoSwitchSettings {
   enabled: boolean
   /** 觸發切換的餘額閾值 */
   balanceThreshold: number
+  /** 餘額預警閾值，0 表示不預警 */
+  warnThreshold?: number
   /** 目標快照的最低餘額要求 */
   minTargetBalance: number
   /** 允許切換的文件夾 ID 列表 */
   folderIds: string[]
+  /** 是否只切換至與當前快照相同文件夾的快照 */
+  folderScoped?: boolean
   /** 允許切換的訂閱類型列表 */
   subscriptionTypes: string[]
+  /** 候選排序的訂閱類型優先順序 */
+  preferSubscriptionOrder?: string[]
+  /** 永不自動切換的快照名稱列表 */
+  blacklist?: string[]
   /** 刷新間隔規則列表 */
   refreshIntervals: RefreshIntervalRule[]
+  /** 固定檢查間隔（秒），0 表示使用刷新間隔規則 */
+  checkInterval?: number
+  /** 滾動 24 小時內最多自動切換次數，0 表示不限制 */
+  maxSwitchesPerDay?: number
+  /** 兩次自動切換之間的最短間隔（秒），0 表示不限制 */
+  minSwitchInterval?: number
+  /** 允許自動切換的開始時間（HH:MM），空字串表示全天 */
+  activeHoursStart?: string
+  /** 允許自動切換的結束時間（HH:MM），早於開始時間表示跨夜 */
+  activeHoursEnd?: string
+  /** 依星期設定的允許時段，非空時優先於 activeHoursStart/activeHoursEnd */
+  activeWindows?: TimeWindow[]
+  /** 試運行模式：只通知不實際切換 */
+  dryRun?: boolean
+  /** 通知 Webhook URL，空字串表示不推送 */
+  webhookUrl?: string
   /** 切換時是否通知 */
   notifyOnSwitch: boolean
   /** 低餘額時是否通知 */
   notifyOnLowBalance: boolean
 }
 
+/**
+ * 自動切換允許時段
+ * @description 依星期設定的允許時段（本地時間）
+ */
+export interface TimeWindow {
+  /** 適用的星期（0 = 週日），空列表表示每天 */
+  weekdays: number[]
+  /** 開始時間（HH:MM） */
+  start: string
+  /** 結束時間（HH:MM），早於開始時間表示跨夜，與開始時間相同表示全天 */
+  end: string
+}
+
 /**
  * 自動切換狀態
  * @description 自動切換監控器的當前狀態
  */
 export interface AutoSwitchStatus {
-  /** 狀態 ('stopped' | 'running' | 'cooldown') */
-  status: 'stopped' | 'running' | 'cooldown'
+  /** 狀態 ('stopped' | 'running' | 'cooldown' | 'paused' | 'idle') */
+  status: 'stopped' | 'running' | 'cooldown' | 'paused' | 'idle'
   /** 最後檢測的餘額 */
   lastBalance: number
   /** 冷卻剩餘時間 (秒) */
   cooldownRemaining: number
   /** 已切換次數 */
   switchCount: number
+  /** 最後一次自動切換的目標快照 */
+  lastSwitchTarget?: string
 }
 
 /**
@@ -52,6 +52,12 @@ export type AutoSwitchEventType =
   | 'cooldown'
   | 'max_switch'
   | 'no_candidates'
+  | 'daily_cap_reached'
+  | 'min_switch_interval'
+  | 'dry_run'
+  | 'outside_active_hours'
+  | 'active_hours_resumed'
+  | 'low_warning'
 
 /** 自動切換事件資料 */
 export interface AutoSwitchEventData {
@@ -0,0 +1,60 @@
+package fsutil
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestWriteFileAtomic_ReplacesContent 測試寫入會覆蓋原有內容且不留下暫存檔
+func TestWriteFileAtomic_ReplacesContent(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "data.json")
+
+	if err := os.WriteFile(path, []byte(`{"old":true}`), 0644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	if err := WriteFileAtomic(path, []byte(`{"new":true}`), 0644); err != nil {
+		t.Fatalf("WriteFileAtomic failed: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile failed: %v", err)
+	}
+	if string(data) != `{"new":true}` {
+		t.Errorf("unexpected content: %s", data)
+	}
+
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Errorf("expected only the target file, got %d entries", len(entries))
+	}
+}
+
+// TestWriteFileAtomic_FailureKeepsOriginal 測試 rename 失敗時保留原檔案並清除暫存檔
+func TestWriteFileAtomic_FailureKeepsOriginal(t *testing.T) {
+	dir := t.TempDir()
+	// 目標為非空目錄，rename 必定失敗
+	target := filepath.Join(dir, "target")
+	if err := os.MkdirAll(filepath.Join(target, "child"), 0755); err != nil {
+		t.Fatalf("MkdirAll failed: %v", err)
+	}
+
+	if err := WriteFileAtomic(target, []byte("data"), 0644); err == nil {
+		t.Fatal("expected error when target is a directory")
+	}
+
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 || entries[0].Name() != "target" {
+		t.Errorf("temp file should be removed, got %v", entries)
+	}
+}
+
+// TestWriteFileAtomic_MissingDir 測試目錄不存在時返回錯誤
+func TestWriteFileAtomic_MissingDir(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "missing", "data.json")
+	if err := WriteFileAtomic(path, []byte("data"), 0644); err == nil {
+		t.Error("expected error when directory does not exist")
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"os"
 	"path/filepath"
 	"runtime"
+	"strings"
 
 	"kiro-manager/settings"
 )
@@ -29,8 +30,15 @@ func (e *DetectionFailedError) Error() string {
 	return "all detection strategies failed"
 }
 
+// KiroHomeEnv 覆寫 Kiro 使用者設定目錄的環境變數
+const KiroHomeEnv = "KIRO_HOME"
+
 // GetKiroHomePath 取得 Kiro 的使用者設定目錄 (~/.kiro)
+// 設定了 KIRO_HOME（絕對路徑）時使用該目錄
 func GetKiroHomePath() (string, error) {
+	if override := os.Getenv(KiroHomeEnv); override != "" && filepath.IsAbs(override) {
+		return filepath.Clean(override), nil
+	}
 	homeDir, err := os.UserHomeDir()
 	if err != nil {
 		return "", err
@@ -152,6 +160,46 @@ func GetKiroInstallPath() (string, error) {
 	}
 }
 
+// ListKiroInstallPaths 列出所有可找到的 Kiro 安裝路徑
+// 第一個為 GetKiroInstallPath 偵測到的主要安裝，其後為自定義路徑與其他常見安裝位置
+// 用於同時安裝穩定版、Insiders 或可攜版的情況；找不到任何安裝時返回空切片
+func ListKiroInstallPaths() []string {
+	var paths []string
+
+	if primary, err := GetKiroInstallPath(); err == nil {
+		paths = append(paths, primary)
+	}
+
+	if customPath := settings.GetCustomKiroInstallPath(); customPath != "" {
+		if _, err := os.Stat(customPath); err == nil {
+			paths = append(paths, customPath)
+		}
+	}
+
+	paths = append(paths, getHardcodedPaths()...)
+
+	return dedupePaths(paths)
+}
+
+// dedupePaths 移除重複的路徑，保留第一次出現的順序
+// Windows 路徑不分大小寫
+func dedupePaths(paths []string) []string {
+	result := make([]string, 0, len(paths))
+	seen := make(map[string]bool, len(paths))
+	for _, path := range paths {
+		key := filepath.Clean(path)
+		if runtime.GOOS == "windows" {
+			key = strings.ToLower(key)
+		}
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		result = append(result, path)
+	}
+	return result
+}
+
 // getRunningProcessPath 從運行中的 Kiro 進程取得安裝路徑
 func getRunningProcessPath() (string, error) {
 	// 延遲導入以避免循環依賴，使用內部實作
@@ -186,6 +234,28 @@ func getHardcodedPath() (string, error) {
 	}
 }
 
+// getHardcodedPaths 列出硬編碼路徑列表中所有存在的安裝路徑
+func getHardcodedPaths() []string {
+	switch runtime.GOOS {
+	case "windows":
+		return getWindowsKiroInstallPaths()
+	case "darwin":
+		return getDarwinKiroInstallPaths()
+	case "linux":
+		return getLinuxKiroInstallPaths()
+	default:
+		return nil
+	}
+}
+
+// firstPath 返回第一個路徑，沒有時返回 ErrKiroNotFound
+func firstPath(paths []string) (string, error) {
+	if len(paths) == 0 {
+		return "", ErrKiroNotFound
+	}
+	return paths[0], nil
+}
+
 // GetKiroInstallPathAutoDetect 自動偵測 Kiro 安裝路徑（忽略自定義設定）
 func GetKiroInstallPathAutoDetect() (string, error) {
 	switch runtime.GOOS {
@@ -255,79 +325,83 @@ func AWSConfigExists() bool {
 }
 
 func getWindowsKiroInstallPath() (string, error) {
-	// 優先檢查使用者安裝路徑
-	localAppData := os.Getenv("LOCALAPPDATA")
-	if localAppData != "" {
-		userPath := filepath.Join(localAppData, "Programs", "Kiro", "Kiro.exe")
-		if _, err := os.Stat(userPath); err == nil {
-			return filepath.Dir(userPath), nil
-		}
-	}
+	return firstPath(getWindowsKiroInstallPaths())
+}
 
-	// 檢查系統安裝路徑
-	programFiles := os.Getenv("PROGRAMFILES")
-	if programFiles != "" {
-		systemPath := filepath.Join(programFiles, "Kiro", "Kiro.exe")
-		if _, err := os.Stat(systemPath); err == nil {
-			return filepath.Dir(systemPath), nil
-		}
+// getWindowsKiroInstallPaths 依優先順序列出所有存在的 Windows 安裝目錄
+func getWindowsKiroInstallPaths() []string {
+	var paths []string
+
+	// 優先檢查使用者安裝路徑，其次為系統安裝路徑與 x86 程式目錄
+	candidates := []struct {
+		env    string
+		subDir string
+	}{
+		{"LOCALAPPDATA", filepath.Join("Programs", "Kiro")},
+		{"PROGRAMFILES", "Kiro"},
+		{"PROGRAMFILES(X86)", "Kiro"},
 	}
-
-	// 檢查 x86 程式目錄
-	programFilesX86 := os.Getenv("PROGRAMFILES(X86)")
-	if programFilesX86 != "" {
-		x86Path := filepath.Join(programFilesX86, "Kiro", "Kiro.exe")
-		if _, err := os.Stat(x86Path); err == nil {
-			return filepath.Dir(x86Path), nil
+	for _, candidate := range candidates {
+		base := os.Getenv(candidate.env)
+		if base == "" {
+			continue
+		}
+		exePath := filepath.Join(base, candidate.subDir, "Kiro.exe")
+		if _, err := os.Stat(exePath); err == nil {
+			paths = append(paths, filepath.Dir(exePath))
 		}
 	}
 
-	return "", ErrKiroNotFound
+	return paths
 }
 
 func getDarwinKiroInstallPath() (string, error) {
+	return firstPath(getDarwinKiroInstallPaths())
+}
+
+// getDarwinKiroInstallPaths 依優先順序列出所有存在的 macOS 安裝目錄
+func getDarwinKiroInstallPaths() []string {
 	// 標準應用程式目錄
-	appPath := "/Applications/Kiro.app"
-	if _, err := os.Stat(appPath); err == nil {
-		return appPath, nil
-	}
+	candidates := []string{"/Applications/Kiro.app"}
 
 	// 使用者應用程式目錄
-	homeDir, err := os.UserHomeDir()
-	if err == nil {
-		userAppPath := filepath.Join(homeDir, "Applications", "Kiro.app")
-		if _, err := os.Stat(userAppPath); err == nil {
-			return userAppPath, nil
-		}
+	if homeDir, err := os.UserHomeDir(); err == nil {
+		candidates = append(candidates, filepath.Join(homeDir, "Applications", "Kiro.app"))
 	}
 
-	return "", ErrKiroNotFound
+	return existingPaths(candidates)
 }
 
 func getLinuxKiroInstallPath() (string, error) {
+	return firstPath(getLinuxKiroInstallPaths())
+}
+
+// getLinuxKiroInstallPaths 依優先順序列出所有存在的 Linux 安裝目錄
+func getLinuxKiroInstallPaths() []string {
 	// 常見的 Linux 安裝路徑
-	paths := []string{
+	candidates := []string{
 		"/usr/share/kiro",
 		"/opt/kiro",
 		"/usr/local/share/kiro",
 	}
 
-	for _, path := range paths {
-		if _, err := os.Stat(path); err == nil {
-			return path, nil
-		}
+	// 檢查使用者本地安裝
+	if homeDir, err := os.UserHomeDir(); err == nil {
+		candidates = append(candidates, filepath.Join(homeDir, ".local", "share", "kiro"))
 	}
 
-	// 檢查使用者本地安裝
-	homeDir, err := os.UserHomeDir()
-	if err == nil {
-		localPath := filepath.Join(homeDir, ".local", "share", "kiro")
-		if _, err := os.Stat(localPath); err == nil {
-			return localPath, nil
+	return existingPaths(candidates)
+}
+
+// existingPaths 過濾出實際存在的路徑
+func existingPaths(candidates []string) []string {
+	var paths []string
+	for _, path := range candidates {
+		if _, err := os.Stat(path); err == nil {
+			paths = append(paths, path)
 		}
 	}
-
-	return "", ErrKiroNotFound
+	return paths
 }
 
 // searchInPath 從 PATH 環境變數中搜索 Kiro 執行檔
@@ -2,6 +2,7 @@ package kiropath
 
 import (
 	"errors"
+	"path/filepath"
 	"testing"
 )
 
@@ -172,3 +173,46 @@ func TestPathCache_SetAndGet(t *testing.T) {
 		t.Errorf("expected '%s', got '%s'", testPath, getPathCache())
 	}
 }
+
+// TestListKiroInstallPaths_PrimaryFirst 測試主要安裝路徑排在第一個
+func TestListKiroInstallPaths_PrimaryFirst(t *testing.T) {
+	InvalidatePathCache()
+	defer InvalidatePathCache()
+
+	primary := t.TempDir()
+	setPathCache(primary)
+
+	paths := ListKiroInstallPaths()
+	if len(paths) == 0 || paths[0] != primary {
+		t.Fatalf("ListKiroInstallPaths() = %v, want %q first", paths, primary)
+	}
+}
+
+// TestDedupePaths 測試移除重複路徑並保留順序
+func TestDedupePaths(t *testing.T) {
+	a := filepath.Join("opt", "kiro")
+	b := filepath.Join("opt", "kiro-insiders")
+
+	got := dedupePaths([]string{a, b, a + string(filepath.Separator), b})
+	if len(got) != 2 || got[0] != a || got[1] != b {
+		t.Errorf("dedupePaths() = %v, want [%s %s]", got, a, b)
+	}
+}
+
+func TestGetKiroHomePath_KiroHomeOverride(t *testing.T) {
+	override := filepath.Join(t.TempDir(), "kiro-home")
+	t.Setenv(KiroHomeEnv, override)
+	if got, err := GetKiroHomePath(); err != nil || got != override {
+		t.Errorf("GetKiroHomePath() = %q, %v, want %q", got, err, override)
+	}
+
+	// 相對路徑不可靠，忽略並使用預設位置
+	t.Setenv(KiroHomeEnv, "relative/kiro")
+	got, err := GetKiroHomePath()
+	if err != nil {
+		t.Fatalf("GetKiroHomePath() error = %v", err)
+	}
+	if filepath.Base(got) != ".kiro" {
+		t.Errorf("GetKiroHomePath() = %q, relative KIRO_HOME should be ignored", got)
+	}
+}
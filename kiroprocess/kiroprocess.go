@@ -2,12 +2,17 @@ package kiroprocess
 
 import (
 	"errors"
+	"os"
+	"path/filepath"
 	"runtime"
+	"strings"
+	"time"
 )
 
 var (
 	ErrUnsupportedPlatform = errors.New("unsupported platform: " + runtime.GOOS)
 	ErrProcessNotFound     = errors.New("kiro process not found")
+	ErrKillTimeout         = errors.New("kiro process did not exit before timeout")
 )
 
 // ProcessInfo 包含進程的基本資訊
@@ -17,6 +22,15 @@ type ProcessInfo struct {
 	ExePath string `json:"exePath"` // 執行檔完整路徑
 }
 
+// 以下函數可於測試時替換
+var (
+	processLister   = listProcesses // 列出系統中的進程
+	killProcessFunc = killProcess   // 終止指定 PID 的進程
+)
+
+// killPollInterval 等待 Kiro 進程結束時的輪詢間隔
+var killPollInterval = 200 * time.Millisecond
+
 // IsKiroRunning 檢查 Kiro 是否正在運行
 func IsKiroRunning() bool {
 	processes, err := GetKiroProcesses()
@@ -28,18 +42,55 @@ func IsKiroRunning() bool {
 
 // GetKiroProcesses 取得所有正在運行的 Kiro 進程
 func GetKiroProcesses() ([]ProcessInfo, error) {
+	processes, err := processLister()
+	if err != nil {
+		return nil, err
+	}
+	return filterKiroProcesses(processes, os.Getpid()), nil
+}
+
+// listProcesses 依平台列出進程
+// Windows 使用 tasklist 篩選 Kiro.exe，macOS 使用 ps，Linux 掃描 /proc
+func listProcesses() ([]ProcessInfo, error) {
 	switch runtime.GOOS {
 	case "windows":
 		return getWindowsKiroProcesses()
 	case "darwin":
-		return getDarwinKiroProcesses()
+		return getDarwinProcesses()
 	case "linux":
-		return getLinuxKiroProcesses()
+		return getLinuxProcesses()
 	default:
 		return nil, ErrUnsupportedPlatform
 	}
 }
 
+// filterKiroProcesses 篩選出 Kiro 進程（排除 selfPID，避免 Kiro Manager 誤判自己）
+func filterKiroProcesses(processes []ProcessInfo, selfPID int) []ProcessInfo {
+	kiroProcesses := []ProcessInfo{}
+	for _, p := range processes {
+		if p.PID != selfPID && isKiroProcess(p) {
+			kiroProcesses = append(kiroProcesses, p)
+		}
+	}
+	return kiroProcesses
+}
+
+// isKiroProcess 依進程名稱或執行檔名稱判斷是否為 Kiro
+// 符合 Kiro.exe（Windows）、kiro（Linux）、Kiro 與 Kiro Helper (*)（macOS），不分大小寫
+func isKiroProcess(p ProcessInfo) bool {
+	names := []string{p.Name}
+	if p.ExePath != "" {
+		names = append(names, filepath.Base(p.ExePath))
+	}
+	for _, name := range names {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "kiro" || name == "kiro.exe" || strings.HasPrefix(name, "kiro helper") {
+			return true
+		}
+	}
+	return false
+}
+
 // GetKiroProcessCount 取得 Kiro 進程數量
 func GetKiroProcessCount() int {
 	processes, err := GetKiroProcesses()
@@ -64,15 +115,7 @@ func KillKiroProcesses() (int, error) {
 
 	killed := 0
 	for _, p := range processes {
-		var killErr error
-		switch runtime.GOOS {
-		case "windows":
-			killErr = killWindowsProcess(p.PID)
-		default:
-			killErr = killUnixProcess(p.PID)
-		}
-
-		if killErr == nil {
+		if killProcessFunc(p.PID) == nil {
 			killed++
 		}
 	}
@@ -80,6 +123,33 @@ func KillKiroProcesses() (int, error) {
 	return killed, nil
 }
 
+// KillKiroProcessesAndWait 關閉所有 Kiro 進程，並等待進程實際結束
+// 進程在 timeout 內仍未結束時返回 ErrKillTimeout
+// 回傳被關閉的進程數量和錯誤
+func KillKiroProcessesAndWait(timeout time.Duration) (int, error) {
+	killed, err := KillKiroProcesses()
+	if err != nil {
+		return killed, err
+	}
+
+	deadline := time.Now().Add(timeout)
+	for IsKiroRunning() {
+		if !time.Now().Before(deadline) {
+			return killed, ErrKillTimeout
+		}
+		time.Sleep(killPollInterval)
+	}
+	return killed, nil
+}
+
+// killProcess 依平台終止指定 PID 的進程
+func killProcess(pid int) error {
+	if runtime.GOOS == "windows" {
+		return killWindowsProcess(pid)
+	}
+	return killUnixProcess(pid)
+}
+
 // GetKiroExecutablePath 從運行中的 Kiro 進程取得執行檔完整路徑
 // 如果 Kiro 未運行，返回 ErrProcessNotFound
 func GetKiroExecutablePath() (string, error) {
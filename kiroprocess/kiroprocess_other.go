@@ -3,7 +3,10 @@
 package kiroprocess
 
 import (
+	"fmt"
+	"os"
 	"os/exec"
+	"path/filepath"
 	"strconv"
 	"strings"
 )
@@ -18,46 +21,78 @@ func killWindowsProcess(pid int) error {
 	return ErrUnsupportedPlatform
 }
 
-func getDarwinKiroProcesses() ([]ProcessInfo, error) {
-	cmd := exec.Command("pgrep", "-l", "Kiro")
+// getDarwinProcesses 使用 ps 列出所有進程 (macOS)
+// comm 欄位為執行檔完整路徑，例如 /Applications/Kiro.app/Contents/MacOS/Kiro
+func getDarwinProcesses() ([]ProcessInfo, error) {
+	cmd := exec.Command("ps", "-axo", "pid=,comm=")
 	output, err := cmd.Output()
 	if err != nil {
-		return []ProcessInfo{}, nil
+		return nil, fmt.Errorf("failed to list processes: %w", err)
 	}
-	return parseUnixPgrep(string(output))
+	return parsePsOutput(string(output)), nil
 }
 
-func getLinuxKiroProcesses() ([]ProcessInfo, error) {
-	cmd := exec.Command("pgrep", "-l", "-i", "kiro")
-	output, err := cmd.Output()
-	if err != nil {
-		return []ProcessInfo{}, nil
-	}
-	return parseUnixPgrep(string(output))
-}
-
-func parseUnixPgrep(output string) ([]ProcessInfo, error) {
+// parsePsOutput 解析 ps -axo pid=,comm= 輸出
+func parsePsOutput(output string) []ProcessInfo {
 	var processes []ProcessInfo
-	lines := strings.Split(strings.TrimSpace(output), "\n")
-
-	for _, line := range lines {
+	for _, line := range strings.Split(output, "\n") {
 		line = strings.TrimSpace(line)
 		if line == "" {
 			continue
 		}
 
+		// 執行檔路徑可能包含空白（例如 Kiro Helper (Renderer)），只以第一個空白分割
 		parts := strings.SplitN(line, " ", 2)
-		if len(parts) >= 2 {
-			pid, err := strconv.Atoi(parts[0])
-			if err == nil {
-				processes = append(processes, ProcessInfo{
-					PID:  pid,
-					Name: parts[1],
-				})
-			}
+		if len(parts) < 2 {
+			continue
 		}
+		pid, err := strconv.Atoi(parts[0])
+		if err != nil {
+			continue
+		}
+
+		command := strings.TrimSpace(parts[1])
+		info := ProcessInfo{PID: pid, Name: filepath.Base(command)}
+		if filepath.IsAbs(command) {
+			info.ExePath = command
+		}
+		processes = append(processes, info)
+	}
+	return processes
+}
+
+// getLinuxProcesses 掃描 /proc 列出所有進程 (Linux)
+func getLinuxProcesses() ([]ProcessInfo, error) {
+	return scanProcDir("/proc")
+}
+
+// scanProcDir 掃描 proc 目錄，從 comm 取得進程名稱、從 exe 連結取得執行檔路徑
+// 無權限讀取 exe 的進程（其他使用者）僅填入名稱
+func scanProcDir(procDir string) ([]ProcessInfo, error) {
+	entries, err := os.ReadDir(procDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", procDir, err)
 	}
 
+	var processes []ProcessInfo
+	for _, entry := range entries {
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil || !entry.IsDir() {
+			continue
+		}
+
+		comm, err := os.ReadFile(filepath.Join(procDir, entry.Name(), "comm"))
+		if err != nil {
+			// 進程可能已結束
+			continue
+		}
+
+		info := ProcessInfo{PID: pid, Name: strings.TrimSpace(string(comm))}
+		if exePath, err := os.Readlink(filepath.Join(procDir, entry.Name(), "exe")); err == nil {
+			info.ExePath = strings.TrimSuffix(exePath, " (deleted)")
+		}
+		processes = append(processes, info)
+	}
 	return processes, nil
 }
 
@@ -72,56 +107,38 @@ func getWindowsKiroExecutablePath() (string, error) {
 	return "", ErrUnsupportedPlatform
 }
 
-// getDarwinKiroExecutablePath 使用 lsof 取得 Kiro 進程的執行檔路徑 (macOS)
+// getDarwinKiroExecutablePath 從 Kiro 主進程取得執行檔路徑 (macOS)
 func getDarwinKiroExecutablePath() (string, error) {
-	processes, err := getDarwinKiroProcesses()
-	if err != nil {
-		return "", err
-	}
-	if len(processes) == 0 {
-		return "", ErrProcessNotFound
-	}
-
-	// 使用 lsof 取得進程的執行檔路徑
-	cmd := exec.Command("lsof", "-p", strconv.Itoa(processes[0].PID), "-Fn")
-	output, err := cmd.Output()
-	if err != nil {
-		return "", ErrProcessNotFound
-	}
-
-	// 解析 lsof 輸出，找到 txt 類型的執行檔
-	lines := strings.Split(string(output), "\n")
-	for _, line := range lines {
-		if strings.HasPrefix(line, "n") && strings.Contains(line, "Kiro") {
-			return strings.TrimPrefix(line, "n"), nil
-		}
-	}
-
-	return "", ErrProcessNotFound
+	return kiroExecutablePath()
 }
 
-// getLinuxKiroExecutablePath 使用 /proc 取得 Kiro 進程的執行檔路徑 (Linux)
+// getLinuxKiroExecutablePath 從 Kiro 進程的 /proc/[pid]/exe 取得執行檔路徑 (Linux)
 func getLinuxKiroExecutablePath() (string, error) {
-	processes, err := getLinuxKiroProcesses()
+	return kiroExecutablePath()
+}
+
+// kiroExecutablePath 返回第一個已知執行檔路徑的 Kiro 進程，優先選擇主進程（非 Kiro Helper）
+func kiroExecutablePath() (string, error) {
+	processes, err := GetKiroProcesses()
 	if err != nil {
 		return "", err
 	}
-	if len(processes) == 0 {
-		return "", ErrProcessNotFound
-	}
 
-	// 讀取 /proc/[pid]/exe 符號連結
-	exePath := "/proc/" + strconv.Itoa(processes[0].PID) + "/exe"
-	cmd := exec.Command("readlink", "-f", exePath)
-	output, err := cmd.Output()
-	if err != nil {
-		return "", ErrProcessNotFound
+	path := ""
+	for _, p := range processes {
+		if p.ExePath == "" {
+			continue
+		}
+		if !strings.HasPrefix(strings.ToLower(filepath.Base(p.ExePath)), "kiro helper") {
+			return p.ExePath, nil
+		}
+		if path == "" {
+			path = p.ExePath
+		}
 	}
 
-	path := strings.TrimSpace(string(output))
 	if path == "" {
 		return "", ErrProcessNotFound
 	}
-
 	return path, nil
 }
@@ -0,0 +1,67 @@
+//go:build !windows
+
+package kiroprocess
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestParsePsOutput 測試解析 macOS ps 輸出（路徑包含空白）
+func TestParsePsOutput(t *testing.T) {
+	output := `    1 /sbin/launchd
+  512 /Applications/Kiro.app/Contents/MacOS/Kiro
+  513 /Applications/Kiro.app/Contents/Frameworks/Kiro Helper (GPU).app/Contents/MacOS/Kiro Helper (GPU)
+  600 zsh
+ bad line
+`
+	processes := parsePsOutput(output)
+	if len(processes) != 4 {
+		t.Fatalf("parsePsOutput() returned %d processes, want 4", len(processes))
+	}
+	if processes[1].PID != 512 || processes[1].Name != "Kiro" || processes[1].ExePath != "/Applications/Kiro.app/Contents/MacOS/Kiro" {
+		t.Errorf("unexpected main process: %+v", processes[1])
+	}
+	if processes[2].Name != "Kiro Helper (GPU)" {
+		t.Errorf("helper name = %q, want %q", processes[2].Name, "Kiro Helper (GPU)")
+	}
+	if processes[3].Name != "zsh" || processes[3].ExePath != "" {
+		t.Errorf("relative command should only set Name: %+v", processes[3])
+	}
+}
+
+// TestScanProcDir 測試掃描 /proc 結構取得 PID、名稱與執行檔路徑
+func TestScanProcDir(t *testing.T) {
+	procDir := t.TempDir()
+	writeProc := func(pid, comm, exe string) {
+		dir := filepath.Join(procDir, pid)
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatalf("failed to create proc dir: %v", err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, "comm"), []byte(comm+"\n"), 0644); err != nil {
+			t.Fatalf("failed to write comm: %v", err)
+		}
+		if exe != "" {
+			if err := os.Symlink(exe, filepath.Join(dir, "exe")); err != nil {
+				t.Fatalf("failed to create exe link: %v", err)
+			}
+		}
+	}
+	writeProc("4321", "kiro", "/opt/Kiro/kiro")
+	writeProc("4322", "sshd", "")
+	os.MkdirAll(filepath.Join(procDir, "self"), 0755)
+
+	processes, err := scanProcDir(procDir)
+	if err != nil {
+		t.Fatalf("scanProcDir() error = %v", err)
+	}
+	if len(processes) != 2 {
+		t.Fatalf("scanProcDir() returned %d processes, want 2", len(processes))
+	}
+
+	kiro := filterKiroProcesses(processes, 0)
+	if len(kiro) != 1 || kiro[0].PID != 4321 || kiro[0].Name != "kiro" || kiro[0].ExePath != "/opt/Kiro/kiro" {
+		t.Errorf("unexpected Kiro processes: %+v", kiro)
+	}
+}
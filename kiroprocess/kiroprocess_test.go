@@ -1,7 +1,10 @@
 package kiroprocess
 
 import (
+	"errors"
+	"os"
 	"testing"
+	"time"
 )
 
 // TestProcessInfo_ExePath 測試 ProcessInfo 結構應包含 ExePath 欄位
@@ -65,3 +68,122 @@ func TestGetKiroExecutablePath_Running(t *testing.T) {
 	// 路徑應該包含 Kiro
 	t.Logf("Found Kiro executable path: %s", path)
 }
+
+// stubProcesses 以固定的進程列表替換 processLister，並記錄被終止的 PID
+func stubProcesses(t *testing.T, processes []ProcessInfo) *[]int {
+	t.Helper()
+	var killed []int
+	previousLister, previousKill := processLister, killProcessFunc
+	processLister = func() ([]ProcessInfo, error) { return processes, nil }
+	killProcessFunc = func(pid int) error {
+		killed = append(killed, pid)
+		return nil
+	}
+	t.Cleanup(func() {
+		processLister, killProcessFunc = previousLister, previousKill
+	})
+	return &killed
+}
+
+// TestGetKiroProcesses_FiltersByName 測試只返回 Kiro 進程，並排除 Kiro Manager 自身
+func TestGetKiroProcesses_FiltersByName(t *testing.T) {
+	stubProcesses(t, []ProcessInfo{
+		{PID: 100, Name: "kiro", ExePath: "/usr/share/kiro/kiro"},
+		{PID: 101, Name: "Kiro Helper (Renderer)", ExePath: "/Applications/Kiro.app/Contents/Frameworks/Kiro Helper (Renderer).app/Contents/MacOS/Kiro Helper (Renderer)"},
+		{PID: 102, Name: "Kiro.exe"},
+		{PID: 103, Name: "kiro-manager", ExePath: "/usr/bin/kiro-manager"},
+		{PID: 104, Name: "bash", ExePath: "/usr/bin/bash"},
+		{PID: os.Getpid(), Name: "kiro"},
+	})
+
+	processes, err := GetKiroProcesses()
+	if err != nil {
+		t.Fatalf("GetKiroProcesses() error = %v", err)
+	}
+
+	var pids []int
+	for _, p := range processes {
+		pids = append(pids, p.PID)
+	}
+	if len(pids) != 3 || pids[0] != 100 || pids[1] != 101 || pids[2] != 102 {
+		t.Errorf("GetKiroProcesses() PIDs = %v, want [100 101 102]", pids)
+	}
+	if !IsKiroRunning() || GetKiroProcessCount() != 3 {
+		t.Errorf("IsKiroRunning() = %v, GetKiroProcessCount() = %d", IsKiroRunning(), GetKiroProcessCount())
+	}
+}
+
+// TestKillKiroProcesses_UsesLister 測試只終止 Kiro 進程
+func TestKillKiroProcesses_UsesLister(t *testing.T) {
+	killed := stubProcesses(t, []ProcessInfo{
+		{PID: 200, Name: "kiro"},
+		{PID: 201, Name: "code"},
+		{PID: 202, Name: "kiro"},
+	})
+
+	count, err := KillKiroProcesses()
+	if err != nil {
+		t.Fatalf("KillKiroProcesses() error = %v", err)
+	}
+	if count != 2 || len(*killed) != 2 || (*killed)[0] != 200 || (*killed)[1] != 202 {
+		t.Errorf("KillKiroProcesses() = %d, killed %v, want 2 [200 202]", count, *killed)
+	}
+}
+
+// TestGetKiroProcesses_ListerError 測試列出進程失敗時 IsKiroRunning 返回 false
+func TestGetKiroProcesses_ListerError(t *testing.T) {
+	stubProcesses(t, nil)
+	processLister = func() ([]ProcessInfo, error) { return nil, errors.New("ps failed") }
+
+	if _, err := GetKiroProcesses(); err == nil {
+		t.Error("expected error from GetKiroProcesses")
+	}
+	if IsKiroRunning() {
+		t.Error("IsKiroRunning() should be false when listing fails")
+	}
+}
+
+// TestKillKiroProcessesAndWait_ExitsAfterPolls 測試進程在數次輪詢後結束時正常返回
+func TestKillKiroProcessesAndWait_ExitsAfterPolls(t *testing.T) {
+	killed := stubProcesses(t, nil)
+	previousInterval := killPollInterval
+	killPollInterval = time.Millisecond
+	t.Cleanup(func() { killPollInterval = previousInterval })
+
+	// 前三次列出進程時 Kiro 仍存在（kill 一次 + 輪詢兩次），之後結束
+	lists := 0
+	processLister = func() ([]ProcessInfo, error) {
+		lists++
+		if lists <= 3 {
+			return []ProcessInfo{{PID: 300, Name: "kiro"}}, nil
+		}
+		return nil, nil
+	}
+
+	count, err := KillKiroProcessesAndWait(time.Second)
+	if err != nil {
+		t.Fatalf("KillKiroProcessesAndWait() error = %v", err)
+	}
+	if count != 1 || len(*killed) != 1 {
+		t.Errorf("KillKiroProcessesAndWait() = %d, killed %v, want 1 [300]", count, *killed)
+	}
+	if lists != 4 {
+		t.Errorf("expected 4 process listings, got %d", lists)
+	}
+}
+
+// TestKillKiroProcessesAndWait_Timeout 測試進程未結束時返回 ErrKillTimeout
+func TestKillKiroProcessesAndWait_Timeout(t *testing.T) {
+	stubProcesses(t, []ProcessInfo{{PID: 301, Name: "kiro"}})
+	previousInterval := killPollInterval
+	killPollInterval = time.Millisecond
+	t.Cleanup(func() { killPollInterval = previousInterval })
+
+	count, err := KillKiroProcessesAndWait(20 * time.Millisecond)
+	if !errors.Is(err, ErrKillTimeout) {
+		t.Errorf("KillKiroProcessesAndWait() error = %v, want ErrKillTimeout", err)
+	}
+	if count != 1 {
+		t.Errorf("KillKiroProcessesAndWait() = %d, want 1", count)
+	}
+}
@@ -183,13 +183,13 @@ func getWindowsKiroExecutablePathPowerShell() (string, error) {
 	return path, nil
 }
 
-// getDarwinKiroProcesses Windows 平台不支援
-func getDarwinKiroProcesses() ([]ProcessInfo, error) {
+// getDarwinProcesses Windows 平台不支援
+func getDarwinProcesses() ([]ProcessInfo, error) {
 	return nil, ErrUnsupportedPlatform
 }
 
-// getLinuxKiroProcesses Windows 平台不支援
-func getLinuxKiroProcesses() ([]ProcessInfo, error) {
+// getLinuxProcesses Windows 平台不支援
+func getLinuxProcesses() ([]ProcessInfo, error) {
 	return nil, ErrUnsupportedPlatform
 }
 
@@ -0,0 +1,64 @@
+package kiroversion
+
+import (
+	"os"
+	"sync"
+	"time"
+)
+
+// versionCache 快取最近一次偵測到的版本號，以來源檔案的修改時間判斷是否失效
+type versionCache struct {
+	mu         sync.Mutex
+	sourcePath string
+	modTime    time.Time
+	version    string
+}
+
+var cache versionCache
+
+// 以下函數可於測試時替換
+var (
+	versionSourcePathFunc = getVersionSourcePath
+	readVersionFunc       = GetKiroVersion
+)
+
+// GetKiroVersionCached 取得 Kiro IDE 的版本號（快取）
+// 來源檔案（Kiro.exe、Info.plist 或 package.json）的路徑與修改時間未變更時直接返回快取值，
+// 避免批次刷新時重複讀取執行檔
+func GetKiroVersionCached() (string, error) {
+	sourcePath, err := versionSourcePathFunc()
+	if err != nil {
+		return "", err
+	}
+	info, err := os.Stat(sourcePath)
+	if err != nil {
+		return "", ErrVersionNotFound
+	}
+
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	if cache.version != "" && cache.sourcePath == sourcePath && cache.modTime.Equal(info.ModTime()) {
+		return cache.version, nil
+	}
+
+	version, err := readVersionFunc()
+	if err != nil {
+		return "", err
+	}
+
+	cache.sourcePath = sourcePath
+	cache.modTime = info.ModTime()
+	cache.version = version
+	return version, nil
+}
+
+// InvalidateVersionCache 清除版本號快取，下次呼叫 GetKiroVersionCached 時重新讀取
+func InvalidateVersionCache() {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	cache.sourcePath = ""
+	cache.modTime = time.Time{}
+	cache.version = ""
+}
@@ -0,0 +1,91 @@
+package kiroversion
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// stubVersionSource 將版本來源替換為暫存檔，返回來源路徑與讀取次數計數器
+func stubVersionSource(t *testing.T, version string) (string, *int) {
+	t.Helper()
+	sourcePath := filepath.Join(t.TempDir(), "package.json")
+	if err := os.WriteFile(sourcePath, []byte(`{"version":"`+version+`"}`), 0644); err != nil {
+		t.Fatalf("failed to write version source: %v", err)
+	}
+
+	reads := 0
+	previousSource, previousRead := versionSourcePathFunc, readVersionFunc
+	versionSourcePathFunc = func() (string, error) { return sourcePath, nil }
+	readVersionFunc = func() (string, error) {
+		reads++
+		return version, nil
+	}
+	InvalidateVersionCache()
+	t.Cleanup(func() {
+		versionSourcePathFunc, readVersionFunc = previousSource, previousRead
+		InvalidateVersionCache()
+	})
+	return sourcePath, &reads
+}
+
+func TestGetKiroVersionCached_UnchangedMtime(t *testing.T) {
+	_, reads := stubVersionSource(t, "0.8.206")
+
+	for i := 0; i < 3; i++ {
+		version, err := GetKiroVersionCached()
+		if err != nil {
+			t.Fatalf("GetKiroVersionCached() error = %v", err)
+		}
+		if version != "0.8.206" {
+			t.Errorf("GetKiroVersionCached() = %q, want 0.8.206", version)
+		}
+	}
+	if *reads != 1 {
+		t.Errorf("expected a single read while mtime is unchanged, got %d", *reads)
+	}
+}
+
+func TestGetKiroVersionCached_MtimeBump(t *testing.T) {
+	sourcePath, reads := stubVersionSource(t, "0.8.206")
+
+	if _, err := GetKiroVersionCached(); err != nil {
+		t.Fatalf("GetKiroVersionCached() error = %v", err)
+	}
+
+	// 模擬 Kiro 更新後來源檔案的修改時間變更
+	bumped := time.Now().Add(time.Hour)
+	if err := os.Chtimes(sourcePath, bumped, bumped); err != nil {
+		t.Fatalf("failed to bump mtime: %v", err)
+	}
+	if _, err := GetKiroVersionCached(); err != nil {
+		t.Fatalf("GetKiroVersionCached() error = %v", err)
+	}
+	if *reads != 2 {
+		t.Errorf("expected a re-read after mtime bump, got %d reads", *reads)
+	}
+
+	// 手動清除快取同樣會重新讀取
+	InvalidateVersionCache()
+	if _, err := GetKiroVersionCached(); err != nil {
+		t.Fatalf("GetKiroVersionCached() error = %v", err)
+	}
+	if *reads != 3 {
+		t.Errorf("expected a re-read after InvalidateVersionCache, got %d reads", *reads)
+	}
+}
+
+func TestGetKiroVersionCached_MissingSource(t *testing.T) {
+	sourcePath, reads := stubVersionSource(t, "0.8.206")
+	if err := os.Remove(sourcePath); err != nil {
+		t.Fatalf("failed to remove version source: %v", err)
+	}
+
+	if _, err := GetKiroVersionCached(); err != ErrVersionNotFound {
+		t.Errorf("GetKiroVersionCached() error = %v, want ErrVersionNotFound", err)
+	}
+	if *reads != 0 {
+		t.Errorf("expected no read when the source is missing, got %d", *reads)
+	}
+}
@@ -18,27 +18,49 @@ var (
 // GetKiroVersion 取得 Kiro IDE 的版本號
 // 從 Kiro 執行檔的 metadata 讀取實際版本
 func GetKiroVersion() (string, error) {
+	sourcePath, err := getVersionSourcePath()
+	if err != nil {
+		return "", err
+	}
+
 	switch runtime.GOOS {
 	case "windows":
-		return getWindowsKiroVersion()
+		return getWindowsKiroVersion(sourcePath)
 	case "darwin":
-		return getDarwinKiroVersion()
-	case "linux":
-		return getLinuxKiroVersion()
+		return getDarwinKiroVersion(sourcePath)
 	default:
-		return "", ErrVersionNotFound
+		return getLinuxKiroVersion(sourcePath)
 	}
 }
 
-// getWindowsKiroVersion 使用 PowerShell 讀取 exe 的 FileVersion
-func getWindowsKiroVersion() (string, error) {
+// getVersionSourcePath 取得記錄 Kiro 版本資訊的檔案路徑
+// Windows 為 Kiro.exe、macOS 為 Info.plist、Linux 為 resources/app/package.json
+func getVersionSourcePath() (string, error) {
+	switch runtime.GOOS {
+	case "windows", "darwin", "linux":
+	default:
+		return "", ErrVersionNotFound
+	}
+
 	installPath, err := kiropath.GetKiroInstallPath()
 	if err != nil {
 		return "", err
 	}
 
-	exePath := filepath.Join(installPath, "Kiro.exe")
+	switch runtime.GOOS {
+	case "windows":
+		return filepath.Join(installPath, "Kiro.exe"), nil
+	case "darwin":
+		// Info.plist 位於 Kiro.app/Contents/Info.plist
+		return filepath.Join(installPath, "Contents", "Info.plist"), nil
+	default:
+		// Electron 應用通常會有 resources/app/package.json
+		return filepath.Join(installPath, "resources", "app", "package.json"), nil
+	}
+}
 
+// getWindowsKiroVersion 使用 PowerShell 讀取 exe 的 FileVersion
+func getWindowsKiroVersion(exePath string) (string, error) {
 	// 使用 PowerShell 讀取版本資訊
 	// (Get-Item "path").VersionInfo.FileVersion
 	cmd := exec.Command("powershell", "-NoProfile", "-Command",
@@ -60,15 +82,7 @@ func getWindowsKiroVersion() (string, error) {
 
 
 // getDarwinKiroVersion 讀取 Kiro.app 的 Info.plist 取得版本
-func getDarwinKiroVersion() (string, error) {
-	installPath, err := kiropath.GetKiroInstallPath()
-	if err != nil {
-		return "", err
-	}
-
-	// Info.plist 位於 Kiro.app/Contents/Info.plist
-	plistPath := filepath.Join(installPath, "Contents", "Info.plist")
-
+func getDarwinKiroVersion(plistPath string) (string, error) {
 	// 使用 defaults read 讀取 CFBundleShortVersionString
 	cmd := exec.Command("defaults", "read", plistPath, "CFBundleShortVersionString")
 	output, err := cmd.Output()
@@ -89,17 +103,8 @@ func getDarwinKiroVersion() (string, error) {
 	return version, nil
 }
 
-// getLinuxKiroVersion 嘗試從常見位置讀取版本資訊
-func getLinuxKiroVersion() (string, error) {
-	installPath, err := kiropath.GetKiroInstallPath()
-	if err != nil {
-		return "", err
-	}
-
-	// 嘗試讀取 package.json 或 version 檔案
-	// Electron 應用通常會有 resources/app/package.json
-	packageJsonPath := filepath.Join(installPath, "resources", "app", "package.json")
-
+// getLinuxKiroVersion 從 resources/app/package.json 讀取版本資訊
+func getLinuxKiroVersion(packageJsonPath string) (string, error) {
 	cmd := exec.Command("grep", "-oP", `"version"\s*:\s*"\K[^"]+`, packageJsonPath)
 	output, err := cmd.Output()
 	if err != nil {
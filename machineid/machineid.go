@@ -4,9 +4,20 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"errors"
+	"fmt"
+	"regexp"
 	"runtime"
+	"strings"
+
+	"github.com/google/uuid"
 )
 
+// ErrInvalidMachineID Machine ID 不是 UUID 或 64 位十六進位字串
+var ErrInvalidMachineID = errors.New("machine ID must be a 64-character hex string or a UUID")
+
+// hashedMachineIDPattern 與 patch 中的格式驗證一致（64 位十六進位）
+var hashedMachineIDPattern = regexp.MustCompile(`^[a-fA-F0-9]{64}$`)
+
 // GetMachineId 取得系統的 Machine ID，經過 SHA-256 雜湊後回傳
 func GetMachineId() (string, error) {
 	rawId, err := GetRawMachineId()
@@ -40,3 +51,21 @@ func hashSHA256(data string) string {
 func HashMachineID(rawMachineID string) string {
 	return hashSHA256(rawMachineID)
 }
+
+// ValidateRawMachineID 驗證使用者輸入的 Machine ID 格式
+// 接受標準 UUID（36 字元）或 64 位十六進位字串，前後空白會被忽略
+func ValidateRawMachineID(s string) error {
+	id := strings.TrimSpace(s)
+	if IsHashedMachineID(id) {
+		return nil
+	}
+	if _, err := uuid.Parse(id); err != nil || len(id) != 36 {
+		return fmt.Errorf("%w: %q", ErrInvalidMachineID, s)
+	}
+	return nil
+}
+
+// IsHashedMachineID 檢查是否為 64 位十六進位（已雜湊）格式
+func IsHashedMachineID(s string) bool {
+	return hashedMachineIDPattern.MatchString(s)
+}
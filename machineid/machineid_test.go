@@ -0,0 +1,36 @@
+package machineid
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestValidateRawMachineID_UUID(t *testing.T) {
+	if err := ValidateRawMachineID("123E4567-E89B-12D3-A456-426614174000"); err != nil {
+		t.Errorf("ValidateRawMachineID(uuid) error = %v, want nil", err)
+	}
+}
+
+func TestValidateRawMachineID_Hex(t *testing.T) {
+	if err := ValidateRawMachineID(" " + strings.Repeat("ab", 32) + "\n"); err != nil {
+		t.Errorf("ValidateRawMachineID(hex) error = %v, want nil", err)
+	}
+}
+
+func TestValidateRawMachineID_Invalid(t *testing.T) {
+	invalid := []string{
+		"",
+		"not-a-machine-id",
+		strings.Repeat("a", 63),
+		strings.Repeat("g", 64),
+		"123e4567e89b12d3a456426614174000",
+		"{123e4567-e89b-12d3-a456-426614174000}",
+	}
+
+	for _, input := range invalid {
+		if err := ValidateRawMachineID(input); !errors.Is(err, ErrInvalidMachineID) {
+			t.Errorf("ValidateRawMachineID(%q) error = %v, want ErrInvalidMachineID", input, err)
+		}
+	}
+}
@@ -24,6 +24,7 @@ type CallbackServer struct {
 	server        *http.Server
 	listener      net.Listener
 	port          int
+	ports         []int // 優先嘗試的端口（依序），為空時使用隨機端口
 	resultChan    chan *CallbackResult
 	errorChan     chan error
 	mu            sync.Mutex
@@ -39,11 +40,19 @@ func NewCallbackServer(expectedState string) *CallbackServer {
 	}
 }
 
-// Start 在隨機端口啟動 HTTP Server
+// NewCallbackServerWithPorts 建立依序嘗試指定端口的 Callback Server
+// 部分提供者只允許白名單內的 localhost 端口作為 redirect_uri
+func NewCallbackServerWithPorts(expectedState string, ports []int) *CallbackServer {
+	s := NewCallbackServer(expectedState)
+	s.ports = append([]int(nil), ports...)
+	return s
+}
+
+// Start 啟動 HTTP Server
+// 設定了候選端口時依序嘗試，使用第一個可綁定的端口；否則使用隨機端口
 // 返回分配的端口號
 func (s *CallbackServer) Start() (int, error) {
-	// 監聽隨機端口
-	listener, err := net.Listen("tcp", "localhost:0")
+	listener, err := s.listen()
 	if err != nil {
 		return 0, err
 	}
@@ -68,6 +77,23 @@ func (s *CallbackServer) Start() (int, error) {
 	return s.port, nil
 }
 
+// listen 依序嘗試候選端口，全部失敗時返回最後一個錯誤
+func (s *CallbackServer) listen() (net.Listener, error) {
+	if len(s.ports) == 0 {
+		return net.Listen("tcp", "localhost:0")
+	}
+
+	var lastErr error
+	for _, port := range s.ports {
+		listener, err := net.Listen("tcp", fmt.Sprintf("localhost:%d", port))
+		if err == nil {
+			return listener, nil
+		}
+		lastErr = err
+	}
+	return nil, fmt.Errorf("no available callback port in %v: %w", s.ports, lastErr)
+}
+
 // handleCallback 處理 OAuth 回調
 func (s *CallbackServer) handleCallback(w http.ResponseWriter, r *http.Request) {
 	query := r.URL.Query()
@@ -114,12 +140,23 @@ func (s *CallbackServer) handleCallback(w http.ResponseWriter, r *http.Request)
 // WaitForCallback 等待回調結果
 // timeout 為等待超時時間
 func (s *CallbackServer) WaitForCallback(timeout time.Duration) (*CallbackResult, error) {
+	return s.WaitForCallbackContext(context.Background(), timeout)
+}
+
+// WaitForCallbackContext 與 WaitForCallback 相同，但 ctx 取消時會關閉 Server 並返回 ErrCodeCancelled
+func (s *CallbackServer) WaitForCallbackContext(ctx context.Context, timeout time.Duration) (*CallbackResult, error) {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
 	select {
 	case result := <-s.resultChan:
 		return result, nil
 	case err := <-s.errorChan:
 		return nil, err
-	case <-time.After(timeout):
+	case <-ctx.Done():
+		s.Stop()
+		return nil, contextError(ctx, "login")
+	case <-timer.C:
 		return nil, &OAuthError{
 			Code:    ErrCodeTimeout,
 			Message: "登入超時，請重試",
@@ -1,8 +1,11 @@
 package oauthlogin
 
 import (
+	"context"
 	"fmt"
+	"net"
 	"net/http"
+	"net/url"
 	"testing"
 	"time"
 )
@@ -122,6 +125,97 @@ func TestCallbackServer_Timeout(t *testing.T) {
 	}
 }
 
+// TestCallbackServer_ContextCancel 測試等待中取消 context 會立即返回並關閉 Server
+func TestCallbackServer_ContextCancel(t *testing.T) {
+	pkce, err := GeneratePKCE()
+	if err != nil {
+		t.Fatalf("GeneratePKCE() failed: %v", err)
+	}
+
+	server := NewCallbackServer(pkce.State)
+	port, err := server.Start()
+	if err != nil {
+		t.Fatalf("server.Start() failed: %v", err)
+	}
+	defer server.Stop()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	start := time.Now()
+	_, err = server.WaitForCallbackContext(ctx, time.Minute)
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("expected prompt return after cancel, took %v", elapsed)
+	}
+
+	oauthErr, ok := err.(*OAuthError)
+	if !ok {
+		t.Fatalf("Expected *OAuthError, got %T", err)
+	}
+	if oauthErr.Code != ErrCodeCancelled {
+		t.Errorf("Expected error code %s, got %s", ErrCodeCancelled, oauthErr.Code)
+	}
+
+	// Server 應已關閉
+	if resp, err := http.Get(fmt.Sprintf("http://localhost:%d/callback", port)); err == nil {
+		resp.Body.Close()
+		t.Error("Expected callback server to be stopped after cancel")
+	}
+}
+
+// TestCallbackServer_PortFallback 測試第一個端口被佔用時改用下一個端口
+func TestCallbackServer_PortFallback(t *testing.T) {
+	// 佔用第一個端口
+	occupied, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatalf("failed to occupy port: %v", err)
+	}
+	defer occupied.Close()
+	busyPort := occupied.Addr().(*net.TCPAddr).Port
+
+	// 取得一個可用端口
+	probe, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatalf("failed to find free port: %v", err)
+	}
+	freePort := probe.Addr().(*net.TCPAddr).Port
+	probe.Close()
+
+	server := NewCallbackServerWithPorts("state", []int{busyPort, freePort})
+	port, err := server.Start()
+	if err != nil {
+		t.Fatalf("server.Start() failed: %v", err)
+	}
+	defer server.Stop()
+
+	if port != freePort {
+		t.Errorf("Expected fallback to port %d, got %d", freePort, port)
+	}
+
+	pkce, _ := GeneratePKCE()
+	authURL := BuildAuthorizationURL(SocialLoginConfig{Provider: ProviderGithub, Port: port}, *pkce)
+	parsedURL, _ := url.Parse(authURL)
+	expectedURI := fmt.Sprintf("http://localhost:%d/callback", freePort)
+	if got := parsedURL.Query().Get("redirect_uri"); got != expectedURI {
+		t.Errorf("redirect_uri mismatch: expected %s, got %s", expectedURI, got)
+	}
+}
+
+// TestCallbackServer_NoAvailablePort 測試所有候選端口都被佔用時返回錯誤
+func TestCallbackServer_NoAvailablePort(t *testing.T) {
+	occupied, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatalf("failed to occupy port: %v", err)
+	}
+	defer occupied.Close()
+
+	server := NewCallbackServerWithPorts("state", []int{occupied.Addr().(*net.TCPAddr).Port})
+	if _, err := server.Start(); err == nil {
+		server.Stop()
+		t.Error("Expected error when no candidate port is available")
+	}
+}
+
 // TestCallbackServer_MissingCode 測試缺少 code 參數
 func TestCallbackServer_MissingCode(t *testing.T) {
 	pkce, err := GeneratePKCE()
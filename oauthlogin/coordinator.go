@@ -16,7 +16,7 @@ import (
 
 // SocialLoginCoordinatorConfig Social 登入協調器配置
 type SocialLoginCoordinatorConfig struct {
-	// Provider 登入提供者 (Github/Google)
+	// Provider 登入提供者（須為已註冊的提供者，預設 Github/Google）
 	Provider string
 	// TokenURL 自定義 Token 端點 URL（用於測試）
 	TokenURL string
@@ -26,6 +26,8 @@ type SocialLoginCoordinatorConfig struct {
 	OpenBrowser bool
 	// HTTPClient 自定義 HTTP 客戶端（用於測試）
 	HTTPClient *http.Client
+	// CallbackPorts 本地 Callback Server 依序嘗試的端口（為空時使用隨機端口）
+	CallbackPorts []int
 }
 
 // IdCLoginCoordinatorConfig IdC 登入協調器配置
@@ -46,6 +48,10 @@ type IdCLoginCoordinatorConfig struct {
 	OpenBrowser bool
 	// HTTPClient 自定義 HTTP 客戶端（用於測試）
 	HTTPClient *http.Client
+	// OnPoll 每次輪詢仍未授權時的進度回調（可選）
+	OnPoll PollProgressFunc
+	// ClientCachePath IdC 客戶端註冊快取路徑（為空時每次都重新註冊）
+	ClientCachePath string
 }
 
 // openBrowser 跨平台開啟瀏覽器
@@ -73,6 +79,10 @@ func openBrowser(url string) error {
 //
 // 返回：登入結果或錯誤
 func SocialLogin(ctx context.Context, config SocialLoginCoordinatorConfig) (*LoginResult, error) {
+	if err := ValidateProvider(config.Provider); err != nil {
+		return nil, err
+	}
+
 	// 1. 生成 PKCE 參數
 	pkce, err := GeneratePKCE()
 	if err != nil {
@@ -83,7 +93,7 @@ func SocialLogin(ctx context.Context, config SocialLoginCoordinatorConfig) (*Log
 	}
 
 	// 2. 啟動本地 Callback Server
-	callbackServer := NewCallbackServer(pkce.State)
+	callbackServer := NewCallbackServerWithPorts(pkce.State, config.CallbackPorts)
 	port, err := callbackServer.Start()
 	if err != nil {
 		return nil, &OAuthError{
@@ -116,7 +126,7 @@ func SocialLogin(ctx context.Context, config SocialLoginCoordinatorConfig) (*Log
 		timeout = 5 * time.Minute
 	}
 
-	callbackResult, err := callbackServer.WaitForCallback(timeout)
+	callbackResult, err := callbackServer.WaitForCallbackContext(ctx, timeout)
 	if err != nil {
 		return nil, err
 	}
@@ -165,6 +175,10 @@ func SocialLogin(ctx context.Context, config SocialLoginCoordinatorConfig) (*Log
 //
 // 返回：登入結果或錯誤
 func SocialLoginWithDeepLink(ctx context.Context, config SocialLoginCoordinatorConfig) (*LoginResult, error) {
+	if err := ValidateProvider(config.Provider); err != nil {
+		return nil, err
+	}
+
 	// 1. 生成 PKCE 參數
 	pkce, err := GeneratePKCE()
 	if err != nil {
@@ -200,7 +214,7 @@ func SocialLoginWithDeepLink(ctx context.Context, config SocialLoginCoordinatorC
 	// 4. 開啟瀏覽器
 	if config.OpenBrowser {
 		if err := openBrowser(authURL); err != nil {
-			deeplink.ClearState()
+			deeplink.ClearStateByValue(oauthState.State)
 			return nil, &OAuthError{
 				Code:    ErrCodeServerError,
 				Message: fmt.Sprintf("failed to open browser: %v", err),
@@ -208,21 +222,86 @@ func SocialLoginWithDeepLink(ctx context.Context, config SocialLoginCoordinatorC
 		}
 	}
 
+	// 5-8. 等待回調並完成 Token 交換
+	return completeDeepLinkLogin(ctx, config, oauthState)
+}
+
+// ResumeSocialLoginWithDeepLink 在應用重新啟動後恢復 Deep Link 登入
+// 有冷啟動的 pending 回調時依其 state 載入對應的 OAuthState，否則載入最近一次保存的 State
+// 等待 state 相符的回調並使用持久化的 PKCE 參數完成 Token 交換
+// config.Provider 為空時使用持久化 State 中的提供者
+func ResumeSocialLoginWithDeepLink(ctx context.Context, config SocialLoginCoordinatorConfig) (*LoginResult, error) {
+	oauthState, err := loadResumableState()
+	if err != nil {
+		if err == deeplink.ErrStateNotFound {
+			return nil, &OAuthError{
+				Code:    ErrCodeStateMismatch,
+				Message: "no pending login to resume",
+			}
+		}
+		return nil, &OAuthError{
+			Code:    ErrCodeServerError,
+			Message: fmt.Sprintf("failed to load state: %v", err),
+		}
+	}
+
+	if deeplink.IsStateExpired(oauthState) {
+		deeplink.ClearStateByValue(oauthState.State)
+		return nil, &OAuthError{
+			Code:    ErrCodeTimeout,
+			Message: "login state expired",
+		}
+	}
+
+	if config.Provider == "" {
+		config.Provider = oauthState.Provider
+	}
+	if err := ValidateProvider(config.Provider); err != nil {
+		deeplink.ClearStateByValue(oauthState.State)
+		return nil, err
+	}
+
+	return completeDeepLinkLogin(ctx, config, oauthState)
+}
+
+// loadResumableState 載入待恢復登入的 State
+// pending 回調的 state 優先，避免多次登入時載入到不相符的最近一次 State
+func loadResumableState() (*deeplink.OAuthState, error) {
+	if pending := deeplink.GetPendingDeepLink(); pending != nil && pending.State != "" {
+		return deeplink.LoadStateByValue(pending.State)
+	}
+	return deeplink.LoadState()
+}
+
+// completeDeepLinkLogin 等待 state 相符的 deep link 回調並以持久化的 PKCE 參數交換 Token
+func completeDeepLinkLogin(ctx context.Context, config SocialLoginCoordinatorConfig, oauthState *deeplink.OAuthState) (*LoginResult, error) {
+	socialConfig := SocialLoginConfig{
+		Provider:    config.Provider,
+		RedirectURI: deeplink.RedirectURI,
+	}
+
 	// 5. 等待 deep link 回調
 	timeout := config.Timeout
 	if timeout == 0 {
 		timeout = 5 * time.Minute
 	}
 
-	callbackResult, err := deeplink.WaitForCallback(timeout)
+	// 僅接受本次登入 state 的回調，其他登入的回調留給對應的等待者
+	callbackResult, err := deeplink.WaitForStateCallbackContext(ctx, oauthState.State, timeout)
 	if err != nil {
-		deeplink.ClearState()
+		deeplink.ClearStateByValue(oauthState.State)
 		if err == deeplink.ErrCallbackTimeout {
 			return nil, &OAuthError{
 				Code:    ErrCodeTimeout,
 				Message: "login timeout",
 			}
 		}
+		if err == deeplink.ErrCallbackCancelled {
+			return nil, &OAuthError{
+				Code:    ErrCodeCancelled,
+				Message: "login cancelled",
+			}
+		}
 		return nil, &OAuthError{
 			Code:    ErrCodeServerError,
 			Message: fmt.Sprintf("callback error: %v", err),
@@ -230,7 +309,7 @@ func SocialLoginWithDeepLink(ctx context.Context, config SocialLoginCoordinatorC
 	}
 
 	// 6. 清理臨時檔案
-	defer deeplink.ClearState()
+	defer deeplink.ClearStateByValue(oauthState.State)
 
 	// 7. 執行 Token 交換
 	httpClient := config.HTTPClient
@@ -326,26 +405,71 @@ func SocialLoginWithMismatchedState(ctx context.Context, config SocialLoginCoord
 // IdCLogin 執行 IdC 登入流程
 // 整合設備註冊、設備授權、Token 輪詢和瀏覽器開啟邏輯
 // 參數：
-//   - ctx: context，用於取消操作
+//   - ctx: context，用於取消操作（註冊、授權與輪詢階段皆會中止並返回 ErrCodeCancelled）
 //   - config: IdC 登入協調器配置
 //
 // 返回：登入結果或錯誤
 func IdCLogin(ctx context.Context, config IdCLoginCoordinatorConfig) (*LoginResult, error) {
-	httpClient := config.HTTPClient
-	if httpClient == nil {
-		httpClient = http.DefaultClient
+	_, poller, err := BeginIdCLogin(ctx, config)
+	if err != nil {
+		return nil, err
 	}
+	return poller.Wait()
+}
 
-	// 設定超時
-	timeout := config.Timeout
-	if timeout == 0 {
-		timeout = 5 * time.Minute
+// Poller 完成 IdC 登入的輪詢階段
+type Poller interface {
+	// Wait 輪詢 Token 直到授權完成、超時或 context 取消
+	Wait() (*LoginResult, error)
+}
+
+// idcPoller BeginIdCLogin 返回的 Poller 實作
+type idcPoller struct {
+	ctx      context.Context
+	cancel   context.CancelFunc
+	config   IdCLoginCoordinatorConfig
+	creds    *IdCClientCredentials
+	authResp *DeviceAuthorizationResponse
+}
+
+// BeginIdCLogin 執行 IdC 登入的註冊與設備授權階段
+// 在輪詢前返回設備授權回應（userCode、verificationUri），讓呼叫端可先顯示給用戶
+// 返回的 Poller.Wait 完成登入流程；登入超時（config.Timeout）從呼叫本函數時開始計算
+// 呼叫端必須呼叫 Poller.Wait 以釋放資源
+func BeginIdCLogin(ctx context.Context, config IdCLoginCoordinatorConfig) (*DeviceAuthorizationResponse, Poller, error) {
+	// 建立帶超時的 context，由 Poller.Wait 結束時釋放
+	ctx, cancel := context.WithTimeout(ctx, idcLoginTimeout(config))
+
+	authResp, creds, err := IdCBeginLogin(ctx, config)
+	if err != nil {
+		cancel()
+		return nil, nil, err
 	}
 
-	// 建立帶超時的 context
-	ctx, cancel := context.WithTimeout(ctx, timeout)
+	return authResp, &idcPoller{
+		ctx:      ctx,
+		cancel:   cancel,
+		config:   config,
+		creds:    creds,
+		authResp: authResp,
+	}, nil
+}
+
+// Wait 輪詢 Token 並建構登入結果
+func (p *idcPoller) Wait() (*LoginResult, error) {
+	defer p.cancel()
+	return IdCCompleteLoginWithConfig(p.ctx, p.config, p.creds, p.authResp)
+}
+
+// IdCBeginLogin 執行設備註冊與設備授權，返回設備授權回應與客戶端憑證
+// 呼叫端可先顯示 userCode 與 verificationUri（或據此產生 QR code），再以 IdCCompleteLogin 完成登入
+// config.OpenBrowser 為 true 時會開啟瀏覽器至 verificationUriComplete
+func IdCBeginLogin(ctx context.Context, config IdCLoginCoordinatorConfig) (*DeviceAuthorizationResponse, *IdCClientCredentials, error) {
+	ctx, cancel := context.WithTimeout(ctx, idcLoginTimeout(config))
 	defer cancel()
 
+	httpClient := idcHTTPClient(config)
+
 	// 1. 註冊設備客戶端
 	registerURL := config.RegisterURL
 	if registerURL == "" {
@@ -357,9 +481,23 @@ func IdCLogin(ctx context.Context, config IdCLoginCoordinatorConfig) (*LoginResu
 		clientName = "Kiro Manager"
 	}
 
-	creds, err := RegisterDeviceClientWithEndpoint(httpClient, registerURL, clientName, config.StartURL)
-	if err != nil {
-		return nil, err
+	register := func() (*IdCClientCredentials, error) {
+		creds, err := RegisterDeviceClientWithContext(ctx, httpClient, registerURL, clientName, config.StartURL)
+		if err != nil {
+			return nil, err
+		}
+		// 快取為盡力而為，寫入失敗不影響登入
+		SaveCachedIdCClient(config.ClientCachePath, config.StartURL, creds)
+		return creds, nil
+	}
+
+	// 優先使用仍有效的快取客戶端，避免重複註冊
+	creds, cached := LoadCachedIdCClient(config.ClientCachePath, config.StartURL)
+	if !cached {
+		var err error
+		if creds, err = register(); err != nil {
+			return nil, nil, err
+		}
 	}
 
 	// 2. 啟動設備授權
@@ -368,28 +506,49 @@ func IdCLogin(ctx context.Context, config IdCLoginCoordinatorConfig) (*LoginResu
 		deviceAuthURL = IdCDeviceAuthURL
 	}
 
-	authResp, err := StartDeviceAuthorizationWithEndpoint(httpClient, deviceAuthURL, creds, config.StartURL)
+	authResp, err := StartDeviceAuthorizationWithContext(ctx, httpClient, deviceAuthURL, creds, config.StartURL)
+	if err != nil && cached && isAuthFailedError(err) {
+		// 快取的客戶端已失效，清除快取並重新註冊一次
+		InvalidateCachedIdCClient(config.ClientCachePath, config.StartURL)
+		if creds, err = register(); err != nil {
+			return nil, nil, err
+		}
+		authResp, err = StartDeviceAuthorizationWithContext(ctx, httpClient, deviceAuthURL, creds, config.StartURL)
+	}
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	// 3. 開啟瀏覽器至 verificationUriComplete
 	if config.OpenBrowser {
 		if err := openBrowser(authResp.VerificationUriComplete); err != nil {
-			return nil, &OAuthError{
+			return nil, nil, &OAuthError{
 				Code:    ErrCodeServerError,
 				Message: fmt.Sprintf("failed to open browser: %v", err),
 			}
 		}
 	}
 
+	return authResp, creds, nil
+}
+
+// IdCCompleteLogin 使用預設端點輪詢 Token，完成 IdCBeginLogin 開始的登入
+func IdCCompleteLogin(ctx context.Context, creds *IdCClientCredentials, authResp *DeviceAuthorizationResponse) (*LoginResult, error) {
+	return IdCCompleteLoginWithConfig(ctx, IdCLoginCoordinatorConfig{}, creds, authResp)
+}
+
+// IdCCompleteLoginWithConfig 與 IdCCompleteLogin 相同，但使用 config 的 TokenURL、HTTPClient、OnPoll 與 Timeout
+func IdCCompleteLoginWithConfig(ctx context.Context, config IdCLoginCoordinatorConfig, creds *IdCClientCredentials, authResp *DeviceAuthorizationResponse) (*LoginResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, idcLoginTimeout(config))
+	defer cancel()
+
 	// 4. 輪詢 Token
 	tokenURL := config.TokenURL
 	if tokenURL == "" {
 		tokenURL = IdCTokenURL
 	}
 
-	tokenResp, err := PollForTokenWithEndpoint(ctx, httpClient, tokenURL, creds, authResp)
+	tokenResp, err := PollForTokenWithProgress(ctx, idcHTTPClient(config), tokenURL, creds, authResp, config.OnPoll)
 	if err != nil {
 		return nil, err
 	}
@@ -411,3 +570,25 @@ func IdCLogin(ctx context.Context, config IdCLoginCoordinatorConfig) (*LoginResu
 		ClientIdHash: clientIdHash,
 	}, nil
 }
+
+// isAuthFailedError 判斷是否為 ErrCodeAuthFailed（HTTP 401）錯誤
+func isAuthFailedError(err error) bool {
+	oauthErr, ok := err.(*OAuthError)
+	return ok && oauthErr.Code == ErrCodeAuthFailed
+}
+
+// idcLoginTimeout 取得 IdC 登入超時時間（未設定時為 5 分鐘）
+func idcLoginTimeout(config IdCLoginCoordinatorConfig) time.Duration {
+	if config.Timeout == 0 {
+		return 5 * time.Minute
+	}
+	return config.Timeout
+}
+
+// idcHTTPClient 取得 IdC 登入使用的 HTTP 客戶端
+func idcHTTPClient(config IdCLoginCoordinatorConfig) *http.Client {
+	if config.HTTPClient == nil {
+		return http.DefaultClient
+	}
+	return config.HTTPClient
+}
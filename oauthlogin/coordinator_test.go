@@ -8,8 +8,11 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"sync/atomic"
 	"testing"
 	"time"
+
+	"kiro-manager/deeplink"
 )
 
 // TestSocialLogin_Success 測試 Social 登入成功流程
@@ -124,6 +127,161 @@ func TestSocialLogin_StateMismatch(t *testing.T) {
 	}
 }
 
+// TestSocialLogin_UnknownProvider 測試未註冊或空的提供者在啟動回調伺服器前即返回錯誤
+func TestSocialLogin_UnknownProvider(t *testing.T) {
+	for _, provider := range []string{"Unknown", ""} {
+		config := SocialLoginCoordinatorConfig{
+			Provider:    provider,
+			Timeout:     10 * time.Second,
+			OpenBrowser: false,
+		}
+		assertUnknownProvider(t, config)
+	}
+}
+
+// assertUnknownProvider 驗證 Social 登入入口對未註冊的提供者返回 ErrCodeUnknownProvider
+func assertUnknownProvider(t *testing.T, config SocialLoginCoordinatorConfig) {
+	t.Helper()
+	for name, login := range map[string]func(context.Context, SocialLoginCoordinatorConfig) (*LoginResult, error){
+		"SocialLogin":             SocialLogin,
+		"SocialLoginWithDeepLink": SocialLoginWithDeepLink,
+	} {
+		_, err := login(context.Background(), config)
+		oauthErr, ok := err.(*OAuthError)
+		if !ok {
+			t.Fatalf("%s: expected OAuthError, got %T", name, err)
+		}
+		if oauthErr.Code != ErrCodeUnknownProvider {
+			t.Errorf("%s: expected error code '%s', got '%s'", name, ErrCodeUnknownProvider, oauthErr.Code)
+		}
+	}
+}
+
+// TestResumeSocialLoginWithDeepLink_AfterRestart 模擬保存 State → 應用重啟 → 冷啟動回調 → 完成 Token 交換
+func TestResumeSocialLoginWithDeepLink_AfterRestart(t *testing.T) {
+	var gotCode, gotVerifier string
+	tokenServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req tokenExchangeRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		gotCode, gotVerifier = req.Code, req.CodeVerifier
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(SocialTokenResponse{
+			AccessToken:  "resumed-access-token",
+			RefreshToken: "resumed-refresh-token",
+			ExpiresIn:    3600,
+		})
+	}))
+	defer tokenServer.Close()
+
+	// 前一個行程：持久化 State 後結束
+	if err := deeplink.SaveState(&deeplink.OAuthState{
+		State:        "resume-state",
+		Provider:     ProviderGoogle,
+		CodeVerifier: "resume-verifier",
+		CreatedAt:    time.Now(),
+		ExpiresAt:    time.Now().Add(deeplink.StateExpiry),
+	}); err != nil {
+		t.Fatalf("SaveState failed: %v", err)
+	}
+	defer deeplink.ClearState()
+
+	// 應用重啟：回調 channel 尚未初始化，冷啟動的 deep link 存入 pending
+	deeplink.ResetCallbackChannel()
+	defer deeplink.ResetCallbackChannel()
+	if err := deeplink.ForwardDeepLink("kiro://kiro.kiroAgent/authenticate-success?code=resume-code&state=resume-state"); err != nil {
+		t.Fatalf("ForwardDeepLink failed: %v", err)
+	}
+
+	config := SocialLoginCoordinatorConfig{
+		TokenURL:   tokenServer.URL,
+		Timeout:    time.Second,
+		HTTPClient: tokenServer.Client(),
+	}
+	result, err := ResumeSocialLoginWithDeepLink(context.Background(), config)
+	if err != nil {
+		t.Fatalf("ResumeSocialLoginWithDeepLink failed: %v", err)
+	}
+
+	if gotCode != "resume-code" || gotVerifier != "resume-verifier" {
+		t.Errorf("expected persisted code/verifier, got %q/%q", gotCode, gotVerifier)
+	}
+	if result.AccessToken != "resumed-access-token" {
+		t.Errorf("expected resumed access token, got %s", result.AccessToken)
+	}
+	if result.Provider != ProviderGoogle {
+		t.Errorf("expected provider from persisted state, got %s", result.Provider)
+	}
+	if _, err := deeplink.LoadState(); err != deeplink.ErrStateNotFound {
+		t.Errorf("expected state to be cleared, got %v", err)
+	}
+}
+
+// TestResumeSocialLoginWithDeepLink_PendingOlderState 測試 pending 回調對應較早的 State 時使用該 State 而非最近一次保存的 State
+func TestResumeSocialLoginWithDeepLink_PendingOlderState(t *testing.T) {
+	var gotVerifier string
+	tokenServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req tokenExchangeRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		gotVerifier = req.CodeVerifier
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(SocialTokenResponse{
+			AccessToken:  "older-access-token",
+			RefreshToken: "older-refresh-token",
+			ExpiresIn:    3600,
+		})
+	}))
+	defer tokenServer.Close()
+
+	for _, s := range []*deeplink.OAuthState{
+		{State: "older-state", Provider: ProviderGithub, CodeVerifier: "older-verifier"},
+		{State: "newer-state", Provider: ProviderGoogle, CodeVerifier: "newer-verifier"},
+	} {
+		s.CreatedAt = time.Now()
+		s.ExpiresAt = time.Now().Add(deeplink.StateExpiry)
+		if err := deeplink.SaveState(s); err != nil {
+			t.Fatalf("SaveState failed: %v", err)
+		}
+		defer deeplink.ClearStateByValue(s.State)
+	}
+	defer deeplink.ClearState()
+
+	deeplink.ResetCallbackChannel()
+	defer deeplink.ResetCallbackChannel()
+	if err := deeplink.ForwardDeepLink("kiro://kiro.kiroAgent/authenticate-success?code=older-code&state=older-state"); err != nil {
+		t.Fatalf("ForwardDeepLink failed: %v", err)
+	}
+
+	config := SocialLoginCoordinatorConfig{
+		TokenURL:   tokenServer.URL,
+		Timeout:    time.Second,
+		HTTPClient: tokenServer.Client(),
+	}
+	result, err := ResumeSocialLoginWithDeepLink(context.Background(), config)
+	if err != nil {
+		t.Fatalf("ResumeSocialLoginWithDeepLink failed: %v", err)
+	}
+
+	if gotVerifier != "older-verifier" {
+		t.Errorf("expected verifier of pending state, got %q", gotVerifier)
+	}
+	if result.Provider != ProviderGithub {
+		t.Errorf("expected provider of pending state, got %s", result.Provider)
+	}
+}
+
+// TestResumeSocialLoginWithDeepLink_NoState 測試沒有持久化 State 時返回錯誤
+func TestResumeSocialLoginWithDeepLink_NoState(t *testing.T) {
+	deeplink.ClearState()
+
+	_, err := ResumeSocialLoginWithDeepLink(context.Background(), SocialLoginCoordinatorConfig{Timeout: time.Second})
+	oauthErr, ok := err.(*OAuthError)
+	if !ok || oauthErr.Code != ErrCodeStateMismatch {
+		t.Errorf("expected ErrCodeStateMismatch, got %v", err)
+	}
+}
+
 // TestIdCLogin_Success 測試 IdC 登入成功流程
 func TestIdCLogin_Success(t *testing.T) {
 	// 建立模擬 IdC 端點
@@ -330,3 +488,237 @@ func TestIdCLogin_Timeout(t *testing.T) {
 		t.Errorf("expected error code '%s', got '%s'", ErrCodeTimeout, oauthErr.Code)
 	}
 }
+
+// TestIdCLogin_CancelDuringPolling 測試輪詢 authorization_pending 期間取消 context 會立即返回
+func TestIdCLogin_CancelDuringPolling(t *testing.T) {
+	pending := make(chan struct{}, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		switch r.URL.Path {
+		case "/register":
+			json.NewEncoder(w).Encode(IdCClientCredentials{
+				ClientId:     "test-client-id",
+				ClientSecret: "test-client-secret",
+			})
+
+		case "/device_authorization":
+			json.NewEncoder(w).Encode(DeviceAuthorizationResponse{
+				DeviceCode: "test-device-code",
+				UserCode:   "TEST-CODE",
+				ExpiresIn:  600,
+				Interval:   5,
+			})
+
+		case "/token":
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(IdCErrorResponse{
+				Error:            IdCErrAuthorizationPending,
+				ErrorDescription: "authorization pending",
+			})
+			select {
+			case pending <- struct{}{}:
+			default:
+			}
+
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	config := IdCLoginCoordinatorConfig{
+		StartURL:      "https://test.awsapps.com/start",
+		RegisterURL:   server.URL + "/register",
+		DeviceAuthURL: server.URL + "/device_authorization",
+		TokenURL:      server.URL + "/token",
+		Timeout:       time.Minute,
+		OpenBrowser:   false,
+		HTTPClient:    server.Client(),
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var cancelledAt time.Time
+	go func() {
+		<-pending
+		cancelledAt = time.Now()
+		cancel()
+	}()
+
+	_, err := IdCLogin(ctx, config)
+	if elapsed := time.Since(cancelledAt); cancelledAt.IsZero() || elapsed > time.Second {
+		t.Errorf("expected prompt return after cancel, took %v", elapsed)
+	}
+
+	oauthErr, ok := err.(*OAuthError)
+	if !ok {
+		t.Fatalf("expected OAuthError, got %T", err)
+	}
+	if oauthErr.Code != ErrCodeCancelled {
+		t.Errorf("expected error code '%s', got '%s'", ErrCodeCancelled, oauthErr.Code)
+	}
+}
+
+// TestIdCLogin_CancelDuringRegistration 測試設備註冊請求進行中取消 context 會中止請求
+func TestIdCLogin_CancelDuringRegistration(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// 模擬無回應的伺服器，直到測試結束
+		<-release
+	}))
+	defer server.Close()
+	defer close(release)
+
+	config := IdCLoginCoordinatorConfig{
+		StartURL:    "https://test.awsapps.com/start",
+		RegisterURL: server.URL + "/register",
+		Timeout:     time.Minute,
+		OpenBrowser: false,
+		HTTPClient:  server.Client(),
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(100*time.Millisecond, cancel)
+
+	start := time.Now()
+	_, err := IdCLogin(ctx, config)
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("expected prompt return after cancel, took %v", elapsed)
+	}
+
+	oauthErr, ok := err.(*OAuthError)
+	if !ok {
+		t.Fatalf("expected OAuthError, got %T", err)
+	}
+	if oauthErr.Code != ErrCodeCancelled {
+		t.Errorf("expected error code '%s', got '%s'", ErrCodeCancelled, oauthErr.Code)
+	}
+}
+
+// TestBeginIdCLogin_UserCodeBeforeToken 測試 BeginIdCLogin 在發出 Token 前即返回 userCode
+func TestBeginIdCLogin_UserCodeBeforeToken(t *testing.T) {
+	var tokenRequests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		switch r.URL.Path {
+		case "/register":
+			json.NewEncoder(w).Encode(IdCClientCredentials{
+				ClientId:     "test-client-id",
+				ClientSecret: "test-client-secret",
+			})
+
+		case "/device_authorization":
+			json.NewEncoder(w).Encode(DeviceAuthorizationResponse{
+				DeviceCode:              "test-device-code",
+				UserCode:                "ABCD-EFGH",
+				VerificationUri:         "https://device.sso.us-east-1.amazonaws.com/",
+				VerificationUriComplete: "https://device.sso.us-east-1.amazonaws.com/?user_code=ABCD-EFGH",
+				ExpiresIn:               600,
+				Interval:                1,
+			})
+
+		case "/token":
+			atomic.AddInt32(&tokenRequests, 1)
+			json.NewEncoder(w).Encode(IdCTokenResponse{
+				AccessToken:  "idc-access-token",
+				RefreshToken: "idc-refresh-token",
+				ExpiresIn:    3600,
+			})
+
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	config := IdCLoginCoordinatorConfig{
+		StartURL:      "https://test.awsapps.com/start",
+		RegisterURL:   server.URL + "/register",
+		DeviceAuthURL: server.URL + "/device_authorization",
+		TokenURL:      server.URL + "/token",
+		Timeout:       10 * time.Second,
+		OpenBrowser:   false,
+		HTTPClient:    server.Client(),
+	}
+
+	authResp, poller, err := BeginIdCLogin(context.Background(), config)
+	if err != nil {
+		t.Fatalf("BeginIdCLogin failed: %v", err)
+	}
+	if authResp.UserCode != "ABCD-EFGH" {
+		t.Errorf("expected user code 'ABCD-EFGH', got '%s'", authResp.UserCode)
+	}
+	if authResp.VerificationUriComplete != "https://device.sso.us-east-1.amazonaws.com/?user_code=ABCD-EFGH" {
+		t.Errorf("unexpected verification URI: %s", authResp.VerificationUriComplete)
+	}
+	if got := atomic.LoadInt32(&tokenRequests); got != 0 {
+		t.Fatalf("expected no token request before Wait, got %d", got)
+	}
+
+	result, err := poller.Wait()
+	if err != nil {
+		t.Fatalf("Wait failed: %v", err)
+	}
+	if result.AccessToken != "idc-access-token" {
+		t.Errorf("expected access token 'idc-access-token', got '%s'", result.AccessToken)
+	}
+}
+
+// TestIdCBeginCompleteLogin 測試分兩步完成 IdC 登入：先取得設備授權與憑證，再輪詢 Token
+func TestIdCBeginCompleteLogin(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		switch r.URL.Path {
+		case "/register":
+			json.NewEncoder(w).Encode(IdCClientCredentials{
+				ClientId:     "test-client-id",
+				ClientSecret: "test-client-secret",
+			})
+		case "/device_authorization":
+			json.NewEncoder(w).Encode(DeviceAuthorizationResponse{
+				DeviceCode:      "test-device-code",
+				UserCode:        "WXYZ-1234",
+				VerificationUri: "https://device.sso.us-east-1.amazonaws.com/",
+				ExpiresIn:       600,
+				Interval:        1,
+			})
+		case "/token":
+			json.NewEncoder(w).Encode(IdCTokenResponse{AccessToken: "idc-access-token", ExpiresIn: 3600})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	config := IdCLoginCoordinatorConfig{
+		StartURL:      "https://test.awsapps.com/start",
+		RegisterURL:   server.URL + "/register",
+		DeviceAuthURL: server.URL + "/device_authorization",
+		TokenURL:      server.URL + "/token",
+		Timeout:       10 * time.Second,
+		HTTPClient:    server.Client(),
+	}
+
+	authResp, creds, err := IdCBeginLogin(context.Background(), config)
+	if err != nil {
+		t.Fatalf("IdCBeginLogin failed: %v", err)
+	}
+	if authResp.UserCode != "WXYZ-1234" || authResp.VerificationUri == "" {
+		t.Errorf("unexpected device authorization: %+v", authResp)
+	}
+	if creds.ClientId != "test-client-id" {
+		t.Errorf("expected client id 'test-client-id', got '%s'", creds.ClientId)
+	}
+
+	result, err := IdCCompleteLoginWithConfig(context.Background(), config, creds, authResp)
+	if err != nil {
+		t.Fatalf("IdCCompleteLoginWithConfig failed: %v", err)
+	}
+	if result.AccessToken != "idc-access-token" || result.ClientId != "test-client-id" {
+		t.Errorf("unexpected login result: %+v", result)
+	}
+}
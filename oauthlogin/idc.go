@@ -39,6 +39,8 @@ type IdCClientCredentials struct {
 	ClientId string `json:"clientId"`
 	// ClientSecret 客戶端密鑰
 	ClientSecret string `json:"clientSecret"`
+	// ClientSecretExpiresAt 客戶端密鑰到期時間（Unix 秒，0 表示未提供）
+	ClientSecretExpiresAt int64 `json:"clientSecretExpiresAt,omitempty"`
 }
 
 // DeviceRegistrationRequest 設備註冊請求結構
@@ -140,6 +142,11 @@ func RegisterDeviceClientWithClient(client *http.Client, clientName, issuerUrl s
 // RegisterDeviceClientWithEndpoint 使用自定義端點執行設備註冊
 // 允許注入 HTTP 客戶端和端點 URL 以便測試
 func RegisterDeviceClientWithEndpoint(client *http.Client, endpoint, clientName, issuerUrl string) (*IdCClientCredentials, error) {
+	return RegisterDeviceClientWithContext(context.Background(), client, endpoint, clientName, issuerUrl)
+}
+
+// RegisterDeviceClientWithContext 與 RegisterDeviceClientWithEndpoint 相同，但 ctx 取消時會中止請求
+func RegisterDeviceClientWithContext(ctx context.Context, client *http.Client, endpoint, clientName, issuerUrl string) (*IdCClientCredentials, error) {
 	// 建構請求體
 	reqBody := DeviceRegistrationRequest{
 		ClientName: clientName,
@@ -157,7 +164,7 @@ func RegisterDeviceClientWithEndpoint(client *http.Client, endpoint, clientName,
 	}
 
 	// 建構 HTTP 請求
-	req, err := http.NewRequest(http.MethodPost, endpoint, bytes.NewBuffer(jsonBody))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewBuffer(jsonBody))
 	if err != nil {
 		return nil, &OAuthError{
 			Code:    ErrCodeNetworkError,
@@ -170,6 +177,9 @@ func RegisterDeviceClientWithEndpoint(client *http.Client, endpoint, clientName,
 	// 執行請求
 	resp, err := client.Do(req)
 	if err != nil {
+		if ctx.Err() != nil {
+			return nil, contextError(ctx, "registration")
+		}
 		return nil, &OAuthError{
 			Code:    ErrCodeNetworkError,
 			Message: fmt.Sprintf("failed to send request: %v", err),
@@ -222,6 +232,11 @@ func StartDeviceAuthorizationWithClient(client *http.Client, creds *IdCClientCre
 // StartDeviceAuthorizationWithEndpoint 使用自定義端點啟動設備授權
 // 允許注入 HTTP 客戶端和端點 URL 以便測試
 func StartDeviceAuthorizationWithEndpoint(client *http.Client, endpoint string, creds *IdCClientCredentials, startUrl string) (*DeviceAuthorizationResponse, error) {
+	return StartDeviceAuthorizationWithContext(context.Background(), client, endpoint, creds, startUrl)
+}
+
+// StartDeviceAuthorizationWithContext 與 StartDeviceAuthorizationWithEndpoint 相同，但 ctx 取消時會中止請求
+func StartDeviceAuthorizationWithContext(ctx context.Context, client *http.Client, endpoint string, creds *IdCClientCredentials, startUrl string) (*DeviceAuthorizationResponse, error) {
 	// 建構請求體
 	reqBody := DeviceAuthorizationRequest{
 		ClientId:     creds.ClientId,
@@ -238,7 +253,7 @@ func StartDeviceAuthorizationWithEndpoint(client *http.Client, endpoint string,
 	}
 
 	// 建構 HTTP 請求
-	req, err := http.NewRequest(http.MethodPost, endpoint, bytes.NewBuffer(jsonBody))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewBuffer(jsonBody))
 	if err != nil {
 		return nil, &OAuthError{
 			Code:    ErrCodeNetworkError,
@@ -251,6 +266,9 @@ func StartDeviceAuthorizationWithEndpoint(client *http.Client, endpoint string,
 	// 執行請求
 	resp, err := client.Do(req)
 	if err != nil {
+		if ctx.Err() != nil {
+			return nil, contextError(ctx, "device authorization")
+		}
 		return nil, &OAuthError{
 			Code:    ErrCodeNetworkError,
 			Message: fmt.Sprintf("failed to send request: %v", err),
@@ -304,6 +322,20 @@ func PollForTokenWithClient(ctx context.Context, client *http.Client, creds *IdC
 // PollForTokenWithEndpoint 使用自定義端點輪詢 Token
 // 允許注入 HTTP 客戶端和端點 URL 以便測試
 func PollForTokenWithEndpoint(ctx context.Context, client *http.Client, endpoint string, creds *IdCClientCredentials, authResp *DeviceAuthorizationResponse) (*IdCTokenResponse, error) {
+	return PollForTokenWithProgress(ctx, client, endpoint, creds, authResp, nil)
+}
+
+// PollProgressFunc 輪詢進度回調
+// attempt 為已完成的輪詢次數（從 1 開始），nextInterval 為距下一次輪詢的等待時間
+type PollProgressFunc func(attempt int, nextInterval time.Duration)
+
+// PollForTokenWithProgress 與 PollForTokenWithEndpoint 相同，每次輪詢仍未授權時呼叫 onPoll
+// onPoll 在獨立的 goroutine 中依序執行，不會阻塞輪詢；onPoll 為 nil 時不回調
+// 返回前會等待已排入的回調執行完畢
+func PollForTokenWithProgress(ctx context.Context, client *http.Client, endpoint string, creds *IdCClientCredentials, authResp *DeviceAuthorizationResponse, onPoll PollProgressFunc) (*IdCTokenResponse, error) {
+	progress := startPollProgress(onPoll)
+	defer progress.stop()
+
 	// 計算輪詢間隔
 	interval := time.Duration(authResp.Interval) * time.Second
 	if interval < time.Second {
@@ -330,7 +362,7 @@ func PollForTokenWithEndpoint(ctx context.Context, client *http.Client, endpoint
 	ticker := time.NewTicker(interval)
 	defer ticker.Stop()
 
-	for {
+	for attempt := 1; ; attempt++ {
 		// 嘗試取得 Token
 		tokenResp, err := pollTokenOnce(ctx, client, endpoint, jsonBody)
 		if err == nil {
@@ -355,41 +387,77 @@ func PollForTokenWithEndpoint(ctx context.Context, client *http.Client, endpoint
 			// 其他錯誤直接返回
 			return nil, err
 		}
+		progress.report(attempt, interval)
 
 		// 等待下一次輪詢或 context 取消
 		select {
 		case <-ctx.Done():
-			if ctx.Err() == context.DeadlineExceeded {
-				return nil, &OAuthError{
-					Code:    ErrCodeTimeout,
-					Message: "polling timeout",
-				}
-			}
-			return nil, &OAuthError{
-				Code:    ErrCodeCancelled,
-				Message: "polling cancelled",
-			}
+			return nil, contextError(ctx, "polling")
 		case <-ticker.C:
 			// 繼續輪詢
 		}
 	}
 }
 
+// pollProgress 依序分派輪詢進度回調，避免回調阻塞輪詢循環
+type pollProgress struct {
+	events chan pollEvent
+	done   chan struct{}
+}
+
+// pollEvent 單次輪詢進度
+type pollEvent struct {
+	attempt      int
+	nextInterval time.Duration
+}
+
+// pollProgressBuffer 尚未執行的回調上限，超過時丟棄新的進度
+const pollProgressBuffer = 16
+
+// startPollProgress 啟動回調分派 goroutine，onPoll 為 nil 時返回 nil
+func startPollProgress(onPoll PollProgressFunc) *pollProgress {
+	if onPoll == nil {
+		return nil
+	}
+	p := &pollProgress{
+		events: make(chan pollEvent, pollProgressBuffer),
+		done:   make(chan struct{}),
+	}
+	go func() {
+		defer close(p.done)
+		for ev := range p.events {
+			onPoll(ev.attempt, ev.nextInterval)
+		}
+	}()
+	return p
+}
+
+// report 排入一次進度回調（不阻塞）
+func (p *pollProgress) report(attempt int, nextInterval time.Duration) {
+	if p == nil {
+		return
+	}
+	select {
+	case p.events <- pollEvent{attempt: attempt, nextInterval: nextInterval}:
+	default:
+	}
+}
+
+// stop 停止分派並等待已排入的回調執行完畢
+func (p *pollProgress) stop() {
+	if p == nil {
+		return
+	}
+	close(p.events)
+	<-p.done
+}
+
 // pollTokenOnce 執行單次 Token 輪詢
 func pollTokenOnce(ctx context.Context, client *http.Client, endpoint string, jsonBody []byte) (*IdCTokenResponse, error) {
 	// 檢查 context 是否已取消
 	select {
 	case <-ctx.Done():
-		if ctx.Err() == context.DeadlineExceeded {
-			return nil, &OAuthError{
-				Code:    ErrCodeTimeout,
-				Message: "polling timeout",
-			}
-		}
-		return nil, &OAuthError{
-			Code:    ErrCodeCancelled,
-			Message: "polling cancelled",
-		}
+		return nil, contextError(ctx, "polling")
 	default:
 	}
 
@@ -409,16 +477,7 @@ func pollTokenOnce(ctx context.Context, client *http.Client, endpoint string, js
 	if err != nil {
 		// 檢查是否為 context 取消導致的錯誤
 		if ctx.Err() != nil {
-			if ctx.Err() == context.DeadlineExceeded {
-				return nil, &OAuthError{
-					Code:    ErrCodeTimeout,
-					Message: "polling timeout",
-				}
-			}
-			return nil, &OAuthError{
-				Code:    ErrCodeCancelled,
-				Message: "polling cancelled",
-			}
+			return nil, contextError(ctx, "polling")
 		}
 		return nil, &OAuthError{
 			Code:    ErrCodeNetworkError,
@@ -453,6 +512,21 @@ func pollTokenOnce(ctx context.Context, client *http.Client, endpoint string, js
 	return &tokenResp, nil
 }
 
+// contextError 將 ctx 結束原因映射到 OAuthError
+// 超時返回 ErrCodeTimeout，其他情況（例如用戶取消）返回 ErrCodeCancelled
+func contextError(ctx context.Context, action string) *OAuthError {
+	if ctx.Err() == context.DeadlineExceeded {
+		return &OAuthError{
+			Code:    ErrCodeTimeout,
+			Message: action + " timeout",
+		}
+	}
+	return &OAuthError{
+		Code:    ErrCodeCancelled,
+		Message: action + " cancelled",
+	}
+}
+
 // mapIdCError 將 IdC API 錯誤映射到 OAuthError
 // 處理 authorization_pending、access_denied、expired_token 等狀態
 func mapIdCError(statusCode int, body []byte) *OAuthError {
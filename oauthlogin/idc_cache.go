@@ -0,0 +1,106 @@
+// Package oauthlogin 提供 OAuth 登入功能
+package oauthlogin
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// IdCClientCacheFileName IdC 客戶端註冊快取檔名
+const IdCClientCacheFileName = "idc-clients.json"
+
+// idcClientExpiryMargin 客戶端密鑰到期前視為失效的提前量
+const idcClientExpiryMargin = time.Hour
+
+// idcClientCacheMu 保護快取檔案的讀寫
+var idcClientCacheMu sync.Mutex
+
+// GetIdCClientCachePath 取得 IdC 客戶端註冊快取路徑（執行檔同層）
+func GetIdCClientCachePath() (string, error) {
+	execPath, err := os.Executable()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(filepath.Dir(execPath), IdCClientCacheFileName), nil
+}
+
+// loadIdCClientCache 讀取快取（以 StartURL 為 key），檔案不存在或無法解析時返回空快取
+func loadIdCClientCache(path string) map[string]IdCClientCredentials {
+	clients := make(map[string]IdCClientCredentials)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return clients
+	}
+	if err := json.Unmarshal(data, &clients); err != nil {
+		return make(map[string]IdCClientCredentials)
+	}
+	return clients
+}
+
+// writeIdCClientCache 寫入快取檔案
+func writeIdCClientCache(path string, clients map[string]IdCClientCredentials) error {
+	data, err := json.MarshalIndent(clients, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0600)
+}
+
+// LoadCachedIdCClient 取得 startURL 仍有效的快取客戶端憑證
+// path 為空、沒有快取或密鑰即將到期時返回 false
+func LoadCachedIdCClient(path, startURL string) (*IdCClientCredentials, bool) {
+	if path == "" {
+		return nil, false
+	}
+
+	idcClientCacheMu.Lock()
+	defer idcClientCacheMu.Unlock()
+
+	creds, ok := loadIdCClientCache(path)[startURL]
+	if !ok || creds.ClientId == "" || !creds.IsValidAt(time.Now()) {
+		return nil, false
+	}
+	return &creds, true
+}
+
+// SaveCachedIdCClient 將 startURL 的客戶端憑證寫入快取
+func SaveCachedIdCClient(path, startURL string, creds *IdCClientCredentials) error {
+	if path == "" {
+		return nil
+	}
+
+	idcClientCacheMu.Lock()
+	defer idcClientCacheMu.Unlock()
+
+	clients := loadIdCClientCache(path)
+	clients[startURL] = *creds
+	return writeIdCClientCache(path, clients)
+}
+
+// InvalidateCachedIdCClient 移除 startURL 的快取客戶端憑證
+func InvalidateCachedIdCClient(path, startURL string) error {
+	if path == "" {
+		return nil
+	}
+
+	idcClientCacheMu.Lock()
+	defer idcClientCacheMu.Unlock()
+
+	clients := loadIdCClientCache(path)
+	if _, ok := clients[startURL]; !ok {
+		return nil
+	}
+	delete(clients, startURL)
+	return writeIdCClientCache(path, clients)
+}
+
+// IsValidAt 檢查客戶端密鑰在 now 時是否仍有效（未提供到期時間時視為有效）
+func (c *IdCClientCredentials) IsValidAt(now time.Time) bool {
+	if c.ClientSecretExpiresAt == 0 {
+		return true
+	}
+	return now.Add(idcClientExpiryMargin).Before(time.Unix(c.ClientSecretExpiresAt, 0))
+}
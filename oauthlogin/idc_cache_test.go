@@ -0,0 +1,141 @@
+// Package oauthlogin 提供 OAuth 登入功能的測試
+package oauthlogin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newIdCCacheTestServer 建立模擬 IdC 端點，每次註冊返回新的 clientId
+// rejectClient 非空時，設備授權對該 clientId 返回 401
+func newIdCCacheTestServer(t *testing.T, registrations *int32, rejectClient string) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		switch r.URL.Path {
+		case "/register":
+			n := atomic.AddInt32(registrations, 1)
+			json.NewEncoder(w).Encode(IdCClientCredentials{
+				ClientId:     fmt.Sprintf("client-%d", n),
+				ClientSecret: "secret",
+			})
+		case "/device_authorization":
+			var req DeviceAuthorizationRequest
+			json.NewDecoder(r.Body).Decode(&req)
+			if req.ClientId == rejectClient {
+				w.WriteHeader(http.StatusUnauthorized)
+				w.Write([]byte(`{"error":"invalid_client"}`))
+				return
+			}
+			json.NewEncoder(w).Encode(DeviceAuthorizationResponse{
+				DeviceCode: "device-code",
+				UserCode:   "USER-CODE",
+				ExpiresIn:  600,
+				Interval:   1,
+			})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// idcCacheTestConfig 建立使用測試伺服器與快取路徑的 IdC 配置
+func idcCacheTestConfig(server *httptest.Server, cachePath string) IdCLoginCoordinatorConfig {
+	return IdCLoginCoordinatorConfig{
+		StartURL:        "https://test.awsapps.com/start",
+		RegisterURL:     server.URL + "/register",
+		DeviceAuthURL:   server.URL + "/device_authorization",
+		Timeout:         10 * time.Second,
+		HTTPClient:      server.Client(),
+		ClientCachePath: cachePath,
+	}
+}
+
+// TestIdCBeginLogin_ReusesCachedClient 驗證第二次登入重用快取的 clientId
+func TestIdCBeginLogin_ReusesCachedClient(t *testing.T) {
+	var registrations int32
+	server := newIdCCacheTestServer(t, &registrations, "")
+	config := idcCacheTestConfig(server, filepath.Join(t.TempDir(), IdCClientCacheFileName))
+
+	_, first, err := IdCBeginLogin(context.Background(), config)
+	if err != nil {
+		t.Fatalf("first IdCBeginLogin failed: %v", err)
+	}
+	_, second, err := IdCBeginLogin(context.Background(), config)
+	if err != nil {
+		t.Fatalf("second IdCBeginLogin failed: %v", err)
+	}
+
+	if got := atomic.LoadInt32(&registrations); got != 1 {
+		t.Errorf("expected 1 registration, got %d", got)
+	}
+	if second.ClientId != first.ClientId {
+		t.Errorf("expected cached clientId %s, got %s", first.ClientId, second.ClientId)
+	}
+}
+
+// TestIdCBeginLogin_ReRegistersOnUnauthorized 驗證快取客戶端被拒絕時清除快取並重新註冊一次
+func TestIdCBeginLogin_ReRegistersOnUnauthorized(t *testing.T) {
+	var registrations int32
+	server := newIdCCacheTestServer(t, &registrations, "stale-client")
+	cachePath := filepath.Join(t.TempDir(), IdCClientCacheFileName)
+	config := idcCacheTestConfig(server, cachePath)
+
+	if err := SaveCachedIdCClient(cachePath, config.StartURL, &IdCClientCredentials{ClientId: "stale-client", ClientSecret: "old"}); err != nil {
+		t.Fatalf("SaveCachedIdCClient failed: %v", err)
+	}
+
+	_, creds, err := IdCBeginLogin(context.Background(), config)
+	if err != nil {
+		t.Fatalf("IdCBeginLogin failed: %v", err)
+	}
+	if got := atomic.LoadInt32(&registrations); got != 1 {
+		t.Errorf("expected 1 re-registration, got %d", got)
+	}
+	if creds.ClientId != "client-1" {
+		t.Errorf("expected new clientId client-1, got %s", creds.ClientId)
+	}
+
+	cached, ok := LoadCachedIdCClient(cachePath, config.StartURL)
+	if !ok || cached.ClientId != "client-1" {
+		t.Errorf("expected cache to hold the new client, got %+v", cached)
+	}
+}
+
+// TestLoadCachedIdCClient_Expired 驗證密鑰即將到期的快取不會被使用
+func TestLoadCachedIdCClient_Expired(t *testing.T) {
+	cachePath := filepath.Join(t.TempDir(), IdCClientCacheFileName)
+	startURL := "https://test.awsapps.com/start"
+
+	expiring := &IdCClientCredentials{
+		ClientId:              "expiring-client",
+		ClientSecret:          "secret",
+		ClientSecretExpiresAt: time.Now().Add(10 * time.Minute).Unix(),
+	}
+	if err := SaveCachedIdCClient(cachePath, startURL, expiring); err != nil {
+		t.Fatalf("SaveCachedIdCClient failed: %v", err)
+	}
+	if _, ok := LoadCachedIdCClient(cachePath, startURL); ok {
+		t.Error("expected expiring client to be ignored")
+	}
+
+	expiring.ClientSecretExpiresAt = time.Now().Add(30 * 24 * time.Hour).Unix()
+	SaveCachedIdCClient(cachePath, startURL, expiring)
+	if _, ok := LoadCachedIdCClient(cachePath, startURL); !ok {
+		t.Error("expected valid client to be loaded")
+	}
+
+	if _, ok := LoadCachedIdCClient(cachePath, "https://other.awsapps.com/start"); ok {
+		t.Error("expected cache to be keyed by StartURL")
+	}
+}
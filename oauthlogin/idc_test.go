@@ -427,6 +427,64 @@ func TestPollForToken_AuthorizationPending(t *testing.T) {
 	}
 }
 
+// TestPollForTokenWithProgress_Callback 驗證每次 authorization_pending 都會回調且次數遞增
+func TestPollForTokenWithProgress_Callback(t *testing.T) {
+	callCount := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		callCount++
+		if callCount < 3 {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "authorization_pending"})
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(IdCTokenResponse{AccessToken: "test_access_token", ExpiresIn: 3600})
+	}))
+	defer server.Close()
+
+	creds := &IdCClientCredentials{ClientId: "test_client_id", ClientSecret: "test_client_secret"}
+	authResp := &DeviceAuthorizationResponse{DeviceCode: "test_device_code", Interval: 1, ExpiresIn: 300}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var attempts []int
+	var intervals []time.Duration
+	onPoll := func(attempt int, nextInterval time.Duration) {
+		attempts = append(attempts, attempt)
+		intervals = append(intervals, nextInterval)
+	}
+
+	if _, err := PollForTokenWithProgress(ctx, http.DefaultClient, server.URL, creds, authResp, onPoll); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if len(attempts) != 2 || attempts[0] != 1 || attempts[1] != 2 {
+		t.Errorf("Expected callbacks for attempts [1 2], got %v", attempts)
+	}
+	for i, interval := range intervals {
+		if interval != time.Second {
+			t.Errorf("Callback %d: expected next interval 1s, got %v", i, interval)
+		}
+	}
+}
+
+// TestPollForTokenWithProgress_NilCallback 驗證 onPoll 為 nil 時正常輪詢
+func TestPollForTokenWithProgress_NilCallback(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(IdCTokenResponse{AccessToken: "test_access_token", ExpiresIn: 3600})
+	}))
+	defer server.Close()
+
+	creds := &IdCClientCredentials{ClientId: "test_client_id", ClientSecret: "test_client_secret"}
+	authResp := &DeviceAuthorizationResponse{DeviceCode: "test_device_code", Interval: 1, ExpiresIn: 300}
+
+	if _, err := PollForTokenWithProgress(context.Background(), http.DefaultClient, server.URL, creds, authResp, nil); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+}
+
 // TestPollForToken_AccessDenied 驗證 access_denied 狀態處理
 func TestPollForToken_AccessDenied(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -19,6 +19,8 @@ const (
 	ErrCodeNetworkError = "network_error"
 	// ErrCodeStateMismatch State 不匹配
 	ErrCodeStateMismatch = "state_mismatch"
+	// ErrCodeUnknownProvider 不支援的登入提供者
+	ErrCodeUnknownProvider = "unknown_provider"
 )
 
 // Provider 常數定義
@@ -27,6 +29,8 @@ const (
 	ProviderGithub = "Github"
 	// ProviderGoogle Google 提供者
 	ProviderGoogle = "Google"
+	// ProviderMicrosoft Microsoft 提供者
+	ProviderMicrosoft = "Microsoft"
 	// ProviderBuilderID AWS Builder ID 提供者
 	ProviderBuilderID = "BuilderID"
 )
@@ -66,7 +70,7 @@ type LoginResult struct {
 	ExpiresAt time.Time
 	// ProfileArn AWS Profile ARN (Social 登入)
 	ProfileArn string
-	// Provider 提供者 (Github/Google/BuilderID)
+	// Provider 提供者 (Github/Google/Microsoft/BuilderID)
 	Provider string
 	// AuthMethod 認證方式 (social/idc)
 	AuthMethod string
@@ -8,6 +8,8 @@ import (
 	"io"
 	"net/http"
 	"net/url"
+	"sort"
+	"sync"
 )
 
 // SocialProvider Social 登入提供者類型
@@ -19,8 +21,71 @@ const (
 	SocialProviderGithub SocialProvider = "Github"
 	// SocialProviderGoogle Google 提供者
 	SocialProviderGoogle SocialProvider = "Google"
+	// SocialProviderMicrosoft Microsoft 提供者
+	SocialProviderMicrosoft SocialProvider = "Microsoft"
 )
 
+// providers 已註冊的 Social 登入提供者（名稱 → 授權 URL 的 idp 參數值）
+// 預設包含 ProviderGithub、ProviderGoogle、ProviderMicrosoft，其他 OIDC 提供者可透過 RegisterProvider 加入
+var (
+	providersMu sync.RWMutex
+	providers   = map[string]string{
+		ProviderGithub:    string(SocialProviderGithub),
+		ProviderGoogle:    string(SocialProviderGoogle),
+		ProviderMicrosoft: string(SocialProviderMicrosoft),
+	}
+)
+
+// RegisterProvider 註冊 Social 登入提供者
+// name 為 SocialLoginConfig.Provider 使用的名稱，idpValue 為授權 URL 的 idp 參數值
+// 重複註冊會覆蓋原有的 idp 參數值；name 為空時忽略
+func RegisterProvider(name, idpValue string) {
+	if name == "" {
+		return
+	}
+	providersMu.Lock()
+	defer providersMu.Unlock()
+	providers[name] = idpValue
+}
+
+// LookupProvider 取得已註冊提供者的 idp 參數值
+func LookupProvider(name string) (string, bool) {
+	providersMu.RLock()
+	defer providersMu.RUnlock()
+	idp, ok := providers[name]
+	return idp, ok
+}
+
+// ValidateProvider 檢查提供者是否已註冊
+// 提供者為空或未註冊時返回 ErrCodeUnknownProvider 錯誤
+func ValidateProvider(name string) error {
+	if name == "" {
+		return &OAuthError{
+			Code:    ErrCodeUnknownProvider,
+			Message: "social login provider is required",
+		}
+	}
+	if _, ok := LookupProvider(name); !ok {
+		return &OAuthError{
+			Code:    ErrCodeUnknownProvider,
+			Message: fmt.Sprintf("unknown social login provider: %q", name),
+		}
+	}
+	return nil
+}
+
+// RegisteredProviders 取得所有已註冊的提供者名稱（依字母排序）
+func RegisteredProviders() []string {
+	providersMu.RLock()
+	defer providersMu.RUnlock()
+	names := make([]string, 0, len(providers))
+	for name := range providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
 // OAuth 端點常數
 const (
 	// AuthBaseURL 授權基礎 URL
@@ -62,6 +127,7 @@ type tokenExchangeRequest struct {
 
 // BuildAuthorizationURL 建構授權 URL
 // 根據配置和 PKCE 參數生成完整的授權 URL
+// idp 參數使用已註冊提供者的 idp 值，未註冊時直接使用 Provider 名稱
 // 參數：
 //   - config: Social 登入配置
 //   - pkce: PKCE 參數
@@ -79,7 +145,11 @@ func BuildAuthorizationURL(config SocialLoginConfig, pkce PKCEParams) string {
 
 	// 建構查詢參數
 	params := url.Values{}
-	params.Set("idp", config.Provider)
+	idp := config.Provider
+	if registered, ok := LookupProvider(config.Provider); ok {
+		idp = registered
+	}
+	params.Set("idp", idp)
 	params.Set("redirect_uri", redirectURI)
 	params.Set("code_challenge", pkce.CodeChallenge)
 	params.Set("code_challenge_method", "S256")
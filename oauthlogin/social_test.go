@@ -217,6 +217,85 @@ func TestBuildAuthorizationURL_RedirectURI(t *testing.T) {
 	}
 }
 
+// TestRegisterProvider_AuthorizationURL 驗證新註冊的提供者使用其 idp 參數值
+func TestRegisterProvider_AuthorizationURL(t *testing.T) {
+	RegisterProvider("Okta", "OktaOIDC")
+	t.Cleanup(func() {
+		providersMu.Lock()
+		delete(providers, "Okta")
+		providersMu.Unlock()
+	})
+
+	if err := ValidateProvider("Okta"); err != nil {
+		t.Fatalf("expected registered provider to be valid, got %v", err)
+	}
+
+	pkce, _ := GeneratePKCE()
+	authURL := BuildAuthorizationURL(SocialLoginConfig{Provider: "Okta", Port: 8080}, *pkce)
+
+	parsedURL, err := url.Parse(authURL)
+	if err != nil {
+		t.Fatalf("Failed to parse URL: %v", err)
+	}
+	if idp := parsedURL.Query().Get("idp"); idp != "OktaOIDC" {
+		t.Errorf("idp mismatch: expected OktaOIDC, got %s", idp)
+	}
+}
+
+// TestValidateProvider_Unknown 驗證未註冊的提供者返回 ErrCodeUnknownProvider
+func TestValidateProvider_Unknown(t *testing.T) {
+	for _, provider := range []string{ProviderGithub, ProviderGoogle} {
+		if err := ValidateProvider(provider); err != nil {
+			t.Errorf("expected %s to be registered, got %v", provider, err)
+		}
+	}
+
+	err := ValidateProvider("Unknown")
+	oauthErr, ok := err.(*OAuthError)
+	if !ok {
+		t.Fatalf("expected OAuthError, got %T", err)
+	}
+	if oauthErr.Code != ErrCodeUnknownProvider {
+		t.Errorf("expected error code '%s', got '%s'", ErrCodeUnknownProvider, oauthErr.Code)
+	}
+}
+
+// TestBuildAuthorizationURL_Microsoft 驗證 Microsoft 提供者的 idp 參數
+func TestBuildAuthorizationURL_Microsoft(t *testing.T) {
+	if err := ValidateProvider(ProviderMicrosoft); err != nil {
+		t.Fatalf("expected Microsoft to be a known provider, got %v", err)
+	}
+
+	pkce, _ := GeneratePKCE()
+	authURL := BuildAuthorizationURL(SocialLoginConfig{Provider: ProviderMicrosoft, Port: 8080}, *pkce)
+
+	parsedURL, err := url.Parse(authURL)
+	if err != nil {
+		t.Fatalf("Failed to parse URL: %v", err)
+	}
+	if idp := parsedURL.Query().Get("idp"); idp != string(SocialProviderMicrosoft) {
+		t.Errorf("idp mismatch: expected %s, got %s", SocialProviderMicrosoft, idp)
+	}
+}
+
+// TestValidateProvider_Empty 驗證空的提供者被拒絕
+func TestValidateProvider_Empty(t *testing.T) {
+	err := ValidateProvider("")
+	oauthErr, ok := err.(*OAuthError)
+	if !ok {
+		t.Fatalf("expected OAuthError, got %T", err)
+	}
+	if oauthErr.Code != ErrCodeUnknownProvider {
+		t.Errorf("expected error code '%s', got '%s'", ErrCodeUnknownProvider, oauthErr.Code)
+	}
+
+	// 空名稱不會被註冊
+	RegisterProvider("", "Empty")
+	if _, ok := LookupProvider(""); ok {
+		t.Error("expected empty provider name to be ignored by RegisterProvider")
+	}
+}
+
 // TestMapHTTPError_400 驗證 400 錯誤映射到 ErrCodeInvalidCode
 func TestMapHTTPError_400(t *testing.T) {
 	oauthErr := mapHTTPError(400, []byte("invalid code"))
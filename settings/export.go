@@ -0,0 +1,124 @@
+package settings
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"regexp"
+)
+
+// SettingsSchemaVersion 設定匯出格式版本，格式不相容時遞增
+const SettingsSchemaVersion = 1
+
+// ErrInvalidSettingsImport 匯入的設定格式錯誤或數值超出範圍
+var ErrInvalidSettingsImport = errors.New("invalid settings import")
+
+// kiroVersionPattern Kiro IDE 版本號格式（如 0.8.206）
+var kiroVersionPattern = regexp.MustCompile(`^\d+\.\d+\.\d+$`)
+
+// settingsExport 設定匯出檔格式
+type settingsExport struct {
+	SchemaVersion int             `json:"schemaVersion"`
+	Settings      json.RawMessage `json:"settings"`
+}
+
+// ExportSettings 將目前使用中的完整設定以 JSON 寫入 w
+func ExportSettings(w io.Writer) error {
+	s := *GetCurrentSettings()
+	return encodeSettingsExport(w, &s)
+}
+
+// ImportSettings 從 r 讀取 ExportSettings 匯出的 JSON，驗證後儲存為使用中的設定
+// 格式版本不符、含未知欄位或數值超出範圍時返回 ErrInvalidSettingsImport，不會修改現有設定
+func ImportSettings(r io.Reader) error {
+	s, err := decodeSettingsExport(r)
+	if err != nil {
+		return err
+	}
+	return SaveSettings(s)
+}
+
+// encodeSettingsExport 將設定與格式版本編碼為 JSON
+func encodeSettingsExport(w io.Writer, s *Settings) error {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(settingsExport{SchemaVersion: SettingsSchemaVersion, Settings: data})
+}
+
+// decodeSettingsExport 解析並驗證設定匯出檔
+func decodeSettingsExport(r io.Reader) (*Settings, error) {
+	decoder := json.NewDecoder(r)
+	decoder.DisallowUnknownFields()
+
+	var export settingsExport
+	if err := decoder.Decode(&export); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSettingsImport, err)
+	}
+	if export.SchemaVersion != SettingsSchemaVersion {
+		return nil, fmt.Errorf("%w: unsupported schema version %d (expected %d)", ErrInvalidSettingsImport, export.SchemaVersion, SettingsSchemaVersion)
+	}
+	if len(export.Settings) == 0 || string(export.Settings) == "null" {
+		return nil, fmt.Errorf("%w: missing settings", ErrInvalidSettingsImport)
+	}
+
+	// 先以嚴格模式解析，拒絕未知欄位
+	strict := json.NewDecoder(bytes.NewReader(export.Settings))
+	strict.DisallowUnknownFields()
+	if err := strict.Decode(&Settings{}); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSettingsImport, err)
+	}
+
+	// 舊版設定同樣經過遷移，缺少的欄位補上預設值
+	var raw map[string]interface{}
+	if err := json.Unmarshal(export.Settings, &raw); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSettingsImport, err)
+	}
+	settings, err := migrate(raw)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSettingsImport, err)
+	}
+	if err := checkImportedSettings(settings); err != nil {
+		return nil, err
+	}
+
+	validated := validateSettings(*settings)
+	return &validated, nil
+}
+
+// checkImportedSettings 檢查匯入設定的數值範圍
+// 與 validateSettings 不同，超出範圍時直接拒絕而非自動修正
+func checkImportedSettings(s *Settings) error {
+	if s.LowBalanceThreshold < 0 || s.LowBalanceThreshold > 1 {
+		return fmt.Errorf("%w: lowBalanceThreshold %v must be between 0 and 1", ErrInvalidSettingsImport, s.LowBalanceThreshold)
+	}
+	if s.KiroVersion != "" && !kiroVersionPattern.MatchString(s.KiroVersion) {
+		return fmt.Errorf("%w: kiroVersion %q must look like 0.8.206", ErrInvalidSettingsImport, s.KiroVersion)
+	}
+	// 視窗大小為 0 表示使用預設值
+	if s.WindowWidth != 0 && s.WindowWidth < MinWindowWidth {
+		return fmt.Errorf("%w: windowWidth %d is below the minimum %d", ErrInvalidSettingsImport, s.WindowWidth, MinWindowWidth)
+	}
+	if s.WindowHeight != 0 && s.WindowHeight < MinWindowHeight {
+		return fmt.Errorf("%w: windowHeight %d is below the minimum %d", ErrInvalidSettingsImport, s.WindowHeight, MinWindowHeight)
+	}
+	if s.AutoPruneKeepCount < 0 {
+		return fmt.Errorf("%w: autoPruneKeepCount %d must not be negative", ErrInvalidSettingsImport, s.AutoPruneKeepCount)
+	}
+	if s.BackupRootOverride != "" && !filepath.IsAbs(s.BackupRootOverride) {
+		return fmt.Errorf("%w: backupRootOverride %q must be an absolute path", ErrInvalidSettingsImport, s.BackupRootOverride)
+	}
+	if err := ValidateRefreshEndpoint(s.SocialRefreshURLOverride); err != nil {
+		return fmt.Errorf("%w: socialRefreshUrlOverride: %v", ErrInvalidSettingsImport, err)
+	}
+	if err := ValidateRefreshEndpoint(s.IdCRefreshURLOverride); err != nil {
+		return fmt.Errorf("%w: idcRefreshUrlOverride: %v", ErrInvalidSettingsImport, err)
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package settings
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"kiro-manager/autoswitch"
+)
+
+func TestSettingsExport_RoundTrip(t *testing.T) {
+	autoSwitch := autoswitch.DefaultAutoSwitchSettings()
+	autoSwitch.Enabled = true
+	autoSwitch.Blacklist = []string{"帳號A"}
+
+	original := &Settings{
+		SchemaVersion:         CurrentSchemaVersion,
+		LowBalanceThreshold:   0.35,
+		KiroVersion:           "0.9.12",
+		UseAutoDetect:         false,
+		CustomKiroInstallPath: "/opt/kiro",
+		WindowWidth:           MinWindowWidth + 200,
+		WindowHeight:          MinWindowHeight,
+		AutoSwitch:            autoSwitch,
+		AutoPruneBackups:      true,
+		AutoPruneKeepCount:    5,
+	}
+
+	var buf bytes.Buffer
+	if err := encodeSettingsExport(&buf, original); err != nil {
+		t.Fatalf("encodeSettingsExport() error = %v", err)
+	}
+	if !strings.Contains(buf.String(), `"schemaVersion": 1`) {
+		t.Errorf("export should contain the schema version, got %s", buf.String())
+	}
+
+	imported, err := decodeSettingsExport(&buf)
+	if err != nil {
+		t.Fatalf("decodeSettingsExport() error = %v", err)
+	}
+	if !reflect.DeepEqual(imported, original) {
+		t.Errorf("round trip mismatch:\n got  %+v\n want %+v", imported, original)
+	}
+}
+
+func TestSettingsExport_RejectsInvalidInput(t *testing.T) {
+	testCases := []struct {
+		name    string
+		input   string
+		wantErr string
+	}{
+		{"sub-minimum window width", `{"schemaVersion":1,"settings":{"windowWidth":800}}`, "windowWidth 800 is below the minimum"},
+		{"sub-minimum window height", `{"schemaVersion":1,"settings":{"windowHeight":100}}`, "windowHeight 100 is below the minimum"},
+		{"malformed kiro version", `{"schemaVersion":1,"settings":{"kiroVersion":"latest"}}`, "kiroVersion"},
+		{"threshold out of range", `{"schemaVersion":1,"settings":{"lowBalanceThreshold":1.5}}`, "lowBalanceThreshold"},
+		{"relative backup root", `{"schemaVersion":1,"settings":{"backupRootOverride":"backups"}}`, "backupRootOverride"},
+		{"insecure refresh endpoint", `{"schemaVersion":1,"settings":{"socialRefreshUrlOverride":"http://example.com"}}`, "socialRefreshUrlOverride"},
+		{"unknown field", `{"schemaVersion":1,"settings":{"theme":"dark"}}`, "unknown field"},
+		{"unsupported schema version", `{"schemaVersion":2,"settings":{}}`, "unsupported schema version 2"},
+		{"missing settings", `{"schemaVersion":1}`, "missing settings"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := decodeSettingsExport(strings.NewReader(tc.input))
+			if !errors.Is(err, ErrInvalidSettingsImport) {
+				t.Fatalf("expected ErrInvalidSettingsImport, got %v", err)
+			}
+			if !strings.Contains(err.Error(), tc.wantErr) {
+				t.Errorf("expected error to contain %q, got %q", tc.wantErr, err.Error())
+			}
+		})
+	}
+}
@@ -0,0 +1,214 @@
+package settings
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const (
+	// DefaultProfileName 預設設定檔名稱（對應既有的 settings.json）
+	DefaultProfileName = "default"
+	// ProfilesDirName 具名設定檔目錄（執行檔同層）
+	ProfilesDirName = "settings-profiles"
+	// ActiveProfileFileName 記錄目前使用中設定檔名稱的檔案
+	ActiveProfileFileName = "active-profile"
+	// profileFileExt 具名設定檔的副檔名
+	profileFileExt = ".json"
+	// maxProfileNameLength 設定檔名稱最大長度
+	maxProfileNameLength = 64
+)
+
+var (
+	ErrInvalidProfileName = errors.New("invalid profile name")
+	ErrProfileNotFound    = errors.New("profile not found")
+)
+
+// GetProfilesDir 取得具名設定檔目錄路徑
+func GetProfilesDir() (string, error) {
+	execPath, err := os.Executable()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(filepath.Dir(execPath), ProfilesDirName), nil
+}
+
+// getProfilePath 取得指定設定檔的檔案路徑
+// default 設定檔沿用 settings.json，維持向後相容
+func getProfilePath(name string) (string, error) {
+	if name == DefaultProfileName {
+		return GetSettingsPath()
+	}
+	if err := validateProfileName(name); err != nil {
+		return "", err
+	}
+	dir, err := GetProfilesDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, name+profileFileExt), nil
+}
+
+// validateProfileName 驗證設定檔名稱（不可為空、不可含路徑或檔名非法字元）
+func validateProfileName(name string) error {
+	if strings.TrimSpace(name) == "" || name != strings.TrimSpace(name) {
+		return ErrInvalidProfileName
+	}
+	if len(name) > maxProfileNameLength || name == "." || name == ".." {
+		return ErrInvalidProfileName
+	}
+	if strings.ContainsAny(name, `\/:*?"<>|`) {
+		return ErrInvalidProfileName
+	}
+	return nil
+}
+
+// profileExists 檢查設定檔是否存在（default 設定檔永遠存在）
+func profileExists(name string) bool {
+	if name == DefaultProfileName {
+		return true
+	}
+	path, err := getProfilePath(name)
+	if err != nil {
+		return false
+	}
+	_, err = os.Stat(path)
+	return err == nil
+}
+
+// GetActiveProfile 取得目前使用中的設定檔名稱
+// 沒有記錄或記錄的設定檔已不存在時返回 default
+func GetActiveProfile() string {
+	dir, err := GetProfilesDir()
+	if err != nil {
+		return DefaultProfileName
+	}
+	data, err := os.ReadFile(filepath.Join(dir, ActiveProfileFileName))
+	if err != nil {
+		return DefaultProfileName
+	}
+	name := strings.TrimSpace(string(data))
+	if name == "" || !profileExists(name) {
+		return DefaultProfileName
+	}
+	return name
+}
+
+// getActiveSettingsPath 取得使用中設定檔的檔案路徑
+func getActiveSettingsPath() (string, error) {
+	return getProfilePath(GetActiveProfile())
+}
+
+// ListProfiles 列出所有設定檔名稱（default 排在第一個，其餘依名稱排序）
+func ListProfiles() ([]string, error) {
+	profiles := []string{DefaultProfileName}
+
+	dir, err := GetProfilesDir()
+	if err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return profiles, nil
+		}
+		return nil, err
+	}
+
+	var names []string
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), profileFileExt) {
+			continue
+		}
+		name := strings.TrimSuffix(entry.Name(), profileFileExt)
+		if name == DefaultProfileName || validateProfileName(name) != nil {
+			continue
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return append(profiles, names...), nil
+}
+
+// LoadProfile 讀取指定設定檔的內容（不會切換使用中的設定檔）
+// default 設定檔不存在時返回預設設定；其他設定檔不存在時返回 ErrProfileNotFound
+func LoadProfile(name string) (*Settings, error) {
+	path, err := getProfilePath(name)
+	if err != nil {
+		return nil, err
+	}
+
+	settings, err := readSettingsFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			if name == DefaultProfileName {
+				return getDefaultSettings(), nil
+			}
+			return nil, ErrProfileNotFound
+		}
+		return nil, err
+	}
+	return settings, nil
+}
+
+// SaveProfile 儲存指定設定檔
+// 儲存的是使用中的設定檔時，等同於 SaveSettings
+func SaveProfile(name string, s *Settings) error {
+	if s == nil {
+		return nil
+	}
+	if name == GetActiveProfile() {
+		return SaveSettings(s)
+	}
+
+	path, err := getProfilePath(name)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	_, err = writeSettingsFile(path, s)
+	return err
+}
+
+// SetActiveProfile 切換使用中的設定檔，並重新載入設定
+// GetKiroVersion、IsAutoDetectEnabled 等函數會立即返回新設定檔的值
+func SetActiveProfile(name string) error {
+	if _, err := getProfilePath(name); err != nil {
+		return err
+	}
+	if !profileExists(name) {
+		return ErrProfileNotFound
+	}
+
+	dir, err := GetProfilesDir()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	// 記錄切換前的自定義路徑，用於判斷是否需要清除路徑快取
+	oldCustomPath := GetCustomKiroInstallPath()
+
+	if err := os.WriteFile(filepath.Join(dir, ActiveProfileFileName), []byte(name), 0644); err != nil {
+		return err
+	}
+
+	settings, err := LoadSettings()
+	if err != nil {
+		return err
+	}
+
+	if oldCustomPath != settings.CustomKiroInstallPath && pathCacheInvalidator != nil {
+		pathCacheInvalidator()
+	}
+
+	return nil
+}
@@ -2,6 +2,9 @@ package settings
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
 	"os"
 	"path/filepath"
 	"sync"
@@ -24,10 +27,17 @@ const (
 	MinWindowWidth = 1040
 	// 最小視窗高度
 	MinWindowHeight = 600
+	// 預設自動清理時保留的備份數量
+	DefaultAutoPruneKeepCount = 20
+	// 目前的設定檔格式版本，新增需要遷移的欄位時遞增
+	CurrentSchemaVersion = 1
 )
 
 // Settings 全域設定結構
 type Settings struct {
+	// SchemaVersion 設定檔格式版本（儲存時一律寫入 CurrentSchemaVersion）
+	// 舊版設定檔沒有此欄位，視為版本 0
+	SchemaVersion int `json:"schemaVersion"`
 	// LowBalanceThreshold 低餘額閾值（0.0 ~ 1.0）
 	// 當餘額比率低於此值時，顯示低餘額警告
 	LowBalanceThreshold float64 `json:"lowBalanceThreshold"`
@@ -49,8 +59,24 @@ type Settings struct {
 	WindowHeight int `json:"windowHeight,omitempty"`
 	// AutoSwitch 自動切換設定
 	AutoSwitch *autoswitch.AutoSwitchSettings `json:"autoSwitch,omitempty"`
+	// AutoPruneBackups 是否於啟動時自動清理舊備份
+	AutoPruneBackups bool `json:"autoPruneBackups"`
+	// AutoPruneKeepCount 自動清理時保留的最新備份數量（不含原始備份與已分配文件夾的快照）
+	AutoPruneKeepCount int `json:"autoPruneKeepCount,omitempty"`
+	// BackupRootOverride 自定義備份根目錄（絕對路徑）
+	// 空字串表示使用執行檔同層的 backups 資料夾
+	BackupRootOverride string `json:"backupRootOverride,omitempty"`
+	// SocialRefreshURLOverride 自定義 Social Token 刷新端點（https URL）
+	// 空字串表示使用內建端點
+	SocialRefreshURLOverride string `json:"socialRefreshUrlOverride,omitempty"`
+	// IdCRefreshURLOverride 自定義 IdC Token 刷新端點（https URL，所有區域共用）
+	// 空字串表示依 token 的 region 使用內建端點
+	IdCRefreshURLOverride string `json:"idcRefreshUrlOverride,omitempty"`
 }
 
+// ErrInvalidRefreshEndpoint 自定義刷新端點不是有效的 https URL
+var ErrInvalidRefreshEndpoint = errors.New("refresh endpoint must be an https URL")
+
 var (
 	currentSettings *Settings
 	settingsMutex   sync.RWMutex
@@ -69,18 +95,18 @@ func GetSettingsPath() (string, error) {
 	return filepath.Join(execDir, SettingsFileName), nil
 }
 
-// LoadSettings 載入設定
+// LoadSettings 載入使用中設定檔的設定
 // 如果設定檔不存在，返回預設設定
 func LoadSettings() (*Settings, error) {
 	settingsMutex.Lock()
 	defer settingsMutex.Unlock()
 
-	settingsPath, err := GetSettingsPath()
+	settingsPath, err := getActiveSettingsPath()
 	if err != nil {
 		return getDefaultSettings(), nil
 	}
 
-	data, err := os.ReadFile(settingsPath)
+	settings, err := readSettingsFile(settingsPath)
 	if err != nil {
 		if os.IsNotExist(err) {
 			currentSettings = getDefaultSettings()
@@ -89,18 +115,72 @@ func LoadSettings() (*Settings, error) {
 		return getDefaultSettings(), nil
 	}
 
-	var settings Settings
-	if err := json.Unmarshal(data, &settings); err != nil {
-		return getDefaultSettings(), nil
+	currentSettings = settings
+	return currentSettings, nil
+}
+
+// readSettingsFile 讀取設定檔，遷移至目前版本並驗證設定值
+func readSettingsFile(path string) (*Settings, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var raw map[string]interface{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+
+	settings, err := migrate(raw)
+	if err != nil {
+		return nil, err
 	}
 
 	// 驗證並修正設定值
-	settings = validateSettings(settings)
-	currentSettings = &settings
-	return currentSettings, nil
+	validated := validateSettings(*settings)
+	return &validated, nil
+}
+
+// writeSettingsFile 驗證設定值後寫入設定檔，返回實際寫入的設定
+func writeSettingsFile(path string, settings *Settings) (*Settings, error) {
+	validated := validateSettings(*settings)
+
+	data, err := json.MarshalIndent(&validated, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return nil, err
+	}
+	return &validated, nil
+}
+
+// migrate 將舊版設定檔內容升級為目前版本
+// 缺少的欄位以預設值補上（例如 v0 沒有 useAutoDetect 時應為 true，而非零值 false）
+func migrate(old map[string]interface{}) (*Settings, error) {
+	version := 0
+	if v, ok := old["schemaVersion"].(float64); ok {
+		version = int(v)
+	}
+
+	data, err := json.Marshal(old)
+	if err != nil {
+		return nil, err
+	}
+
+	// 以預設設定為基底解析，JSON 中沒有的欄位保留預設值
+	// v0 → v1：v0 沒有 schemaVersion，新增的欄位全部由預設值補上
+	// 較新版本的設定檔保留可辨識的欄位，其餘忽略
+	settings := getDefaultSettings()
+	if err := json.Unmarshal(data, settings); err != nil {
+		return nil, fmt.Errorf("failed to migrate settings from version %d: %w", version, err)
+	}
+
+	settings.SchemaVersion = CurrentSchemaVersion
+	return settings, nil
 }
 
-// SaveSettings 儲存設定
+// SaveSettings 儲存設定至使用中的設定檔
 func SaveSettings(settings *Settings) error {
 	if settings == nil {
 		return nil
@@ -115,24 +195,17 @@ func SaveSettings(settings *Settings) error {
 		oldCustomPath = currentSettings.CustomKiroInstallPath
 	}
 
-	// 驗證並修正設定值
-	validated := validateSettings(*settings)
-	settings = &validated
-
-	settingsPath, err := GetSettingsPath()
+	settingsPath, err := getActiveSettingsPath()
 	if err != nil {
 		return err
 	}
 
-	data, err := json.MarshalIndent(settings, "", "  ")
+	// 驗證並修正設定值後寫入
+	settings, err = writeSettingsFile(settingsPath, settings)
 	if err != nil {
 		return err
 	}
 
-	if err := os.WriteFile(settingsPath, data, 0644); err != nil {
-		return err
-	}
-
 	currentSettings = settings
 
 	// 如果自定義路徑變更，清除路徑快取
@@ -203,6 +276,48 @@ func GetCustomKiroInstallPath() string {
 	return settings.CustomKiroInstallPath
 }
 
+// GetBackupRootOverride 取得自定義備份根目錄
+// 返回空字串表示使用預設位置
+func GetBackupRootOverride() string {
+	settings := GetCurrentSettings()
+	if settings == nil {
+		return ""
+	}
+	return settings.BackupRootOverride
+}
+
+// GetSocialRefreshURLOverride 取得自定義 Social 刷新端點
+// 返回空字串表示使用內建端點
+func GetSocialRefreshURLOverride() string {
+	settings := GetCurrentSettings()
+	if settings == nil {
+		return ""
+	}
+	return settings.SocialRefreshURLOverride
+}
+
+// GetIdCRefreshURLOverride 取得自定義 IdC 刷新端點
+// 返回空字串表示使用內建端點
+func GetIdCRefreshURLOverride() string {
+	settings := GetCurrentSettings()
+	if settings == nil {
+		return ""
+	}
+	return settings.IdCRefreshURLOverride
+}
+
+// ValidateRefreshEndpoint 驗證自定義刷新端點為 https URL（空字串視為有效）
+func ValidateRefreshEndpoint(endpoint string) error {
+	if endpoint == "" {
+		return nil
+	}
+	u, err := url.Parse(endpoint)
+	if err != nil || u.Scheme != "https" || u.Host == "" {
+		return fmt.Errorf("%w: %q", ErrInvalidRefreshEndpoint, endpoint)
+	}
+	return nil
+}
+
 // GetWindowWidth 取得視窗寬度
 // 返回 0 表示使用預設值
 func GetWindowWidth() int {
@@ -223,17 +338,39 @@ func GetWindowHeight() int {
 	return settings.WindowHeight
 }
 
+// IsAutoPruneEnabled 檢查是否啟用啟動時自動清理舊備份
+func IsAutoPruneEnabled() bool {
+	settings := GetCurrentSettings()
+	if settings == nil {
+		return false
+	}
+	return settings.AutoPruneBackups
+}
+
+// GetAutoPruneKeepCount 取得自動清理時保留的備份數量
+func GetAutoPruneKeepCount() int {
+	settings := GetCurrentSettings()
+	if settings == nil || settings.AutoPruneKeepCount <= 0 {
+		return DefaultAutoPruneKeepCount
+	}
+	return settings.AutoPruneKeepCount
+}
+
 // getDefaultSettings 取得預設設定
 func getDefaultSettings() *Settings {
 	return &Settings{
+		SchemaVersion:       CurrentSchemaVersion,
 		LowBalanceThreshold: DefaultLowBalanceThreshold,
 		KiroVersion:         DefaultKiroVersion,
 		UseAutoDetect:       true, // 預設使用自動偵測
+		AutoPruneKeepCount:  DefaultAutoPruneKeepCount,
 	}
 }
 
 // validateSettings 驗證並修正設定值
 func validateSettings(settings Settings) Settings {
+	// 儲存的設定一律標記為目前版本
+	settings.SchemaVersion = CurrentSchemaVersion
 	// LowBalanceThreshold 必須在 0.0 ~ 1.0 之間
 	if settings.LowBalanceThreshold < 0 {
 		settings.LowBalanceThreshold = 0
@@ -245,6 +382,10 @@ func validateSettings(settings Settings) Settings {
 	if settings.KiroVersion == "" {
 		settings.KiroVersion = DefaultKiroVersion
 	}
+	// AutoPruneKeepCount 必須 >= 1
+	if settings.AutoPruneKeepCount <= 0 {
+		settings.AutoPruneKeepCount = DefaultAutoPruneKeepCount
+	}
 	// WindowWidth 必須 >= MinWindowWidth（若有設定）
 	if settings.WindowWidth > 0 && settings.WindowWidth < MinWindowWidth {
 		settings.WindowWidth = MinWindowWidth
@@ -253,5 +394,12 @@ func validateSettings(settings Settings) Settings {
 	if settings.WindowHeight > 0 && settings.WindowHeight < MinWindowHeight {
 		settings.WindowHeight = MinWindowHeight
 	}
+	// 無效的自定義刷新端點忽略，改用內建端點
+	if ValidateRefreshEndpoint(settings.SocialRefreshURLOverride) != nil {
+		settings.SocialRefreshURLOverride = ""
+	}
+	if ValidateRefreshEndpoint(settings.IdCRefreshURLOverride) != nil {
+		settings.IdCRefreshURLOverride = ""
+	}
 	return settings
 }
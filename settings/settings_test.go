@@ -0,0 +1,116 @@
+package settings
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReadSettingsFile_MigratesV0(t *testing.T) {
+	path := filepath.Join(t.TempDir(), SettingsFileName)
+	// v0 設定檔沒有 schemaVersion，也沒有之後新增的欄位
+	v0 := `{"kiroVersion": "0.8.100", "windowWidth": 1200}`
+	if err := os.WriteFile(path, []byte(v0), 0644); err != nil {
+		t.Fatalf("failed to write v0 settings: %v", err)
+	}
+
+	s, err := readSettingsFile(path)
+	if err != nil {
+		t.Fatalf("readSettingsFile() error = %v", err)
+	}
+	if s.SchemaVersion != CurrentSchemaVersion {
+		t.Errorf("SchemaVersion = %d, want %d", s.SchemaVersion, CurrentSchemaVersion)
+	}
+	if s.KiroVersion != "0.8.100" || s.WindowWidth != 1200 {
+		t.Errorf("existing fields not preserved: %+v", s)
+	}
+	// 缺少的欄位應為預設值而非零值
+	if !s.UseAutoDetect {
+		t.Error("UseAutoDetect should default to true for v0 settings")
+	}
+	if s.LowBalanceThreshold != DefaultLowBalanceThreshold {
+		t.Errorf("LowBalanceThreshold = %v, want %v", s.LowBalanceThreshold, DefaultLowBalanceThreshold)
+	}
+	if s.AutoPruneKeepCount != DefaultAutoPruneKeepCount {
+		t.Errorf("AutoPruneKeepCount = %d, want %d", s.AutoPruneKeepCount, DefaultAutoPruneKeepCount)
+	}
+
+	if _, err := writeSettingsFile(path, s); err != nil {
+		t.Fatalf("writeSettingsFile() error = %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read saved settings: %v", err)
+	}
+	var saved map[string]interface{}
+	if err := json.Unmarshal(data, &saved); err != nil {
+		t.Fatalf("saved settings are not valid JSON: %v", err)
+	}
+	if saved["schemaVersion"] != float64(CurrentSchemaVersion) {
+		t.Errorf("saved schemaVersion = %v, want %d", saved["schemaVersion"], CurrentSchemaVersion)
+	}
+}
+
+func TestMigrate_KeepsExplicitValues(t *testing.T) {
+	s, err := migrate(map[string]interface{}{
+		"schemaVersion":       float64(CurrentSchemaVersion),
+		"useAutoDetect":       false,
+		"lowBalanceThreshold": float64(0),
+	})
+	if err != nil {
+		t.Fatalf("migrate() error = %v", err)
+	}
+	// 明確設定的零值不可被預設值覆蓋
+	if s.UseAutoDetect {
+		t.Error("explicit useAutoDetect=false should be kept")
+	}
+	if s.LowBalanceThreshold != 0 {
+		t.Errorf("explicit lowBalanceThreshold=0 should be kept, got %v", s.LowBalanceThreshold)
+	}
+}
+
+func TestMigrate_RejectsMistypedField(t *testing.T) {
+	if _, err := migrate(map[string]interface{}{"windowWidth": "wide"}); err == nil {
+		t.Error("expected error for mistyped windowWidth")
+	}
+}
+
+func TestValidateRefreshEndpoint(t *testing.T) {
+	testCases := []struct {
+		endpoint string
+		valid    bool
+	}{
+		{"", true},
+		{"https://prod.us-east-1.auth.desktop.kiro.dev/refreshToken", true},
+		{"https://oidc.eu-west-1.amazonaws.com/token", true},
+		{"http://prod.us-east-1.auth.desktop.kiro.dev/refreshToken", false},
+		{"https:///token", false},
+		{"oidc.us-east-1.amazonaws.com/token", false},
+		{"://bad", false},
+	}
+
+	for _, tc := range testCases {
+		err := ValidateRefreshEndpoint(tc.endpoint)
+		if tc.valid && err != nil {
+			t.Errorf("ValidateRefreshEndpoint(%q) error = %v, want nil", tc.endpoint, err)
+		}
+		if !tc.valid && !errors.Is(err, ErrInvalidRefreshEndpoint) {
+			t.Errorf("ValidateRefreshEndpoint(%q) error = %v, want ErrInvalidRefreshEndpoint", tc.endpoint, err)
+		}
+	}
+}
+
+func TestValidateSettings_DropsInvalidRefreshEndpoints(t *testing.T) {
+	s := validateSettings(Settings{
+		SocialRefreshURLOverride: "http://insecure.example.com/refreshToken",
+		IdCRefreshURLOverride:    "https://oidc.example.com/token",
+	})
+	if s.SocialRefreshURLOverride != "" {
+		t.Errorf("insecure Social endpoint should be dropped, got %q", s.SocialRefreshURLOverride)
+	}
+	if s.IdCRefreshURLOverride != "https://oidc.example.com/token" {
+		t.Errorf("valid IdC endpoint should be kept, got %q", s.IdCRefreshURLOverride)
+	}
+}
@@ -1,25 +1,35 @@
 package softreset
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"errors"
 	"io"
 	"os"
 	"path/filepath"
 	"runtime"
 	"strings"
+	"unicode/utf8"
 
+	"kiro-manager/internal/fsutil"
 	"kiro-manager/kiropath"
+	"kiro-manager/kiroprocess"
 )
 
 const (
 	// PatchMarker 用於識別是否已 patch 的標記
-	PatchMarker    = "/* KIRO_MANAGER_PATCH_V4 */"
+	PatchMarker    = "/* KIRO_MANAGER_PATCH_V5 */"
 	PatchEndMarker = "/* END_KIRO_MANAGER_PATCH */"
 	BackupSuffix   = ".kiro-manager-backup"
+	// ChecksumSuffix 記錄原始 extension.js SHA256 的檔案後綴
+	ChecksumSuffix = BackupSuffix + ".sha256"
 	// OldPatchMarker 用於識別舊版 patch，需要重新 patch
 	OldPatchMarker   = "/* KIRO_MANAGER_PATCH_V1 */"
 	OldPatchMarkerV2 = "/* KIRO_MANAGER_PATCH_V2 */"
 	OldPatchMarkerV3 = "/* KIRO_MANAGER_PATCH_V3 */"
+	// OldPatchMarkerV4 V4 固定讀取 ~/.kiro/custom-machine-id，不支援 KIRO_HOME
+	OldPatchMarkerV4 = "/* KIRO_MANAGER_PATCH_V4 */"
 )
 
 var (
@@ -27,17 +37,33 @@ var (
 	ErrAlreadyPatched    = errors.New("extension.js is already patched")
 	ErrNotPatched        = errors.New("extension.js is not patched")
 	ErrBackupNotFound    = errors.New("backup file not found")
+	ErrChecksumNotFound  = errors.New("original checksum not found")
+	ErrKiroRunning       = errors.New("kiro is running, close it before modifying extension.js")
 )
 
-// patchCode 注入的 JavaScript 程式碼
+// kiroRunningCheck 檢查 Kiro 是否執行中（測試時可替換以強制寫入）
+var kiroRunningCheck = kiroprocess.IsKiroRunning
+
+// ensureKiroNotRunning Kiro 執行中時返回 ErrKiroRunning
+// Windows 上 Kiro 開啟中的 extension.js 被覆寫可能導致檔案損壞
+func ensureKiroNotRunning() error {
+	if kiroRunningCheck() {
+		return ErrKiroRunning
+	}
+	return nil
+}
+
+// customIDPathPlaceholder patchCodeTemplate 中 custom-machine-id 路徑的佔位符
+const customIDPathPlaceholder = "__KIRO_MANAGER_CUSTOM_ID_PATH__"
+
+// patchCodeTemplate 注入的 JavaScript 程式碼，patch 時以 GetCustomMachineIDPath 取代路徑佔位符
 // V4: 動態讀取 - 每次訪問時從檔案讀取，無需重啟即可生效
-const patchCode = `/* KIRO_MANAGER_PATCH_V4 */
+// V5: custom-machine-id 路徑於 patch 時寫入，遵循 KIRO_HOME
+const patchCodeTemplate = `/* KIRO_MANAGER_PATCH_V5 */
 (function() {
   const fs = require('fs');
-  const path = require('path');
-  const os = require('os');
   const childProcess = require('child_process');
-  const customIdPath = path.join(os.homedir(), '.kiro', 'custom-machine-id');
+  const customIdPath = __KIRO_MANAGER_CUSTOM_ID_PATH__;
 
   // V4: 動態讀取函數，每次調用都從檔案讀取
   function getCustomMachineId() {
@@ -181,161 +207,580 @@ const patchCode = `/* KIRO_MANAGER_PATCH_V4 */
 /* END_KIRO_MANAGER_PATCH */
 `
 
+// currentPatchCode 產生注入的 JavaScript 程式碼，路徑與 WriteCustomMachineID 寫入的位置一致
+func currentPatchCode() (string, error) {
+	idPath, err := GetCustomMachineIDPath()
+	if err != nil {
+		return "", err
+	}
+	// JSON 字串同時是合法的 JavaScript 字串字面值，Windows 路徑的反斜線也會正確跳脫
+	quoted, err := json.Marshal(idPath)
+	if err != nil {
+		return "", err
+	}
+	return strings.Replace(patchCodeTemplate, customIDPathPlaceholder, string(quoted), 1), nil
+}
+
 
 // GetExtensionJSPath 取得 extension.js 的路徑
+// 有多個 agent 目錄時返回排序後的第一個（通常為 kiro.kiro-agent）
 func GetExtensionJSPath() (string, error) {
-	installPath, err := kiropath.GetKiroInstallPath()
+	paths, err := FindExtensionJSPaths()
 	if err != nil {
 		return "", err
 	}
+	return paths[0], nil
+}
+
+// FindExtensionJSPaths 找出安裝目錄下所有 kiro.kiro-agent* 的 extension.js
+// 涵蓋帶版本號或不同命名的 agent 目錄；找不到任何檔案時返回 ErrExtensionNotFound
+func FindExtensionJSPaths() ([]string, error) {
+	installPath, err := kiropath.GetKiroInstallPath()
+	if err != nil {
+		return nil, err
+	}
+	return findExtensionJSPathsIn(installPath)
+}
+
+// findExtensionJSPathsIn 在指定安裝目錄下找出所有 agent 的 extension.js
+func findExtensionJSPathsIn(installPath string) ([]string, error) {
+	extensionsDir, err := getExtensionsDir(installPath)
+	if err != nil {
+		return nil, err
+	}
 
-	var extensionPath string
+	pattern := filepath.Join(extensionsDir, "kiro.kiro-agent*", "dist", "extension.js")
+	paths, err := filepath.Glob(pattern)
+	if err != nil {
+		return nil, err
+	}
+	if len(paths) == 0 {
+		return nil, ErrExtensionNotFound
+	}
+
+	return paths, nil
+}
+
+// getExtensionsDir 取得安裝目錄下的 extensions 目錄
+func getExtensionsDir(installPath string) (string, error) {
 	switch runtime.GOOS {
-	case "windows":
-		// Windows: {install}/resources/app/extensions/kiro.kiro-agent/dist/extension.js
-		extensionPath = filepath.Join(installPath, "resources", "app", "extensions", "kiro.kiro-agent", "dist", "extension.js")
+	case "windows", "linux":
+		// Windows/Linux: {install}/resources/app/extensions
+		return filepath.Join(installPath, "resources", "app", "extensions"), nil
 	case "darwin":
-		// macOS: {install}/Contents/Resources/app/extensions/kiro.kiro-agent/dist/extension.js
-		extensionPath = filepath.Join(installPath, "Contents", "Resources", "app", "extensions", "kiro.kiro-agent", "dist", "extension.js")
-	case "linux":
-		// Linux: {install}/resources/app/extensions/kiro.kiro-agent/dist/extension.js
-		extensionPath = filepath.Join(installPath, "resources", "app", "extensions", "kiro.kiro-agent", "dist", "extension.js")
+		// macOS: {install}/Contents/Resources/app/extensions
+		return filepath.Join(installPath, "Contents", "Resources", "app", "extensions"), nil
 	default:
 		return "", errors.New("unsupported platform: " + runtime.GOOS)
 	}
+}
+
+// allPaths 所有 extension.js 都符合 check 時返回 true
+func allPaths(paths []string, check func(string) (bool, error)) (bool, error) {
+	for _, path := range paths {
+		ok, err := check(path)
+		if err != nil || !ok {
+			return false, err
+		}
+	}
+	return true, nil
+}
 
-	if _, err := os.Stat(extensionPath); os.IsNotExist(err) {
-		return "", ErrExtensionNotFound
+// anyPath 任一 extension.js 符合 check 時返回 true
+func anyPath(paths []string, check func(string) (bool, error)) (bool, error) {
+	for _, path := range paths {
+		ok, err := check(path)
+		if err != nil {
+			return false, err
+		}
+		if ok {
+			return true, nil
+		}
 	}
+	return false, nil
+}
 
-	return extensionPath, nil
+// eachPath 對每個 extension.js 執行 op，遇到錯誤立即返回
+func eachPath(paths []string, op func(string) error) error {
+	for _, path := range paths {
+		if err := op(path); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
-// IsPatched 檢查 extension.js 是否已被 patch（當前版本）
+// IsPatched 檢查所有 extension.js 是否已被 patch（當前版本）
 func IsPatched() (bool, error) {
-	extPath, err := GetExtensionJSPath()
+	paths, err := FindExtensionJSPaths()
 	if err != nil {
 		return false, err
 	}
+	return allPaths(paths, isPatchedAt)
+}
 
-	// 只讀取檔案開頭部分來檢查
-	file, err := os.Open(extPath)
+// isPatchedAt 檢查指定的 extension.js 是否已被 patch（當前版本）
+func isPatchedAt(extPath string) (bool, error) {
+	head, err := readHead(extPath)
 	if err != nil {
 		return false, err
 	}
+	return strings.Contains(head, PatchMarker), nil
+}
+
+// readHead 只讀取檔案開頭 1KB 來檢查標記
+func readHead(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
 	defer file.Close()
 
-	// 讀取前 1KB 來檢查標記
 	buf := make([]byte, 1024)
 	n, err := file.Read(buf)
 	if err != nil && err != io.EOF {
+		return "", err
+	}
+
+	return string(buf[:n]), nil
+}
+
+// PatchState extension.js 的 patch 狀態
+type PatchState string
+
+const (
+	PatchStateNotPatched      PatchState = "not_patched"             // 從未 patch
+	PatchStateCurrent         PatchState = "current_patch"           // 已套用當前版本 patch
+	PatchStateOldVersion      PatchState = "old_patch_version"       // 套用的是舊版 patch，需要重新 patch
+	PatchStateLostAfterUpdate PatchState = "patch_lost_after_update" // 曾經 patch，但 Kiro 更新後標記消失
+)
+
+// GetPatchState 取得 extension.js 的 patch 狀態
+// 當備份存在但檔案沒有任何 patch 標記時，推斷為 Kiro 更新後 patch 遺失
+// 有多個 extension.js 時返回最需要處理的狀態
+func GetPatchState() (PatchState, error) {
+	paths, err := FindExtensionJSPaths()
+	if err != nil {
+		return PatchStateNotPatched, err
+	}
+	return getPatchStateIn(paths)
+}
+
+// getPatchStateIn 合併多個 extension.js 的 patch 狀態
+func getPatchStateIn(paths []string) (PatchState, error) {
+	result := PatchStateCurrent
+	for _, path := range paths {
+		state, err := getPatchStateAt(path)
+		if err != nil {
+			return PatchStateNotPatched, err
+		}
+		if patchStatePriority[state] > patchStatePriority[result] {
+			result = state
+		}
+	}
+	return result, nil
+}
+
+// patchStatePriority 多個 extension.js 合併狀態時的優先順序（越大越需要處理）
+var patchStatePriority = map[PatchState]int{
+	PatchStateCurrent:         0,
+	PatchStateNotPatched:      1,
+	PatchStateOldVersion:      2,
+	PatchStateLostAfterUpdate: 3,
+}
+
+// getPatchStateAt 取得指定 extension.js 的 patch 狀態
+func getPatchStateAt(extPath string) (PatchState, error) {
+	patched, err := isPatchedAt(extPath)
+	if err != nil {
+		return PatchStateNotPatched, err
+	}
+	if patched {
+		return PatchStateCurrent, nil
+	}
+
+	oldPatched, err := isOldPatchedAt(extPath)
+	if err != nil {
+		return PatchStateNotPatched, err
+	}
+	if oldPatched {
+		return PatchStateOldVersion, nil
+	}
+
+	if _, err := os.Stat(extPath + BackupSuffix); err == nil {
+		return PatchStateLostAfterUpdate, nil
+	}
+
+	return PatchStateNotPatched, nil
+}
+
+// VerifyPatchIntegrity 檢查所有 extension.js 的 patch 是否完整
+// 需同時具備開始與結束標記、注入區塊與預期的 patchCode 完全相同，且檔案為有效 UTF-8
+// 未 patch 時返回 false
+func VerifyPatchIntegrity() (bool, error) {
+	paths, err := FindExtensionJSPaths()
+	if err != nil {
 		return false, err
 	}
+	return allPaths(paths, verifyPatchIntegrityAt)
+}
 
-	return strings.Contains(string(buf[:n]), PatchMarker), nil
+// verifyPatchIntegrityAt 檢查指定 extension.js 的 patch 是否完整
+func verifyPatchIntegrityAt(extPath string) (bool, error) {
+	content, err := os.ReadFile(extPath)
+	if err != nil {
+		return false, err
+	}
+	return isPatchIntact(content), nil
 }
 
-// IsOldPatched 檢查 extension.js 是否被舊版 patch（V1, V2 或 V3）
-func IsOldPatched() (bool, error) {
-	extPath, err := GetExtensionJSPath()
+// isPatchIntact 檢查內容開頭是否為完整且未被修改的 patch
+func isPatchIntact(content []byte) bool {
+	if !utf8.Valid(content) {
+		return false
+	}
+
+	contentStr := string(content)
+	if !strings.Contains(contentStr, PatchMarker) || !strings.Contains(contentStr, PatchEndMarker) {
+		return false
+	}
+
+	// custom-machine-id 路徑變更（例如設定了 KIRO_HOME）時同樣視為需要重新 patch
+	code, err := currentPatchCode()
+	if err != nil {
+		return false
+	}
+	return strings.HasPrefix(contentStr, code)
+}
+
+// PatchRequiresAdmin 檢查 patch extension.js 是否需要管理員權限（唯讀檢查，不修改任何檔案）
+// patch 完整的檔案不需要寫入；其餘檔案目前使用者無法寫入時返回 true
+func PatchRequiresAdmin() (bool, error) {
+	paths, err := FindExtensionJSPaths()
 	if err != nil {
 		return false, err
 	}
+	return anyPath(paths, patchRequiresAdminAt)
+}
 
-	file, err := os.Open(extPath)
+// patchRequiresAdminAt 檢查指定 extension.js 是否需要寫入但沒有寫入權限
+func patchRequiresAdminAt(extPath string) (bool, error) {
+	content, err := os.ReadFile(extPath)
 	if err != nil {
 		return false, err
 	}
-	defer file.Close()
+	if isPatchIntact(content) {
+		return false, nil
+	}
 
-	buf := make([]byte, 1024)
-	n, err := file.Read(buf)
-	if err != nil && err != io.EOF {
+	// 以唯寫模式開啟但不截斷，僅確認權限
+	file, err := os.OpenFile(extPath, os.O_WRONLY, 0)
+	if errors.Is(err, os.ErrPermission) {
+		return true, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	file.Close()
+	return false, nil
+}
+
+// IsOldPatched 檢查是否有 extension.js 被舊版 patch（V1 至 V4）
+func IsOldPatched() (bool, error) {
+	paths, err := FindExtensionJSPaths()
+	if err != nil {
+		return false, err
+	}
+	return anyPath(paths, isOldPatchedAt)
+}
+
+// isOldPatchedAt 檢查指定的 extension.js 是否被舊版 patch
+func isOldPatchedAt(extPath string) (bool, error) {
+	content, err := readHead(extPath)
+	if err != nil {
 		return false, err
 	}
 
-	content := string(buf[:n])
-	// 有舊版標記（V1, V2 或 V3）但沒有新版標記（V4）
+	// 有舊版標記（V1 至 V4）但沒有新版標記（V5）
 	hasOldPatch := strings.Contains(content, OldPatchMarker) ||
 		strings.Contains(content, OldPatchMarkerV2) ||
-		strings.Contains(content, OldPatchMarkerV3)
+		strings.Contains(content, OldPatchMarkerV3) ||
+		strings.Contains(content, OldPatchMarkerV4)
 	hasCurrentPatch := strings.Contains(content, PatchMarker)
 	return hasOldPatch && !hasCurrentPatch, nil
 }
 
-// BackupExtensionJS 備份原始 extension.js
+// BackupExtensionJS 備份原始 extension.js，並記錄原始內容的 SHA256
+// 備份已存在時不覆蓋，但若 Kiro 已更新（未 patch 的內容與記錄不同）則以新版本重新備份
 func BackupExtensionJS() error {
-	extPath, err := GetExtensionJSPath()
+	paths, err := FindExtensionJSPaths()
 	if err != nil {
 		return err
 	}
+	return eachPath(paths, backupExtensionJSAt)
+}
 
+// backupExtensionJSAt 備份指定的 extension.js
+func backupExtensionJSAt(extPath string) error {
 	backupPath := extPath + BackupSuffix
+	checksumPath := extPath + ChecksumSuffix
 
 	// 如果備份已存在，不覆蓋
 	if _, err := os.Stat(backupPath); err == nil {
-		return nil
+		recorded, err := readChecksum(checksumPath)
+		if err == ErrChecksumNotFound {
+			// 舊版備份沒有 checksum，從備份補上
+			backupContent, err := os.ReadFile(backupPath)
+			if err != nil {
+				return err
+			}
+			return writeChecksum(checksumPath, backupContent)
+		}
+		if err != nil {
+			return err
+		}
+
+		content, err := os.ReadFile(extPath)
+		if err != nil {
+			return err
+		}
+		if strings.Contains(string(content), PatchMarker) || sha256Hex(content) == recorded {
+			return nil
+		}
+		// Kiro 已更新，以新版本取代過期的備份
+	}
+
+	if err := copyFile(extPath, backupPath); err != nil {
+		return err
+	}
+
+	content, err := os.ReadFile(backupPath)
+	if err != nil {
+		return err
+	}
+	return writeChecksum(checksumPath, content)
+}
+
+// IsKiroUpdated 檢查 Kiro 是否已自動更新而使 patch 失效
+// 當 patch 標記消失，或移除 patch 後的內容與備份時記錄的 SHA256 不同時返回 true
+// 沒有任何 checksum 記錄時無法判斷，返回 ErrChecksumNotFound
+func IsKiroUpdated() (bool, error) {
+	paths, err := FindExtensionJSPaths()
+	if err != nil {
+		return false, err
+	}
+	return isKiroUpdatedIn(paths)
+}
+
+// isKiroUpdatedIn 檢查多個 extension.js 中是否有任一被 Kiro 更新
+func isKiroUpdatedIn(paths []string) (bool, error) {
+	known := false
+	for _, path := range paths {
+		updated, err := isKiroUpdatedAt(path)
+		if err == ErrChecksumNotFound {
+			continue
+		}
+		if err != nil {
+			return false, err
+		}
+		if updated {
+			return true, nil
+		}
+		known = true
+	}
+
+	if !known {
+		return false, ErrChecksumNotFound
+	}
+	return false, nil
+}
+
+// isKiroUpdatedAt 檢查指定 extension.js 是否已被 Kiro 更新
+func isKiroUpdatedAt(extPath string) (bool, error) {
+	recorded, err := readChecksum(extPath + ChecksumSuffix)
+	if err != nil {
+		return false, err
+	}
+
+	content, err := os.ReadFile(extPath)
+	if err != nil {
+		return false, err
+	}
+
+	return isContentUpdated(content, recorded), nil
+}
+
+// isContentUpdated 比對 extension.js 內容與記錄的原始 SHA256
+func isContentUpdated(content []byte, recorded string) bool {
+	original, hasPatch := stripPatch(content)
+	if !hasPatch {
+		return true
+	}
+	return sha256Hex(original) != recorded
+}
+
+// stripPatch 移除開頭的當前版本 patch，返回原始內容
+// 沒有完整的 patch 區塊時返回 false
+func stripPatch(content []byte) ([]byte, bool) {
+	contentStr := string(content)
+	if !strings.HasPrefix(contentStr, PatchMarker) {
+		return content, false
+	}
+
+	endIdx := strings.Index(contentStr, PatchEndMarker)
+	if endIdx == -1 {
+		return content, false
+	}
+
+	endIdx += len(PatchEndMarker)
+	if endIdx < len(contentStr) && contentStr[endIdx] == '\n' {
+		endIdx++
+	}
+
+	return content[endIdx:], true
+}
+
+// sha256Hex 計算內容的 SHA256 十六進位字串
+func sha256Hex(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+// readChecksum 讀取 checksum 記錄檔
+func readChecksum(checksumPath string) (string, error) {
+	data, err := os.ReadFile(checksumPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", ErrChecksumNotFound
+		}
+		return "", err
+	}
+
+	checksum := strings.TrimSpace(string(data))
+	if checksum == "" {
+		return "", ErrChecksumNotFound
 	}
 
-	return copyFile(extPath, backupPath)
+	return checksum, nil
+}
+
+// writeChecksum 寫入內容的 SHA256 記錄檔
+func writeChecksum(checksumPath string, content []byte) error {
+	return os.WriteFile(checksumPath, []byte(sha256Hex(content)), 0644)
 }
 
 // RestoreExtensionJS 從備份還原 extension.js
+// 所有 extension.js 都沒有備份時返回 ErrBackupNotFound；Kiro 執行中時返回 ErrKiroRunning
 func RestoreExtensionJS() error {
-	extPath, err := GetExtensionJSPath()
+	paths, err := FindExtensionJSPaths()
 	if err != nil {
 		return err
 	}
 
+	restored := false
+	for _, path := range paths {
+		err := restoreExtensionJSAt(path)
+		if err == ErrBackupNotFound {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		restored = true
+	}
+
+	if !restored {
+		return ErrBackupNotFound
+	}
+	return nil
+}
+
+// restoreExtensionJSAt 從備份還原指定的 extension.js
+func restoreExtensionJSAt(extPath string) error {
 	backupPath := extPath + BackupSuffix
 
 	if _, err := os.Stat(backupPath); os.IsNotExist(err) {
 		return ErrBackupNotFound
 	}
 
-	// 還原檔案
-	if err := copyFile(backupPath, extPath); err != nil {
+	if err := ensureKiroNotRunning(); err != nil {
+		return err
+	}
+
+	// 還原檔案（原子覆蓋，避免中途失敗截斷 extension.js）
+	content, err := os.ReadFile(backupPath)
+	if err != nil {
+		return err
+	}
+	if err := writeExtensionJS(extPath, content); err != nil {
 		return err
 	}
 
-	// 還原成功後刪除備份檔案
+	// 還原成功後刪除備份與 checksum 檔案
 	_ = os.Remove(backupPath)
+	_ = os.Remove(extPath + ChecksumSuffix)
 
 	return nil
 }
 
-// PatchExtensionJS 在 extension.js 開頭注入攔截程式碼
+// PatchExtensionJS 在所有 extension.js 開頭注入攔截程式碼
+// 已是最新版 patch 時不修改檔案；需要修改但 Kiro 執行中時返回 ErrKiroRunning
 func PatchExtensionJS() error {
-	extPath, err := GetExtensionJSPath()
+	paths, err := FindExtensionJSPaths()
 	if err != nil {
 		return err
 	}
+	return eachPath(paths, patchExtensionJSAt)
+}
 
+// patchExtensionJSAt 在指定的 extension.js 開頭注入攔截程式碼
+func patchExtensionJSAt(extPath string) error {
 	// 檢查是否已是最新版 patch
-	patched, err := IsPatched()
+	patched, err := isPatchedAt(extPath)
 	if err != nil {
 		return err
 	}
 	if patched {
-		return nil // 已經是最新版 patch，不重複處理
+		content, err := os.ReadFile(extPath)
+		if err != nil {
+			return err
+		}
+		if isPatchIntact(content) {
+			return nil // 已經是最新版 patch，不重複處理
+		}
+	}
+
+	// 需要修改檔案，Kiro 執行中時不處理
+	if err := ensureKiroNotRunning(); err != nil {
+		return err
+	}
+
+	if patched {
+		// patch 已損壞，從備份還原原始檔案後重新 patch
+		if err := restoreExtensionJSAt(extPath); err != nil {
+			return err
+		}
 	}
 
 	// 檢查是否有舊版 patch，需要先移除
-	oldPatched, err := IsOldPatched()
+	oldPatched, err := isOldPatchedAt(extPath)
 	if err != nil {
 		return err
 	}
 	if oldPatched {
 		// 移除舊版 patch
-		if err := UnpatchExtensionJS(); err != nil {
+		if err := unpatchExtensionJSAt(extPath); err != nil {
 			return err
 		}
 	}
 
-	// 備份原始檔案
-	if err := BackupExtensionJS(); err != nil {
+	// 備份原始檔案，確認備份存在後才修改原檔
+	if err := backupExtensionJSAt(extPath); err != nil {
 		return err
 	}
+	if _, err := os.Stat(extPath + BackupSuffix); err != nil {
+		return ErrBackupNotFound
+	}
 
 	// 讀取原始內容
 	content, err := os.ReadFile(extPath)
@@ -344,25 +789,101 @@ func PatchExtensionJS() error {
 	}
 
 	// 在開頭加入 patch 程式碼
-	newContent := patchCode + string(content)
+	code, err := currentPatchCode()
+	if err != nil {
+		return err
+	}
+	newContent := code + string(content)
 
 	// 寫回檔案
-	return os.WriteFile(extPath, []byte(newContent), 0644)
+	return writeExtensionJS(extPath, []byte(newContent))
+}
+
+// PatchExtensionJSAt 對指定 Kiro 安裝目錄下的所有 extension.js 注入攔截程式碼
+// 用於同時安裝多個 Kiro（穩定版、Insiders、可攜版）時個別處理
+func PatchExtensionJSAt(installPath string) error {
+	paths, err := findExtensionJSPathsIn(installPath)
+	if err != nil {
+		return err
+	}
+	return eachPath(paths, patchExtensionJSAt)
 }
 
-// UnpatchExtensionJS 移除注入的程式碼
+// UnpatchExtensionJS 移除所有 extension.js 中注入的程式碼
+// 需要修改但 Kiro 執行中時返回 ErrKiroRunning
 func UnpatchExtensionJS() error {
-	extPath, err := GetExtensionJSPath()
+	paths, err := FindExtensionJSPaths()
 	if err != nil {
 		return err
 	}
+	return eachPath(paths, unpatchExtensionJSAt)
+}
+
+// UnpatchExtensionJSAt 移除指定 Kiro 安裝目錄下所有 extension.js 中注入的程式碼
+func UnpatchExtensionJSAt(installPath string) error {
+	paths, err := findExtensionJSPathsIn(installPath)
+	if err != nil {
+		return err
+	}
+	return eachPath(paths, unpatchExtensionJSAt)
+}
+
+// InstallPatchStatus 單一 Kiro 安裝的 patch 狀態
+type InstallPatchStatus struct {
+	InstallPath    string   `json:"installPath"`
+	ExtensionPaths []string `json:"extensionPaths"`
+	IsPatched      bool     `json:"isPatched"`
+	PatchState     string   `json:"patchState"`
+	PatchCorrupted bool     `json:"patchCorrupted"`
+	KiroUpdated    bool     `json:"kiroUpdated"`
+}
+
+// GetInstallPatchStatus 取得指定 Kiro 安裝目錄的 patch 狀態
+func GetInstallPatchStatus(installPath string) (*InstallPatchStatus, error) {
+	paths, err := findExtensionJSPathsIn(installPath)
+	if err != nil {
+		return nil, err
+	}
+
+	status := &InstallPatchStatus{
+		InstallPath:    installPath,
+		ExtensionPaths: paths,
+	}
+
+	if status.IsPatched, err = allPaths(paths, isPatchedAt); err != nil {
+		return nil, err
+	}
+
+	state, err := getPatchStateIn(paths)
+	if err != nil {
+		return nil, err
+	}
+	status.PatchState = string(state)
+
+	if status.IsPatched {
+		intact, err := allPaths(paths, verifyPatchIntegrityAt)
+		if err != nil {
+			return nil, err
+		}
+		status.PatchCorrupted = !intact
+	}
+
+	// 沒有 checksum 記錄時視為未知
+	if updated, err := isKiroUpdatedIn(paths); err == nil {
+		status.KiroUpdated = updated
+	}
+
+	return status, nil
+}
 
+// unpatchExtensionJSAt 移除指定 extension.js 中注入的程式碼
+func unpatchExtensionJSAt(extPath string) error {
 	// 檢查是否有任何版本的 patch
-	patched, err := IsPatched()
+	patched, err := isPatchedAt(extPath)
 	if err != nil {
 		return err
 	}
-	oldPatched, err := IsOldPatched()
+	oldPatched, err := isOldPatchedAt(extPath)
 	if err != nil {
 		return err
 	}
@@ -370,6 +891,10 @@ func UnpatchExtensionJS() error {
 		return nil // 沒有任何 patch，不需要處理
 	}
 
+	if err := ensureKiroNotRunning(); err != nil {
+		return err
+	}
+
 	// 讀取內容
 	content, err := os.ReadFile(extPath)
 	if err != nil {
@@ -382,7 +907,7 @@ func UnpatchExtensionJS() error {
 	endIdx := strings.Index(contentStr, PatchEndMarker)
 	if endIdx == -1 {
 		// 找不到結束標記，嘗試從備份還原
-		return RestoreExtensionJS()
+		return restoreExtensionJSAt(extPath)
 	}
 
 	// 移除 patch 程式碼（包含結束標記和換行）
@@ -393,7 +918,21 @@ func UnpatchExtensionJS() error {
 
 	newContent := contentStr[endIdx:]
 
-	return os.WriteFile(extPath, []byte(newContent), 0644)
+	return writeExtensionJS(extPath, []byte(newContent))
+}
+
+// extensionContentWriter 將內容寫入暫存檔（測試時可替換以模擬寫入失敗）
+var extensionContentWriter = func(w io.Writer, content []byte) error {
+	_, err := w.Write(content)
+	return err
+}
+
+// writeExtensionJS 以原子方式覆寫 extension.js
+// 先寫入同目錄暫存檔再 rename，寫入中途失敗或程式中止時原檔保持不變
+func writeExtensionJS(extPath string, content []byte) error {
+	return fsutil.WriteAtomic(extPath, 0644, func(w io.Writer) error {
+		return extensionContentWriter(w, content)
+	})
 }
 
 // copyFile 複製檔案
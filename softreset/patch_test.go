@@ -1,44 +1,65 @@
 package softreset
 
 import (
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
+
+	"kiro-manager/kiropath"
 )
 
+func TestMain(m *testing.M) {
+	// 測試只操作暫存目錄，強制寫入不受本機 Kiro 是否執行影響
+	kiroRunningCheck = func() bool { return false }
+	os.Exit(m.Run())
+}
+
+// setKiroRunning 模擬 Kiro 是否執行中
+func setKiroRunning(t *testing.T, running bool) {
+	t.Helper()
+	previous := kiroRunningCheck
+	kiroRunningCheck = func() bool { return running }
+	t.Cleanup(func() { kiroRunningCheck = previous })
+}
+
 // Task 3.1: 測試 V4 Patch 程式碼結構
 func TestPatchCode_ContainsGetCustomMachineIdFunction(t *testing.T) {
-	if !strings.Contains(patchCode, "function getCustomMachineId()") {
+	if !strings.Contains(patchCodeTemplate, "function getCustomMachineId()") {
 		t.Error("patchCode should contain getCustomMachineId function definition")
 	}
 }
 
 func TestPatchCode_ContainsFormatValidationRegex(t *testing.T) {
-	if !strings.Contains(patchCode, "/^[a-f0-9]{64}$/i") {
+	if !strings.Contains(patchCodeTemplate, "/^[a-f0-9]{64}$/i") {
 		t.Error("patchCode should contain format validation regex /^[a-f0-9]{64}$/i")
 	}
 }
 
 func TestPatchCode_ContainsControlCharacterCleanup(t *testing.T) {
 	// 檢查控制字元清理正則
-	if !strings.Contains(patchCode, `[\x00-\x1F\x7F]`) {
+	if !strings.Contains(patchCodeTemplate, `[\x00-\x1F\x7F]`) {
 		t.Error("patchCode should contain control character cleanup regex")
 	}
 }
 
 func TestPatchCode_ContainsDynamicReadLogic(t *testing.T) {
 	// V4 不應該有 let customMachineId = null 的靜態變數
-	if strings.Contains(patchCode, "let customMachineId = null") {
+	if strings.Contains(patchCodeTemplate, "let customMachineId = null") {
 		t.Error("V4 patchCode should not have static customMachineId variable")
 	}
 	// V4 不應該有 if (!customMachineId) return 的提前退出
-	if strings.Contains(patchCode, "if (!customMachineId) return") {
+	if strings.Contains(patchCodeTemplate, "if (!customMachineId) return") {
 		t.Error("V4 patchCode should not have early return based on static variable")
 	}
 }
 
 // Task 3.2: 測試版本標記系統
-func TestPatchMarker_IsV4(t *testing.T) {
-	expected := "/* KIRO_MANAGER_PATCH_V4 */"
+func TestPatchMarker_IsV5(t *testing.T) {
+	expected := "/* KIRO_MANAGER_PATCH_V5 */"
 	if PatchMarker != expected {
 		t.Errorf("PatchMarker should be %q, got %q", expected, PatchMarker)
 	}
@@ -57,28 +78,494 @@ func TestIsOldPatched_DetectsV3(t *testing.T) {
 	t.Skip("Requires file system mocking")
 }
 
-// Task 3.3: 測試 patchCode 包含 fs.promises.readFile 攔截
+// Task 3.3: 測試 patchCodeTemplate 包含 fs.promises.readFile 攔截
 func TestPatchCode_ContainsFsPromisesReadFileInterception(t *testing.T) {
-	if !strings.Contains(patchCode, "fs.promises.readFile") {
+	if !strings.Contains(patchCodeTemplate, "fs.promises.readFile") {
 		t.Error("patchCode should contain fs.promises.readFile interception")
 	}
 }
 
-// 測試 patchCode 包含錯誤處理邏輯
+// 測試 patchCodeTemplate 包含錯誤處理邏輯
 func TestPatchCode_ContainsErrorHandling(t *testing.T) {
 	// 應該有 try-catch
-	if !strings.Contains(patchCode, "try {") || !strings.Contains(patchCode, "catch") {
+	if !strings.Contains(patchCodeTemplate, "try {") || !strings.Contains(patchCodeTemplate, "catch") {
 		t.Error("patchCode should contain try-catch error handling")
 	}
 	// 應該有 ENOENT 檢查
-	if !strings.Contains(patchCode, "ENOENT") {
+	if !strings.Contains(patchCodeTemplate, "ENOENT") {
 		t.Error("patchCode should handle ENOENT error")
 	}
 }
 
-// 測試 patchCode 包含警告日誌
+// 測試 patchCodeTemplate 包含警告日誌
 func TestPatchCode_ContainsWarningLogs(t *testing.T) {
-	if !strings.Contains(patchCode, "[KIRO_PATCH]") {
+	if !strings.Contains(patchCodeTemplate, "[KIRO_PATCH]") {
 		t.Error("patchCode should contain [KIRO_PATCH] warning prefix")
 	}
 }
+
+// testPatchCode 產生當前環境下注入的 patch 程式碼
+func testPatchCode(t *testing.T) string {
+	t.Helper()
+	code, err := currentPatchCode()
+	if err != nil {
+		t.Fatalf("currentPatchCode() error = %v", err)
+	}
+	return code
+}
+
+// 測試以舊版 V4 patch（固定讀取 ~/.kiro）的檔案會被視為舊版並升級為目前的 patch
+func TestPatchExtensionJSAt_UpgradesV4Patch(t *testing.T) {
+	t.Setenv(kiropath.KiroHomeEnv, filepath.Join(t.TempDir(), "kiro-home"))
+	v4Patch, err := os.ReadFile(filepath.Join("testdata", "patch_v4.js"))
+	if err != nil {
+		t.Fatalf("failed to read V4 patch fixture: %v", err)
+	}
+	original := "module.exports = {};\n"
+	extPath := writeTestExtensionJS(t, string(v4Patch)+original)
+	os.WriteFile(extPath+BackupSuffix, []byte(original), 0644)
+
+	if state, err := getPatchStateAt(extPath); err != nil || state != PatchStateOldVersion {
+		t.Fatalf("getPatchStateAt(V4) = %s, %v, want %s", state, err, PatchStateOldVersion)
+	}
+
+	if err := patchExtensionJSAt(extPath); err != nil {
+		t.Fatalf("patchExtensionJSAt() error = %v", err)
+	}
+	content, _ := os.ReadFile(extPath)
+	if string(content) != testPatchCode(t)+original {
+		t.Error("V4 patch should be replaced by the current patch on top of the original content")
+	}
+	if strings.Contains(string(content), OldPatchMarkerV4) {
+		t.Error("upgraded extension.js should not contain the V4 marker")
+	}
+}
+
+// 測試 patch 程式碼引用的路徑與 WriteCustomMachineID 寫入的位置一致，並遵循 KIRO_HOME
+func TestPatchExtensionJSAt_HonorsKiroHome(t *testing.T) {
+	kiroHome := filepath.Join(t.TempDir(), `custom "kiro" home`)
+	t.Setenv(kiropath.KiroHomeEnv, kiroHome)
+	extPath := writeTestExtensionJS(t, "module.exports = {};\n")
+
+	if err := patchExtensionJSAt(extPath); err != nil {
+		t.Fatalf("patchExtensionJSAt() error = %v", err)
+	}
+
+	idPath, err := GetCustomMachineIDPath()
+	if err != nil {
+		t.Fatalf("GetCustomMachineIDPath() error = %v", err)
+	}
+	if idPath != filepath.Join(kiroHome, CustomMachineIDFileName) {
+		t.Errorf("GetCustomMachineIDPath() = %s, want it under KIRO_HOME", idPath)
+	}
+	quoted, _ := json.Marshal(idPath)
+	content, _ := os.ReadFile(extPath)
+	if !strings.Contains(string(content), "const customIdPath = "+string(quoted)+";") {
+		t.Errorf("patched extension.js should reference %s", quoted)
+	}
+	if strings.Contains(string(content), customIDPathPlaceholder) {
+		t.Error("patched extension.js should not contain the path placeholder")
+	}
+
+	// KIRO_HOME 變更後舊的 patch 不再視為完整
+	t.Setenv(kiropath.KiroHomeEnv, t.TempDir())
+	if isPatchIntact(content) {
+		t.Error("patch referencing a previous KIRO_HOME should not be intact")
+	}
+}
+
+// 測試 patch 完整性檢查
+func TestIsPatchIntact_Complete(t *testing.T) {
+	content := []byte(testPatchCode(t) + "module.exports = {};\n")
+	if !isPatchIntact(content) {
+		t.Error("complete patch should be intact")
+	}
+}
+
+func TestIsPatchIntact_Truncated(t *testing.T) {
+	// 只保留前半段 patch，結束標記遺失
+	code := testPatchCode(t)
+	content := []byte(code[:len(code)/2] + "module.exports = {};\n")
+	if isPatchIntact(content) {
+		t.Error("truncated patch should not be intact")
+	}
+}
+
+func TestIsPatchIntact_Modified(t *testing.T) {
+	code := testPatchCode(t)
+	modified := strings.Replace(code, "custom-machine-id", "other-machine-id", 1)
+	if modified == code {
+		t.Fatal("test setup failed: patchCode not modified")
+	}
+	if isPatchIntact([]byte(modified + "module.exports = {};\n")) {
+		t.Error("modified patch should not be intact")
+	}
+}
+
+func TestIsPatchIntact_InvalidUTF8(t *testing.T) {
+	content := append([]byte(testPatchCode(t)), 0xff, 0xfe)
+	if isPatchIntact(content) {
+		t.Error("invalid UTF-8 content should not be intact")
+	}
+}
+
+func TestIsPatchIntact_NotPatched(t *testing.T) {
+	if isPatchIntact([]byte("module.exports = {};\n")) {
+		t.Error("unpatched content should not be intact")
+	}
+}
+
+// 測試 Kiro 更新偵測
+func TestIsContentUpdated_SameOriginal(t *testing.T) {
+	original := []byte("module.exports = {};\n")
+	if isContentUpdated([]byte(testPatchCode(t)+string(original)), sha256Hex(original)) {
+		t.Error("patched content with unchanged original should not be reported as updated")
+	}
+}
+
+func TestIsContentUpdated_OriginalChanged(t *testing.T) {
+	recorded := sha256Hex([]byte("module.exports = {};\n"))
+	updated := []byte(testPatchCode(t) + "module.exports = { version: 2 };\n")
+	if !isContentUpdated(updated, recorded) {
+		t.Error("changed original content should be reported as updated")
+	}
+}
+
+func TestIsContentUpdated_MarkerAbsent(t *testing.T) {
+	original := []byte("module.exports = {};\n")
+	if !isContentUpdated(original, sha256Hex(original)) {
+		t.Error("content without patch marker should be reported as updated")
+	}
+}
+
+func TestReadChecksum_Missing(t *testing.T) {
+	checksumPath := filepath.Join(t.TempDir(), "extension.js"+ChecksumSuffix)
+	if _, err := readChecksum(checksumPath); err != ErrChecksumNotFound {
+		t.Errorf("readChecksum() error = %v, want %v", err, ErrChecksumNotFound)
+	}
+
+	content := []byte("module.exports = {};\n")
+	if err := writeChecksum(checksumPath, content); err != nil {
+		t.Fatalf("writeChecksum() error = %v", err)
+	}
+	got, err := readChecksum(checksumPath)
+	if err != nil {
+		t.Fatalf("readChecksum() error = %v", err)
+	}
+	if got != sha256Hex(content) {
+		t.Errorf("readChecksum() = %s, want %s", got, sha256Hex(content))
+	}
+}
+
+// 測試 patch 寫入的原子性
+func writeTestExtensionJS(t *testing.T, content string) string {
+	t.Helper()
+	extPath := filepath.Join(t.TempDir(), "extension.js")
+	if err := os.WriteFile(extPath, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write extension.js: %v", err)
+	}
+	return extPath
+}
+
+func TestPatchExtensionJSAt_RoundTrip(t *testing.T) {
+	original := "module.exports = {};\n"
+	extPath := writeTestExtensionJS(t, original)
+
+	if err := patchExtensionJSAt(extPath); err != nil {
+		t.Fatalf("patchExtensionJSAt() error = %v", err)
+	}
+	patched, _ := os.ReadFile(extPath)
+	if !isPatchIntact(patched) {
+		t.Error("patched file should contain the full patch")
+	}
+	if _, err := os.Stat(extPath + BackupSuffix); err != nil {
+		t.Errorf("backup should exist after patching: %v", err)
+	}
+
+	if err := unpatchExtensionJSAt(extPath); err != nil {
+		t.Fatalf("unpatchExtensionJSAt() error = %v", err)
+	}
+	restored, _ := os.ReadFile(extPath)
+	if string(restored) != original {
+		t.Errorf("unpatched content = %q, want %q", restored, original)
+	}
+}
+
+func TestPatchRequiresAdminAt(t *testing.T) {
+	extPath := writeTestExtensionJS(t, "module.exports = {};\n")
+	if requiresAdmin, err := patchRequiresAdminAt(extPath); err != nil || requiresAdmin {
+		t.Errorf("writable extension.js: patchRequiresAdminAt() = %v, %v, want false, nil", requiresAdmin, err)
+	}
+
+	if err := patchExtensionJSAt(extPath); err != nil {
+		t.Fatalf("patchExtensionJSAt() error = %v", err)
+	}
+	before, _ := os.ReadFile(extPath)
+	// 已 patch 的檔案即使唯讀也不需要寫入
+	if err := os.Chmod(extPath, 0444); err != nil {
+		t.Fatalf("failed to chmod extension.js: %v", err)
+	}
+	if requiresAdmin, err := patchRequiresAdminAt(extPath); err != nil || requiresAdmin {
+		t.Errorf("patched extension.js: patchRequiresAdminAt() = %v, %v, want false, nil", requiresAdmin, err)
+	}
+	if after, _ := os.ReadFile(extPath); string(after) != string(before) {
+		t.Error("patchRequiresAdminAt should not modify extension.js")
+	}
+}
+
+func TestPatchExtensionJSAt_FailingWriterKeepsOriginal(t *testing.T) {
+	original := "module.exports = {};\n"
+	extPath := writeTestExtensionJS(t, original)
+
+	// 模擬寫入一半時失敗
+	defaultWriter := extensionContentWriter
+	extensionContentWriter = func(w io.Writer, content []byte) error {
+		w.Write(content[:len(content)/2])
+		return errors.New("disk full")
+	}
+	defer func() { extensionContentWriter = defaultWriter }()
+
+	if err := patchExtensionJSAt(extPath); err == nil {
+		t.Fatal("patchExtensionJSAt() should fail when the writer fails")
+	}
+
+	content, err := os.ReadFile(extPath)
+	if err != nil {
+		t.Fatalf("failed to read extension.js: %v", err)
+	}
+	if string(content) != original {
+		t.Errorf("extension.js = %q, want original %q", content, original)
+	}
+
+	entries, _ := os.ReadDir(filepath.Dir(extPath))
+	for _, entry := range entries {
+		if strings.Contains(entry.Name(), ".tmp-") {
+			t.Errorf("temp file %s should be removed", entry.Name())
+		}
+	}
+}
+
+func TestUnpatchExtensionJSAt_FailingWriterKeepsPatched(t *testing.T) {
+	extPath := writeTestExtensionJS(t, testPatchCode(t)+"module.exports = {};\n")
+
+	defaultWriter := extensionContentWriter
+	extensionContentWriter = func(w io.Writer, content []byte) error {
+		return errors.New("disk full")
+	}
+	defer func() { extensionContentWriter = defaultWriter }()
+
+	if err := unpatchExtensionJSAt(extPath); err == nil {
+		t.Fatal("unpatchExtensionJSAt() should fail when the writer fails")
+	}
+
+	content, _ := os.ReadFile(extPath)
+	if !isPatchIntact(content) {
+		t.Error("extension.js should keep the intact patch after a failed unpatch")
+	}
+}
+
+// 測試 patch 狀態判斷
+func TestGetPatchStateAt(t *testing.T) {
+	original := "module.exports = {};\n"
+
+	tests := []struct {
+		name      string
+		content   string
+		hasBackup bool
+		want      PatchState
+	}{
+		{"not_patched", original, false, PatchStateNotPatched},
+		{"current_patch", testPatchCode(t) + original, true, PatchStateCurrent},
+		{"old_patch_version", OldPatchMarkerV3 + "\n" + PatchEndMarker + "\n" + original, true, PatchStateOldVersion},
+		{"patch_lost_after_update", original, true, PatchStateLostAfterUpdate},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			extPath := writeTestExtensionJS(t, tt.content)
+			if tt.hasBackup {
+				if err := os.WriteFile(extPath+BackupSuffix, []byte(original), 0644); err != nil {
+					t.Fatalf("failed to write backup: %v", err)
+				}
+			}
+
+			got, err := getPatchStateAt(extPath)
+			if err != nil {
+				t.Fatalf("getPatchStateAt() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("getPatchStateAt() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+// 測試多個 agent 目錄
+func TestFindExtensionJSPathsIn_PatchesAllAgents(t *testing.T) {
+	installPath := t.TempDir()
+	extensionsDir, err := getExtensionsDir(installPath)
+	if err != nil {
+		t.Skipf("unsupported platform: %v", err)
+	}
+
+	original := "module.exports = {};\n"
+	for _, agent := range []string{"kiro.kiro-agent", "kiro.kiro-agent-0.2.13"} {
+		distDir := filepath.Join(extensionsDir, agent, "dist")
+		if err := os.MkdirAll(distDir, 0755); err != nil {
+			t.Fatalf("failed to create agent dir: %v", err)
+		}
+		if err := os.WriteFile(filepath.Join(distDir, "extension.js"), []byte(original), 0644); err != nil {
+			t.Fatalf("failed to write extension.js: %v", err)
+		}
+	}
+	// 非 agent 的擴充套件不應被 patch
+	otherDist := filepath.Join(extensionsDir, "other.extension", "dist")
+	os.MkdirAll(otherDist, 0755)
+	os.WriteFile(filepath.Join(otherDist, "extension.js"), []byte(original), 0644)
+
+	paths, err := findExtensionJSPathsIn(installPath)
+	if err != nil {
+		t.Fatalf("findExtensionJSPathsIn() error = %v", err)
+	}
+	if len(paths) != 2 {
+		t.Fatalf("findExtensionJSPathsIn() found %d paths, want 2: %v", len(paths), paths)
+	}
+
+	if err := eachPath(paths, patchExtensionJSAt); err != nil {
+		t.Fatalf("patch all error = %v", err)
+	}
+	for _, path := range paths {
+		if intact, _ := verifyPatchIntegrityAt(path); !intact {
+			t.Errorf("%s should be patched", path)
+		}
+	}
+	if patched, _ := allPaths(paths, isPatchedAt); !patched {
+		t.Error("allPaths(isPatchedAt) should be true after patching all agents")
+	}
+
+	other, _ := os.ReadFile(filepath.Join(otherDist, "extension.js"))
+	if string(other) != original {
+		t.Error("non-agent extension should not be patched")
+	}
+}
+
+func TestFindExtensionJSPathsIn_NotFound(t *testing.T) {
+	if _, err := findExtensionJSPathsIn(t.TempDir()); err != ErrExtensionNotFound {
+		t.Errorf("findExtensionJSPathsIn() error = %v, want %v", err, ErrExtensionNotFound)
+	}
+}
+
+// writeTestInstall 建立只含 kiro.kiro-agent extension.js 的假安裝目錄
+func writeTestInstall(t *testing.T, content string) string {
+	t.Helper()
+	installPath := t.TempDir()
+	extensionsDir, err := getExtensionsDir(installPath)
+	if err != nil {
+		t.Skipf("unsupported platform: %v", err)
+	}
+	distDir := filepath.Join(extensionsDir, "kiro.kiro-agent", "dist")
+	if err := os.MkdirAll(distDir, 0755); err != nil {
+		t.Fatalf("failed to create agent dir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(distDir, "extension.js"), []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write extension.js: %v", err)
+	}
+	return installPath
+}
+
+func TestPatchExtensionJSAt_IndependentInstalls(t *testing.T) {
+	original := "module.exports = {};\n"
+	stable := writeTestInstall(t, original)
+	insiders := writeTestInstall(t, original)
+
+	if err := PatchExtensionJSAt(stable); err != nil {
+		t.Fatalf("PatchExtensionJSAt(stable) error = %v", err)
+	}
+
+	stableStatus, err := GetInstallPatchStatus(stable)
+	if err != nil {
+		t.Fatalf("GetInstallPatchStatus(stable) error = %v", err)
+	}
+	if !stableStatus.IsPatched || stableStatus.PatchCorrupted || stableStatus.PatchState != string(PatchStateCurrent) {
+		t.Errorf("stable status = %+v, want patched and intact", stableStatus)
+	}
+
+	insidersStatus, err := GetInstallPatchStatus(insiders)
+	if err != nil {
+		t.Fatalf("GetInstallPatchStatus(insiders) error = %v", err)
+	}
+	if insidersStatus.IsPatched || insidersStatus.PatchState != string(PatchStateNotPatched) {
+		t.Errorf("insiders status = %+v, want not patched", insidersStatus)
+	}
+
+	if err := PatchExtensionJSAt(insiders); err != nil {
+		t.Fatalf("PatchExtensionJSAt(insiders) error = %v", err)
+	}
+	if err := UnpatchExtensionJSAt(stable); err != nil {
+		t.Fatalf("UnpatchExtensionJSAt(stable) error = %v", err)
+	}
+
+	if status, _ := GetInstallPatchStatus(stable); status.IsPatched {
+		t.Error("stable should not be patched after UnpatchExtensionJSAt")
+	}
+	if status, _ := GetInstallPatchStatus(insiders); !status.IsPatched {
+		t.Error("insiders should stay patched when only stable is unpatched")
+	}
+
+	content, _ := os.ReadFile(stableStatus.ExtensionPaths[0])
+	if string(content) != original {
+		t.Errorf("stable extension.js = %q, want original content", content)
+	}
+}
+
+func TestGetInstallPatchStatus_NoExtension(t *testing.T) {
+	if _, err := GetInstallPatchStatus(t.TempDir()); err != ErrExtensionNotFound {
+		t.Errorf("GetInstallPatchStatus() error = %v, want %v", err, ErrExtensionNotFound)
+	}
+}
+
+func TestExtensionJSWrites_KiroRunning(t *testing.T) {
+	original := "module.exports = {};\n"
+	installPath := writeTestInstall(t, original)
+	paths, err := findExtensionJSPathsIn(installPath)
+	if err != nil {
+		t.Fatalf("findExtensionJSPathsIn() error = %v", err)
+	}
+	extPath := paths[0]
+
+	setKiroRunning(t, true)
+
+	if err := PatchExtensionJSAt(installPath); !errors.Is(err, ErrKiroRunning) {
+		t.Fatalf("PatchExtensionJSAt() error = %v, want ErrKiroRunning", err)
+	}
+	content, _ := os.ReadFile(extPath)
+	if string(content) != original {
+		t.Fatalf("extension.js modified while Kiro is running")
+	}
+	if _, err := os.Stat(extPath + BackupSuffix); !os.IsNotExist(err) {
+		t.Errorf("backup should not be created while Kiro is running")
+	}
+
+	// 關閉 Kiro 後 patch，再模擬 Kiro 啟動
+	setKiroRunning(t, false)
+	if err := PatchExtensionJSAt(installPath); err != nil {
+		t.Fatalf("PatchExtensionJSAt() error = %v", err)
+	}
+	setKiroRunning(t, true)
+
+	// 已是最新版 patch 時不需要寫入，不受 Kiro 執行影響
+	if err := PatchExtensionJSAt(installPath); err != nil {
+		t.Errorf("PatchExtensionJSAt() on patched file error = %v, want nil", err)
+	}
+	if err := UnpatchExtensionJSAt(installPath); !errors.Is(err, ErrKiroRunning) {
+		t.Errorf("UnpatchExtensionJSAt() error = %v, want ErrKiroRunning", err)
+	}
+	if err := restoreExtensionJSAt(extPath); !errors.Is(err, ErrKiroRunning) {
+		t.Errorf("restoreExtensionJSAt() error = %v, want ErrKiroRunning", err)
+	}
+
+	patched, err := isPatchedAt(extPath)
+	if err != nil || !patched {
+		t.Errorf("extension.js should stay patched, patched=%v err=%v", patched, err)
+	}
+}
@@ -0,0 +1,142 @@
+package softreset
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"kiro-manager/internal/fsutil"
+)
+
+// fileSnapshot 還原交易開始前的檔案內容
+type fileSnapshot struct {
+	path    string
+	existed bool
+	content []byte
+	perm    os.FileMode
+}
+
+// restoreStep 還原交易中的單一步驟
+type restoreStep struct {
+	name string
+	run  func() error
+}
+
+// RestoreAll 以交易方式還原所有 Machine ID 來源
+// 依序刪除 custom-machine-id 與 custom-machine-id-raw、還原 storage.json 與 extension.js
+// 任一步驟失敗時，所有檔案會回復為執行前的狀態，並返回合併後的錯誤
+func RestoreAll() error {
+	idPath, err := GetCustomMachineIDPath()
+	if err != nil {
+		return err
+	}
+	rawPath, err := GetCustomMachineIDRawPath()
+	if err != nil {
+		return err
+	}
+	storagePath, err := GetStorageJSONPath()
+	if err != nil {
+		return err
+	}
+
+	// 找不到 extension.js 時只還原其他來源
+	extPaths, err := FindExtensionJSPaths()
+	if err != nil && err != ErrExtensionNotFound {
+		return err
+	}
+
+	paths, steps := restoreAllSteps(idPath, rawPath, storagePath, extPaths)
+	return runRestoreTransaction(paths, steps)
+}
+
+// restoreAllSteps 建立還原交易會影響的檔案與執行步驟
+func restoreAllSteps(idPath, rawPath, storagePath string, extPaths []string) ([]string, []restoreStep) {
+	paths := []string{idPath, rawPath, storagePath, storagePath + StorageBackupSuffix}
+	steps := []restoreStep{
+		{name: "remove custom machine ID", run: func() error { return removeIfExists(idPath) }},
+		{name: "remove raw custom machine ID", run: func() error { return removeIfExists(rawPath) }},
+		{name: "restore storage.json", run: func() error {
+			// 沒有備份時略過
+			if err := restoreStorageJSONAt(storagePath); err != nil && err != ErrBackupNotFound {
+				return err
+			}
+			return nil
+		}},
+	}
+
+	for _, extPath := range extPaths {
+		paths = append(paths, extPath, extPath+BackupSuffix, extPath+ChecksumSuffix)
+		steps = append(steps, restoreStep{name: "restore " + extPath, run: func() error {
+			err := restoreExtensionJSAt(extPath)
+			if err == ErrBackupNotFound {
+				// 備份不存在，嘗試移除 patch
+				_ = unpatchExtensionJSAt(extPath) // 忽略錯誤
+				return nil
+			}
+			return err
+		}})
+	}
+
+	return paths, steps
+}
+
+// runRestoreTransaction 快照 paths 後依序執行 steps
+// 任一步驟失敗時回復所有快照，返回步驟錯誤與回復失敗的錯誤
+func runRestoreTransaction(paths []string, steps []restoreStep) error {
+	snapshots := make([]fileSnapshot, 0, len(paths))
+	for _, path := range paths {
+		snapshot, err := takeFileSnapshot(path)
+		if err != nil {
+			return fmt.Errorf("failed to snapshot %s: %w", path, err)
+		}
+		snapshots = append(snapshots, snapshot)
+	}
+
+	for _, step := range steps {
+		if err := step.run(); err != nil {
+			errs := []error{fmt.Errorf("failed to %s: %w", step.name, err)}
+			for _, snapshot := range snapshots {
+				if rollbackErr := snapshot.restore(); rollbackErr != nil {
+					errs = append(errs, fmt.Errorf("failed to roll back %s: %w", snapshot.path, rollbackErr))
+				}
+			}
+			return errors.Join(errs...)
+		}
+	}
+
+	return nil
+}
+
+// takeFileSnapshot 記錄檔案目前的內容（不存在時記錄為不存在）
+func takeFileSnapshot(path string) (fileSnapshot, error) {
+	info, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		return fileSnapshot{path: path}, nil
+	}
+	if err != nil {
+		return fileSnapshot{}, err
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return fileSnapshot{}, err
+	}
+
+	return fileSnapshot{path: path, existed: true, content: content, perm: info.Mode().Perm()}, nil
+}
+
+// restore 將檔案回復為快照內容
+func (s fileSnapshot) restore() error {
+	if !s.existed {
+		return removeIfExists(s.path)
+	}
+	return fsutil.WriteFileAtomic(s.path, s.content, s.perm)
+}
+
+// removeIfExists 刪除檔案，檔案不存在時視為成功
+func removeIfExists(path string) error {
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+package softreset
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// setupRestoreFixture 建立已重置狀態的自訂 Machine ID 與 storage.json
+func setupRestoreFixture(t *testing.T) (idPath, rawPath, storagePath string) {
+	t.Helper()
+	dir := t.TempDir()
+	idPath = filepath.Join(dir, CustomMachineIDFileName)
+	rawPath = filepath.Join(dir, CustomMachineIDRawFileName)
+	os.WriteFile(idPath, []byte("hashed-custom-id"), 0644)
+	os.WriteFile(rawPath, []byte("raw-custom-id"), 0644)
+
+	storagePath = writeTestStorageJSON(t, map[string]interface{}{
+		"telemetry.machineId":   "original-machine-id",
+		"telemetry.devDeviceId": "original-device-id",
+	})
+	if err := patchStorageJSONAt(storagePath, "new-machine-id", "new-device-id"); err != nil {
+		t.Fatalf("patchStorageJSONAt() error = %v", err)
+	}
+	return idPath, rawPath, storagePath
+}
+
+func TestRunRestoreTransaction_Success(t *testing.T) {
+	idPath, rawPath, storagePath := setupRestoreFixture(t)
+
+	paths, steps := restoreAllSteps(idPath, rawPath, storagePath, nil)
+	if err := runRestoreTransaction(paths, steps); err != nil {
+		t.Fatalf("runRestoreTransaction() error = %v", err)
+	}
+
+	for _, path := range []string{idPath, rawPath, storagePath + StorageBackupSuffix} {
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Errorf("%s should be removed after restore", filepath.Base(path))
+		}
+	}
+	if got := readTestStorageJSON(t, storagePath)["telemetry.machineId"]; got != "original-machine-id" {
+		t.Errorf("telemetry.machineId = %v, want original-machine-id", got)
+	}
+}
+
+func TestRunRestoreTransaction_SecondWriteFailsRollsBackFirst(t *testing.T) {
+	idPath, rawPath, storagePath := setupRestoreFixture(t)
+
+	paths, steps := restoreAllSteps(idPath, rawPath, storagePath, nil)
+	injected := errors.New("disk full")
+	steps[1].run = func() error { return injected }
+
+	err := runRestoreTransaction(paths, steps)
+	if !errors.Is(err, injected) {
+		t.Fatalf("runRestoreTransaction() error = %v, want %v", err, injected)
+	}
+
+	// 第一步刪除的 custom-machine-id 應被回復
+	data, readErr := os.ReadFile(idPath)
+	if readErr != nil || string(data) != "hashed-custom-id" {
+		t.Errorf("custom-machine-id = %q (err %v), want rolled back to hashed-custom-id", data, readErr)
+	}
+	if data, _ := os.ReadFile(rawPath); string(data) != "raw-custom-id" {
+		t.Errorf("custom-machine-id-raw = %q, want unchanged", data)
+	}
+	if got := readTestStorageJSON(t, storagePath)["telemetry.machineId"]; got != "new-machine-id" {
+		t.Errorf("telemetry.machineId = %v, want unchanged new-machine-id", got)
+	}
+}
+
+func TestRunRestoreTransaction_LaterFailureRollsBackStorage(t *testing.T) {
+	idPath, rawPath, storagePath := setupRestoreFixture(t)
+
+	paths, steps := restoreAllSteps(idPath, rawPath, storagePath, nil)
+	injected := errors.New("extension restore failed")
+	steps = append(steps, restoreStep{name: "fail", run: func() error { return injected }})
+
+	if err := runRestoreTransaction(paths, steps); !errors.Is(err, injected) {
+		t.Fatalf("runRestoreTransaction() error = %v, want %v", err, injected)
+	}
+
+	if got := readTestStorageJSON(t, storagePath)["telemetry.machineId"]; got != "new-machine-id" {
+		t.Errorf("telemetry.machineId = %v, want rolled back to new-machine-id", got)
+	}
+	if _, err := os.Stat(storagePath + StorageBackupSuffix); err != nil {
+		t.Errorf("storage backup should be restored: %v", err)
+	}
+	for _, path := range []string{idPath, rawPath} {
+		if _, err := os.Stat(path); err != nil {
+			t.Errorf("%s should be restored: %v", filepath.Base(path), err)
+		}
+	}
+}
@@ -21,22 +21,48 @@ const (
 var (
 	ErrCustomIDNotFound = errors.New("custom machine ID not found")
 	ErrKiroHomeNotFound = errors.New("kiro home directory not found")
+	ErrInvalidMachineID = machineid.ErrInvalidMachineID // 與 machineid 共用同一個錯誤值
 )
 
 // SoftResetResult 重置結果
 type SoftResetResult struct {
-	OldMachineID string `json:"oldMachineId"`
-	NewMachineID string `json:"newMachineId"`
-	Patched      bool   `json:"patched"`
-	CacheCleared bool   `json:"cacheCleared"`
+	OldMachineID   string `json:"oldMachineId"`
+	NewMachineID   string `json:"newMachineId"`
+	Patched        bool   `json:"patched"`
+	StoragePatched bool   `json:"storagePatched"`
+	CacheCleared   bool   `json:"cacheCleared"`
 }
 
 // SoftResetStatus 重置狀態
 type SoftResetStatus struct {
 	IsPatched       bool   `json:"isPatched"`
+	PatchCorrupted  bool   `json:"patchCorrupted"`
+	KiroUpdated     bool   `json:"kiroUpdated"`
+	PatchState      string `json:"patchState"`
 	HasCustomID     bool   `json:"hasCustomId"`
 	CustomMachineID string `json:"customMachineId"`
 	ExtensionPath   string `json:"extensionPath"`
+	// Installs 每個偵測到的 Kiro 安裝各自的 patch 狀態
+	Installs []InstallPatchStatus `json:"installs"`
+}
+
+// SoftResetPreview 一鍵新機的預覽（不會寫入任何檔案）
+type SoftResetPreview struct {
+	Extensions     []ExtensionPreview `json:"extensions"`
+	StoragePath    string             `json:"storagePath"`
+	HasStorageJSON bool               `json:"hasStorageJson"`
+	OldMachineID   string             `json:"oldMachineId"`
+	NewMachineID   string             `json:"newMachineId"`
+	NewHashedID    string             `json:"newHashedId"`
+	WillClearSSO   bool               `json:"willClearSso"`
+}
+
+// ExtensionPreview 單一 extension.js 將被處理的方式
+type ExtensionPreview struct {
+	Path         string `json:"path"`
+	HasBackup    bool   `json:"hasBackup"`
+	IsPatched    bool   `json:"isPatched"`
+	IsOldPatched bool   `json:"isOldPatched"`
 }
 
 // GetCustomMachineIDPath 取得自訂 Machine ID 檔案路徑 (~/.kiro/custom-machine-id)
@@ -140,6 +166,37 @@ func GenerateNewMachineID() string {
 	return strings.ToLower(uuid.New().String())
 }
 
+// SetCustomMachineIDRaw 設定指定的自訂 Machine ID
+// 接受 64 位十六進位（直接作為 Kiro 使用的值）或 UUID（經 SHA256 雜湊後使用）
+// 同時寫入原始值與雜湊值，並與一鍵新機相同改寫 storage.json；格式無效時返回 ErrInvalidMachineID
+func SetCustomMachineIDRaw(rawID string) error {
+	rawID, hashedID, err := normalizeCustomMachineID(rawID)
+	if err != nil {
+		return err
+	}
+
+	if err := WriteCustomMachineID(hashedID); err != nil {
+		return err
+	}
+	if err := WriteCustomMachineIDRaw(rawID); err != nil {
+		return err
+	}
+	return PatchStorageJSON(hashedID)
+}
+
+// normalizeCustomMachineID 驗證並轉換輸入，返回原始值與 Kiro 使用的雜湊值
+func normalizeCustomMachineID(input string) (string, string, error) {
+	if err := machineid.ValidateRawMachineID(input); err != nil {
+		return "", "", err
+	}
+
+	id := strings.ToLower(strings.TrimSpace(input))
+	if machineid.IsHashedMachineID(id) {
+		return id, id, nil
+	}
+	return id, machineid.HashMachineID(id), nil
+}
+
 // ClearCustomMachineID 刪除自訂 Machine ID 檔案（還原為系統原始值）
 func ClearCustomMachineID() error {
 	// 刪除 SHA256 雜湊檔案
@@ -183,17 +240,43 @@ func ClearSSOCache() error {
 
 // SoftResetEnvironment 執行一鍵新機
 func SoftResetEnvironment() (*SoftResetResult, error) {
+	// 生成新的 Machine ID（UUID v4）
+	return SoftResetEnvironmentWithID(GenerateNewMachineID())
+}
+
+// 一鍵新機的進度階段（傳給 ProgressFunc）
+const (
+	StageGeneratingID = "generating id" // 產生並寫入新的 Machine ID
+	StagePatching     = "patching"      // patch extension.js
+)
+
+// ProgressFunc 接收一鍵新機的進度階段
+type ProgressFunc func(stage string)
+
+// SoftResetEnvironmentWithID 以指定的 Machine ID 執行一鍵新機
+// 用於套用 PreviewSoftReset 預覽時顯示的 Machine ID
+func SoftResetEnvironmentWithID(newID string) (*SoftResetResult, error) {
+	return SoftResetEnvironmentWithProgress(newID, nil)
+}
+
+// SoftResetEnvironmentWithProgress 與 SoftResetEnvironmentWithID 相同，並在進入各階段時呼叫 progress（可為 nil）
+func SoftResetEnvironmentWithProgress(newID string, progress ProgressFunc) (*SoftResetResult, error) {
+	if progress == nil {
+		progress = func(string) {}
+	}
 	result := &SoftResetResult{}
 
+	progress(StageGeneratingID)
+
 	// 1. 讀取舊的原始 Machine ID（如果有，用於 UI 顯示）
 	oldID, _ := ReadCustomMachineIDRaw()
 	result.OldMachineID = oldID
 
-	// 2. 生成新的 Machine ID（UUID v4）
-	rawID := GenerateNewMachineID()
-
-	// 3. 將 UUID 經過 SHA256 雜湊（Kiro 使用雜湊後的值）
-	hashedID := machineid.HashMachineID(rawID)
+	// 2-3. 驗證新的 Machine ID，UUID 經過 SHA256 雜湊（Kiro 使用雜湊後的值）
+	rawID, hashedID, err := normalizeCustomMachineID(newID)
+	if err != nil {
+		return result, err
+	}
 
 	// 4. 返回原始 UUID（用於 UI 顯示）
 	result.NewMachineID = rawID
@@ -208,20 +291,18 @@ func SoftResetEnvironment() (*SoftResetResult, error) {
 		return result, err
 	}
 
-	// 7. Patch extension.js（如果尚未 patch）
-	patched, err := IsPatched()
-	if err != nil {
+	// 改寫 storage.json 中持久化的 telemetry ID（檔案不存在時略過）
+	if err := PatchStorageJSON(hashedID); err != nil {
 		return result, err
 	}
+	result.StoragePatched = true
 
-	if !patched {
-		if err := PatchExtensionJS(); err != nil {
-			return result, err
-		}
-		result.Patched = true
-	} else {
-		result.Patched = true // 已經 patch 過
+	// 7. Patch extension.js（patch 完整時不修改；舊版或損壞的 patch 會重新套用）
+	progress(StagePatching)
+	if err := PatchExtensionJS(); err != nil {
+		return result, err
 	}
+	result.Patched = true
 
 	// 5. 清除 SSO cache
 	if err := ClearSSOCache(); err != nil {
@@ -232,27 +313,67 @@ func SoftResetEnvironment() (*SoftResetResult, error) {
 	return result, nil
 }
 
-// RestoreOriginalMachineID 還原為系統原始 Machine ID
-func RestoreOriginalMachineID() error {
-	// 1. 刪除自訂 Machine ID 檔案
-	if err := ClearCustomMachineID(); err != nil {
-		return err
+// PreviewSoftReset 預覽一鍵新機將會修改的內容（唯讀）
+// 與 GetSoftResetStatus 不同，返回的是即將執行的動作，供確認對話框使用
+func PreviewSoftReset() (*SoftResetPreview, error) {
+	paths, err := FindExtensionJSPaths()
+	if err != nil {
+		return nil, err
 	}
 
-	// 2. 從備份還原 extension.js
-	if err := RestoreExtensionJS(); err != nil {
-		// 如果備份不存在，嘗試移除 patch
-		if err == ErrBackupNotFound {
-			_ = UnpatchExtensionJS() // 忽略錯誤
-		} else if err != ErrExtensionNotFound {
-			return err
+	extensions, err := previewExtensions(paths)
+	if err != nil {
+		return nil, err
+	}
+	preview := &SoftResetPreview{Extensions: extensions}
+
+	if storagePath, err := GetStorageJSONPath(); err == nil {
+		preview.StoragePath = storagePath
+		if _, err := os.Stat(storagePath); err == nil {
+			preview.HasStorageJSON = true
 		}
 	}
 
+	if cachePath, err := awssso.GetSSOCachePath(); err == nil {
+		if _, err := os.Stat(cachePath); err == nil {
+			preview.WillClearSSO = true
+		}
+	}
+
+	preview.OldMachineID, _ = ReadCustomMachineIDRaw()
+	preview.NewMachineID = GenerateNewMachineID()
+	preview.NewHashedID = machineid.HashMachineID(preview.NewMachineID)
+
+	return preview, nil
+}
+
+// previewExtensions 讀取每個 extension.js 的備份與 patch 狀態
+func previewExtensions(paths []string) ([]ExtensionPreview, error) {
+	extensions := make([]ExtensionPreview, 0, len(paths))
+	for _, path := range paths {
+		ext := ExtensionPreview{Path: path}
+		if _, err := os.Stat(path + BackupSuffix); err == nil {
+			ext.HasBackup = true
+		}
+
+		var err error
+		if ext.IsPatched, err = isPatchedAt(path); err != nil {
+			return nil, err
+		}
+		if ext.IsOldPatched, err = isOldPatchedAt(path); err != nil {
+			return nil, err
+		}
+		extensions = append(extensions, ext)
+	}
+	return extensions, nil
+}
+
+// RestoreOriginalMachineID 還原為系統原始 Machine ID
+// 以 RestoreAll 交易方式執行，任一來源還原失敗時全部回復
+func RestoreOriginalMachineID() error {
 	// 注意：SSO cache 的恢復邏輯由呼叫端（app.go）處理
 	// 因為需要比對備份的 Machine ID，這是 backup 模組的職責
-
-	return nil
+	return RestoreAll()
 }
 
 // GetSoftResetStatus 取得重置狀態
@@ -265,6 +386,23 @@ func GetSoftResetStatus() (*SoftResetStatus, error) {
 		status.IsPatched = patched
 	}
 
+	// 取得詳細的 patch 狀態（含 Kiro 更新後 patch 遺失）
+	if state, err := GetPatchState(); err == nil {
+		status.PatchState = string(state)
+	}
+
+	// 已 patch 時檢查完整性，損壞時提示重新 patch
+	if status.IsPatched {
+		if intact, err := VerifyPatchIntegrity(); err == nil {
+			status.PatchCorrupted = !intact
+		}
+	}
+
+	// 檢查 Kiro 是否已更新使 patch 失效（沒有 checksum 記錄時視為未知）
+	if updated, err := IsKiroUpdated(); err == nil {
+		status.KiroUpdated = updated
+	}
+
 	// 檢查自訂 Machine ID（優先讀取原始 UUID，用於 UI 顯示）
 	rawID, err := ReadCustomMachineIDRaw()
 	if err == nil {
@@ -285,5 +423,12 @@ func GetSoftResetStatus() (*SoftResetStatus, error) {
 		status.ExtensionPath = extPath
 	}
 
+	// 列出每個 Kiro 安裝的 patch 狀態（找不到 extension.js 的安裝略過）
+	for _, installPath := range kiropath.ListKiroInstallPaths() {
+		if install, err := GetInstallPatchStatus(installPath); err == nil {
+			status.Installs = append(status.Installs, *install)
+		}
+	}
+
 	return status, nil
 }
@@ -0,0 +1,113 @@
+package softreset
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"kiro-manager/machineid"
+)
+
+func TestNormalizeCustomMachineID_Hex(t *testing.T) {
+	hexID := strings.Repeat("AB", 32)
+
+	rawID, hashedID, err := normalizeCustomMachineID("  " + hexID + "\n")
+	if err != nil {
+		t.Fatalf("normalizeCustomMachineID() error = %v", err)
+	}
+	want := strings.ToLower(hexID)
+	if rawID != want || hashedID != want {
+		t.Errorf("normalizeCustomMachineID() = %q, %q, want %q for both", rawID, hashedID, want)
+	}
+}
+
+func TestNormalizeCustomMachineID_UUID(t *testing.T) {
+	uuidID := "123E4567-E89B-12D3-A456-426614174000"
+
+	rawID, hashedID, err := normalizeCustomMachineID(uuidID)
+	if err != nil {
+		t.Fatalf("normalizeCustomMachineID() error = %v", err)
+	}
+	if rawID != strings.ToLower(uuidID) {
+		t.Errorf("rawID = %q, want %q", rawID, strings.ToLower(uuidID))
+	}
+	if hashedID != machineid.HashMachineID(rawID) {
+		t.Errorf("hashedID = %q, want SHA256 of raw UUID", hashedID)
+	}
+	if !machineid.IsHashedMachineID(hashedID) {
+		t.Errorf("hashedID %q should match patch format", hashedID)
+	}
+}
+
+func TestNormalizeCustomMachineID_Invalid(t *testing.T) {
+	invalid := []string{
+		"",
+		"not-a-machine-id",
+		strings.Repeat("a", 63),
+		strings.Repeat("g", 64),
+		"{123e4567-e89b-12d3-a456-426614174000}",
+		"urn:uuid:123e4567-e89b-12d3-a456-426614174000",
+	}
+
+	for _, input := range invalid {
+		if _, _, err := normalizeCustomMachineID(input); !errors.Is(err, ErrInvalidMachineID) {
+			t.Errorf("normalizeCustomMachineID(%q) error = %v, want ErrInvalidMachineID", input, err)
+		}
+	}
+}
+
+func TestPreviewExtensions_ReadOnly(t *testing.T) {
+	dir := t.TempDir()
+	patchedPath := filepath.Join(dir, "patched.js")
+	plainPath := filepath.Join(dir, "plain.js")
+	os.WriteFile(patchedPath, []byte(testPatchCode(t)+"module.exports = {};\n"), 0644)
+	os.WriteFile(patchedPath+BackupSuffix, []byte("module.exports = {};\n"), 0644)
+	os.WriteFile(plainPath, []byte("module.exports = {};\n"), 0644)
+
+	before := snapshotDir(t, dir)
+
+	extensions, err := previewExtensions([]string{patchedPath, plainPath})
+	if err != nil {
+		t.Fatalf("previewExtensions() error = %v", err)
+	}
+
+	want := []ExtensionPreview{
+		{Path: patchedPath, HasBackup: true, IsPatched: true},
+		{Path: plainPath},
+	}
+	if len(extensions) != len(want) {
+		t.Fatalf("previewExtensions() returned %d entries, want %d", len(extensions), len(want))
+	}
+	for i := range want {
+		if extensions[i] != want[i] {
+			t.Errorf("previewExtensions()[%d] = %+v, want %+v", i, extensions[i], want[i])
+		}
+	}
+
+	after := snapshotDir(t, dir)
+	if len(before) != len(after) {
+		t.Fatalf("preview should not create or remove files: before %v, after %v", before, after)
+	}
+	for name, content := range before {
+		if after[name] != content {
+			t.Errorf("preview should not modify %s", name)
+		}
+	}
+}
+
+// snapshotDir 記錄目錄中所有檔案內容
+func snapshotDir(t *testing.T, dir string) map[string]string {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("ReadDir failed: %v", err)
+	}
+	files := make(map[string]string)
+	for _, entry := range entries {
+		data, _ := os.ReadFile(filepath.Join(dir, entry.Name()))
+		files[entry.Name()] = string(data)
+	}
+	return files
+}
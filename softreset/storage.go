@@ -0,0 +1,162 @@
+package softreset
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"kiro-manager/internal/fsutil"
+	"kiro-manager/kiropath"
+)
+
+const (
+	// StorageJSONFileName Kiro globalStorage 的設定檔名稱
+	StorageJSONFileName = "storage.json"
+	// StorageBackupSuffix 保存 telemetry 原始值的備份檔後綴
+	StorageBackupSuffix = ".kiro-manager-telemetry-backup"
+
+	// telemetryMachineIDKey storage.json 中的 Machine ID 鍵
+	telemetryMachineIDKey = "telemetry.machineId"
+	// telemetryDevDeviceIDKey storage.json 中的 Device ID 鍵
+	telemetryDevDeviceIDKey = "telemetry.devDeviceId"
+)
+
+// telemetryKeys 需要改寫的 telemetry 鍵
+var telemetryKeys = []string{telemetryMachineIDKey, telemetryDevDeviceIDKey}
+
+// GetStorageJSONPath 取得 Kiro globalStorage/storage.json 的路徑
+func GetStorageJSONPath() (string, error) {
+	configPath, err := kiropath.GetKiroConfigPath()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configPath, "User", "globalStorage", StorageJSONFileName), nil
+}
+
+// PatchStorageJSON 將 storage.json 的 telemetry.machineId 改寫為指定值，並重新產生 telemetry.devDeviceId
+// 首次改寫前會備份原始值；storage.json 不存在時直接返回 nil
+func PatchStorageJSON(machineID string) error {
+	storagePath, err := GetStorageJSONPath()
+	if err != nil {
+		return err
+	}
+	return patchStorageJSONAt(storagePath, machineID, GenerateNewMachineID())
+}
+
+// RestoreStorageJSON 從備份還原 storage.json 的 telemetry 原始值
+// 備份不存在時返回 ErrBackupNotFound
+func RestoreStorageJSON() error {
+	storagePath, err := GetStorageJSONPath()
+	if err != nil {
+		return err
+	}
+	return restoreStorageJSONAt(storagePath)
+}
+
+// patchStorageJSONAt 改寫指定 storage.json 的 telemetry 鍵
+func patchStorageJSONAt(storagePath, machineID, devDeviceID string) error {
+	storage, err := readStorageJSON(storagePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	// 備份原始值（已有備份時不覆蓋，保留最初的值）
+	backupPath := storagePath + StorageBackupSuffix
+	if _, err := os.Stat(backupPath); os.IsNotExist(err) {
+		original := make(map[string]json.RawMessage)
+		for _, key := range telemetryKeys {
+			if value, ok := storage[key]; ok {
+				original[key] = value
+			}
+		}
+		if err := writeJSONFile(backupPath, original); err != nil {
+			return fmt.Errorf("failed to backup storage.json: %w", err)
+		}
+	}
+
+	if err := setStorageString(storage, telemetryMachineIDKey, machineID); err != nil {
+		return err
+	}
+	if err := setStorageString(storage, telemetryDevDeviceIDKey, devDeviceID); err != nil {
+		return err
+	}
+
+	return writeJSONFile(storagePath, storage)
+}
+
+// restoreStorageJSONAt 從備份還原指定 storage.json 的 telemetry 鍵
+// 備份中不存在的鍵代表原始檔案沒有該鍵，還原時會移除
+func restoreStorageJSONAt(storagePath string) error {
+	backupPath := storagePath + StorageBackupSuffix
+	original, err := readStorageJSON(backupPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return ErrBackupNotFound
+		}
+		return err
+	}
+
+	storage, err := readStorageJSON(storagePath)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			return err
+		}
+		// storage.json 已被刪除，無需還原
+		return os.Remove(backupPath)
+	}
+
+	for _, key := range telemetryKeys {
+		if value, ok := original[key]; ok {
+			storage[key] = value
+		} else {
+			delete(storage, key)
+		}
+	}
+
+	if err := writeJSONFile(storagePath, storage); err != nil {
+		return err
+	}
+
+	// 還原成功後刪除備份檔案
+	_ = os.Remove(backupPath)
+
+	return nil
+}
+
+// readStorageJSON 讀取 JSON 物件，保留未知欄位的原始內容
+func readStorageJSON(path string) (map[string]json.RawMessage, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	storage := make(map[string]json.RawMessage)
+	if err := json.Unmarshal(data, &storage); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", filepath.Base(path), err)
+	}
+
+	return storage, nil
+}
+
+// setStorageString 以 JSON 字串設定指定鍵
+func setStorageString(storage map[string]json.RawMessage, key, value string) error {
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	storage[key] = encoded
+	return nil
+}
+
+// writeJSONFile 以縮排格式原子寫入 JSON 檔案
+func writeJSONFile(path string, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "    ")
+	if err != nil {
+		return err
+	}
+	return fsutil.WriteFileAtomic(path, data, 0644)
+}
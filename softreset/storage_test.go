@@ -0,0 +1,112 @@
+package softreset
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeTestStorageJSON 建立測試用 storage.json
+func writeTestStorageJSON(t *testing.T, content map[string]interface{}) string {
+	t.Helper()
+	storagePath := filepath.Join(t.TempDir(), StorageJSONFileName)
+	data, err := json.Marshal(content)
+	if err != nil {
+		t.Fatalf("failed to marshal storage.json: %v", err)
+	}
+	if err := os.WriteFile(storagePath, data, 0644); err != nil {
+		t.Fatalf("failed to write storage.json: %v", err)
+	}
+	return storagePath
+}
+
+// readTestStorageJSON 讀取測試用 storage.json
+func readTestStorageJSON(t *testing.T, storagePath string) map[string]interface{} {
+	t.Helper()
+	data, err := os.ReadFile(storagePath)
+	if err != nil {
+		t.Fatalf("failed to read storage.json: %v", err)
+	}
+	var content map[string]interface{}
+	if err := json.Unmarshal(data, &content); err != nil {
+		t.Fatalf("failed to parse storage.json: %v", err)
+	}
+	return content
+}
+
+func TestPatchStorageJSON_ReplacesAndRestores(t *testing.T) {
+	storagePath := writeTestStorageJSON(t, map[string]interface{}{
+		"telemetry.machineId":   "original-machine-id",
+		"telemetry.devDeviceId": "original-device-id",
+		"theme":                 "dark",
+	})
+
+	if err := patchStorageJSONAt(storagePath, "new-machine-id", "new-device-id"); err != nil {
+		t.Fatalf("patchStorageJSONAt() error = %v", err)
+	}
+
+	patched := readTestStorageJSON(t, storagePath)
+	if patched["telemetry.machineId"] != "new-machine-id" {
+		t.Errorf("telemetry.machineId = %v, want new-machine-id", patched["telemetry.machineId"])
+	}
+	if patched["telemetry.devDeviceId"] != "new-device-id" {
+		t.Errorf("telemetry.devDeviceId = %v, want new-device-id", patched["telemetry.devDeviceId"])
+	}
+	if patched["theme"] != "dark" {
+		t.Errorf("unrelated key should be preserved, got %v", patched["theme"])
+	}
+
+	// 再次 patch 不應覆蓋原始值的備份
+	if err := patchStorageJSONAt(storagePath, "newer-machine-id", "newer-device-id"); err != nil {
+		t.Fatalf("patchStorageJSONAt() error = %v", err)
+	}
+
+	if err := restoreStorageJSONAt(storagePath); err != nil {
+		t.Fatalf("restoreStorageJSONAt() error = %v", err)
+	}
+
+	restored := readTestStorageJSON(t, storagePath)
+	if restored["telemetry.machineId"] != "original-machine-id" {
+		t.Errorf("telemetry.machineId = %v, want original-machine-id", restored["telemetry.machineId"])
+	}
+	if restored["telemetry.devDeviceId"] != "original-device-id" {
+		t.Errorf("telemetry.devDeviceId = %v, want original-device-id", restored["telemetry.devDeviceId"])
+	}
+	if _, err := os.Stat(storagePath + StorageBackupSuffix); !os.IsNotExist(err) {
+		t.Error("backup file should be removed after restore")
+	}
+}
+
+func TestPatchStorageJSON_RestoreRemovesAddedKeys(t *testing.T) {
+	storagePath := writeTestStorageJSON(t, map[string]interface{}{"theme": "dark"})
+
+	if err := patchStorageJSONAt(storagePath, "new-machine-id", "new-device-id"); err != nil {
+		t.Fatalf("patchStorageJSONAt() error = %v", err)
+	}
+	if err := restoreStorageJSONAt(storagePath); err != nil {
+		t.Fatalf("restoreStorageJSONAt() error = %v", err)
+	}
+
+	restored := readTestStorageJSON(t, storagePath)
+	if _, ok := restored["telemetry.machineId"]; ok {
+		t.Error("telemetry.machineId should be removed when originally absent")
+	}
+	if _, ok := restored["telemetry.devDeviceId"]; ok {
+		t.Error("telemetry.devDeviceId should be removed when originally absent")
+	}
+}
+
+func TestPatchStorageJSON_MissingFile(t *testing.T) {
+	storagePath := filepath.Join(t.TempDir(), StorageJSONFileName)
+
+	if err := patchStorageJSONAt(storagePath, "new-machine-id", "new-device-id"); err != nil {
+		t.Errorf("patchStorageJSONAt() error = %v, want nil for missing file", err)
+	}
+	if _, err := os.Stat(storagePath); !os.IsNotExist(err) {
+		t.Error("storage.json should not be created")
+	}
+	if err := restoreStorageJSONAt(storagePath); err != ErrBackupNotFound {
+		t.Errorf("restoreStorageJSONAt() error = %v, want %v", err, ErrBackupNotFound)
+	}
+}
@@ -0,0 +1,148 @@
+/* KIRO_MANAGER_PATCH_V4 */
+(function() {
+  const fs = require('fs');
+  const path = require('path');
+  const os = require('os');
+  const childProcess = require('child_process');
+  const customIdPath = path.join(os.homedir(), '.kiro', 'custom-machine-id');
+
+  // V4: 動態讀取函數，每次調用都從檔案讀取
+  function getCustomMachineId() {
+    try {
+      let content = fs.readFileSync(customIdPath, 'utf8');
+      // 移除控制字元
+      content = content.replace(/[\x00-\x1F\x7F]/g, '');
+      // trim 空白
+      content = content.trim();
+      // 空內容檢查
+      if (!content) return null;
+      // 格式驗證：64 字元 hex
+      if (!/^[a-f0-9]{64}$/i.test(content)) {
+        console.warn('[KIRO_PATCH] Invalid machine ID format, ignoring');
+        return null;
+      }
+      return content;
+    } catch (err) {
+      if (err.code !== 'ENOENT') {
+        console.warn('[KIRO_PATCH] Failed to read custom-machine-id:', err.code || err.message);
+      }
+      return null;
+    }
+  }
+
+  // 1. 攔截 Module._load（vscode.env.machineId 和 node-machine-id）
+  const Module = require('module');
+  const originalLoad = Module._load;
+  Module._load = function(request, parent, isMain) {
+    const mod = originalLoad.call(this, request, parent, isMain);
+    if (request === 'vscode') {
+      const originalEnv = mod.env;
+      return new Proxy(mod, {
+        get(target, prop) {
+          if (prop === 'env') {
+            return new Proxy(originalEnv, {
+              get(envTarget, envProp) {
+                if (envProp === 'machineId') {
+                  const customId = getCustomMachineId();
+                  return customId !== null ? customId : envTarget[envProp];
+                }
+                return envTarget[envProp];
+              }
+            });
+          }
+          return target[prop];
+        }
+      });
+    }
+    if (mod && typeof mod === 'object' && (typeof mod.machineIdSync === 'function' || typeof mod.machineId === 'function')) {
+      const originalMachineIdSync = mod.machineIdSync;
+      const originalMachineId = mod.machineId;
+      return new Proxy(mod, {
+        get(target, prop) {
+          if (prop === 'machineIdSync') {
+            return function() {
+              const customId = getCustomMachineId();
+              return customId !== null ? customId : originalMachineIdSync.call(target);
+            };
+          }
+          if (prop === 'machineId') {
+            return async function() {
+              const customId = getCustomMachineId();
+              return customId !== null ? customId : originalMachineId.call(target);
+            };
+          }
+          return target[prop];
+        }
+      });
+    }
+    return mod;
+  };
+
+  // 2. 攔截 child_process（針對 @opentelemetry 和其他直接執行命令的模組）
+  const machineIdPatterns = [
+    'REG.exe QUERY', 'REG QUERY', 'MachineGuid',
+    'ioreg', 'IOPlatformExpertDevice',
+    'kenv', 'smbios.system.uuid', 'kern.hostuuid'
+  ];
+  const isMachineIdCmd = (cmd) => cmd && machineIdPatterns.some(p => cmd.includes(p));
+
+  const originalExec = childProcess.exec;
+  childProcess.exec = function(cmd, options, callback) {
+    if (isMachineIdCmd(cmd)) {
+      const customId = getCustomMachineId();
+      if (customId !== null) {
+        if (typeof options === 'function') { callback = options; options = {}; }
+        setImmediate(() => callback && callback(null, customId, ''));
+        return { on: () => {}, stdout: { on: () => {} }, stderr: { on: () => {} } };
+      }
+    }
+    return originalExec.apply(this, arguments);
+  };
+
+  const originalExecSync = childProcess.execSync;
+  childProcess.execSync = function(cmd, options) {
+    if (isMachineIdCmd(cmd)) {
+      const customId = getCustomMachineId();
+      if (customId !== null) return Buffer.from(customId);
+    }
+    return originalExecSync.apply(this, arguments);
+  };
+
+  // 3. 攔截 fs（針對 Linux /etc/machine-id）
+  const machineIdPaths = ['/etc/machine-id', '/var/lib/dbus/machine-id', '/etc/hostid'];
+  const isMachineIdPath = (p) => p && machineIdPaths.some(mp => String(p).includes(mp));
+
+  const originalReadFile = fs.readFile;
+  fs.readFile = function(filePath, options, callback) {
+    if (isMachineIdPath(filePath)) {
+      const customId = getCustomMachineId();
+      if (customId !== null) {
+        if (typeof options === 'function') { callback = options; }
+        setImmediate(() => callback && callback(null, customId));
+        return;
+      }
+    }
+    return originalReadFile.apply(this, arguments);
+  };
+
+  const originalReadFileSync = fs.readFileSync;
+  fs.readFileSync = function(filePath, options) {
+    if (isMachineIdPath(filePath)) {
+      const customId = getCustomMachineId();
+      if (customId !== null) return customId;
+    }
+    return originalReadFileSync.apply(this, arguments);
+  };
+
+  if (fs.promises) {
+    const originalPromisesReadFile = fs.promises.readFile;
+    fs.promises.readFile = async function(filePath, options) {
+      if (isMachineIdPath(filePath)) {
+        const customId = getCustomMachineId();
+        if (customId !== null) return customId;
+      }
+      return originalPromisesReadFile.apply(this, arguments);
+    };
+  }
+})();
+/* END_KIRO_MANAGER_PATCH */
@@ -0,0 +1,136 @@
+package tokenrefresh
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"kiro-manager/awssso"
+)
+
+// RetryPolicy Token 刷新重試策略
+// 僅在 HTTP 429 與 5xx 時重試，等待時間以指數退避計算
+type RetryPolicy struct {
+	MaxAttempts int           // 最多嘗試次數（含第一次，<= 0 視為 1）
+	BaseDelay   time.Duration // 第一次重試前的等待時間，之後每次加倍
+	MaxDelay    time.Duration // 單次等待時間上限（0 表示不限制）
+}
+
+// DefaultRetryPolicy 預設重試策略
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 3,
+	BaseDelay:   1 * time.Second,
+	MaxDelay:    30 * time.Second,
+}
+
+// sleepContext 等待指定時間，ctx 取消時提前返回（可在測試中替換）
+var sleepContext = func(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// RefreshWithRetry 刷新 AccessToken，遇到 HTTP 429 / 5xx 時依策略重試
+// 伺服器回應 Retry-After 標頭時優先採用其指定的等待時間
+// 401/403、解析錯誤等其他錯誤不會重試
+func RefreshWithRetry(token *awssso.KiroAuthToken, machineId string, policy RetryPolicy) (*TokenInfo, error) {
+	return RefreshWithRetryContext(context.Background(), token, machineId, policy)
+}
+
+// RefreshWithRetryContext 與 RefreshWithRetry 相同，但 ctx 取消時會中止請求與等待
+func RefreshWithRetryContext(ctx context.Context, token *awssso.KiroAuthToken, machineId string, policy RetryPolicy) (*TokenInfo, error) {
+	return retryRefresh(ctx, policy, func(ctx context.Context) (*TokenInfo, error) {
+		return RefreshAccessTokenWithCredentialsContext(ctx, token, machineId, "", "")
+	})
+}
+
+// retryRefresh 依重試策略執行 refresh，直到成功、遇到不可重試的錯誤或用盡次數
+func retryRefresh(ctx context.Context, policy RetryPolicy, refresh func(ctx context.Context) (*TokenInfo, error)) (*TokenInfo, error) {
+	maxAttempts := policy.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = 1
+	}
+
+	var lastErr error
+	for attempt := 1; attempt <= maxAttempts; attempt++ {
+		info, err := refresh(ctx)
+		if err == nil {
+			return info, nil
+		}
+		lastErr = err
+
+		var refreshErr *RefreshError
+		if !errors.As(err, &refreshErr) || !isRetryableStatus(refreshErr.Code) {
+			return nil, err
+		}
+		if attempt == maxAttempts {
+			break
+		}
+
+		delay := policy.backoff(attempt)
+		if refreshErr.RetryAfter > 0 {
+			// 伺服器要求的等待時間超過上限時，重試也只會再次被拒絕，直接返回
+			if policy.MaxDelay > 0 && refreshErr.RetryAfter > policy.MaxDelay {
+				return nil, err
+			}
+			delay = refreshErr.RetryAfter
+		}
+
+		if err := sleepContext(ctx, delay); err != nil {
+			return nil, newRequestError(ctx, err)
+		}
+	}
+
+	return nil, lastErr
+}
+
+// backoff 計算第 attempt 次失敗後的等待時間（BaseDelay * 2^(attempt-1)，不超過 MaxDelay）
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	delay := p.BaseDelay
+	for i := 1; i < attempt; i++ {
+		delay *= 2
+		if p.MaxDelay > 0 && delay >= p.MaxDelay {
+			break
+		}
+	}
+	if p.MaxDelay > 0 && delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+	return delay
+}
+
+// isRetryableStatus 判斷 HTTP 狀態碼是否可重試（429 與 5xx）
+func isRetryableStatus(statusCode int) bool {
+	return statusCode == http.StatusTooManyRequests || (statusCode >= 500 && statusCode < 600)
+}
+
+// parseRetryAfter 解析 Retry-After 標頭（秒數或 HTTP-date）
+// 無法解析或已過期時返回 0
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds <= 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+
+	if at, err := http.ParseTime(value); err == nil {
+		if d := at.Sub(now); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
@@ -0,0 +1,195 @@
+package tokenrefresh
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// statusSequenceServer 依序回應指定的狀態碼，用盡後一律回應 200
+func statusSequenceServer(t *testing.T, statuses []int, header http.Header) (*httptest.Server, *int32) {
+	t.Helper()
+	var hits int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.ReadAll(r.Body)
+		n := int(atomic.AddInt32(&hits, 1))
+		if n <= len(statuses) {
+			for k, v := range header {
+				w.Header()[k] = v
+			}
+			w.WriteHeader(statuses[n-1])
+			w.Write([]byte(`{"message":"error"}`))
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"accessToken":"retried-token","expiresIn":3600}`))
+	}))
+	t.Cleanup(server.Close)
+	return server, &hits
+}
+
+// socialRefreshTo 返回對指定測試伺服器執行 Social 刷新的函數
+func socialRefreshTo(server *httptest.Server) func(ctx context.Context) (*TokenInfo, error) {
+	return func(ctx context.Context) (*TokenInfo, error) {
+		return refreshSocialToken(ctx, server.Client(), server.URL, "refresh-token", "machine-id")
+	}
+}
+
+// recordSleeps 替換 sleepContext，記錄每次等待時間而不實際等待
+func recordSleeps(t *testing.T) *[]time.Duration {
+	t.Helper()
+	var delays []time.Duration
+	original := sleepContext
+	sleepContext = func(ctx context.Context, d time.Duration) error {
+		delays = append(delays, d)
+		return nil
+	}
+	t.Cleanup(func() { sleepContext = original })
+	return &delays
+}
+
+// TestRetryRefresh_429ThenSuccess 測試 429 兩次後成功
+func TestRetryRefresh_429ThenSuccess(t *testing.T) {
+	server, hits := statusSequenceServer(t, []int{429, 429}, nil)
+	policy := RetryPolicy{MaxAttempts: 3, BaseDelay: 10 * time.Millisecond, MaxDelay: 100 * time.Millisecond}
+
+	info, err := retryRefresh(context.Background(), policy, socialRefreshTo(server))
+	if err != nil {
+		t.Fatalf("Expected success after retries, got %v", err)
+	}
+	if info.AccessToken != "retried-token" {
+		t.Errorf("AccessToken = %q, want %q", info.AccessToken, "retried-token")
+	}
+	if got := atomic.LoadInt32(hits); got != 3 {
+		t.Errorf("Expected 3 requests, got %d", got)
+	}
+}
+
+// TestRetryRefresh_HonorsRetryAfter 測試 429 帶 Retry-After: 2 時等待 2 秒
+func TestRetryRefresh_HonorsRetryAfter(t *testing.T) {
+	delays := recordSleeps(t)
+	server, hits := statusSequenceServer(t, []int{429}, http.Header{"Retry-After": []string{"2"}})
+	policy := RetryPolicy{MaxAttempts: 3, BaseDelay: 10 * time.Millisecond, MaxDelay: 10 * time.Second}
+
+	if _, err := retryRefresh(context.Background(), policy, socialRefreshTo(server)); err != nil {
+		t.Fatalf("Expected success after retry, got %v", err)
+	}
+	if got := atomic.LoadInt32(hits); got != 2 {
+		t.Errorf("Expected 2 requests, got %d", got)
+	}
+	if len(*delays) != 1 || (*delays)[0] != 2*time.Second {
+		t.Errorf("Expected a single 2s wait, got %v", *delays)
+	}
+}
+
+// TestRetryRefresh_RetryAfterExceedsMaxDelay 測試 Retry-After 超過 MaxDelay 時不重試
+func TestRetryRefresh_RetryAfterExceedsMaxDelay(t *testing.T) {
+	delays := recordSleeps(t)
+	server, hits := statusSequenceServer(t, []int{429}, http.Header{"Retry-After": []string{"120"}})
+	policy := RetryPolicy{MaxAttempts: 3, BaseDelay: 10 * time.Millisecond, MaxDelay: 5 * time.Second}
+
+	_, err := retryRefresh(context.Background(), policy, socialRefreshTo(server))
+	var refreshErr *RefreshError
+	if !errors.As(err, &refreshErr) || refreshErr.Code != 429 {
+		t.Fatalf("Expected 429 RefreshError, got %v", err)
+	}
+	if got := atomic.LoadInt32(hits); got != 1 {
+		t.Errorf("Expected 1 request, got %d", got)
+	}
+	if len(*delays) != 0 {
+		t.Errorf("Expected no wait, got %v", *delays)
+	}
+}
+
+// TestRetryRefresh_ExponentialBackoff 測試 5xx 重試的等待時間以指數遞增並受 MaxDelay 限制
+func TestRetryRefresh_ExponentialBackoff(t *testing.T) {
+	delays := recordSleeps(t)
+	server, hits := statusSequenceServer(t, []int{500, 502, 503, 504}, nil)
+	policy := RetryPolicy{MaxAttempts: 4, BaseDelay: 100 * time.Millisecond, MaxDelay: 300 * time.Millisecond}
+
+	_, err := retryRefresh(context.Background(), policy, socialRefreshTo(server))
+	var refreshErr *RefreshError
+	if !errors.As(err, &refreshErr) || refreshErr.Code != 504 {
+		t.Fatalf("Expected 504 RefreshError after exhausting attempts, got %v", err)
+	}
+	if got := atomic.LoadInt32(hits); got != 4 {
+		t.Errorf("Expected 4 requests, got %d", got)
+	}
+	expected := []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 300 * time.Millisecond}
+	if len(*delays) != len(expected) {
+		t.Fatalf("Expected %d waits, got %v", len(expected), *delays)
+	}
+	for i, d := range expected {
+		if (*delays)[i] != d {
+			t.Errorf("Wait %d = %v, want %v", i, (*delays)[i], d)
+		}
+	}
+}
+
+// TestRetryRefresh_NoRetryOnAuthError 測試 401/403 不會重試
+func TestRetryRefresh_NoRetryOnAuthError(t *testing.T) {
+	for _, status := range []int{401, 403} {
+		server, hits := statusSequenceServer(t, []int{status}, nil)
+		policy := RetryPolicy{MaxAttempts: 3, BaseDelay: 10 * time.Millisecond}
+
+		_, err := retryRefresh(context.Background(), policy, socialRefreshTo(server))
+		if err == nil {
+			t.Errorf("HTTP %d: expected error", status)
+		}
+		if got := atomic.LoadInt32(hits); got != 1 {
+			t.Errorf("HTTP %d: expected 1 request, got %d", status, got)
+		}
+	}
+}
+
+// TestRetryRefresh_NoRetryOnParseError 測試回應無法解析時不會重試
+func TestRetryRefresh_NoRetryOnParseError(t *testing.T) {
+	var hits int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.ReadAll(r.Body)
+		atomic.AddInt32(&hits, 1)
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`not json`))
+	}))
+	defer server.Close()
+
+	policy := RetryPolicy{MaxAttempts: 3, BaseDelay: 10 * time.Millisecond}
+	if _, err := retryRefresh(context.Background(), policy, socialRefreshTo(server)); err == nil {
+		t.Error("Expected parse error")
+	}
+	if got := atomic.LoadInt32(&hits); got != 1 {
+		t.Errorf("Expected 1 request, got %d", got)
+	}
+}
+
+// TestParseRetryAfter 測試 Retry-After 標頭解析
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		value    string
+		expected time.Duration
+	}{
+		{"empty", "", 0},
+		{"seconds", "2", 2 * time.Second},
+		{"seconds with spaces", " 5 ", 5 * time.Second},
+		{"negative seconds", "-1", 0},
+		{"http date", now.Add(30 * time.Second).Format(http.TimeFormat), 30 * time.Second},
+		{"past http date", now.Add(-time.Minute).Format(http.TimeFormat), 0},
+		{"invalid", "soon", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseRetryAfter(tt.value, now); got != tt.expected {
+				t.Errorf("parseRetryAfter(%q) = %v, want %v", tt.value, got, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,61 @@
+package tokenrefresh
+
+import (
+	"errors"
+	"sync"
+)
+
+// RefreshStats Token 刷新次數統計（診斷用）
+type RefreshStats struct {
+	Attempts  int `json:"attempts"`  // 刷新嘗試次數
+	Successes int `json:"successes"` // 成功次數
+	Failures  int `json:"failures"`  // 失敗次數
+	// FailuresByCode 依 HTTP 狀態碼統計的失敗次數，0 表示非 HTTP 錯誤（網路、解析失敗等）
+	FailuresByCode map[int]int `json:"failuresByCode"`
+}
+
+var (
+	statsMu sync.Mutex
+	stats   = RefreshStats{FailuresByCode: map[int]int{}}
+)
+
+// recordRefreshResult 記錄一次刷新結果
+func recordRefreshResult(err error) {
+	statsMu.Lock()
+	defer statsMu.Unlock()
+
+	stats.Attempts++
+	if err == nil {
+		stats.Successes++
+		return
+	}
+
+	stats.Failures++
+	code := 0
+	var refreshErr *RefreshError
+	if errors.As(err, &refreshErr) {
+		code = refreshErr.Code
+	}
+	stats.FailuresByCode[code]++
+}
+
+// GetRefreshStats 取得自啟動（或上次重置）以來的刷新統計
+func GetRefreshStats() RefreshStats {
+	statsMu.Lock()
+	defer statsMu.Unlock()
+
+	snapshot := stats
+	snapshot.FailuresByCode = make(map[int]int, len(stats.FailuresByCode))
+	for code, count := range stats.FailuresByCode {
+		snapshot.FailuresByCode[code] = count
+	}
+	return snapshot
+}
+
+// ResetRefreshStats 將刷新統計歸零
+func ResetRefreshStats() {
+	statsMu.Lock()
+	defer statsMu.Unlock()
+
+	stats = RefreshStats{FailuresByCode: map[int]int{}}
+}
@@ -0,0 +1,84 @@
+package tokenrefresh
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+func TestRefreshStats_CountsByHTTPCode(t *testing.T) {
+	ResetRefreshStats()
+	t.Cleanup(ResetRefreshStats)
+
+	codes := []int{http.StatusOK, http.StatusUnauthorized, http.StatusUnauthorized, http.StatusTooManyRequests}
+	var mu sync.Mutex
+	next := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		code := codes[next]
+		next++
+		mu.Unlock()
+
+		w.WriteHeader(code)
+		if code == http.StatusOK {
+			w.Write([]byte(`{"accessToken":"new-token","expiresIn":3600}`))
+		}
+	}))
+	defer server.Close()
+
+	for range codes {
+		RefreshSocialTokenWithClient(server.Client(), server.URL, "refresh-token", "hashed-machine-id")
+	}
+	// 非 HTTP 錯誤歸類為 0
+	RefreshSocialTokenWithClient(server.Client(), server.URL, "refresh-token", "")
+
+	got := GetRefreshStats()
+	if got.Attempts != 5 || got.Successes != 1 || got.Failures != 4 {
+		t.Errorf("unexpected totals: %+v", got)
+	}
+	want := map[int]int{0: 1, 401: 2, 429: 1}
+	for code, count := range want {
+		if got.FailuresByCode[code] != count {
+			t.Errorf("FailuresByCode[%d] = %d, want %d", code, got.FailuresByCode[code], count)
+		}
+	}
+
+	// 返回的是快照，修改不影響內部統計
+	got.FailuresByCode[401] = 100
+	if GetRefreshStats().FailuresByCode[401] != 2 {
+		t.Error("GetRefreshStats should return a copy")
+	}
+
+	ResetRefreshStats()
+	if reset := GetRefreshStats(); reset.Attempts != 0 || len(reset.FailuresByCode) != 0 {
+		t.Errorf("expected empty stats after reset, got %+v", reset)
+	}
+}
+
+func TestRefreshStats_Concurrent(t *testing.T) {
+	ResetRefreshStats()
+	t.Cleanup(ResetRefreshStats)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	const workers = 20
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			RefreshIdCTokenWithClient(server.Client(), server.URL, "refresh-token", "client-id", "client-secret")
+			GetRefreshStats()
+		}()
+	}
+	wg.Wait()
+
+	got := GetRefreshStats()
+	if got.Attempts != workers || got.FailuresByCode[http.StatusServiceUnavailable] != workers {
+		t.Errorf("expected %d failed attempts with HTTP 503, got %+v", workers, got)
+	}
+}
@@ -2,13 +2,20 @@ package tokenrefresh
 
 import (
 	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"strings"
 	"time"
 
+	"github.com/andybalholm/brotli"
 	"github.com/google/uuid"
 	"kiro-manager/awssso"
 	"kiro-manager/kiroversion"
@@ -19,6 +26,17 @@ import (
 const (
 	SocialRefreshURL = "https://prod.us-east-1.auth.desktop.kiro.dev/refreshToken"
 	IdCRefreshURL    = "https://oidc.us-east-1.amazonaws.com/token"
+
+	// DefaultIdCRegion token 未指定 region 時使用的 IdC 區域
+	DefaultIdCRegion = "us-east-1"
+)
+
+// 常見刷新失敗情況的 sentinel 錯誤，可用 errors.Is 判斷
+var (
+	ErrTokenExpired        = errors.New("token expired or revoked")
+	ErrRateLimited         = errors.New("rate limited")
+	ErrServerUnavailable   = errors.New("server unavailable")
+	ErrUnsupportedAuthType = errors.New("unsupported auth type")
 )
 
 // getEffectiveKiroVersion 取得有效的 Kiro 版本號
@@ -26,7 +44,7 @@ const (
 func getEffectiveKiroVersion() string {
 	if settings.IsAutoDetectEnabled() {
 		// 嘗試自動偵測
-		if version, err := kiroversion.GetKiroVersion(); err == nil && version != "" {
+		if version, err := kiroversion.GetKiroVersionCached(); err == nil && version != "" {
 			return version
 		}
 		// 偵測失敗時回退到設定值
@@ -48,6 +66,8 @@ type RefreshError struct {
 	Code    int    // HTTP 狀態碼（0 表示非 HTTP 錯誤）
 	Message string // 使用者友善的錯誤訊息
 	Cause   error  // 底層錯誤（用於除錯）
+
+	RetryAfter time.Duration // 伺服器 Retry-After 標頭指定的等待時間（0 表示未指定）
 }
 
 // Error 實作 error 介面
@@ -113,13 +133,17 @@ func CalculateExpiresAtString(expiresIn int) string {
 // - HTTP 5xx 映射為「伺服器暫時無法使用，請稍後再試」
 func MapHTTPError(statusCode int, body string) *RefreshError {
 	var message string
+	var cause error
 	switch {
 	case statusCode == 401 || statusCode == 403:
 		message = "Token 已失效，請重新登入 Kiro"
+		cause = ErrTokenExpired
 	case statusCode == 429:
 		message = "請求過於頻繁，請稍後再試"
+		cause = ErrRateLimited
 	case statusCode >= 500 && statusCode < 600:
 		message = "伺服器暫時無法使用，請稍後再試"
+		cause = ErrServerUnavailable
 	default:
 		// 包含 HTTP 狀態碼和回應內容以便除錯
 		message = fmt.Sprintf("Token 刷新失敗 (HTTP %d): %s", statusCode, truncateString(body, 200))
@@ -127,9 +151,73 @@ func MapHTTPError(statusCode int, body string) *RefreshError {
 	return &RefreshError{
 		Code:    statusCode,
 		Message: message,
+		Cause:   cause,
+	}
+}
+
+// mapHTTPResponseError 將 HTTP 錯誤回應轉換為 RefreshError，並解析 Retry-After 標頭
+func mapHTTPResponseError(statusCode int, body string, header http.Header) *RefreshError {
+	refreshErr := MapHTTPError(statusCode, body)
+	refreshErr.RetryAfter = parseRetryAfter(header.Get("Retry-After"), time.Now())
+	return refreshErr
+}
+
+// newDefaultHTTPClient 建立預設的 HTTP 客戶端（30 秒超時）
+func newDefaultHTTPClient() *http.Client {
+	return &http.Client{Timeout: 30 * time.Second}
+}
+
+// newRequestError 將發送請求時的錯誤轉換為 RefreshError
+// 若是因 context 取消或逾時而失敗，返回對應的取消訊息
+func newRequestError(ctx context.Context, err error) *RefreshError {
+	if ctx.Err() != nil {
+		return &RefreshError{
+			Code:    0,
+			Message: "Token 刷新已取消",
+			Cause:   err,
+		}
+	}
+	return &RefreshError{
+		Code:    0,
+		Message: "網路連線失敗: " + err.Error(),
+		Cause:   err,
 	}
 }
 
+// readResponseBody 讀取回應 body，並依 Content-Encoding 解壓縮
+// 支援 gzip、br（brotli）、deflate，其他或未設定時視為未壓縮
+func readResponseBody(resp *http.Response) ([]byte, error) {
+	var reader io.Reader = resp.Body
+
+	switch strings.ToLower(strings.TrimSpace(resp.Header.Get("Content-Encoding"))) {
+	case "gzip":
+		gzipReader, err := gzip.NewReader(resp.Body)
+		if err != nil {
+			return nil, err
+		}
+		defer gzipReader.Close()
+		reader = gzipReader
+	case "br":
+		reader = brotli.NewReader(resp.Body)
+	case "deflate":
+		// HTTP deflate 規範為 zlib 格式，但部分伺服器會送出原始 deflate 資料
+		raw, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return nil, err
+		}
+		if zlibReader, err := zlib.NewReader(bytes.NewReader(raw)); err == nil {
+			defer zlibReader.Close()
+			reader = zlibReader
+		} else {
+			flateReader := flate.NewReader(bytes.NewReader(raw))
+			defer flateReader.Close()
+			reader = flateReader
+		}
+	}
+
+	return io.ReadAll(reader)
+}
+
 // truncateString 截斷字串到指定長度
 func truncateString(s string, maxLen int) string {
 	if len(s) <= maxLen {
@@ -142,6 +230,33 @@ func truncateString(s string, maxLen int) string {
 // 發送 POST 請求到 Social 刷新端點，解析回應並返回新的 Token 資訊
 // machineId 參數應為對應環境快照的 Machine ID 的 SHA256 雜湊值
 func RefreshSocialToken(refreshToken string, machineId string) (*TokenInfo, error) {
+	return RefreshSocialTokenWithClient(newDefaultHTTPClient(), socialRefreshEndpoint(), refreshToken, machineId)
+}
+
+// RefreshSocialTokenWithClient 使用指定的 HTTP 客戶端和端點執行 Social 刷新（用於測試）
+func RefreshSocialTokenWithClient(client *http.Client, endpoint, refreshToken, machineId string) (*TokenInfo, error) {
+	return refreshSocialToken(context.Background(), client, endpoint, refreshToken, machineId)
+}
+
+// RefreshSocialTokenContext 使用 Social 認證方式刷新 Token（支援 context 取消）
+// ctx 取消時會中止進行中的 HTTP 請求
+func RefreshSocialTokenContext(ctx context.Context, refreshToken string, machineId string) (*TokenInfo, error) {
+	return refreshSocialToken(ctx, newDefaultHTTPClient(), socialRefreshEndpoint(), refreshToken, machineId)
+}
+
+// socialRefreshEndpoint 取得 Social 刷新端點
+// 設定中有自定義端點時使用自定義值，否則使用 SocialRefreshURL
+func socialRefreshEndpoint() string {
+	if override := settings.GetSocialRefreshURLOverride(); override != "" {
+		return override
+	}
+	return SocialRefreshURL
+}
+
+// refreshSocialToken Social 刷新的內部實作，可指定 HTTP 客戶端和端點
+func refreshSocialToken(ctx context.Context, client *http.Client, endpoint string, refreshToken string, machineId string) (info *TokenInfo, err error) {
+	defer func() { recordRefreshResult(err) }()
+
 	// 驗證參數
 	if machineId == "" {
 		return nil, &RefreshError{
@@ -164,7 +279,7 @@ func RefreshSocialToken(refreshToken string, machineId string) (*TokenInfo, erro
 	}
 
 	// 建立 HTTP 請求
-	req, err := http.NewRequest("POST", SocialRefreshURL, bytes.NewBuffer(jsonBody))
+	req, err := http.NewRequestWithContext(ctx, "POST", endpoint, bytes.NewBuffer(jsonBody))
 	if err != nil {
 		return nil, &RefreshError{
 			Code:    0,
@@ -182,19 +297,14 @@ func RefreshSocialToken(refreshToken string, machineId string) (*TokenInfo, erro
 	req.Header.Set("Sec-Fetch-Mode", "cors")
 
 	// 發送請求
-	client := &http.Client{Timeout: 30 * time.Second}
 	resp, err := client.Do(req)
 	if err != nil {
-		return nil, &RefreshError{
-			Code:    0,
-			Message: "網路連線失敗: " + err.Error(),
-			Cause:   err,
-		}
+		return nil, newRequestError(ctx, err)
 	}
 	defer resp.Body.Close()
 
-	// 讀取回應 body
-	body, err := io.ReadAll(resp.Body)
+	// 讀取回應 body（依 Content-Encoding 解壓縮）
+	body, err := readResponseBody(resp)
 	if err != nil {
 		return nil, &RefreshError{
 			Code:    0,
@@ -205,7 +315,7 @@ func RefreshSocialToken(refreshToken string, machineId string) (*TokenInfo, erro
 
 	// 處理 HTTP 錯誤（需求 4.1, 4.2, 4.3）
 	if resp.StatusCode != http.StatusOK {
-		return nil, MapHTTPError(resp.StatusCode, string(body))
+		return nil, mapHTTPResponseError(resp.StatusCode, string(body), resp.Header)
 	}
 
 	// 解析 JSON 回應
@@ -252,6 +362,79 @@ func ParseSocialResponse(jsonData []byte) (*TokenInfo, error) {
 // 發送 POST 請求到 IdC 刷新端點，包含必要的 Headers
 // 需求: 2.2, 2.3, 5.2, 5.3
 func RefreshIdCToken(refreshToken, clientID, clientSecret string) (*TokenInfo, error) {
+	return RefreshIdCTokenWithRegionContext(context.Background(), DefaultIdCRegion, refreshToken, clientID, clientSecret)
+}
+
+// RefreshIdCTokenWithClient 使用指定的 HTTP 客戶端和端點執行 IdC 刷新（用於測試）
+// Host 標頭使用 us-east-1 的 OIDC 主機
+func RefreshIdCTokenWithClient(client *http.Client, endpoint, refreshToken, clientID, clientSecret string) (*TokenInfo, error) {
+	return RefreshIdCTokenWithClientRegion(context.Background(), client, endpoint, DefaultIdCRegion, refreshToken, clientID, clientSecret)
+}
+
+// RefreshIdCTokenContext 使用 IdC 認證方式刷新 Token（支援 context 取消）
+// ctx 取消時會中止進行中的 HTTP 請求
+func RefreshIdCTokenContext(ctx context.Context, refreshToken, clientID, clientSecret string) (*TokenInfo, error) {
+	return RefreshIdCTokenWithRegionContext(ctx, DefaultIdCRegion, refreshToken, clientID, clientSecret)
+}
+
+// RefreshIdCTokenWithRegionContext 使用指定區域的 IdC OIDC 端點刷新 Token
+// region 為空時使用 us-east-1
+// 設定中有自定義 IdC 端點時，所有區域皆使用自定義端點
+func RefreshIdCTokenWithRegionContext(ctx context.Context, region, refreshToken, clientID, clientSecret string) (*TokenInfo, error) {
+	endpoint, host := idcRefreshTarget(region)
+	return refreshIdCToken(ctx, newDefaultHTTPClient(), endpoint, host, refreshToken, clientID, clientSecret)
+}
+
+// RefreshIdCTokenWithClientRegion 使用指定的 HTTP 客戶端、端點和區域執行 IdC 刷新（用於測試）
+// Host 標頭依 region 設定，region 為空時使用 us-east-1
+func RefreshIdCTokenWithClientRegion(ctx context.Context, client *http.Client, endpoint, region, refreshToken, clientID, clientSecret string) (*TokenInfo, error) {
+	return refreshIdCToken(ctx, client, endpoint, IdCHostForRegion(region), refreshToken, clientID, clientSecret)
+}
+
+// idcRefreshTarget 取得 IdC 刷新端點與對應的 Host 標頭
+// 設定中有自定義端點時使用自定義值（Host 取自該 URL），否則依 region 使用內建端點
+func idcRefreshTarget(region string) (endpoint, host string) {
+	if override := settings.GetIdCRefreshURLOverride(); override != "" {
+		if u, err := url.Parse(override); err == nil {
+			return override, u.Host
+		}
+	}
+	return IdCRefreshURLForRegion(region), IdCHostForRegion(region)
+}
+
+// IdCHostForRegion 取得指定區域的 IdC OIDC 主機名稱
+// region 為空或格式不正確時使用 us-east-1
+func IdCHostForRegion(region string) string {
+	region = strings.ToLower(strings.TrimSpace(region))
+	if !isValidRegion(region) {
+		region = DefaultIdCRegion
+	}
+	return "oidc." + region + ".amazonaws.com"
+}
+
+// IdCRefreshURLForRegion 取得指定區域的 IdC 刷新端點
+func IdCRefreshURLForRegion(region string) string {
+	return "https://" + IdCHostForRegion(region) + "/token"
+}
+
+// isValidRegion 檢查 region 是否只包含小寫字母、數字與連字號（例如 eu-west-1）
+func isValidRegion(region string) bool {
+	if region == "" {
+		return false
+	}
+	for _, r := range region {
+		if !(r >= 'a' && r <= 'z') && !(r >= '0' && r <= '9') && r != '-' {
+			return false
+		}
+	}
+	return true
+}
+
+// refreshIdCToken IdC 刷新的內部實作，可指定 HTTP 客戶端和端點
+// host 為 Host 標頭，需與實際的 OIDC 端點一致；空字串表示使用 endpoint 的主機
+func refreshIdCToken(ctx context.Context, client *http.Client, endpoint string, host string, refreshToken, clientID, clientSecret string) (info *TokenInfo, err error) {
+	defer func() { recordRefreshResult(err) }()
+
 	// 建立請求 body
 	reqBody := IdCRefreshRequest{
 		ClientID:     clientID,
@@ -269,7 +452,7 @@ func RefreshIdCToken(refreshToken, clientID, clientSecret string) (*TokenInfo, e
 	}
 
 	// 建立 HTTP 請求
-	req, err := http.NewRequest("POST", IdCRefreshURL, bytes.NewBuffer(jsonBody))
+	req, err := http.NewRequestWithContext(ctx, "POST", endpoint, bytes.NewBuffer(jsonBody))
 	if err != nil {
 		return nil, &RefreshError{
 			Code:    0,
@@ -280,7 +463,9 @@ func RefreshIdCToken(refreshToken, clientID, clientSecret string) (*TokenInfo, e
 
 	// 設定必要的 Headers（需求 2.3）
 	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Host", "oidc.us-east-1.amazonaws.com")
+	if host != "" {
+		req.Host = host
+	}
 	req.Header.Set("x-amz-user-agent", "aws-sdk-js/3.738.0 KiroIDE")
 	req.Header.Set("User-Agent", "aws-sdk-js/3.738.0 ua/2.1 os/win32#10.0.26100 lang/js md/nodejs#22.21.1 api/sso-oidc#3.738.0 m/E KiroIDE")
 	req.Header.Set("Accept", "*/*")
@@ -289,19 +474,14 @@ func RefreshIdCToken(refreshToken, clientID, clientSecret string) (*TokenInfo, e
 	req.Header.Set("amz-sdk-request", "attempt=1; max=4")
 
 	// 發送請求
-	client := &http.Client{Timeout: 30 * time.Second}
 	resp, err := client.Do(req)
 	if err != nil {
-		return nil, &RefreshError{
-			Code:    0,
-			Message: "網路連線失敗: " + err.Error(),
-			Cause:   err,
-		}
+		return nil, newRequestError(ctx, err)
 	}
 	defer resp.Body.Close()
 
-	// 讀取回應 body
-	body, err := io.ReadAll(resp.Body)
+	// 讀取回應 body（依 Content-Encoding 解壓縮）
+	body, err := readResponseBody(resp)
 	if err != nil {
 		return nil, &RefreshError{
 			Code:    0,
@@ -312,7 +492,7 @@ func RefreshIdCToken(refreshToken, clientID, clientSecret string) (*TokenInfo, e
 
 	// 處理 HTTP 錯誤（需求 4.1, 4.2, 4.3）
 	if resp.StatusCode != http.StatusOK {
-		return nil, MapHTTPError(resp.StatusCode, string(body))
+		return nil, mapHTTPResponseError(resp.StatusCode, string(body), resp.Header)
 	}
 
 	// 解析 JSON 回應
@@ -374,6 +554,12 @@ func RefreshAccessTokenFromBackup(token *awssso.KiroAuthToken, machineId string,
 // 如果提供了 clientID 和 clientSecret，IdC 認證時會直接使用
 // 否則會從 SSO cache 讀取
 func RefreshAccessTokenWithCredentials(token *awssso.KiroAuthToken, machineId string, clientID, clientSecret string) (*TokenInfo, error) {
+	return RefreshAccessTokenWithCredentialsContext(context.Background(), token, machineId, clientID, clientSecret)
+}
+
+// RefreshAccessTokenWithCredentialsContext 刷新 AccessToken（支援 context 取消）
+// 與 RefreshAccessTokenWithCredentials 相同，但 ctx 取消時會中止進行中的請求
+func RefreshAccessTokenWithCredentialsContext(ctx context.Context, token *awssso.KiroAuthToken, machineId string, clientID, clientSecret string) (*TokenInfo, error) {
 	if token == nil {
 		return nil, &RefreshError{
 			Code:    0,
@@ -400,7 +586,7 @@ func RefreshAccessTokenWithCredentials(token *awssso.KiroAuthToken, machineId st
 				Message: "RefreshToken 不可為空",
 			}
 		}
-		return RefreshSocialToken(token.RefreshToken, machineId)
+		return RefreshSocialTokenContext(ctx, token.RefreshToken, machineId)
 
 	case "idc":
 		// IdC 認證路由到 RefreshIdCToken
@@ -418,16 +604,52 @@ func RefreshAccessTokenWithCredentials(token *awssso.KiroAuthToken, machineId st
 				return nil, err
 			}
 		}
-		return RefreshIdCToken(token.RefreshToken, clientID, clientSecret)
+		return RefreshIdCTokenWithRegionContext(ctx, token.Region, token.RefreshToken, clientID, clientSecret)
 
 	default:
 		return nil, &RefreshError{
 			Code:    0,
 			Message: "不支援的認證類型: " + authType,
+			Cause:   ErrUnsupportedAuthType,
 		}
 	}
 }
 
+// RefreshCurrentToken 刷新當前登入帳號的 Token 並寫回 kiro-auth-token.json
+// 保留原有欄位，僅更新 accessToken、expiresAt
+// machineId 參數應為當前 Machine ID 的 SHA256 雜湊值
+func RefreshCurrentToken(machineId string) (*TokenInfo, error) {
+	return refreshCurrentToken(machineId, RefreshAccessToken)
+}
+
+// refreshCurrentToken RefreshCurrentToken 的內部實作，可指定刷新函數
+func refreshCurrentToken(machineId string, refresh func(token *awssso.KiroAuthToken, machineId string) (*TokenInfo, error)) (*TokenInfo, error) {
+	token, err := awssso.ReadKiroAuthToken()
+	if err != nil {
+		return nil, &RefreshError{
+			Code:    0,
+			Message: "無法讀取當前的 Token",
+			Cause:   err,
+		}
+	}
+
+	tokenInfo, err := refresh(token, machineId)
+	if err != nil {
+		return nil, err
+	}
+
+	expiresAt := tokenInfo.ExpiresAt.UTC().Format("2006-01-02T15:04:05.000Z")
+	if err := awssso.UpdateKiroAuthToken(tokenInfo.AccessToken, expiresAt); err != nil {
+		return nil, &RefreshError{
+			Code:    0,
+			Message: "無法寫入刷新後的 Token",
+			Cause:   err,
+		}
+	}
+
+	return tokenInfo, nil
+}
+
 // DetectAuthType 偵測 token 的認證類型
 // 根據 AuthMethod 欄位或其他特徵判斷是 Social 還是 IdC
 func DetectAuthType(token *awssso.KiroAuthToken) string {
@@ -1,15 +1,27 @@
 package tokenrefresh
 
 import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"math"
 	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 	"testing/quick"
 	"time"
 
+	"github.com/andybalholm/brotli"
 	"kiro-manager/awssso"
 )
 
@@ -674,6 +686,83 @@ func TestRefreshAccessToken_UnknownAuthType(t *testing.T) {
 	}
 }
 
+// TestCalculateExpiresAtString_Parseable 測試 CalculateExpiresAtString 的輸出可被 awssso.ParseExpiresAt 解析
+func TestCalculateExpiresAtString_Parseable(t *testing.T) {
+	before := time.Now().Add(time.Hour).Truncate(time.Millisecond)
+	raw := CalculateExpiresAtString(3600)
+
+	expiresAt, err := awssso.ParseExpiresAt(raw)
+	if err != nil {
+		t.Fatalf("ParseExpiresAt(%q) error = %v", raw, err)
+	}
+	if expiresAt.Before(before) || expiresAt.After(time.Now().Add(time.Hour)) {
+		t.Errorf("ParseExpiresAt(%q) = %v, want about one hour from now", raw, expiresAt)
+	}
+}
+
+// TestMapHTTPError_Sentinels 測試 HTTP 錯誤可用 errors.Is 判斷類別，且 Message 不變
+func TestMapHTTPError_Sentinels(t *testing.T) {
+	tests := []struct {
+		statusCode int
+		sentinel   error
+		message    string
+	}{
+		{401, ErrTokenExpired, "Token 已失效，請重新登入 Kiro"},
+		{403, ErrTokenExpired, "Token 已失效，請重新登入 Kiro"},
+		{429, ErrRateLimited, "請求過於頻繁，請稍後再試"},
+		{500, ErrServerUnavailable, "伺服器暫時無法使用，請稍後再試"},
+		{503, ErrServerUnavailable, "伺服器暫時無法使用，請稍後再試"},
+	}
+
+	sentinels := []error{ErrTokenExpired, ErrRateLimited, ErrServerUnavailable, ErrUnsupportedAuthType}
+	for _, tt := range tests {
+		err := error(MapHTTPError(tt.statusCode, ""))
+		if !errors.Is(err, tt.sentinel) {
+			t.Errorf("HTTP %d: expected errors.Is(err, %v)", tt.statusCode, tt.sentinel)
+		}
+		for _, other := range sentinels {
+			if other != tt.sentinel && errors.Is(err, other) {
+				t.Errorf("HTTP %d: unexpected match with %v", tt.statusCode, other)
+			}
+		}
+		if err.Error() != tt.message {
+			t.Errorf("HTTP %d: message = %q, want %q", tt.statusCode, err.Error(), tt.message)
+		}
+	}
+
+	// 其他狀態碼不對應任何 sentinel
+	err := error(MapHTTPError(400, "bad request"))
+	for _, sentinel := range sentinels {
+		if errors.Is(err, sentinel) {
+			t.Errorf("HTTP 400: unexpected match with %v", sentinel)
+		}
+	}
+}
+
+// TestRefreshAccessToken_UnsupportedAuthTypeSentinel 測試未知認證類型可用 errors.Is 判斷
+func TestRefreshAccessToken_UnsupportedAuthTypeSentinel(t *testing.T) {
+	token := &awssso.KiroAuthToken{RefreshToken: "some-refresh-token"}
+
+	_, err := RefreshAccessToken(token, "test-machine-id")
+	if !errors.Is(err, ErrUnsupportedAuthType) {
+		t.Errorf("Expected errors.Is(err, ErrUnsupportedAuthType), got %v", err)
+	}
+}
+
+// TestRefreshSocialToken_HTTPErrorSentinel 測試實際 HTTP 回應的錯誤也能以 errors.Is 判斷
+func TestRefreshSocialToken_HTTPErrorSentinel(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	_, err := refreshSocialToken(context.Background(), server.Client(), server.URL, "refresh-token", "machine-id")
+	if !errors.Is(err, ErrTokenExpired) {
+		t.Errorf("Expected errors.Is(err, ErrTokenExpired), got %v", err)
+	}
+}
+
 
 // **Feature: token-refresh, Property 4: HTTP Error Code Mapping**
 // *For any* HTTP error response with status code C, the returned RefreshError
@@ -858,3 +947,477 @@ func TestMapHTTPError_5xxRange(t *testing.T) {
 		}
 	}
 }
+
+// newSlowServer 建立一個延遲回應的測試伺服器
+// 請求的 context 取消時立即結束，避免測試結束時阻塞
+func newSlowServer(t *testing.T, delay time.Duration) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// 讀完 body 後伺服器才會偵測到客戶端斷線並取消 r.Context()
+		io.ReadAll(r.Body)
+		select {
+		case <-r.Context().Done():
+			return
+		case <-time.After(delay):
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"accessToken":"late-token","expiresIn":3600}`))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// TestRefreshSocialToken_ContextCancelled 測試取消 context 會中止進行中的 Social 刷新請求
+func TestRefreshSocialToken_ContextCancelled(t *testing.T) {
+	server := newSlowServer(t, 5*time.Second)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(100*time.Millisecond, cancel)
+
+	start := time.Now()
+	_, err := refreshSocialToken(ctx, server.Client(), server.URL, "refresh-token", "machine-id")
+	elapsed := time.Since(start)
+
+	if err == nil {
+		t.Fatal("Expected error after context cancellation")
+	}
+	if elapsed >= 2*time.Second {
+		t.Errorf("Request was not aborted promptly, took %v", elapsed)
+	}
+
+	var refreshErr *RefreshError
+	if !errors.As(err, &refreshErr) {
+		t.Fatalf("Expected RefreshError, got %T", err)
+	}
+	if refreshErr.Message != "Token 刷新已取消" {
+		t.Errorf("Unexpected message: %q", refreshErr.Message)
+	}
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected errors.Is(err, context.Canceled), got %v", err)
+	}
+}
+
+// TestRefreshIdCToken_ContextCancelled 測試取消 context 會中止進行中的 IdC 刷新請求
+func TestRefreshIdCToken_ContextCancelled(t *testing.T) {
+	server := newSlowServer(t, 5*time.Second)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(100*time.Millisecond, cancel)
+
+	start := time.Now()
+	_, err := refreshIdCToken(ctx, server.Client(), server.URL, "", "refresh-token", "client-id", "client-secret")
+	elapsed := time.Since(start)
+
+	if err == nil {
+		t.Fatal("Expected error after context cancellation")
+	}
+	if elapsed >= 2*time.Second {
+		t.Errorf("Request was not aborted promptly, took %v", elapsed)
+	}
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected errors.Is(err, context.Canceled), got %v", err)
+	}
+}
+
+// TestRefreshSocialTokenContext_AlreadyCancelled 測試傳入已取消的 context 不會發送請求
+func TestRefreshSocialTokenContext_AlreadyCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := RefreshSocialTokenContext(ctx, "refresh-token", "machine-id")
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected errors.Is(err, context.Canceled), got %v", err)
+	}
+}
+
+// compressBody 依指定 Content-Encoding 壓縮回應內容
+func compressBody(t *testing.T, encoding string, data []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	var writer io.WriteCloser
+	switch encoding {
+	case "gzip":
+		writer = gzip.NewWriter(&buf)
+	case "br":
+		writer = brotli.NewWriter(&buf)
+	case "deflate":
+		writer = zlib.NewWriter(&buf)
+	case "raw-deflate":
+		fw, err := flate.NewWriter(&buf, flate.DefaultCompression)
+		if err != nil {
+			t.Fatalf("flate.NewWriter failed: %v", err)
+		}
+		writer = fw
+	default:
+		return data
+	}
+	if _, err := writer.Write(data); err != nil {
+		t.Fatalf("compress failed: %v", err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("compress close failed: %v", err)
+	}
+	return buf.Bytes()
+}
+
+// newEncodedServer 建立以指定 Content-Encoding 回應的測試伺服器
+func newEncodedServer(t *testing.T, encoding string, payload []byte) *httptest.Server {
+	t.Helper()
+	body := compressBody(t, encoding, payload)
+	header := encoding
+	if encoding == "raw-deflate" {
+		header = "deflate"
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.ReadAll(r.Body)
+		if header != "identity" {
+			w.Header().Set("Content-Encoding", header)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write(body)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// TestRefreshSocialToken_CompressedResponse 測試 Social 刷新可解析各種壓縮格式的回應
+func TestRefreshSocialToken_CompressedResponse(t *testing.T) {
+	payload := []byte(`{"accessToken":"social-token","expiresIn":3600,"refreshToken":"new-refresh","profileArn":"arn:aws:test"}`)
+
+	for _, encoding := range []string{"identity", "gzip", "br", "deflate", "raw-deflate"} {
+		t.Run(encoding, func(t *testing.T) {
+			server := newEncodedServer(t, encoding, payload)
+
+			info, err := refreshSocialToken(context.Background(), server.Client(), server.URL, "refresh-token", "machine-id")
+			if err != nil {
+				t.Fatalf("refreshSocialToken failed: %v", err)
+			}
+			if info.AccessToken != "social-token" {
+				t.Errorf("AccessToken = %q, want %q", info.AccessToken, "social-token")
+			}
+			if info.ExpiresIn != 3600 {
+				t.Errorf("ExpiresIn = %d, want 3600", info.ExpiresIn)
+			}
+			if info.ProfileArn != "arn:aws:test" {
+				t.Errorf("ProfileArn = %q, want %q", info.ProfileArn, "arn:aws:test")
+			}
+		})
+	}
+}
+
+// TestRefreshIdCToken_CompressedResponse 測試 IdC 刷新可解析 gzip 壓縮的回應
+func TestRefreshIdCToken_CompressedResponse(t *testing.T) {
+	payload := []byte(`{"accessToken":"idc-token","expiresIn":28800,"tokenType":"Bearer"}`)
+	server := newEncodedServer(t, "gzip", payload)
+
+	info, err := refreshIdCToken(context.Background(), server.Client(), server.URL, "", "refresh-token", "client-id", "client-secret")
+	if err != nil {
+		t.Fatalf("refreshIdCToken failed: %v", err)
+	}
+	if info.AccessToken != "idc-token" {
+		t.Errorf("AccessToken = %q, want %q", info.AccessToken, "idc-token")
+	}
+	if info.TokenType != "Bearer" {
+		t.Errorf("TokenType = %q, want %q", info.TokenType, "Bearer")
+	}
+}
+
+// TestReadResponseBody_InvalidGzip 測試 gzip 標頭與內容不符時回傳錯誤
+func TestReadResponseBody_InvalidGzip(t *testing.T) {
+	resp := &http.Response{
+		Header: http.Header{"Content-Encoding": []string{"gzip"}},
+		Body:   io.NopCloser(strings.NewReader(`{"accessToken":"plain"}`)),
+	}
+	if _, err := readResponseBody(resp); err == nil {
+		t.Error("Expected error for invalid gzip body")
+	}
+}
+
+// writeCurrentToken 在臨時 HOME 下建立 kiro-auth-token.json 並返回其路徑
+func writeCurrentToken(t *testing.T, content map[string]interface{}) string {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+
+	tokenPath, err := awssso.GetKiroAuthTokenPath()
+	if err != nil {
+		t.Fatalf("GetKiroAuthTokenPath failed: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(tokenPath), 0755); err != nil {
+		t.Fatalf("MkdirAll failed: %v", err)
+	}
+	if content != nil {
+		data, _ := json.MarshalIndent(content, "", "  ")
+		if err := os.WriteFile(tokenPath, data, 0644); err != nil {
+			t.Fatalf("WriteFile failed: %v", err)
+		}
+	}
+	return tokenPath
+}
+
+// TestRefreshCurrentToken_WritesLiveToken 測試刷新後寫回當前 token 檔案並保留其他欄位
+func TestRefreshCurrentToken_WritesLiveToken(t *testing.T) {
+	tokenPath := writeCurrentToken(t, map[string]interface{}{
+		"accessToken":  "old-access-token",
+		"refreshToken": "live-refresh-token",
+		"profileArn":   "arn:aws:kiro::123:profile/live",
+		"expiresAt":    "2025-01-01T00:00:00.000Z",
+		"authMethod":   "social",
+		"provider":     "Google",
+	})
+
+	expiresAt := time.Date(2030, 6, 1, 12, 0, 0, 0, time.UTC)
+	var gotRefreshToken, gotMachineID string
+	info, err := refreshCurrentToken("hashed-machine-id", func(token *awssso.KiroAuthToken, machineId string) (*TokenInfo, error) {
+		gotRefreshToken = token.RefreshToken
+		gotMachineID = machineId
+		return &TokenInfo{AccessToken: "new-access-token", ExpiresAt: expiresAt}, nil
+	})
+	if err != nil {
+		t.Fatalf("refreshCurrentToken failed: %v", err)
+	}
+	if info.AccessToken != "new-access-token" {
+		t.Errorf("AccessToken = %q, want %q", info.AccessToken, "new-access-token")
+	}
+	if gotRefreshToken != "live-refresh-token" || gotMachineID != "hashed-machine-id" {
+		t.Errorf("unexpected refresh arguments: %q, %q", gotRefreshToken, gotMachineID)
+	}
+
+	data, err := os.ReadFile(tokenPath)
+	if err != nil {
+		t.Fatalf("ReadFile failed: %v", err)
+	}
+	var written map[string]interface{}
+	if err := json.Unmarshal(data, &written); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	expected := map[string]string{
+		"accessToken":  "new-access-token",
+		"refreshToken": "live-refresh-token",
+		"profileArn":   "arn:aws:kiro::123:profile/live",
+		"expiresAt":    "2030-06-01T12:00:00.000Z",
+		"authMethod":   "social",
+		"provider":     "Google",
+	}
+	for key, want := range expected {
+		if written[key] != want {
+			t.Errorf("%s = %v, want %q", key, written[key], want)
+		}
+	}
+}
+
+// TestRefreshCurrentToken_RefreshFailure 測試刷新失敗時不修改 token 檔案
+func TestRefreshCurrentToken_RefreshFailure(t *testing.T) {
+	tokenPath := writeCurrentToken(t, map[string]interface{}{
+		"accessToken":  "old-access-token",
+		"refreshToken": "live-refresh-token",
+		"expiresAt":    "2025-01-01T00:00:00.000Z",
+		"authMethod":   "social",
+	})
+	before, _ := os.ReadFile(tokenPath)
+
+	refreshErr := MapHTTPError(401, "")
+	_, err := refreshCurrentToken("hashed-machine-id", func(token *awssso.KiroAuthToken, machineId string) (*TokenInfo, error) {
+		return nil, refreshErr
+	})
+	if err != refreshErr {
+		t.Errorf("Expected refresh error to be returned unchanged, got %v", err)
+	}
+
+	after, _ := os.ReadFile(tokenPath)
+	if string(before) != string(after) {
+		t.Error("token file should not be modified when refresh fails")
+	}
+}
+
+// TestRefreshCurrentToken_NoToken 測試沒有當前 token 時返回錯誤
+func TestRefreshCurrentToken_NoToken(t *testing.T) {
+	writeCurrentToken(t, nil)
+
+	_, err := refreshCurrentToken("hashed-machine-id", func(token *awssso.KiroAuthToken, machineId string) (*TokenInfo, error) {
+		t.Fatal("refresh should not be called without a token")
+		return nil, nil
+	})
+	if !errors.Is(err, awssso.ErrTokenNotFound) {
+		t.Errorf("Expected ErrTokenNotFound, got %v", err)
+	}
+}
+
+// TestRefreshIdCTokenWithClientRegion 測試 IdC 刷新依 region 設定 OIDC 主機
+func TestRefreshIdCTokenWithClientRegion(t *testing.T) {
+	tests := []struct {
+		region       string
+		expectedHost string
+	}{
+		{"eu-west-1", "oidc.eu-west-1.amazonaws.com"},
+		{"", "oidc.us-east-1.amazonaws.com"},
+	}
+
+	for _, tt := range tests {
+		t.Run("region="+tt.region, func(t *testing.T) {
+			var gotHost string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				io.ReadAll(r.Body)
+				gotHost = r.Host
+				w.WriteHeader(http.StatusOK)
+				w.Write([]byte(`{"accessToken":"idc-token","expiresIn":3600,"tokenType":"Bearer"}`))
+			}))
+			defer server.Close()
+
+			_, err := RefreshIdCTokenWithClientRegion(context.Background(), server.Client(), server.URL, tt.region, "idc-refresh-token", "client-id", "client-secret")
+			if err != nil {
+				t.Fatalf("RefreshIdCTokenWithClientRegion failed: %v", err)
+			}
+			if gotHost != tt.expectedHost {
+				t.Errorf("Host = %q, want %q", gotHost, tt.expectedHost)
+			}
+		})
+	}
+}
+
+// TestIdCRefreshURLForRegion 測試 IdC 刷新端點的區域推導
+func TestIdCRefreshURLForRegion(t *testing.T) {
+	tests := map[string]string{
+		"eu-west-1":      "https://oidc.eu-west-1.amazonaws.com/token",
+		"AP-NORTHEAST-1": "https://oidc.ap-northeast-1.amazonaws.com/token",
+		"":               IdCRefreshURL,
+		"evil.com/x?":    IdCRefreshURL,
+	}
+	for region, expected := range tests {
+		if got := IdCRefreshURLForRegion(region); got != expected {
+			t.Errorf("IdCRefreshURLForRegion(%q) = %q, want %q", region, got, expected)
+		}
+	}
+}
+
+// TestRefreshSocialTokenWithClient_Headers 測試 Social 刷新請求的 Headers 與 body
+func TestRefreshSocialTokenWithClient_Headers(t *testing.T) {
+	var gotReq *http.Request
+	var gotBody SocialRefreshRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotReq = r
+		json.NewDecoder(r.Body).Decode(&gotBody)
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"accessToken":"social-token","expiresIn":3600,"profileArn":"arn:aws:test"}`))
+	}))
+	defer server.Close()
+
+	info, err := RefreshSocialTokenWithClient(server.Client(), server.URL, "refresh-token", "hashed-machine-id")
+	if err != nil {
+		t.Fatalf("RefreshSocialTokenWithClient failed: %v", err)
+	}
+	if info.AccessToken != "social-token" || info.ProfileArn != "arn:aws:test" {
+		t.Errorf("unexpected token info: %+v", info)
+	}
+
+	expectedUA := "KiroIDE-" + getEffectiveKiroVersion() + "-hashed-machine-id"
+	if ua := gotReq.Header.Get("User-Agent"); ua != expectedUA {
+		t.Errorf("User-Agent = %q, want %q", ua, expectedUA)
+	}
+	if gotReq.Method != http.MethodPost {
+		t.Errorf("Method = %q, want POST", gotReq.Method)
+	}
+	if ct := gotReq.Header.Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Content-Type = %q, want application/json", ct)
+	}
+	if gotBody.RefreshToken != "refresh-token" {
+		t.Errorf("request refreshToken = %q, want %q", gotBody.RefreshToken, "refresh-token")
+	}
+}
+
+// TestRefreshSocialTokenWithClient_HTTPError 測試 Social 刷新的 HTTP 錯誤映射
+func TestRefreshSocialTokenWithClient_HTTPError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	_, err := RefreshSocialTokenWithClient(server.Client(), server.URL, "refresh-token", "hashed-machine-id")
+	var refreshErr *RefreshError
+	if !errors.As(err, &refreshErr) || refreshErr.Code != 429 || refreshErr.Message != "請求過於頻繁，請稍後再試" {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+// TestRefreshToken_RetryAfterHeader 測試 429 回應的 Retry-After（秒數或 HTTP-date）會記錄於 RefreshError
+func TestRefreshToken_RetryAfterHeader(t *testing.T) {
+	testCases := []struct {
+		name   string
+		header string
+		min    time.Duration
+		max    time.Duration
+	}{
+		{"seconds", "30", 30 * time.Second, 30 * time.Second},
+		{"http-date", time.Now().Add(time.Minute).UTC().Format(http.TimeFormat), 50 * time.Second, time.Minute},
+		{"missing", "", 0, 0},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if tc.header != "" {
+					w.Header().Set("Retry-After", tc.header)
+				}
+				w.WriteHeader(http.StatusTooManyRequests)
+			}))
+			defer server.Close()
+
+			refreshFuncs := map[string]func() error{
+				"social": func() error {
+					_, err := RefreshSocialTokenWithClient(server.Client(), server.URL, "refresh-token", "hashed-machine-id")
+					return err
+				},
+				"idc": func() error {
+					_, err := RefreshIdCTokenWithClient(server.Client(), server.URL, "refresh-token", "client-id", "client-secret")
+					return err
+				},
+			}
+			for kind, refresh := range refreshFuncs {
+				var refreshErr *RefreshError
+				if !errors.As(refresh(), &refreshErr) {
+					t.Fatalf("%s: expected RefreshError", kind)
+				}
+				if refreshErr.RetryAfter < tc.min || refreshErr.RetryAfter > tc.max {
+					t.Errorf("%s: RetryAfter = %v, want between %v and %v", kind, refreshErr.RetryAfter, tc.min, tc.max)
+				}
+				if refreshErr.Message != "請求過於頻繁，請稍後再試" {
+					t.Errorf("%s: Message = %q, should be unchanged", kind, refreshErr.Message)
+				}
+			}
+		})
+	}
+}
+
+// TestRefreshIdCTokenWithClient_Request 測試 IdC 刷新請求的 Headers、body 與回應解析
+func TestRefreshIdCTokenWithClient_Request(t *testing.T) {
+	var gotReq *http.Request
+	var gotBody IdCRefreshRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotReq = r
+		json.NewDecoder(r.Body).Decode(&gotBody)
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"accessToken":"idc-token","expiresIn":28800,"tokenType":"Bearer"}`))
+	}))
+	defer server.Close()
+
+	info, err := RefreshIdCTokenWithClient(server.Client(), server.URL, "refresh-token", "client-id", "client-secret")
+	if err != nil {
+		t.Fatalf("RefreshIdCTokenWithClient failed: %v", err)
+	}
+	if info.AccessToken != "idc-token" || info.TokenType != "Bearer" || info.ExpiresIn != 28800 {
+		t.Errorf("unexpected token info: %+v", info)
+	}
+
+	if gotReq.Host != "oidc.us-east-1.amazonaws.com" {
+		t.Errorf("Host = %q, want oidc.us-east-1.amazonaws.com", gotReq.Host)
+	}
+	if gotReq.Header.Get("amz-sdk-invocation-id") == "" {
+		t.Error("amz-sdk-invocation-id header should be set")
+	}
+	expectedBody := IdCRefreshRequest{ClientID: "client-id", ClientSecret: "client-secret", GrantType: "refresh_token", RefreshToken: "refresh-token"}
+	if gotBody != expectedBody {
+		t.Errorf("request body = %+v, want %+v", gotBody, expectedBody)
+	}
+}
@@ -0,0 +1,42 @@
+package usage
+
+import (
+	"fmt"
+
+	"kiro-manager/awssso"
+	"kiro-manager/backup"
+)
+
+// RefreshAllUsageCaches 批次刷新多個備份的餘額緩存
+// 每個備份會先檢查 token，過期時刷新並寫回備份，再透過 fetch 查詢餘額並寫入 usage-cache.json
+// 單一備份失敗不會中斷其他備份，所有錯誤收集後一併返回
+func RefreshAllUsageCaches(names []string, fetch func(name string) (*backup.UsageCache, error)) []error {
+	var errs []error
+	for _, name := range names {
+		if err := refreshUsageCache(name, fetch); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+		}
+	}
+	return errs
+}
+
+// refreshUsageCache 刷新單一備份的餘額緩存
+func refreshUsageCache(name string, fetch func(name string) (*backup.UsageCache, error)) error {
+	token, err := backup.ReadBackupToken(name)
+	if err != nil {
+		return fmt.Errorf("failed to read backup token: %w", err)
+	}
+
+	if awssso.IsTokenExpired(token) {
+		if _, err := backup.RefreshAndWriteBackup(name); err != nil {
+			return fmt.Errorf("failed to refresh token: %w", err)
+		}
+	}
+
+	cache, err := fetch(name)
+	if err != nil {
+		return fmt.Errorf("failed to fetch usage: %w", err)
+	}
+
+	return backup.WriteUsageCache(name, cache)
+}
@@ -0,0 +1,75 @@
+package usage
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"kiro-manager/backup"
+)
+
+// setupTestBackup 在備份根目錄建立含有效 token 的測試備份
+func setupTestBackup(t *testing.T, name string) {
+	t.Helper()
+
+	backupPath, err := backup.GetBackupPath(name)
+	if err != nil {
+		t.Fatalf("GetBackupPath failed: %v", err)
+	}
+	if err := os.MkdirAll(backupPath, 0755); err != nil {
+		t.Fatalf("Failed to create backup dir: %v", err)
+	}
+	t.Cleanup(func() { os.RemoveAll(backupPath) })
+
+	token := map[string]interface{}{
+		"accessToken":  "access-token",
+		"refreshToken": "refresh-token",
+		"expiresAt":    time.Now().Add(time.Hour).UTC().Format("2006-01-02T15:04:05.000Z"),
+		"authMethod":   "social",
+		"provider":     "Github",
+	}
+	data, _ := json.Marshal(token)
+	if err := os.WriteFile(filepath.Join(backupPath, backup.KiroAuthTokenFile), data, 0644); err != nil {
+		t.Fatalf("Failed to write token: %v", err)
+	}
+}
+
+func TestRefreshAllUsageCaches_CollectsErrors(t *testing.T) {
+	okName := "test_refresh_all_usage_ok"
+	failName := "test_refresh_all_usage_fail"
+	missingName := "test_refresh_all_usage_missing"
+	setupTestBackup(t, okName)
+	setupTestBackup(t, failName)
+
+	var fetched []string
+	fetch := func(name string) (*backup.UsageCache, error) {
+		fetched = append(fetched, name)
+		if name == failName {
+			return nil, errors.New("api unavailable")
+		}
+		return &backup.UsageCache{SubscriptionTitle: "KIRO PRO", UsageLimit: 1000, Balance: 600}, nil
+	}
+
+	errs := RefreshAllUsageCaches([]string{okName, failName, missingName}, fetch)
+	if len(errs) != 2 {
+		t.Fatalf("RefreshAllUsageCaches() returned %d errors, want 2: %v", len(errs), errs)
+	}
+	if len(fetched) != 2 {
+		t.Errorf("fetch called for %v, want only backups with a token", fetched)
+	}
+
+	cache, err := backup.ReadUsageCache(okName)
+	if err != nil {
+		t.Fatalf("ReadUsageCache(%s) error = %v", okName, err)
+	}
+	if cache.Balance != 600 || cache.CachedAt.IsZero() {
+		t.Errorf("cache = %+v, want balance 600 with CachedAt set", cache)
+	}
+
+	if _, err := backup.ReadUsageCache(failName); err == nil {
+		t.Errorf("failed backup should not have a usage cache")
+	}
+}